@@ -2,21 +2,80 @@ package gsh
 
 import (
 	"context"
+	"errors"
 	"github.com/elankath/gardener-scaling-types"
 	"io"
 	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"time"
 )
 
 var ZoneLabels = []string{"topology.gke.io/zone", "topology.ebs.csi.aws.com/zone"}
 
+// CADefaultArgs holds the default values of the cluster-autoscaler flags. These are used when a flag is not explicitly
+// set on the recorded cluster-autoscaler deployment.
+// See https://github.com/kubernetes/autoscaler/blob/master/cluster-autoscaler/FAQ.md#what-are-the-parameters-to-ca
+var CADefaultArgs = map[string]string{
+	"expander":                             "random",
+	"max-nodes-total":                      "0",
+	"max-graceful-termination-sec":         "600",
+	"max-node-provision-time":              "15m0s",
+	"scan-interval":                        "10s",
+	"ignore-daemonsets-utilization":        "false",
+	"new-pod-scale-up-delay":               "0s",
+	"max-empty-bulk-delete":                "10",
+	"scale-down-enabled":                   "true",
+	"scale-down-utilization-threshold":     "0.5",
+	"scale-down-gpu-utilization-threshold": "0.5",
+	"scale-down-unneeded-time":             "10m0s",
+	"scale-down-unready-time":              "20m0s",
+	"scale-down-delay-after-add":           "10m0s",
+	"scale-down-delay-after-delete":        "0s",
+	"scale-down-delay-after-failure":       "3m0s",
+	"scale-down-candidates-pool-ratio":     "0.1",
+	"scale-down-candidates-pool-min-count": "50",
+	"balance-similar-node-groups":          "false",
+	"max-node-group-backoff-duration":      "30m0s",
+	"initial-node-group-backoff-duration":  "5m0s",
+	"node-group-backoff-reset-timeout":     "3h0m0s",
+	"skip-nodes-with-local-storage":        "true",
+	"skip-nodes-with-system-pods":          "true",
+	"max-total-unready-percentage":         "45",
+	"ok-total-unready-count":               "3",
+	"max-bulk-soft-taint-count":            "10",
+	"max-bulk-soft-taint-time":             "3s",
+}
+
 // Recorder monitors the cluster denoted by given kubeconfig and records events and cluster data into cluster database
 type Recorder interface {
 	io.Closer
 	Start(ctx context.Context) error
+	// CheckHealth returns the current liveness and readiness of the recorder.
+	CheckHealth(ctx context.Context) HealthStatus
 	//	GetRecordedClusterSnapshot(time time.Time) (ClusterSnapshot, error)
 }
 
+// HealthStatus is the health of the recorder of a single cluster at CheckTime. The recorder is live if there are no
+// LivenessFailures and ready if there are neither LivenessFailures nor ReadinessFailures. Failures are human-readable
+// reasons such as an expired kubeconfig token or a stalled watch.
+type HealthStatus struct {
+	Landscape         string
+	Cluster           string
+	CheckTime         time.Time
+	LivenessFailures  []string `json:",omitempty"`
+	ReadinessFailures []string `json:",omitempty"`
+}
+
+func (h HealthStatus) IsLive() bool {
+	return len(h.LivenessFailures) == 0
+}
+
+func (h HealthStatus) IsReady() bool {
+	return h.IsLive() && len(h.ReadinessFailures) == 0
+}
+
 //Current ClusterInfo in gst -> ClusterAutoscalerConfig
 
 type RecorderParams struct {
@@ -26,26 +85,353 @@ type RecorderParams struct {
 	SeedKubeConfigPath  string
 	DBDir               string
 	SchedulerName       string
+	// PostgresDSN is the connection string of a PostgreSQL data db shared by the recorded clusters. When set, the
+	// recorder stores the rows of the cluster there under the cluster identifier Landscape/ShootNameSpace instead of
+	// in a SQLite data db in DBDir.
+	PostgresDSN string
+	// CheckpointInterval is the interval at which the recorder stores a checkpoint of the latest rows, from which
+	// the point-in-time queries of the replayer start. No checkpoints are stored if it is zero.
+	CheckpointInterval time.Duration
+	// CheckpointRetention is the number of latest checkpoints kept by the recorder, older checkpoints are deleted
+	// after storing a checkpoint. All checkpoints are kept if it is zero.
+	CheckpointRetention int
 }
 
 type ReplayerParams struct {
-	DBPath                       string
+	DBPath string
+	// PostgresDSN is the connection string of a PostgreSQL data db. When set, the replayer replays the rows of the
+	// cluster with identifier ClusterID from it instead of the SQLite data db at DBPath.
+	PostgresDSN                  string
+	ClusterID                    string
 	ReportDir                    string
 	VirtualAutoScalerConfigPath  string
 	VirtualClusterKubeConfigPath string
 	StabilizeInterval            time.Duration
 	ReplayInterval               time.Duration
 	TotalReplayTime              time.Duration
+	// StartTime is the recording time of the first replay. The replay starts StabilizeInterval after the initial
+	// recorder start time if it is zero.
+	StartTime time.Time
+	// ScaleDownEnabled enables scale-down of the virtual cluster-autoscaler during replay. When enabled, the replayer
+	// reports the nodes removed by the virtual cluster-autoscaler compared with the real node deletions.
+	ScaleDownEnabled bool
+	// RefuseRecordingGaps makes the replayer fail with ErrRecordingGap instead of only warning when a replay window
+	// crosses a gap in the recording.
+	RefuseRecordingGaps bool
+	// InMemoryVirtualCluster makes the replayer replay into an in-memory virtual cluster instead of the virtual
+	// cluster at VirtualClusterKubeConfigPath. Nothing schedules the pods or scales the nodes of an in-memory virtual
+	// cluster, so it is meant for testing the replayer without a kvcl deployment.
+	InMemoryVirtualCluster bool
+	// InProcessScheduler makes the replayer bind the replayed pods to the virtual nodes itself after every replay
+	// step, for virtual clusters without a kube-scheduler. Nodes are scored following the recorded SchedulerName of
+	// every pod.
+	InProcessScheduler bool
+	// InProcessScaleUp makes the replayer create the initial nodes and scale up the node groups of the virtual cluster
+	// itself with a ScaleUpSimulator, instead of a virtual cluster-autoscaler reading VirtualAutoScalerConfigPath. The
+	// simulated scale-ups are written as Scenarios into the scale-up report in ReportDir.
+	InProcessScaleUp bool
+	// NodeProvisionDelay is the time a node created by the in-process scale-up takes to become ready. The in-process
+	// scheduler binds no pods to the node before, so the pods of a scale-up stay pending while their nodes are
+	// provisioned. A node becomes ready at the first replay at or after the delay, or at once if it is zero.
+	NodeProvisionDelay time.Duration
+	// OverridesPath is the optional path of an AutoScalerConfigOverrides YAML file applied on top of the recorded
+	// autoscaler config at every snapshot, for what-if replays.
+	OverridesPath string
+	// PriceCatalog prices the virtual nodes in the cost report written into ReportDir after every replay step. All
+	// nodes are unpriced if it is nil.
+	PriceCatalog PriceCatalog
+	// CostWindow is the length of the time windows of the cost report. The cost report has a single window if it is
+	// not positive.
+	CostWindow time.Duration
+}
+
+// BatchReplayParams are the parameters of a batch replay, which replays one recording once for every ReplayVariant
+// concurrently and ranks the outcomes of the variants.
+type BatchReplayParams struct {
+	DBPath          string
+	PostgresDSN     string
+	ClusterID       string
+	ReportDir       string
+	ReplayInterval  time.Duration
+	TotalReplayTime time.Duration
+	// StabilizeInterval is the time after the initial recorder start time at which the variants start. It is only
+	// waited for in variants replayed into a virtual cluster with a virtual cluster-autoscaler.
+	StabilizeInterval time.Duration
+	// NodeProvisionDelay is the ReplayerParams.NodeProvisionDelay of the variants replayed with the in-process
+	// scale-up.
+	NodeProvisionDelay time.Duration
+	// Parallelism is the maximum number of variants replayed at the same time. All variants are replayed at the same
+	// time if it is not positive.
+	Parallelism int
+	// PriceCatalog prices the nodes of the variants and of the recording. The MachinePrices of the BatchReplaySpec are
+	// used if it is nil.
+	PriceCatalog PriceCatalog
+	// CostWindow is the length of the time windows of the cost reports. The cost reports have a single window if it
+	// is not positive.
+	CostWindow time.Duration
+	BatchReplaySpec
+}
+
+// BatchReplaySpec is the YAML file of the variants of a batch replay.
+type BatchReplaySpec struct {
+	Variants []ReplayVariant `json:"variants"`
+	// MachinePrices are the prices per hour of the machine types used for the estimated cost of the variants.
+	MachinePrices map[string]float64 `json:"machinePrices,omitempty"`
+}
+
+// ReplayVariant is one autoscaler configuration replayed in a batch replay.
+type ReplayVariant struct {
+	Name string `json:"name"`
+	// OverridesPath is the path of the AutoScalerConfigOverrides of the variant, relative to the BatchReplaySpec. The
+	// variant replays the recorded config if it is empty.
+	OverridesPath string `json:"overridesPath,omitempty"`
+	// VirtualClusterKubeConfigPath and VirtualAutoScalerConfigPath are the virtual cluster and the config of its
+	// virtual cluster-autoscaler of the variant. The variant is replayed into an in-memory virtual cluster with the
+	// in-process scheduler and scale-up if VirtualClusterKubeConfigPath is empty.
+	VirtualClusterKubeConfigPath string `json:"virtualClusterKubeConfigPath,omitempty"`
+	VirtualAutoScalerConfigPath  string `json:"virtualAutoScalerConfigPath,omitempty"`
+}
+
+// VariantOutcome is the outcome of the replay of a ReplayVariant, sampled from its virtual cluster after every replay
+// step. Variants are ranked by the fewest UnscheduledPodMinutes, then by the lowest EstimatedCost and then by the
+// fewest ScaledUpNodeCount.
+type VariantOutcome struct {
+	Rank    int
+	Variant string
+	// UnscheduledPodMinutes is the sum of the minutes that pods were pending in the virtual cluster, including the
+	// time the pods of a scale-up wait for their nodes to be provisioned.
+	UnscheduledPodMinutes float64
+	// NodeHoursByMachineType maps the machine types to the sum of the hours that nodes of the type existed.
+	NodeHoursByMachineType map[string]float64
+	EstimatedCost          float64
+	Currency               string `json:",omitempty"`
+	// UnpricedMachineTypes are the machine types without a price, which are missing in the EstimatedCost.
+	UnpricedMachineTypes []string `json:",omitempty"`
+	// ScaledUpNodeCount is the number of nodes added to the virtual cluster during the replay.
+	ScaledUpNodeCount int
+	Error             string `json:",omitempty"`
+}
+
+// CloudProvider is the cloud provider of the instance types of a PriceCatalog.
+type CloudProvider string
+
+const (
+	AWSCloudProvider   CloudProvider = "aws"
+	GCPCloudProvider   CloudProvider = "gcp"
+	AzureCloudProvider CloudProvider = "azure"
+)
+
+// PriceCatalog is the catalog of the prices per hour of the instance types of the cloud providers.
+type PriceCatalog interface {
+	// Currency returns the currency of all prices of the catalog, such as EUR.
+	Currency() string
+	// GetHourlyPrice returns the price per hour of the given instance type of the given cloud provider in the given
+	// region, or else the price of the instance type of the cloud provider for all regions. Prices without a provider
+	// apply to the instance types of all cloud providers.
+	GetHourlyPrice(provider CloudProvider, region, instanceType string) (price float64, ok bool)
+}
+
+// InstancePrice is the price per hour of an instance type in a PriceCatalog. The price applies to all regions if
+// Region is empty, and to all cloud providers if Provider is empty.
+type InstancePrice struct {
+	Provider     CloudProvider `json:"provider"`
+	Region       string        `json:"region,omitempty"`
+	InstanceType string        `json:"instanceType"`
+	HourlyPrice  float64       `json:"hourlyPrice"`
+}
+
+// PriceCatalogFile is the JSON file of a PriceCatalog.
+type PriceCatalogFile struct {
+	Currency string          `json:"currency"`
+	Prices   []InstancePrice `json:"prices"`
+}
+
+// CostReport is the node-hours and cost of the nodes of a recorded or replayed cluster between StartTime and EndTime,
+// split into time windows of length Window.
+type CostReport struct {
+	Currency  string
+	StartTime time.Time
+	EndTime   time.Time
+	Window    time.Duration
+	NodeHours float64
+	Cost      float64
+	// UnpricedInstanceTypes are the instance types without a price in the PriceCatalog, which are missing in the Cost.
+	UnpricedInstanceTypes []string `json:",omitempty"`
+	Entries               []CostEntry
+	// Overrides are the overrides applied on top of the recorded autoscaler config during a replay, if any.
+	Overrides *AutoScalerConfigOverrides `json:",omitempty"`
+}
+
+// CostEntry is the node-hours and cost of the nodes of one instance type in one worker pool and zone during the time
+// window starting at WindowStart. Provider is empty if the cloud provider of the nodes is unknown.
+type CostEntry struct {
+	WindowStart  time.Time
+	Pool         string
+	Zone         string
+	Provider     CloudProvider
+	Region       string
+	InstanceType string
+	NodeHours    float64
+	Cost         float64
+}
+
+// UtilizationParams are the parameters of the utilization analysis of a recording, which samples the recorded
+// cluster every Step from StartTime to EndTime.
+type UtilizationParams struct {
+	DBPath      string
+	PostgresDSN string
+	ClusterID   string
+	ReportDir   string
+	// StartTime is the recorder start time if it is zero.
+	StartTime time.Time
+	// EndTime is the time of the last recorder heartbeat if it is zero.
+	EndTime time.Time
+	Step    time.Duration
+	// UnderutilizedThreshold is the utilization below which a node is underutilized. The recorded
+	// scale-down-utilization-threshold and scale-down-gpu-utilization-threshold of the cluster-autoscaler are used if
+	// it is not positive.
+	UnderutilizedThreshold float64
+	// UnderutilizedDuration is the minimum time a node has to stay underutilized to be reported as UnderutilizedNode.
+	UnderutilizedDuration time.Duration
+}
+
+// UtilizationScope is the scope of a UtilizationSample.
+type UtilizationScope string
+
+const (
+	NodeUtilizationScope    UtilizationScope = "node"
+	PoolUtilizationScope    UtilizationScope = "pool"
+	ClusterUtilizationScope UtilizationScope = "cluster"
+)
+
+// ResourceUsage is the amount of a resource requested by the scheduled pods and the allocatable amount of the nodes, in
+// cores for CPU, in bytes for memory and ephemeral storage and in devices for GPU.
+type ResourceUsage struct {
+	Requested   float64
+	Allocatable float64
+}
+
+// UtilizationSample is the resource usage of a node, a worker pool or the whole cluster at SampleTime. Name is the name
+// of the node or of the pool and empty for the cluster.
+type UtilizationSample struct {
+	SampleTime       time.Time
+	Scope            UtilizationScope
+	Name             string
+	Pool             string `json:",omitempty"`
+	NumNodes         int
+	NumPods          int
+	CPU              ResourceUsage
+	Memory           ResourceUsage
+	GPU              ResourceUsage
+	EphemeralStorage ResourceUsage
+}
+
+// UnderutilizedNode is a period of at least UtilizationParams.UnderutilizedDuration during which a node was
+// underutilized in every sample between From and To. MaxUtilization is the highest utilization of the node in the
+// period, computed like the cluster-autoscaler: the GPU utilization for GPU nodes and the higher of the CPU and memory
+// utilization for other nodes.
+type UnderutilizedNode struct {
+	Name           string
+	Pool           string
+	From           time.Time
+	To             time.Time
+	Duration       time.Duration
+	MaxUtilization float64
+}
+
+// UtilizationReport is the result of the utilization analysis of a recording.
+type UtilizationReport struct {
+	StartTime             time.Time
+	EndTime               time.Time
+	Step                  time.Duration
+	UnderutilizedDuration time.Duration
+	Samples               []UtilizationSample
+	UnderutilizedNodes    []UnderutilizedNode
+}
+
+// GeneratorParams are the parameters of the synthetic workload generator, which writes the recording of the
+// WorkloadScenario at ScenarioPath into a data db without a real cluster.
+type GeneratorParams struct {
+	ScenarioPath string
+	DBPath       string
+	// PostgresDSN is the connection string of a PostgreSQL data db. When set, the generator stores the rows under the
+	// cluster identifier ClusterID there instead of in a SQLite data db at DBPath.
+	PostgresDSN string
+	ClusterID   string
+}
+
+// ErrRecordingGap is returned by the replayer when a replay window crosses a gap in the recording and
+// ReplayerParams.RefuseRecordingGaps is set.
+var ErrRecordingGap = errors.New("replay window crosses a recording gap")
+
+// RecorderSessionInfo is a single run of the recorder. HeartbeatTime is periodically updated while the recorder runs.
+// EndTime is zero if the recorder did not stop cleanly.
+type RecorderSessionInfo struct {
+	SessionID     int64
+	BeginTime     time.Time
+	HeartbeatTime time.Time
+	EndTime       time.Time
+}
+
+// WatchDisconnectInfo is a period during which the watch of an informer of the recorder kept failing. BeginTime is the
+// time of the first and EndTime the time of the last watch error, after which the watch was re-established.
+type WatchDisconnectInfo struct {
+	RowID     int64
+	SessionID int64
+	Informer  string
+	BeginTime time.Time
+	EndTime   time.Time
+	Error     string
+}
+
+// CheckpointInfo is a compact full checkpoint of the recorded cluster stored periodically by the recorder. It holds the
+// RowIDs of the latest pod, machine deployment, machine class, worker pool, priority class and cluster-autoscaler node
+// group status rows at SnapshotTime, so that the recorded cluster at a later time is reconstructed from the
+// checkpoint and the rows stored after it instead of from the whole history. RowCount is the number of these rows.
+type CheckpointInfo struct {
+	CheckpointID int64
+	SnapshotTime time.Time
+	RowCount     int
+}
+
+type RecordingGapCause string
+
+const (
+	// RecorderStoppedGap is a gap between a recorder session that was stopped cleanly and the next session.
+	RecorderStoppedGap RecordingGapCause = "RecorderStopped"
+	// RecorderCrashedGap is a gap between the last heartbeat of a recorder session that did not stop cleanly and
+	// the next session.
+	RecorderCrashedGap RecordingGapCause = "RecorderCrashed"
+	// WatchDisconnectedGap is a period during which the watch of an informer of the recorder kept failing.
+	WatchDisconnectedGap RecordingGapCause = "WatchDisconnected"
+)
+
+// RecordingGap is a period in which changes of the recorded cluster may be missing from the recording. Informer is
+// only set for a WatchDisconnectedGap.
+type RecordingGap struct {
+	BeginTime time.Time
+	EndTime   time.Time
+	Cause     RecordingGapCause
+	Informer  string `json:",omitempty"`
 }
 
 type ClusterSnapshot struct {
 	SnapshotTime     time.Time
 	AutoscalerConfig gst.AutoScalerConfig
+	CAArgs           CAArgsInfo
+	CAStatus         []CANodeGroupStatusInfo
 	PriorityClasses  []gst.PriorityClassInfo
 	Pods             []gst.PodInfo
 	Nodes            []gst.NodeInfo
 }
 
+// ReplayAutoScalerConfig is the configuration written for the virtual cluster-autoscaler. It extends gst.AutoScalerConfig
+// with the complete set of cluster-autoscaler arguments recorded at the time of the snapshot.
+type ReplayAutoScalerConfig struct {
+	gst.AutoScalerConfig
+	CAArgs map[string]string `json:"caArgs"`
+}
+
 type Scenario struct {
 	ExistingNodes      []corev1.Node
 	UnscheduledPods    []corev1.Pod
@@ -56,8 +442,94 @@ type Scenario struct {
 }
 
 type ReplayReport struct {
-	StartTime time.Time
-	Scenarios []Scenario
+	StartTime  time.Time
+	Scenarios  []Scenario
+	ScaleDowns []NodeScaleDownComparison
+	// Overrides are the overrides applied on top of the recorded autoscaler config during the replay, if any.
+	Overrides *AutoScalerConfigOverrides `json:",omitempty"`
+}
+
+// AutoScalerConfigOverrides are what-if modifications of the recorded autoscaler config, such as another machine type
+// or max size of a worker pool or another expander. They are applied on top of the recorded config at every snapshot.
+// The node group and node template overrides are keyed by node group name, or by worker pool name to apply to all
+// node groups of the worker pool.
+type AutoScalerConfigOverrides struct {
+	// Description describes the what-if question answered by the overrides.
+	Description   string                          `json:"description,omitempty"`
+	NodeGroups    map[string]NodeGroupOverride    `json:"nodeGroups,omitempty"`
+	NodeTemplates map[string]NodeTemplateOverride `json:"nodeTemplates,omitempty"`
+	CASettings    CASettingsOverride              `json:"caSettings,omitempty"`
+	// Priorities replace the config of the priority expander. They map priorities to regular expressions of node
+	// group names.
+	Priorities map[int][]string `json:"priorities,omitempty"`
+	// CAArgs override cluster-autoscaler flags by name without leading dashes, such as
+	// scale-down-utilization-threshold. They are applied after the CASettings.
+	CAArgs map[string]string `json:"caArgs,omitempty"`
+}
+
+type NodeGroupOverride struct {
+	MinSize *int `json:"minSize,omitempty"`
+	MaxSize *int `json:"maxSize,omitempty"`
+}
+
+// NodeTemplateOverride overrides the machine of a node template. Capacity and Labels are merged into the recorded
+// ones, Taints replace the recorded ones if set.
+type NodeTemplateOverride struct {
+	InstanceType string              `json:"instanceType,omitempty"`
+	Capacity     corev1.ResourceList `json:"capacity,omitempty"`
+	Labels       map[string]string   `json:"labels,omitempty"`
+	Taints       []corev1.Taint      `json:"taints,omitempty"`
+}
+
+// CASettingsOverride overrides the CA settings and the corresponding cluster-autoscaler flags.
+type CASettingsOverride struct {
+	Expander                      string           `json:"expander,omitempty"`
+	MaxNodesTotal                 *int             `json:"maxNodesTotal,omitempty"`
+	MaxNodeProvisionTime          *metav1.Duration `json:"maxNodeProvisionTime,omitempty"`
+	ScanInterval                  *metav1.Duration `json:"scanInterval,omitempty"`
+	NewPodScaleUpDelay            *metav1.Duration `json:"newPodScaleUpDelay,omitempty"`
+	MaxEmptyBulkDelete            *int             `json:"maxEmptyBulkDelete,omitempty"`
+	MaxGracefulTerminationSeconds *int             `json:"maxGracefulTerminationSeconds,omitempty"`
+	IgnoreDaemonSetUtilization    *bool            `json:"ignoreDaemonSetUtilization,omitempty"`
+}
+
+// NodeScaleDownComparison compares the removal of a node by the virtual cluster-autoscaler during replay with the
+// deletion of the same node in the recorded cluster.
+type NodeScaleDownComparison struct {
+	NodeName string
+	// VirtualRemovalTime is the replay time at which the node was found removed by the virtual cluster-autoscaler.
+	// Its resolution is the ReplayerParams.ReplayInterval. Zero if the virtual cluster-autoscaler did not remove the node.
+	VirtualRemovalTime time.Time
+	// RealDeletionTime is the recorded DeletionTimestamp of the node. Zero if the node was not deleted in the real cluster.
+	RealDeletionTime time.Time
+}
+
+// VirtualCluster is the cluster into which the replayer deploys the recorded priority classes and pods, and in which
+// a virtual cluster-autoscaler scales the recorded node groups. The listed objects include their status.
+type VirtualCluster interface {
+	// Clean deletes all pods, nodes and priority classes except the `system-` priority classes.
+	Clean(ctx context.Context) error
+	ListNodes(ctx context.Context) ([]corev1.Node, error)
+	CreateNode(ctx context.Context, node *corev1.Node) error
+	DeleteNode(ctx context.Context, name string) error
+	ListPods(ctx context.Context) ([]corev1.Pod, error)
+	CreatePod(ctx context.Context, pod *corev1.Pod) error
+	DeletePod(ctx context.Context, namespace, name string) error
+	// BindPod binds the pod with the given namespace and name to the node with the given name, like kube-scheduler.
+	BindPod(ctx context.Context, namespace, name, nodeName string) error
+	UpdatePodStatus(ctx context.Context, pod *corev1.Pod) error
+	ListPriorityClasses(ctx context.Context) ([]schedulingv1.PriorityClass, error)
+	CreatePriorityClass(ctx context.Context, pc *schedulingv1.PriorityClass) error
+	DeletePriorityClass(ctx context.Context, name string) error
+}
+
+// ScaleUpSimulator decides in process which node groups a cluster-autoscaler would scale up for unscheduled pods, as an
+// alternative to running a virtual cluster-autoscaler.
+type ScaleUpSimulator interface {
+	// SimulateScaleUp scales up the node groups of the given config with its expander for the given pods without a
+	// node, respecting the min and max sizes of the node groups and the MaxNodesTotal of the CA settings. The given
+	// nodes are matched to their node groups by their worker pool and zone labels.
+	SimulateScaleUp(config gst.AutoScalerConfig, nodes []corev1.Node, pods []corev1.Pod) (Scenario, error)
 }
 
 type Replayer interface {
@@ -74,6 +546,9 @@ type MachineClassInfo struct {
 	// Instance type of the node belonging to nodeGroup
 	InstanceType string
 
+	// Provider is the provider of the machine class, such as AWS, which is the provider type of the shoot
+	Provider string
+
 	// PoolName is the name of the gardener shoot worker pool that this machine class belongs to
 	PoolName string
 
@@ -93,8 +568,251 @@ type MachineClassInfo struct {
 	Hash              string
 }
 
+// CAArgsInfo represents the complete set of command line arguments of the cluster-autoscaler deployment
+// at a particular moment in time. A new CAArgsInfo is only captured if there is a change in any of the arguments.
+type CAArgsInfo struct {
+	RowID             int64
+	SnapshotTimestamp time.Time
+
+	// Args is the map of the cluster-autoscaler flag names (without the leading `--`) to their values.
+	Args map[string]string
+	Hash string
+}
+
+// CAStatusClusterWide is the name used for the CANodeGroupStatusInfo representing the cluster-wide status
+// reported by the cluster-autoscaler.
+const CAStatusClusterWide = "cluster-wide"
+
+// CANodeGroupStatusInfo represents the status of a single node group as reported by the cluster-autoscaler in the
+// `cluster-autoscaler-status` config map at a particular moment in time. The cluster-wide status is represented
+// by a CANodeGroupStatusInfo with Name CAStatusClusterWide.
+type CANodeGroupStatusInfo struct {
+	RowID             int64
+	SnapshotTimestamp time.Time
+	Name              string
+
+	// Health is the health status of the node group. Ex: Healthy, Unhealthy
+	Health              string
+	Ready               int
+	Unready             int
+	NotStarted          int
+	LongNotStarted      int
+	Registered          int
+	LongUnregistered    int
+	CloudProviderTarget int
+	MinSize             int
+	MaxSize             int
+
+	// ScaleUp is the scale-up status of the node group. Ex: NoActivity, InProgress, Backoff
+	ScaleUp string
+	Backoff bool
+
+	// ScaleDown is the scale-down status of the node group. Ex: NoCandidates, CandidatesPresent
+	ScaleDown           string
+	ScaleDownCandidates int
+	Hash                string
+}
+
+// ToBeDeletedTaint is the taint added by the cluster-autoscaler to a node that it is draining and about to delete.
+const ToBeDeletedTaint = "ToBeDeletedByClusterAutoscaler"
+
+// DeletionCandidateTaint is the soft taint added by the cluster-autoscaler to a node that is a scale-down candidate.
+const DeletionCandidateTaint = "DeletionCandidateOfClusterAutoscaler"
+
+// ScaleDownActivity is the kind of scale-down related activity observed on a node.
+type ScaleDownActivity string
+
+const (
+	ScaleDownActivityCordoned          ScaleDownActivity = "Cordoned"
+	ScaleDownActivityUncordoned        ScaleDownActivity = "Uncordoned"
+	ScaleDownActivityDeletionCandidate ScaleDownActivity = "DeletionCandidate"
+	ScaleDownActivityToBeDeleted       ScaleDownActivity = "ToBeDeleted"
+	ScaleDownActivityDeleted           ScaleDownActivity = "Deleted"
+)
+
+// NodeScaleDownActivityInfo represents a scale-down related activity on a node: cordoning, the cluster-autoscaler
+// marking the node as a deletion candidate or as to be deleted (which starts the drain) and the node deletion.
+type NodeScaleDownActivityInfo struct {
+	RowID        int64
+	ActivityTime time.Time
+	NodeName     string
+	Activity     ScaleDownActivity
+	Detail       string
+}
+
+// FailedSchedulingReason is the reason of a scheduler `FailedScheduling` event.
+const FailedSchedulingReason = "FailedScheduling"
+
+// FailedSchedulingInfo represents an occurrence of a scheduler `FailedScheduling` event of a pod whose message has been
+// parsed into structured reason counts. Ex: the message `0/12 nodes are available: 3 Insufficient cpu, 9 node(s) had
+// untolerated taint {node-role: master}.` is parsed into TotalNodes 12 and ReasonCounts {"Insufficient cpu": 3,
+// "node(s) had untolerated taint {node-role: master}": 9}. The API server merges repeated events into one event whose
+// count and last timestamp increase, so every occurrence has the same EventUID and its own Count and EventTime.
+type FailedSchedulingInfo struct {
+	EventUID  string
+	EventTime time.Time
+	// Count is the number of occurrences of the event up to and including this one.
+	Count        int
+	PodUID       string
+	PodName      string
+	PodNamespace string
+	// Zone is the zone the pod is restricted to by its node selector or required node affinity, with multiple zones
+	// separated by ",". It is empty if the pod is not restricted to a zone.
+	Zone       string
+	TotalNodes int
+	// ReasonCounts is a map of the failure reason to the number of nodes that failed for the reason.
+	ReasonCounts map[string]int
+}
+
+// FailedSchedulingReasonCount is the number of distinct pods and the total number of nodes, summed over all event
+// occurrences, that failed scheduling for a particular reason.
+type FailedSchedulingReasonCount struct {
+	Reason    string
+	PodCount  int
+	NodeCount int
+}
+
+// Reasons of the cluster-autoscaler events that are parsed into CAScalingEventInfos.
+const (
+	TriggeredScaleUpReason  = "TriggeredScaleUp"
+	NotTriggerScaleUpReason = "NotTriggerScaleUp"
+	ScaledUpGroupReason     = "ScaledUpGroup"
+	ScaleDownEmptyReason    = "ScaleDownEmpty"
+)
+
+// CANodeGroupSize is the size change of a node group reported in a cluster-autoscaler event message.
+type CANodeGroupSize struct {
+	Name        string
+	CurrentSize int
+	TargetSize  int
+	MaxSize     int
+}
+
+// CAScalingEventInfo represents structured information parsed from a cluster-autoscaler TriggeredScaleUp,
+// NotTriggerScaleUp, ScaledUpGroup or ScaleDownEmpty event.
+type CAScalingEventInfo struct {
+	RowID     int64
+	EventUID  string
+	EventTime time.Time
+	// Reason is the reason of the event. Ex: TriggeredScaleUp
+	Reason string
+
+	// PodUID, PodName and PodNamespace identify the pod of TriggeredScaleUp and NotTriggerScaleUp events.
+	PodUID       string
+	PodName      string
+	PodNamespace string
+
+	// NodeGroupName is the cluster-autoscaler node group name which is the name of the MachineDeployment.
+	NodeGroupName string
+	CurrentSize   int
+	TargetSize    int
+	MaxSize       int
+
+	// NodeName is the name of the node removed by a ScaleDownEmpty event.
+	NodeName string
+
+	// NotTriggerReason is a reason of a NotTriggerScaleUp event and NodeGroupCount is the number of node groups that
+	// were not scaled-up for the reason.
+	NotTriggerReason string
+	NodeGroupCount   int
+}
+
 type PodInfoKey struct {
 	UID  string
 	Name string
 	Hash string
 }
+
+// WorkloadScenario is the declarative description of a synthetic cluster history from which the generator writes a
+// recording. The cluster starts at StartTime with the minimum number of nodes of every worker pool and the pods of the
+// Workloads arrive and leave during Duration. The same scenario with the same Seed always yields the same recording.
+type WorkloadScenario struct {
+	Name string `json:"name"`
+	// Namespace is the shoot namespace of the synthetic cluster. It prefixes the names of the machine deployments,
+	// machine classes and nodes.
+	Namespace string          `json:"namespace"`
+	Region    string          `json:"region"`
+	StartTime time.Time       `json:"startTime"`
+	Duration  metav1.Duration `json:"duration"`
+	Seed      int64           `json:"seed"`
+	// CAArgs are the cluster-autoscaler flags, without the leading `--`, recorded for the synthetic cluster.
+	CAArgs          map[string]string       `json:"caArgs,omitempty"`
+	PriorityClasses []ScenarioPriorityClass `json:"priorityClasses,omitempty"`
+	WorkerPools     []ScenarioWorkerPool    `json:"workerPools"`
+	Workloads       []ScenarioWorkload      `json:"workloads"`
+}
+
+type ScenarioPriorityClass struct {
+	Name  string `json:"name"`
+	Value int32  `json:"value"`
+}
+
+// ScenarioWorkerPool is a worker pool of the synthetic cluster. It has one machine deployment per zone, over which
+// Minimum and Maximum are distributed like gardener does. Allocatable defaults to Capacity.
+type ScenarioWorkerPool struct {
+	Name         string              `json:"name"`
+	MachineType  string              `json:"machineType"`
+	Architecture string              `json:"architecture,omitempty"`
+	Minimum      int                 `json:"minimum"`
+	Maximum      int                 `json:"maximum"`
+	Zones        []string            `json:"zones"`
+	Capacity     corev1.ResourceList `json:"capacity"`
+	Allocatable  corev1.ResourceList `json:"allocatable,omitempty"`
+}
+
+// ScenarioWorkload is a group of pods of the synthetic cluster that arrive according to Arrival. Every pod requests
+// resources drawn from Requests. Pods are only scheduled on nodes of PoolName if it is set.
+type ScenarioWorkload struct {
+	Name              string                                      `json:"name"`
+	Namespace         string                                      `json:"namespace,omitempty"`
+	PoolName          string                                      `json:"poolName,omitempty"`
+	PriorityClassName string                                      `json:"priorityClassName,omitempty"`
+	Arrival           ArrivalPattern                              `json:"arrival"`
+	Requests          map[corev1.ResourceName]RequestDistribution `json:"requests"`
+}
+
+type ArrivalPatternType string
+
+const (
+	// BurstArrival is a single burst of Count pods that arrive at At, spread uniformly over Spread.
+	BurstArrival ArrivalPatternType = "burst"
+	// DiurnalArrival scales the pods of a workload every Step between MinReplicas and MaxReplicas following a cosine
+	// curve with the given Period that peaks at PeakAt. Scaling in removes the newest pods first.
+	DiurnalArrival ArrivalPatternType = "diurnal"
+	// BatchArrival starts a job of Count pods at At and then Every, whose pods complete after Lifetime.
+	BatchArrival ArrivalPatternType = "batch"
+)
+
+// ArrivalPattern describes when the pods of a workload arrive and leave. At and PeakAt are offsets from the
+// WorkloadScenario.StartTime. Pods with a zero Lifetime stay until the end of the scenario.
+type ArrivalPattern struct {
+	Type        ArrivalPatternType `json:"type"`
+	At          metav1.Duration    `json:"at,omitempty"`
+	Count       int                `json:"count,omitempty"`
+	Spread      metav1.Duration    `json:"spread,omitempty"`
+	Every       metav1.Duration    `json:"every,omitempty"`
+	Lifetime    metav1.Duration    `json:"lifetime,omitempty"`
+	MinReplicas int                `json:"minReplicas,omitempty"`
+	MaxReplicas int                `json:"maxReplicas,omitempty"`
+	Period      metav1.Duration    `json:"period,omitempty"`
+	PeakAt      metav1.Duration    `json:"peakAt,omitempty"`
+	Step        metav1.Duration    `json:"step,omitempty"`
+}
+
+type RequestDistributionType string
+
+const (
+	// UniformDistribution draws requests uniformly between Min and Max.
+	UniformDistribution RequestDistributionType = "uniform"
+	// NormalDistribution draws requests from a normal distribution centered between Min and Max with a standard
+	// deviation of a sixth of their difference, clamped to Min and Max.
+	NormalDistribution RequestDistributionType = "normal"
+)
+
+// RequestDistribution is the distribution of the request of a resource by the pods of a workload. The request is
+// always Min if Max is zero.
+type RequestDistribution struct {
+	Distribution RequestDistributionType `json:"distribution,omitempty"`
+	Min          resource.Quantity       `json:"min"`
+	Max          resource.Quantity       `json:"max,omitempty"`
+}
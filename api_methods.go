@@ -13,6 +13,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"slices"
+	"time"
 )
 
 func header(prefix string, meta gst.SnapshotMeta) string {
@@ -22,8 +23,8 @@ func header(prefix string, meta gst.SnapshotMeta) string {
 
 func (m MachineClassInfo) String() string {
 	metaStr := header("MachineClass", m.SnapshotMeta)
-	return fmt.Sprintf("%s, InstanceType=%s, PoolName=%s, Region=%s, Zone=%s, Labels=%s, Capacity=%s, Hash=%s)",
-		metaStr, m.InstanceType, m.PoolName, m.Region, m.Zone, m.Labels, gst.ResourcesAsString(m.Capacity), m.Hash)
+	return fmt.Sprintf("%s, InstanceType=%s, Provider=%s, PoolName=%s, Region=%s, Zone=%s, Labels=%s, Capacity=%s, Hash=%s)",
+		metaStr, m.InstanceType, m.Provider, m.PoolName, m.Region, m.Zone, m.Labels, gst.ResourcesAsString(m.Capacity), m.Hash)
 }
 func (m MachineClassInfo) GetHash() string {
 	int64buf := make([]byte, 8) // 8 bytes for int64
@@ -35,6 +36,7 @@ func (m MachineClassInfo) GetHash() string {
 	binary.BigEndian.PutUint64(int64buf, uint64(m.CreationTimestamp.UnixMilli()))
 	hasher.Write(int64buf)
 
+	// the Provider is not hashed so that the rows stored before it was recorded are not re-inserted after an upgrade
 	hasher.Write([]byte(m.InstanceType))
 	hasher.Write([]byte(m.PoolName))
 	hasher.Write([]byte(m.Region))
@@ -46,6 +48,49 @@ func (m MachineClassInfo) GetHash() string {
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
+func (c CAArgsInfo) String() string {
+	return fmt.Sprintf("CAArgsInfo(RowID=%d, SnapshotTimestamp=%s, Args=%s, Hash=%s)", c.RowID, c.SnapshotTimestamp, c.Args, c.Hash)
+}
+
+func (c CAArgsInfo) GetHash() string {
+	hasher := md5.New()
+	hashLabels(hasher, c.Args)
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// GetEffectiveArgs returns the recorded cluster-autoscaler arguments overlaid on top of the CADefaultArgs so that
+// flags which were not explicitly set on the cluster-autoscaler carry their default values.
+func (c CAArgsInfo) GetEffectiveArgs() map[string]string {
+	effectiveArgs := maps.Clone(CADefaultArgs)
+	maps.Copy(effectiveArgs, c.Args)
+	return effectiveArgs
+}
+
+func (s CANodeGroupStatusInfo) String() string {
+	return fmt.Sprintf("CANodeGroupStatusInfo(RowID=%d, SnapshotTimestamp=%s, Name=%s, Health=%s, Ready=%d, Unready=%d, NotStarted=%d, LongNotStarted=%d, Registered=%d, LongUnregistered=%d, CloudProviderTarget=%d, MinSize=%d, MaxSize=%d, ScaleUp=%s, Backoff=%t, ScaleDown=%s, ScaleDownCandidates=%d, Hash=%s)",
+		s.RowID, s.SnapshotTimestamp, s.Name, s.Health, s.Ready, s.Unready, s.NotStarted, s.LongNotStarted, s.Registered, s.LongUnregistered,
+		s.CloudProviderTarget, s.MinSize, s.MaxSize, s.ScaleUp, s.Backoff, s.ScaleDown, s.ScaleDownCandidates, s.Hash)
+}
+
+// GetHash returns the hash of the CANodeGroupStatusInfo. The SnapshotTimestamp is deliberately not part of the hash
+// since the cluster-autoscaler refreshes the status config map on every scan.
+func (s CANodeGroupStatusInfo) GetHash() string {
+	int64buf := make([]byte, 8) // 8 bytes for int64
+
+	hasher := md5.New()
+	hasher.Write([]byte(s.Name))
+	hasher.Write([]byte(s.Health))
+	for _, v := range []int{s.Ready, s.Unready, s.NotStarted, s.LongNotStarted, s.Registered, s.LongUnregistered,
+		s.CloudProviderTarget, s.MinSize, s.MaxSize, s.ScaleDownCandidates} {
+		binary.BigEndian.PutUint64(int64buf, uint64(v))
+		hasher.Write(int64buf)
+	}
+	hasher.Write([]byte(s.ScaleUp))
+	hasher.Write(gst.BoolToBytes(s.Backoff))
+	hasher.Write([]byte(s.ScaleDown))
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
 func (c ClusterSnapshot) GetPriorityClassUIDs() sets.Set[string] {
 	uids := lo.Map(c.PriorityClasses, func(item gst.PriorityClassInfo, index int) string {
 		return string(item.UID)
@@ -434,3 +479,35 @@ func (c ClusterSnapshot) GetPodUIDs() sets.Set[string] {
 //	}
 //	return sumRequests
 //}
+
+func (n NodeScaleDownActivityInfo) String() string {
+	return fmt.Sprintf("NodeScaleDownActivityInfo(RowID=%d, ActivityTime=%s, NodeName=%s, Activity=%s, Detail=%s)",
+		n.RowID, n.ActivityTime, n.NodeName, n.Activity, n.Detail)
+}
+
+func (f FailedSchedulingInfo) String() string {
+	return fmt.Sprintf("FailedSchedulingInfo(EventUID=%s, EventTime=%s, Count=%d, PodUID=%s, PodName=%s, PodNamespace=%s, Zone=%s, TotalNodes=%d, ReasonCounts=%v)",
+		f.EventUID, f.EventTime, f.Count, f.PodUID, f.PodName, f.PodNamespace, f.Zone, f.TotalNodes, f.ReasonCounts)
+}
+
+func (c CAScalingEventInfo) String() string {
+	return fmt.Sprintf("CAScalingEventInfo(RowID=%d, EventUID=%s, EventTime=%s, Reason=%s, PodName=%s, NodeGroupName=%s, CurrentSize=%d, TargetSize=%d, MaxSize=%d, NodeName=%s, NotTriggerReason=%s, NodeGroupCount=%d)",
+		c.RowID, c.EventUID, c.EventTime, c.Reason, c.PodName, c.NodeGroupName, c.CurrentSize, c.TargetSize, c.MaxSize, c.NodeName, c.NotTriggerReason, c.NodeGroupCount)
+}
+
+// Utilization returns the ratio of the requested to the allocatable amount, or 0 if nothing is allocatable.
+func (u ResourceUsage) Utilization() float64 {
+	if u.Allocatable <= 0 {
+		return 0
+	}
+	return u.Requested / u.Allocatable
+}
+
+// Overlaps returns whether the gap overlaps the inclusive window from startTime to endTime.
+func (g RecordingGap) Overlaps(startTime, endTime time.Time) bool {
+	return !g.EndTime.Before(startTime) && !g.BeginTime.After(endTime)
+}
+
+func (g RecordingGap) String() string {
+	return fmt.Sprintf("RecordingGap(Cause=%s, Informer=%s, BeginTime=%s, EndTime=%s)", g.Cause, g.Informer, g.BeginTime, g.EndTime)
+}
@@ -0,0 +1,63 @@
+package apputil
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// GetDuration returns the duration of the env with the given name or defVal if the env is not set. It exits if the
+// env is not a duration.
+func GetDuration(name string, defVal time.Duration) time.Duration {
+	return getEnv(name, defVal, "duration", time.ParseDuration)
+}
+
+// GetInt returns the int of the env with the given name or defVal if the env is not set. It exits if the env is not
+// an int.
+func GetInt(name string, defVal int) int {
+	return getEnv(name, defVal, "int", strconv.Atoi)
+}
+
+// GetFloat returns the float of the env with the given name or defVal if the env is not set. It exits if the env is
+// not a float.
+func GetFloat(name string, defVal float64) float64 {
+	return getEnv(name, defVal, "float", func(val string) (float64, error) {
+		return strconv.ParseFloat(val, 64)
+	})
+}
+
+// GetBool returns the bool of the env with the given name or defVal if the env is not set. It exits if the env is not
+// a bool.
+func GetBool(name string, defVal bool) bool {
+	return getEnv(name, defVal, "bool", strconv.ParseBool)
+}
+
+// GetTime returns the RFC3339 time of the env with the given name or the zero time if the env is not set. It exits if
+// the env is not an RFC3339 time.
+func GetTime(name string) time.Time {
+	val := os.Getenv(name)
+	if val == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		slog.Error("cannot parse the env val as RFC3339 time", "name", name, "error", err)
+		os.Exit(1)
+	}
+	return t
+}
+
+func getEnv[T any](name string, defVal T, typeName string, parse func(string) (T, error)) T {
+	val := os.Getenv(name)
+	if val == "" {
+		slog.Warn("env not set, assuming default", "name", name, "default", defVal)
+		return defVal
+	}
+	v, err := parse(val)
+	if err != nil {
+		slog.Error("cannot parse the env val as "+typeName, "name", name, "error", err)
+		os.Exit(1)
+	}
+	return v
+}
@@ -2,12 +2,85 @@ package apputil
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	gsh "github.com/elankath/gardener-scaling-history"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/exp/maps"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 )
 
+// HealthCheckFunc returns the health of the recorder of a single cluster.
+type HealthCheckFunc func(ctx context.Context) gsh.HealthStatus
+
+// NewServeMux returns a mux which serves the metrics of the prometheus default registry in OpenMetrics format on the
+// /metrics path.
+func NewServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true})))
+	return mux
+}
+
+// HandleProbes registers liveness and readiness probes for the given health checks keyed by cluster on the given mux.
+// /healthz and /readyz check all clusters while /healthz/{cluster} and /readyz/{cluster} check a single cluster. A
+// probe responds with 200 if all checked clusters are live (ready) and 503 otherwise. The body holds the health
+// status of the checked clusters as JSON.
+func HandleProbes(mux *http.ServeMux, healthChecks map[string]HealthCheckFunc) {
+	isLive := func(h gsh.HealthStatus) bool { return h.IsLive() }
+	isReady := func(h gsh.HealthStatus) bool { return h.IsReady() }
+	mux.HandleFunc("GET /healthz", probeHandler(healthChecks, isLive))
+	mux.HandleFunc("GET /healthz/{cluster}", probeHandler(healthChecks, isLive))
+	mux.HandleFunc("GET /readyz", probeHandler(healthChecks, isReady))
+	mux.HandleFunc("GET /readyz/{cluster}", probeHandler(healthChecks, isReady))
+}
+
+func probeHandler(healthChecks map[string]HealthCheckFunc, isHealthy func(gsh.HealthStatus) bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clusters := maps.Keys(healthChecks)
+		if cluster := req.PathValue("cluster"); cluster != "" {
+			if _, ok := healthChecks[cluster]; !ok {
+				http.Error(w, fmt.Sprintf("unknown cluster %q", cluster), http.StatusNotFound)
+				return
+			}
+			clusters = []string{cluster}
+		}
+		slices.Sort(clusters)
+		statusCode := http.StatusOK
+		statuses := make([]gsh.HealthStatus, 0, len(clusters))
+		for _, cluster := range clusters {
+			status := healthChecks[cluster](req.Context())
+			if !isHealthy(status) {
+				statusCode = http.StatusServiceUnavailable
+				slog.Warn("health check failed", "path", req.URL.Path, "cluster", cluster,
+					"livenessFailures", status.LivenessFailures, "readinessFailures", status.ReadinessFailures)
+			}
+			statuses = append(statuses, status)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		_ = json.NewEncoder(w).Encode(statuses)
+	}
+}
+
+// ListenAndServe serves the given mux on the given address. The server is started in a goroutine.
+func ListenAndServe(addr string, mux *http.ServeMux) {
+	go func() {
+		slog.Info("serving metrics and probes", "addr", addr)
+		err := http.ListenAndServe(addr, mux)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("http server failed", "addr", addr, "error", err)
+		}
+	}()
+}
+
 func WaitForSignalAndShutdown(cancelFunc context.CancelFunc) {
 	slog.Info("Waiting until quit...")
 	quit := make(chan os.Signal, 1)
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	gsh "github.com/elankath/gardener-scaling-history"
+	"github.com/elankath/gardener-scaling-history/apputil"
+	"github.com/elankath/gardener-scaling-history/cost"
+	"github.com/elankath/gardener-scaling-history/replayer"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	dbPath := os.Getenv("DB_PATH")
+	postgresDSN := os.Getenv("POSTGRES_DSN")
+	clusterID := os.Getenv("CLUSTER_ID")
+	if len(dbPath) == 0 && len(postgresDSN) == 0 {
+		slog.Error("DB_PATH or POSTGRES_DSN env must be set")
+		os.Exit(1)
+	}
+	if len(postgresDSN) != 0 && len(clusterID) == 0 {
+		slog.Error("CLUSTER_ID env must be set with POSTGRES_DSN")
+		os.Exit(1)
+	}
+	reportDir := os.Getenv("REPORT_DIR")
+	if len(reportDir) == 0 {
+		slog.Error("REPORT_DIR env must be set")
+		os.Exit(1)
+	}
+	batchSpecPath := os.Getenv("BATCH_SPEC_PATH")
+	if len(batchSpecPath) == 0 {
+		slog.Error("BATCH_SPEC_PATH env must be set")
+		os.Exit(1)
+	}
+	spec, err := replayer.LoadBatchReplaySpec(batchSpecPath)
+	if err != nil {
+		slog.Error("cannot load the batch replay spec", "error", err)
+		os.Exit(1)
+	}
+
+	var priceCatalog gsh.PriceCatalog
+	if priceCatalogPaths := os.Getenv("PRICE_CATALOG_PATHS"); len(priceCatalogPaths) != 0 {
+		priceCatalog, err = cost.LoadPriceCatalog(strings.Split(priceCatalogPaths, ",")...)
+		if err != nil {
+			slog.Error("cannot load the price catalog", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	go apputil.WaitForSignalAndShutdown(cancelFn)
+	outcomes, err := replayer.RunBatchReplay(ctx, gsh.BatchReplayParams{
+		DBPath:             dbPath,
+		PostgresDSN:        postgresDSN,
+		ClusterID:          clusterID,
+		ReportDir:          reportDir,
+		ReplayInterval:     apputil.GetDuration("REPLAY_INTERVAL", replayer.DefaultReplayInterval),
+		TotalReplayTime:    apputil.GetDuration("TOTAL_REPLAY_TIME", replayer.DefaultTotalReplayTime),
+		StabilizeInterval:  apputil.GetDuration("STABILIZE_INTERVAL", replayer.DefaultStabilizeInterval),
+		NodeProvisionDelay: apputil.GetDuration("NODE_PROVISION_DELAY", replayer.DefaultNodeProvisionDelay),
+		Parallelism:        apputil.GetInt("PARALLELISM", 0),
+		PriceCatalog:       priceCatalog,
+		CostWindow:         apputil.GetDuration("COST_WINDOW", time.Hour),
+		BatchReplaySpec:    spec,
+	})
+	if err != nil {
+		slog.Error("cannot run the batch replay", "error", err)
+		os.Exit(1)
+	}
+	for _, o := range outcomes {
+		slog.Info("variant outcome", "rank", o.Rank, "variant", o.Variant, "unscheduledPodMinutes", o.UnscheduledPodMinutes,
+			"nodeHoursByMachineType", o.NodeHoursByMachineType, "estimatedCost", o.EstimatedCost, "currency", o.Currency, "scaledUpNodeCount", o.ScaledUpNodeCount,
+			"error", o.Error)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	gsh "github.com/elankath/gardener-scaling-history"
+	"github.com/elankath/gardener-scaling-history/apputil"
+	"github.com/elankath/gardener-scaling-history/cost"
+	"github.com/elankath/gardener-scaling-history/db"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func main() {
+	dbPath := os.Getenv("DB_PATH")
+	postgresDSN := os.Getenv("POSTGRES_DSN")
+	clusterID := os.Getenv("CLUSTER_ID")
+	if len(dbPath) == 0 && len(postgresDSN) == 0 {
+		slog.Error("DB_PATH or POSTGRES_DSN env must be set")
+		os.Exit(1)
+	}
+	if len(postgresDSN) != 0 && len(clusterID) == 0 {
+		slog.Error("CLUSTER_ID env must be set with POSTGRES_DSN")
+		os.Exit(1)
+	}
+	priceCatalogPaths := os.Getenv("PRICE_CATALOG_PATHS")
+	if len(priceCatalogPaths) == 0 {
+		slog.Error("PRICE_CATALOG_PATHS env must be set")
+		os.Exit(1)
+	}
+	reportDir := os.Getenv("REPORT_DIR")
+	if len(reportDir) == 0 {
+		slog.Error("REPORT_DIR env must be set")
+		os.Exit(1)
+	}
+	window := apputil.GetDuration("COST_WINDOW", time.Hour)
+
+	catalog, err := cost.LoadPriceCatalog(strings.Split(priceCatalogPaths, ",")...)
+	if err != nil {
+		slog.Error("cannot load the price catalog", "error", err)
+		os.Exit(1)
+	}
+	var store db.Store
+	if len(postgresDSN) != 0 {
+		store = db.NewPostgresDataAccess(postgresDSN, clusterID)
+	} else {
+		store = db.NewReadOnlyDataAccess(dbPath)
+	}
+	err = store.Init()
+	if err != nil {
+		slog.Error("cannot initialize the data db", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+
+	startTime := apputil.GetTime("START_TIME")
+	if startTime.IsZero() {
+		startTime, err = store.GetInitialRecorderStartTime()
+		if err != nil {
+			slog.Error("cannot get the recorder start time", "error", err)
+			os.Exit(1)
+		}
+	}
+	endTime := apputil.GetTime("END_TIME")
+	if endTime.IsZero() {
+		endTime, err = db.GetRecordingEndTime(store)
+		if err != nil {
+			slog.Error("cannot get the recording end time", "error", err)
+			os.Exit(1)
+		}
+	}
+	var report gsh.CostReport
+	report, err = cost.ComputeRecordedCost(store, catalog, startTime, endTime, window)
+	if err != nil {
+		slog.Error("cannot compute the recorded cost", "error", err)
+		os.Exit(1)
+	}
+	err = cost.WriteReport(report, filepath.Join(reportDir, cost.ReportFileName), filepath.Join(reportDir, cost.EntriesFileName))
+	if err != nil {
+		slog.Error("cannot write the cost report", "error", err)
+		os.Exit(1)
+	}
+	if len(report.UnpricedInstanceTypes) > 0 {
+		slog.Warn("instance types without a price are missing in the cost", "unpricedInstanceTypes", report.UnpricedInstanceTypes)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	gsh "github.com/elankath/gardener-scaling-history"
+	"github.com/elankath/gardener-scaling-history/generator"
+	"log/slog"
+	"os"
+)
+
+func main() {
+	scenarioPath := os.Getenv("SCENARIO_PATH")
+	if len(scenarioPath) == 0 {
+		slog.Error("SCENARIO_PATH env must be set")
+		os.Exit(1)
+	}
+	dbPath := os.Getenv("DB_PATH")
+	postgresDSN := os.Getenv("POSTGRES_DSN")
+	clusterID := os.Getenv("CLUSTER_ID")
+	if len(dbPath) == 0 && len(postgresDSN) == 0 {
+		slog.Error("DB_PATH or POSTGRES_DSN env must be set")
+		os.Exit(1)
+	}
+	if len(postgresDSN) != 0 && len(clusterID) == 0 {
+		slog.Error("CLUSTER_ID env must be set with POSTGRES_DSN")
+		os.Exit(1)
+	}
+
+	err := generator.GenerateRecording(gsh.GeneratorParams{
+		ScenarioPath: scenarioPath,
+		DBPath:       dbPath,
+		PostgresDSN:  postgresDSN,
+		ClusterID:    clusterID,
+	})
+	if err != nil {
+		slog.Error("cannot generate the recording", "error", err)
+		os.Exit(1)
+	}
+}
@@ -15,6 +15,7 @@ import (
 )
 
 const CLUSTERS_CFG_FILE = "clusters.csv"
+const DEFAULT_METRICS_ADDR = ":8080"
 
 func main() {
 	configDir := os.Getenv("CONFIG_DIR")
@@ -23,8 +24,16 @@ func main() {
 		os.Exit(1)
 	}
 	dbDir := os.Getenv("DB_DIR")
-	if len(dbDir) == 0 {
-		slog.Error("DB_DIR env must be set")
+	postgresDSN := os.Getenv("POSTGRES_DSN")
+	if len(dbDir) == 0 && len(postgresDSN) == 0 {
+		slog.Error("DB_DIR or POSTGRES_DSN env must be set")
+		os.Exit(2)
+	}
+
+	checkpointInterval := apputil.GetDuration("CHECKPOINT_INTERVAL", recorder.DefaultCheckpointInterval)
+	checkpointRetention := apputil.GetInt("CHECKPOINT_RETENTION", recorder.DefaultCheckpointRetention)
+	if checkpointRetention < 0 {
+		slog.Error("CHECKPOINT_RETENTION env must be a non-negative number of checkpoints", "CHECKPOINT_RETENTION", checkpointRetention)
 		os.Exit(2)
 	}
 
@@ -62,7 +71,7 @@ func main() {
 			slog.Error("Seed kubeconfig does not exist", "path", seedKubeConfigPath)
 			os.Exit(6)
 		}
-		if _, err := os.Stat(dbDir); os.IsNotExist(err) {
+		if _, err := os.Stat(dbDir); len(postgresDSN) == 0 && os.IsNotExist(err) {
 			slog.Error("DB Dir", "path", dbDir)
 			os.Exit(6)
 		}
@@ -72,6 +81,9 @@ func main() {
 			ShootKubeConfigPath: shootKubeConfigPath,
 			SeedKubeConfigPath:  seedKubeConfigPath,
 			DBDir:               dbDir,
+			PostgresDSN:         postgresDSN,
+			CheckpointInterval:  checkpointInterval,
+			CheckpointRetention: checkpointRetention,
 		}
 	}
 
@@ -82,12 +94,22 @@ func main() {
 
 	slog.Info("Will monitor, record & analyze clusters for scaling history", "shootKubeConfigs", recorderParams[0].ShootKubeConfigPath, "dbdir", dbDir)
 
+	metricsAddr := os.Getenv("METRICS_ADDR")
+	if len(metricsAddr) == 0 {
+		metricsAddr = DEFAULT_METRICS_ADDR
+	}
+
 	startTime := time.Now()
 	defaultRecorder, err := recorder.NewDefaultRecorder(recorderParams[0], startTime)
 	if err != nil {
 		slog.Error("cannot create recorder recorder", "error", err)
 		os.Exit(3)
 	}
+	mux := apputil.NewServeMux()
+	apputil.HandleProbes(mux, map[string]apputil.HealthCheckFunc{
+		recorderParams[0].ShootNameSpace: defaultRecorder.CheckHealth,
+	})
+	apputil.ListenAndServe(metricsAddr, mux)
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	err = defaultRecorder.Start(ctx)
 	if err != nil {
@@ -4,31 +4,25 @@ import (
 	"context"
 	gsh "github.com/elankath/gardener-scaling-history"
 	"github.com/elankath/gardener-scaling-history/apputil"
+	"github.com/elankath/gardener-scaling-history/cost"
 	"github.com/elankath/gardener-scaling-history/replayer"
 	"log/slog"
 	"os"
+	"strings"
 	"time"
 )
 
-func GetDuration(name string, defVal time.Duration) time.Duration {
-	val := os.Getenv(name)
-	if val == "" {
-		slog.Warn("env not set, assuming default", "name", name, "default", defVal)
-		return defVal
-	}
-	duration, err := time.ParseDuration(val)
-	if err != nil {
-		slog.Error("cannot parse the env val as duration", "name", name)
-		os.Exit(1)
-	}
-	return duration
-}
-
 func main() {
 
 	dbPath := os.Getenv("DB_PATH")
-	if len(dbPath) == 0 {
-		slog.Error("DB_PATH env must be set")
+	postgresDSN := os.Getenv("POSTGRES_DSN")
+	clusterID := os.Getenv("CLUSTER_ID")
+	if len(dbPath) == 0 && len(postgresDSN) == 0 {
+		slog.Error("DB_PATH or POSTGRES_DSN env must be set")
+		os.Exit(1)
+	}
+	if len(postgresDSN) != 0 && len(clusterID) == 0 {
+		slog.Error("CLUSTER_ID env must be set with POSTGRES_DSN")
 		os.Exit(1)
 	}
 	reportDir := os.Getenv("REPORT_DIR")
@@ -41,24 +35,53 @@ func main() {
 		slog.Error("VIRTUAL_AUTOSCALER_CONFIG env must be set")
 		os.Exit(1)
 	}
+	inMemoryVirtualCluster := apputil.GetBool("IN_MEMORY_VIRTUAL_CLUSTER", false)
 	virtualClusterKubeConfig := os.Getenv("VIRTUAL_CLUSTER_KUBECONFIG")
-	if len(virtualAutoScalerConfig) == 0 {
-		slog.Error("VIRTUAL_CLUSTER_KUBECONFIG env must be set")
+	if len(virtualClusterKubeConfig) == 0 && !inMemoryVirtualCluster {
+		slog.Error("VIRTUAL_CLUSTER_KUBECONFIG env must be set unless IN_MEMORY_VIRTUAL_CLUSTER=true")
 		os.Exit(1)
 	}
 
-	stabilizeInterval := GetDuration("STABILIZE_INTERVAL", replayer.DefaultStabilizeInterval)
-	totalReplayTime := GetDuration("TOTAL_REPLAY_TIME", replayer.DefaultTotalReplayTime)
-	replayInterval := GetDuration("REPLAY_INTERVAL", replayer.DefaultReplayInterval)
+	stabilizeInterval := apputil.GetDuration("STABILIZE_INTERVAL", replayer.DefaultStabilizeInterval)
+	totalReplayTime := apputil.GetDuration("TOTAL_REPLAY_TIME", replayer.DefaultTotalReplayTime)
+	replayInterval := apputil.GetDuration("REPLAY_INTERVAL", replayer.DefaultReplayInterval)
+	scaleDownEnabled := apputil.GetBool("SCALE_DOWN_ENABLED", false)
+	refuseRecordingGaps := apputil.GetBool("REFUSE_RECORDING_GAPS", false)
+	inProcessScheduler := apputil.GetBool("IN_PROCESS_SCHEDULER", false)
+	inProcessScaleUp := apputil.GetBool("IN_PROCESS_SCALE_UP", false)
+	nodeProvisionDelay := apputil.GetDuration("NODE_PROVISION_DELAY", replayer.DefaultNodeProvisionDelay)
+	overridesPath := os.Getenv("OVERRIDES_PATH")
+	costWindow := apputil.GetDuration("COST_WINDOW", time.Hour)
+
+	var priceCatalog gsh.PriceCatalog
+	if priceCatalogPaths := os.Getenv("PRICE_CATALOG_PATHS"); len(priceCatalogPaths) != 0 {
+		var err error
+		priceCatalog, err = cost.LoadPriceCatalog(strings.Split(priceCatalogPaths, ",")...)
+		if err != nil {
+			slog.Error("cannot load the price catalog", "error", err)
+			os.Exit(1)
+		}
+	}
 
 	defaultReplayer, err := replayer.NewDefaultReplayer(gsh.ReplayerParams{
 		DBPath:                       dbPath,
+		PostgresDSN:                  postgresDSN,
+		ClusterID:                    clusterID,
 		ReportDir:                    reportDir,
 		VirtualAutoScalerConfigPath:  virtualAutoScalerConfig,
 		VirtualClusterKubeConfigPath: virtualClusterKubeConfig,
 		TotalReplayTime:              totalReplayTime,
 		StabilizeInterval:            stabilizeInterval,
 		ReplayInterval:               replayInterval,
+		ScaleDownEnabled:             scaleDownEnabled,
+		RefuseRecordingGaps:          refuseRecordingGaps,
+		InMemoryVirtualCluster:       inMemoryVirtualCluster,
+		InProcessScheduler:           inProcessScheduler,
+		InProcessScaleUp:             inProcessScaleUp,
+		NodeProvisionDelay:           nodeProvisionDelay,
+		OverridesPath:                overridesPath,
+		PriceCatalog:                 priceCatalog,
+		CostWindow:                   costWindow,
 	})
 	if err != nil {
 		slog.Error("cannot contruct the default replayer", "error", err)
@@ -0,0 +1,49 @@
+package main
+
+import (
+	gsh "github.com/elankath/gardener-scaling-history"
+	"github.com/elankath/gardener-scaling-history/apputil"
+	"github.com/elankath/gardener-scaling-history/replayer"
+	"log/slog"
+	"os"
+	"time"
+)
+
+func main() {
+	dbPath := os.Getenv("DB_PATH")
+	postgresDSN := os.Getenv("POSTGRES_DSN")
+	clusterID := os.Getenv("CLUSTER_ID")
+	if len(dbPath) == 0 && len(postgresDSN) == 0 {
+		slog.Error("DB_PATH or POSTGRES_DSN env must be set")
+		os.Exit(1)
+	}
+	if len(postgresDSN) != 0 && len(clusterID) == 0 {
+		slog.Error("CLUSTER_ID env must be set with POSTGRES_DSN")
+		os.Exit(1)
+	}
+	reportDir := os.Getenv("REPORT_DIR")
+	if len(reportDir) == 0 {
+		slog.Error("REPORT_DIR env must be set")
+		os.Exit(1)
+	}
+
+	report, err := replayer.AnalyzeUtilization(gsh.UtilizationParams{
+		DBPath:                 dbPath,
+		PostgresDSN:            postgresDSN,
+		ClusterID:              clusterID,
+		ReportDir:              reportDir,
+		StartTime:              apputil.GetTime("START_TIME"),
+		EndTime:                apputil.GetTime("END_TIME"),
+		Step:                   apputil.GetDuration("UTILIZATION_STEP", 5*time.Minute),
+		UnderutilizedThreshold: apputil.GetFloat("UNDERUTILIZED_THRESHOLD", 0),
+		UnderutilizedDuration:  apputil.GetDuration("UNDERUTILIZED_DURATION", time.Hour),
+	})
+	if err != nil {
+		slog.Error("cannot analyze the utilization", "error", err)
+		os.Exit(1)
+	}
+	for _, n := range report.UnderutilizedNodes {
+		slog.Info("underutilized node", "name", n.Name, "pool", n.Pool, "from", n.From, "to", n.To, "duration", n.Duration,
+			"maxUtilization", n.MaxUtilization)
+	}
+}
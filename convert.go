@@ -1,6 +1,7 @@
 package gsh
 
 import (
+	"errors"
 	"fmt"
 	gst "github.com/elankath/gardener-scaling-types"
 	"golang.org/x/exp/maps"
@@ -8,13 +9,39 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/yaml"
 	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
 const PoolLabel = "worker.gardener.cloud/pool"
 const PoolLabelAlt = "worker_gardener_cloud_pool"
 
+const CAStatusConfigMapName = "cluster-autoscaler-status"
+const CAStatusConfigMapNamespace = "kube-system"
+
+// caStatusTimeLayout is the layout of the timestamp in the header line of the cluster-autoscaler status.
+// Ex: "Cluster-autoscaler status at 2024-07-01 10:00:00.123456789 +0000 UTC:"
+const caStatusTimeLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+const caStatusHeaderPrefix = "Cluster-autoscaler status at "
+
+var caStatusCountRegex = regexp.MustCompile(`(\w+)=(\d+)`)
+
+// failedSchedulingRegex matches the scheduler FailedScheduling message. Ex:
+// "0/12 nodes are available: 3 Insufficient cpu, 9 node(s) had untolerated taint {node-role: master}. preemption: ..."
+var failedSchedulingRegex = regexp.MustCompile(`^\d+/(\d+) nodes are available: (.*)$`)
+var failedSchedulingReasonRegex = regexp.MustCompile(`^(\d+) (.+)$`)
+
+var triggeredScaleUpRegex = regexp.MustCompile(`\{(\S+) (\d+)->(\d+) \(max: (\d+)\)\}`)
+var scaledUpGroupRegex = regexp.MustCompile(`Scale-up: (?:setting group|group) (\S+) size (?:to|set to) (\d+) instead of (\d+) \(max: (\d+)\)`)
+var scaleDownEmptyRegex = regexp.MustCompile(`Scale-down: removing empty node (\S+)`)
+
+const notTriggerScaleUpPrefix = "pod didn't trigger scale-up"
+
 func MachineDeploymentInfoFromUnstructured(mcd *unstructured.Unstructured, snapshotTime time.Time) (mcdInfo gst.MachineDeploymentInfo, err error) {
 	mcdName := mcd.GetName()
 	replicasPath := []string{"spec", "replicas"}
@@ -153,6 +180,13 @@ func MachineClassInfoFromUnstructured(mcc *unstructured.Unstructured, snapshotTi
 		return
 	}
 
+	// machine classes of older machine-controller-managers have no provider
+	provider, _, err := unstructured.NestedString(mcc.UnstructuredContent(), "provider")
+	if err != nil {
+		err = fmt.Errorf("error looking up provider in %q: %w", mccName, err)
+		return
+	}
+
 	capacityPath := []string{"nodeTemplate", "capacity"}
 	capacityMap, found, err := unstructured.NestedMap(mcc.UnstructuredContent(), capacityPath...)
 	if err != nil {
@@ -194,6 +228,7 @@ func MachineClassInfoFromUnstructured(mcc *unstructured.Unstructured, snapshotTi
 			Namespace:         mcc.GetNamespace(),
 		},
 		InstanceType: instanceType,
+		Provider:     provider,
 		PoolName:     poolName,
 		Region:       region,
 		Zone:         zone,
@@ -375,3 +410,462 @@ func parseIntOrStr(val any) (intstr.IntOrString, error) {
 	}
 	return intstr.IntOrString{}, fmt.Errorf("cannot parse %v as int or string", val)
 }
+
+// CAArgsInfoFromCASettings returns the CAArgsInfo with the cluster-autoscaler flags of the given CASettingsInfo. It
+// stands in for the args of data dbs recorded before the complete flag set was recorded in ca_args_info.
+func CAArgsInfoFromCASettings(caSettings gst.CASettingsInfo) CAArgsInfo {
+	caArgs := CAArgsInfo{
+		SnapshotTimestamp: caSettings.SnapshotTimestamp,
+		Args: map[string]string{
+			"expander":                      caSettings.Expander,
+			"max-nodes-total":               strconv.Itoa(caSettings.MaxNodesTotal),
+			"max-graceful-termination-sec":  strconv.Itoa(caSettings.MaxGracefulTerminationSeconds),
+			"max-node-provision-time":       caSettings.MaxNodeProvisionTime.String(),
+			"scan-interval":                 caSettings.ScanInterval.String(),
+			"new-pod-scale-up-delay":        caSettings.NewPodScaleUpDelay.String(),
+			"max-empty-bulk-delete":         strconv.Itoa(caSettings.MaxEmptyBulkDelete),
+			"ignore-daemonsets-utilization": strconv.FormatBool(caSettings.IgnoreDaemonSetUtilization),
+		},
+	}
+	caArgs.Hash = caArgs.GetHash()
+	return caArgs
+}
+
+// CANodeGroupStatusInfosFromConfigMap parses the `status` of the given `cluster-autoscaler-status` config map into
+// CANodeGroupStatusInfos. The first element of the returned slice is always the cluster-wide status.
+func CANodeGroupStatusInfosFromConfigMap(cm *corev1.ConfigMap) ([]CANodeGroupStatusInfo, error) {
+	status, ok := cm.Data["status"]
+	if !ok {
+		return nil, fmt.Errorf("cannot find 'status' in data of config map %q: %w", cm.Name, gst.ErrKeyNotFound)
+	}
+	return CANodeGroupStatusInfosFromText(status, time.Now().UTC())
+}
+
+// CANodeGroupStatusInfosFromText parses the given cluster-autoscaler status into CANodeGroupStatusInfos. Both the
+// legacy human-readable format and the YAML format written by cluster-autoscaler >= 1.30 are supported. The status
+// time from the header line or the `time` field is used as the SnapshotTimestamp, falling back to the given
+// defaultTime if it cannot be parsed.
+func CANodeGroupStatusInfosFromText(status string, defaultTime time.Time) (statusInfos []CANodeGroupStatusInfo, err error) {
+	if !strings.HasPrefix(strings.TrimSpace(status), caStatusHeaderPrefix) {
+		var yamlStatus caStatusYAML
+		if yamlErr := yaml.Unmarshal([]byte(status), &yamlStatus); yamlErr == nil && yamlStatus.ClusterWide != nil {
+			return caNodeGroupStatusInfosFromYAML(yamlStatus, defaultTime), nil
+		}
+	}
+	snapshotTime := defaultTime
+	var current *CANodeGroupStatusInfo
+	for _, line := range strings.Split(status, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, caStatusHeaderPrefix) {
+			timeStr := strings.TrimSuffix(strings.TrimPrefix(line, caStatusHeaderPrefix), ":")
+			t, parseErr := time.Parse(caStatusTimeLayout, timeStr)
+			if parseErr == nil {
+				snapshotTime = t.UTC()
+			}
+			continue
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Cluster-wide":
+			statusInfos = append(statusInfos, CANodeGroupStatusInfo{Name: CAStatusClusterWide})
+			current = &statusInfos[len(statusInfos)-1]
+		case "Name":
+			statusInfos = append(statusInfos, CANodeGroupStatusInfo{Name: value})
+			current = &statusInfos[len(statusInfos)-1]
+		case "Health":
+			if current == nil {
+				continue
+			}
+			var counts map[string]int
+			current.Health, counts, err = parseCAStatusValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse health of %q: %w", current.Name, err)
+			}
+			current.Ready = counts["ready"]
+			current.Unready = counts["unready"]
+			current.NotStarted = counts["notStarted"]
+			current.LongNotStarted = counts["longNotStarted"]
+			current.Registered = counts["registered"]
+			current.LongUnregistered = counts["longUnregistered"]
+			current.CloudProviderTarget = counts["cloudProviderTarget"]
+			current.MinSize = counts["minSize"]
+			current.MaxSize = counts["maxSize"]
+		case "ScaleUp":
+			if current == nil {
+				continue
+			}
+			current.ScaleUp, _, err = parseCAStatusValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse scale-up status of %q: %w", current.Name, err)
+			}
+			current.Backoff = current.ScaleUp == "Backoff"
+		case "ScaleDown":
+			if current == nil {
+				continue
+			}
+			var counts map[string]int
+			current.ScaleDown, counts, err = parseCAStatusValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse scale-down status of %q: %w", current.Name, err)
+			}
+			current.ScaleDownCandidates = counts["candidates"]
+		}
+	}
+	if len(statusInfos) == 0 {
+		return nil, errors.New("cannot find cluster-wide or node group status in cluster-autoscaler status")
+	}
+	for i := range statusInfos {
+		statusInfos[i].SnapshotTimestamp = snapshotTime
+		statusInfos[i].Hash = statusInfos[i].GetHash()
+	}
+	return
+}
+
+// caStatusYAML is the subset of the YAML cluster-autoscaler status written by cluster-autoscaler >= 1.30 that is
+// recorded.
+type caStatusYAML struct {
+	Time        string                  `json:"time"`
+	ClusterWide *caStatusGroupYAML      `json:"clusterWide"`
+	NodeGroups  []caStatusNodeGroupYAML `json:"nodeGroups"`
+}
+
+type caStatusNodeGroupYAML struct {
+	Name string `json:"name"`
+	caStatusGroupYAML
+}
+
+type caStatusGroupYAML struct {
+	Health struct {
+		Status     string `json:"status"`
+		NodeCounts struct {
+			Registered struct {
+				Total      int `json:"total"`
+				Ready      int `json:"ready"`
+				NotStarted int `json:"notStarted"`
+				Unready    struct {
+					Total int `json:"total"`
+				} `json:"unready"`
+			} `json:"registered"`
+			LongUnregistered int `json:"longUnregistered"`
+		} `json:"nodeCounts"`
+		CloudProviderTarget int `json:"cloudProviderTarget"`
+		MinSize             int `json:"minSize"`
+		MaxSize             int `json:"maxSize"`
+	} `json:"health"`
+	ScaleUp struct {
+		Status string `json:"status"`
+	} `json:"scaleUp"`
+	ScaleDown struct {
+		Status     string `json:"status"`
+		Candidates int    `json:"candidates"`
+	} `json:"scaleDown"`
+}
+
+func (g caStatusGroupYAML) toStatusInfo(name string) CANodeGroupStatusInfo {
+	counts := g.Health.NodeCounts
+	return CANodeGroupStatusInfo{
+		Name:                name,
+		Health:              g.Health.Status,
+		Ready:               counts.Registered.Ready,
+		Unready:             counts.Registered.Unready.Total,
+		NotStarted:          counts.Registered.NotStarted,
+		Registered:          counts.Registered.Total,
+		LongUnregistered:    counts.LongUnregistered,
+		CloudProviderTarget: g.Health.CloudProviderTarget,
+		MinSize:             g.Health.MinSize,
+		MaxSize:             g.Health.MaxSize,
+		ScaleUp:             g.ScaleUp.Status,
+		Backoff:             g.ScaleUp.Status == "Backoff",
+		ScaleDown:           g.ScaleDown.Status,
+		ScaleDownCandidates: g.ScaleDown.Candidates,
+	}
+}
+
+// caNodeGroupStatusInfosFromYAML converts the given YAML cluster-autoscaler status into CANodeGroupStatusInfos.
+func caNodeGroupStatusInfosFromYAML(status caStatusYAML, defaultTime time.Time) []CANodeGroupStatusInfo {
+	snapshotTime := defaultTime
+	for _, layout := range []string{caStatusTimeLayout, time.RFC3339Nano} {
+		t, err := time.Parse(layout, status.Time)
+		if err == nil {
+			snapshotTime = t.UTC()
+			break
+		}
+	}
+	statusInfos := []CANodeGroupStatusInfo{status.ClusterWide.toStatusInfo(CAStatusClusterWide)}
+	for _, ng := range status.NodeGroups {
+		statusInfos = append(statusInfos, ng.toStatusInfo(ng.Name))
+	}
+	for i := range statusInfos {
+		statusInfos[i].SnapshotTimestamp = snapshotTime
+		statusInfos[i].Hash = statusInfos[i].GetHash()
+	}
+	return statusInfos
+}
+
+// parseCAStatusValue parses a cluster-autoscaler status value like
+// "Healthy (ready=1 unready=0 (resourceUnready=0) notStarted=0 cloudProviderTarget=1 (minSize=1, maxSize=3))"
+// into the status word and a map of the counts.
+func parseCAStatusValue(value string) (statusWord string, counts map[string]int, err error) {
+	statusWord, _, _ = strings.Cut(value, " ")
+	counts = make(map[string]int)
+	for _, match := range caStatusCountRegex.FindAllStringSubmatch(value, -1) {
+		counts[match[1]], err = strconv.Atoi(match[2])
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// FailedSchedulingInfoFromEvent parses the message of the given scheduler FailedScheduling event into a FailedSchedulingInfo.
+func FailedSchedulingInfoFromEvent(event gst.EventInfo) (failedSchedulingInfo FailedSchedulingInfo, err error) {
+	if event.Reason != FailedSchedulingReason {
+		err = fmt.Errorf("event %q has reason %q and not %q", event.UID, event.Reason, FailedSchedulingReason)
+		return
+	}
+	totalNodes, reasonCounts, err := ParseFailedSchedulingMessage(event.Message)
+	if err != nil {
+		return
+	}
+	failedSchedulingInfo = FailedSchedulingInfo{
+		EventUID:     event.UID,
+		EventTime:    event.EventTime,
+		Count:        1,
+		PodUID:       event.InvolvedObjectUID,
+		PodName:      event.InvolvedObjectName,
+		PodNamespace: event.InvolvedObjectNamespace,
+		TotalNodes:   totalNodes,
+		ReasonCounts: reasonCounts,
+	}
+	return
+}
+
+// PodZone returns the zone the pod with the given spec is restricted to by its node selector or, if that has no zone,
+// by its required node affinity. Multiple zones allowed by the affinity are sorted and separated by ",". PodZone
+// returns "" if the pod is not restricted to a zone.
+func PodZone(spec corev1.PodSpec) string {
+	zoneLabels := append([]string{corev1.LabelTopologyZone, corev1.LabelFailureDomainBetaZone}, ZoneLabels...)
+	for _, label := range zoneLabels {
+		if zone := spec.NodeSelector[label]; zone != "" {
+			return zone
+		}
+	}
+	if spec.Affinity == nil || spec.Affinity.NodeAffinity == nil || spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return ""
+	}
+	// the pod is only restricted to zones if every node selector term, which are ORed, restricts it
+	var zones []string
+	for _, term := range spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		var termZones []string
+		for _, expr := range term.MatchExpressions {
+			if expr.Operator == corev1.NodeSelectorOpIn && slices.Contains(zoneLabels, expr.Key) {
+				termZones = expr.Values
+				break
+			}
+		}
+		if len(termZones) == 0 {
+			return ""
+		}
+		zones = append(zones, termZones...)
+	}
+	slices.Sort(zones)
+	return strings.Join(slices.Compact(zones), ",")
+}
+
+// ParseFailedSchedulingMessage parses a scheduler FailedScheduling message like
+// "0/12 nodes are available: 3 Insufficient cpu, 9 node(s) had untolerated taint {node-role: master}. preemption: ..."
+// into the total number of nodes and a map of the failure reason to the number of nodes that failed for the reason.
+// The preemption part of the message is ignored.
+func ParseFailedSchedulingMessage(message string) (totalNodes int, reasonCounts map[string]int, err error) {
+	message, _, _ = strings.Cut(strings.TrimSpace(message), " preemption:")
+	matches := failedSchedulingRegex.FindStringSubmatch(message)
+	if matches == nil {
+		err = fmt.Errorf("cannot parse FailedScheduling message %q", message)
+		return
+	}
+	totalNodes, err = strconv.Atoi(matches[1])
+	if err != nil {
+		return
+	}
+	reasonCounts, err = parseReasonCounts(strings.TrimSuffix(matches[2], "."))
+	if err != nil {
+		err = fmt.Errorf("cannot parse reasons of FailedScheduling message %q: %w", message, err)
+	}
+	return
+}
+
+// parseReasonCounts parses text like "3 Insufficient cpu, 9 node(s) had untolerated taint {node-role: master}" into a
+// map of the reason to its count.
+func parseReasonCounts(text string) (reasonCounts map[string]int, err error) {
+	// reasons are separated by ", " but the reason text itself may contain ", " (Ex: a taint with multiple values),
+	// so a part that does not start with a count is joined to the previous reason.
+	var reasons []string
+	for _, part := range strings.Split(text, ", ") {
+		if len(reasons) > 0 && !failedSchedulingReasonRegex.MatchString(part) {
+			reasons[len(reasons)-1] += ", " + part
+			continue
+		}
+		reasons = append(reasons, part)
+	}
+	reasonCounts = make(map[string]int, len(reasons))
+	for _, reason := range reasons {
+		reasonMatches := failedSchedulingReasonRegex.FindStringSubmatch(reason)
+		if reasonMatches == nil {
+			err = fmt.Errorf("cannot parse reason %q", reason)
+			return
+		}
+		var count int
+		count, err = strconv.Atoi(reasonMatches[1])
+		if err != nil {
+			return
+		}
+		reasonCounts[reasonMatches[2]] += count
+	}
+	return
+}
+
+// CAScalingEventInfosFromEvent parses the message of the given cluster-autoscaler TriggeredScaleUp, NotTriggerScaleUp,
+// ScaledUpGroup or ScaleDownEmpty event into CAScalingEventInfos. A row is returned for each node group of a
+// TriggeredScaleUp event and for each reason of a NotTriggerScaleUp event.
+func CAScalingEventInfosFromEvent(event gst.EventInfo) (scalingEventInfos []CAScalingEventInfo, err error) {
+	base := CAScalingEventInfo{
+		EventUID:  event.UID,
+		EventTime: event.EventTime,
+		Reason:    event.Reason,
+	}
+	if event.InvolvedObjectKind == "Pod" {
+		base.PodUID = event.InvolvedObjectUID
+		base.PodName = event.InvolvedObjectName
+		base.PodNamespace = event.InvolvedObjectNamespace
+	}
+	switch event.Reason {
+	case TriggeredScaleUpReason:
+		var nodeGroupSizes []CANodeGroupSize
+		nodeGroupSizes, err = ParseTriggeredScaleUpMessage(event.Message)
+		if err != nil {
+			return
+		}
+		for _, ngs := range nodeGroupSizes {
+			scalingEventInfo := base
+			scalingEventInfo.NodeGroupName = ngs.Name
+			scalingEventInfo.CurrentSize = ngs.CurrentSize
+			scalingEventInfo.TargetSize = ngs.TargetSize
+			scalingEventInfo.MaxSize = ngs.MaxSize
+			scalingEventInfos = append(scalingEventInfos, scalingEventInfo)
+		}
+	case NotTriggerScaleUpReason:
+		var reasonCounts map[string]int
+		reasonCounts, err = ParseNotTriggerScaleUpMessage(event.Message)
+		if err != nil {
+			return
+		}
+		reasons := maps.Keys(reasonCounts)
+		slices.Sort(reasons)
+		for _, reason := range reasons {
+			scalingEventInfo := base
+			scalingEventInfo.NotTriggerReason = reason
+			scalingEventInfo.NodeGroupCount = reasonCounts[reason]
+			scalingEventInfos = append(scalingEventInfos, scalingEventInfo)
+		}
+	case ScaledUpGroupReason:
+		var ngs CANodeGroupSize
+		ngs, err = ParseScaledUpGroupMessage(event.Message)
+		if err != nil {
+			return
+		}
+		scalingEventInfo := base
+		scalingEventInfo.NodeGroupName = ngs.Name
+		scalingEventInfo.CurrentSize = ngs.CurrentSize
+		scalingEventInfo.TargetSize = ngs.TargetSize
+		scalingEventInfo.MaxSize = ngs.MaxSize
+		scalingEventInfos = append(scalingEventInfos, scalingEventInfo)
+	case ScaleDownEmptyReason:
+		scalingEventInfo := base
+		scalingEventInfo.NodeName, err = ParseScaleDownEmptyMessage(event.Message)
+		if err != nil {
+			return
+		}
+		scalingEventInfos = append(scalingEventInfos, scalingEventInfo)
+	default:
+		err = fmt.Errorf("event %q has unsupported reason %q for CAScalingEventInfo", event.UID, event.Reason)
+	}
+	return
+}
+
+// ParseTriggeredScaleUpMessage parses a TriggeredScaleUp message like
+// "pod triggered scale-up: [{shoot--i034796--aw2-p2-z1 1->3 (max: 3)} {shoot--i034796--aw2-p2-z2 0->1 (max: 3)}]"
+// into the sizes of the scaled-up node groups.
+func ParseTriggeredScaleUpMessage(message string) (nodeGroupSizes []CANodeGroupSize, err error) {
+	for _, matches := range triggeredScaleUpRegex.FindAllStringSubmatch(message, -1) {
+		var ngs CANodeGroupSize
+		ngs, err = nodeGroupSizeFromMatches(matches[1], matches[2], matches[3], matches[4])
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse TriggeredScaleUp message %q: %w", message, err)
+		}
+		nodeGroupSizes = append(nodeGroupSizes, ngs)
+	}
+	if len(nodeGroupSizes) == 0 {
+		return nil, fmt.Errorf("cannot find node groups in TriggeredScaleUp message %q", message)
+	}
+	return
+}
+
+// ParseNotTriggerScaleUpMessage parses a NotTriggerScaleUp message like
+// "pod didn't trigger scale-up: 1 max node group size reached, 2 node(s) didn't match Pod's node affinity/selector"
+// into a map of the reason to the number of node groups that were not scaled-up for the reason.
+func ParseNotTriggerScaleUpMessage(message string) (reasonCounts map[string]int, err error) {
+	if !strings.HasPrefix(message, notTriggerScaleUpPrefix) {
+		return nil, fmt.Errorf("cannot parse NotTriggerScaleUp message %q", message)
+	}
+	// the prefix may be followed by a note in parentheses. Ex: "pod didn't trigger scale-up (it wouldn't fit if a new node is added): 1 Insufficient cpu"
+	_, reasons, found := strings.Cut(message, ": ")
+	if !found {
+		return nil, fmt.Errorf("cannot find reasons in NotTriggerScaleUp message %q", message)
+	}
+	reasonCounts, err = parseReasonCounts(strings.TrimSuffix(strings.TrimSpace(reasons), "."))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse reasons of NotTriggerScaleUp message %q: %w", message, err)
+	}
+	return
+}
+
+// ParseScaledUpGroupMessage parses a ScaledUpGroup message like
+// "Scale-up: setting group shoot--i034796--aw2-p2-z1 size to 3 instead of 1 (max: 3)" into the node group size.
+func ParseScaledUpGroupMessage(message string) (ngs CANodeGroupSize, err error) {
+	matches := scaledUpGroupRegex.FindStringSubmatch(message)
+	if matches == nil {
+		err = fmt.Errorf("cannot parse ScaledUpGroup message %q", message)
+		return
+	}
+	return nodeGroupSizeFromMatches(matches[1], matches[3], matches[2], matches[4])
+}
+
+// ParseScaleDownEmptyMessage parses a ScaleDownEmpty message like `Scale-down: removing empty node "node-a"` into the
+// name of the removed node.
+func ParseScaleDownEmptyMessage(message string) (nodeName string, err error) {
+	matches := scaleDownEmptyRegex.FindStringSubmatch(message)
+	if matches == nil {
+		err = fmt.Errorf("cannot parse ScaleDownEmpty message %q", message)
+		return
+	}
+	nodeName = strings.Trim(matches[1], `"`)
+	return
+}
+
+func nodeGroupSizeFromMatches(name, currentSize, targetSize, maxSize string) (ngs CANodeGroupSize, err error) {
+	ngs.Name = name
+	ngs.CurrentSize, err = strconv.Atoi(currentSize)
+	if err != nil {
+		return
+	}
+	ngs.TargetSize, err = strconv.Atoi(targetSize)
+	if err != nil {
+		return
+	}
+	ngs.MaxSize, err = strconv.Atoi(maxSize)
+	return
+}
@@ -0,0 +1,201 @@
+package cost
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	gsh "github.com/elankath/gardener-scaling-history"
+	"io"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// priceCSVColumns are the columns of a CSV price catalog file. The region column may be omitted.
+var priceCSVColumns = []string{"provider", "region", "instanceType", "hourlyPrice", "currency"}
+
+type priceKey struct {
+	provider     gsh.CloudProvider
+	region       string
+	instanceType string
+}
+
+type priceCatalog struct {
+	currency string
+	prices   map[priceKey]float64
+}
+
+var _ gsh.PriceCatalog = (*priceCatalog)(nil)
+
+// NewPriceCatalog creates a PriceCatalog of the given prices in the given currency. An instance type must not have
+// two different prices of the same cloud provider in the same region.
+func NewPriceCatalog(currency string, prices []gsh.InstancePrice) (gsh.PriceCatalog, error) {
+	c := &priceCatalog{
+		currency: currency,
+		prices:   make(map[priceKey]float64, len(prices)),
+	}
+	if err := c.addPrices(prices); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadPriceCatalog loads a PriceCatalog from the given JSON or CSV files. A JSON file is a gsh.PriceCatalogFile and a
+// CSV file has a header with the columns provider, region, instanceType, hourlyPrice and currency. Every price must
+// have one of the known cloud providers and all prices must be in the same currency.
+func LoadPriceCatalog(paths ...string) (gsh.PriceCatalog, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no price catalog files")
+	}
+	c := &priceCatalog{prices: make(map[priceKey]float64)}
+	for _, path := range paths {
+		var catalogFile gsh.PriceCatalogFile
+		var err error
+		switch ext := strings.ToLower(filepath.Ext(path)); ext {
+		case ".json":
+			catalogFile, err = readPriceCatalogJSON(path)
+		case ".csv":
+			catalogFile, err = readPriceCatalogCSV(path)
+		default:
+			err = fmt.Errorf("unsupported price catalog file extension %q", ext)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot load price catalog %q: %w", path, err)
+		}
+		if c.currency == "" {
+			c.currency = catalogFile.Currency
+		} else if catalogFile.Currency != c.currency {
+			return nil, fmt.Errorf("currency %q of price catalog %q differs from currency %q", catalogFile.Currency, path, c.currency)
+		}
+		for _, p := range catalogFile.Prices {
+			if !slices.Contains([]gsh.CloudProvider{gsh.AWSCloudProvider, gsh.GCPCloudProvider, gsh.AzureCloudProvider}, p.Provider) {
+				return nil, fmt.Errorf("unknown cloud provider %q of instance type %q in price catalog %q", p.Provider, p.InstanceType, path)
+			}
+		}
+		if err = c.addPrices(catalogFile.Prices); err != nil {
+			return nil, fmt.Errorf("cannot load price catalog %q: %w", path, err)
+		}
+	}
+	return c, nil
+}
+
+func (c *priceCatalog) Currency() string {
+	return c.currency
+}
+
+func (c *priceCatalog) GetHourlyPrice(provider gsh.CloudProvider, region, instanceType string) (price float64, ok bool) {
+	for _, key := range []priceKey{
+		{provider: provider, region: region, instanceType: instanceType},
+		{provider: provider, instanceType: instanceType},
+		{region: region, instanceType: instanceType},
+		{instanceType: instanceType},
+	} {
+		if price, ok = c.prices[key]; ok {
+			return
+		}
+	}
+	return
+}
+
+func (c *priceCatalog) addPrices(prices []gsh.InstancePrice) error {
+	for _, p := range prices {
+		if p.InstanceType == "" {
+			return fmt.Errorf("price of cloud provider %q has no instance type", p.Provider)
+		}
+		if p.HourlyPrice < 0 {
+			return fmt.Errorf("negative price %v of instance type %q", p.HourlyPrice, p.InstanceType)
+		}
+		key := priceKey{provider: p.Provider, region: p.Region, instanceType: p.InstanceType}
+		if price, ok := c.prices[key]; ok && price != p.HourlyPrice {
+			return fmt.Errorf("instance type %q of cloud provider %q has the prices %v and %v in region %q", p.InstanceType,
+				p.Provider, price, p.HourlyPrice, p.Region)
+		}
+		c.prices[key] = p.HourlyPrice
+	}
+	return nil
+}
+
+func readPriceCatalogJSON(path string) (catalogFile gsh.PriceCatalogFile, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	err = yaml.UnmarshalStrict(data, &catalogFile)
+	if err != nil {
+		return
+	}
+	if catalogFile.Currency == "" {
+		err = errors.New("missing currency")
+	}
+	return
+}
+
+func readPriceCatalogCSV(path string) (catalogFile gsh.PriceCatalogFile, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		err = fmt.Errorf("cannot read header: %w", err)
+		return
+	}
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.TrimSpace(column)] = i
+	}
+	for _, column := range priceCSVColumns {
+		if _, ok := columns[column]; !ok && column != "region" {
+			err = fmt.Errorf("missing column %q", column)
+			return
+		}
+	}
+	value := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	for {
+		var record []string
+		record, err = r.Read()
+		if errors.Is(err, io.EOF) {
+			err = nil
+			break
+		}
+		if err != nil {
+			return
+		}
+		line, _ := r.FieldPos(0)
+		currency := value(record, "currency")
+		if catalogFile.Currency == "" {
+			catalogFile.Currency = currency
+		} else if currency != catalogFile.Currency {
+			err = fmt.Errorf("currency %q in line %d differs from currency %q", currency, line, catalogFile.Currency)
+			return
+		}
+		var price float64
+		price, err = strconv.ParseFloat(value(record, "hourlyPrice"), 64)
+		if err != nil {
+			err = fmt.Errorf("cannot parse hourly price in line %d: %w", line, err)
+			return
+		}
+		catalogFile.Prices = append(catalogFile.Prices, gsh.InstancePrice{
+			Provider:     gsh.CloudProvider(value(record, "provider")),
+			Region:       value(record, "region"),
+			InstanceType: value(record, "instanceType"),
+			HourlyPrice:  price,
+		})
+	}
+	if catalogFile.Currency == "" {
+		err = errors.New("missing currency")
+	}
+	return
+}
@@ -0,0 +1,213 @@
+package cost
+
+import (
+	gsh "github.com/elankath/gardener-scaling-history"
+	"github.com/elankath/gardener-scaling-history/db"
+	gst "github.com/elankath/gardener-scaling-types"
+	assert "github.com/stretchr/testify/require"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPriceCatalog(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "aws.json")
+	assert.Nil(t, os.WriteFile(jsonPath, []byte(`{"currency": "EUR", "prices": [
+		{"provider": "aws", "region": "eu-west-1", "instanceType": "m5.large", "hourlyPrice": 0.1},
+		{"provider": "aws", "instanceType": "m5.large", "hourlyPrice": 0.12}
+	]}`), 0644))
+	csvPath := filepath.Join(dir, "gcp-azure.csv")
+	assert.Nil(t, os.WriteFile(csvPath, []byte(`provider,region,instanceType,hourlyPrice,currency
+gcp,europe-west1,n2-standard-4,0.18,EUR
+azure,,Standard_D4s_v3,0.2,EUR
+`), 0644))
+
+	catalog, err := LoadPriceCatalog(jsonPath, csvPath)
+	assert.Nil(t, err)
+	assert.Equal(t, "EUR", catalog.Currency())
+	for _, tc := range []struct {
+		provider     gsh.CloudProvider
+		region       string
+		instanceType string
+		price        float64
+		ok           bool
+	}{
+		{gsh.AWSCloudProvider, "eu-west-1", "m5.large", 0.1, true},
+		{gsh.AWSCloudProvider, "us-east-1", "m5.large", 0.12, true},
+		{gsh.GCPCloudProvider, "eu-west-1", "m5.large", 0, false},
+		{"", "eu-west-1", "m5.large", 0, false},
+		{gsh.GCPCloudProvider, "europe-west1", "n2-standard-4", 0.18, true},
+		{gsh.GCPCloudProvider, "europe-west4", "n2-standard-4", 0, false},
+		{gsh.AzureCloudProvider, "westeurope", "Standard_D4s_v3", 0.2, true},
+		{gsh.AWSCloudProvider, "eu-west-1", "m5.xlarge", 0, false},
+	} {
+		price, ok := catalog.GetHourlyPrice(tc.provider, tc.region, tc.instanceType)
+		assert.Equal(t, tc.ok, ok, "%s of %s in %s", tc.instanceType, tc.provider, tc.region)
+		assert.Equal(t, tc.price, price, "%s of %s in %s", tc.instanceType, tc.provider, tc.region)
+	}
+
+	// prices without a provider apply to all providers, prices of another provider do not conflict
+	catalog, err = NewPriceCatalog("EUR", []gsh.InstancePrice{
+		{Provider: gsh.AWSCloudProvider, InstanceType: "n1", HourlyPrice: 0.1},
+		{Provider: gsh.GCPCloudProvider, InstanceType: "n1", HourlyPrice: 0.2},
+		{InstanceType: "n1", HourlyPrice: 0.3},
+	})
+	assert.Nil(t, err)
+	for provider, want := range map[gsh.CloudProvider]float64{gsh.AWSCloudProvider: 0.1, gsh.GCPCloudProvider: 0.2, gsh.AzureCloudProvider: 0.3} {
+		price, ok := catalog.GetHourlyPrice(provider, "eu-west-1", "n1")
+		assert.True(t, ok, provider)
+		assert.Equal(t, want, price, provider)
+	}
+
+	for name, content := range map[string]string{
+		"currency.csv": "provider,instanceType,hourlyPrice,currency\naws,m5.large,0.1,USD\n",
+		"provider.csv": "provider,instanceType,hourlyPrice,currency\nalibaba,ecs.g6.large,0.1,EUR\n",
+		"conflict.csv": "provider,instanceType,hourlyPrice,currency\naws,m5.large,0.1,EUR\naws,m5.large,0.2,EUR\n",
+		"columns.csv":  "provider,instanceType,currency\naws,m5.large,EUR\n",
+		"prices.yaml":  "currency: EUR\n",
+	} {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0644))
+		_, err = LoadPriceCatalog(jsonPath, path)
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestAccumulator(t *testing.T) {
+	catalog, err := NewPriceCatalog("EUR", []gsh.InstancePrice{
+		{Provider: gsh.AWSCloudProvider, Region: "eu-west-1", InstanceType: "m5.large", HourlyPrice: 0.1},
+	})
+	assert.Nil(t, err)
+	startTime := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	acc := NewAccumulator(catalog, startTime, time.Hour)
+	large := NodeAttributes{Pool: "p1", Zone: "eu-west-1a", Provider: gsh.AWSCloudProvider, Region: "eu-west-1", InstanceType: "m5.large"}
+	xlarge := NodeAttributes{Pool: "p2", Zone: "eu-west-1b", Region: "eu-west-1", InstanceType: "m5.xlarge"}
+	// the time before the start time is ignored
+	acc.AddNode(large, startTime.Add(-time.Hour), startTime.Add(90*time.Minute))
+	acc.AddNode(large, startTime.Add(30*time.Minute), startTime.Add(time.Hour))
+	acc.AddNode(xlarge, startTime.Add(45*time.Minute), startTime.Add(75*time.Minute))
+	acc.AddNode(large, startTime.Add(-2*time.Hour), startTime.Add(-time.Hour))
+
+	report := acc.Report()
+	assert.Equal(t, "EUR", report.Currency)
+	assert.Equal(t, startTime.Add(90*time.Minute), report.EndTime)
+	assert.Equal(t, []string{"m5.xlarge"}, report.UnpricedInstanceTypes)
+	assert.InDelta(t, 2.5, report.NodeHours, 1e-9)
+	assert.InDelta(t, 0.2, report.Cost, 1e-9)
+	assert.Equal(t, []gsh.CostEntry{
+		{WindowStart: startTime, Pool: "p1", Zone: "eu-west-1a", Provider: gsh.AWSCloudProvider, Region: "eu-west-1", InstanceType: "m5.large", NodeHours: 1.5, Cost: 0.15},
+		{WindowStart: startTime, Pool: "p2", Zone: "eu-west-1b", Region: "eu-west-1", InstanceType: "m5.xlarge", NodeHours: 0.25},
+		{WindowStart: startTime.Add(time.Hour), Pool: "p1", Zone: "eu-west-1a", Provider: gsh.AWSCloudProvider, Region: "eu-west-1", InstanceType: "m5.large", NodeHours: 0.5, Cost: 0.05},
+		{WindowStart: startTime.Add(time.Hour), Pool: "p2", Zone: "eu-west-1b", Region: "eu-west-1", InstanceType: "m5.xlarge", NodeHours: 0.25},
+	}, roundEntries(report.Entries))
+
+	single := NewAccumulator(nil, startTime, 0)
+	single.AddNode(large, startTime, startTime.Add(3*time.Hour))
+	report = single.Report()
+	assert.Len(t, report.Entries, 1)
+	assert.InDelta(t, 3, report.NodeHours, 1e-9)
+	assert.Zero(t, report.Cost)
+	assert.Equal(t, []string{"m5.large"}, report.UnpricedInstanceTypes)
+}
+
+func TestComputeRecordedCost(t *testing.T) {
+	store := db.NewDataAccess(filepath.Join(t.TempDir(), "cost.db"))
+	assert.Nil(t, store.Init())
+	defer func() {
+		_ = store.Close()
+	}()
+	startTime := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	mcc := gsh.MachineClassInfo{
+		SnapshotMeta: gst.SnapshotMeta{
+			Name:              "shoot--test--cost-p1-z1-8f2a1",
+			Namespace:         "shoot--test--cost",
+			CreationTimestamp: startTime.Add(-time.Hour),
+			SnapshotTimestamp: startTime.Add(-time.Hour),
+		},
+		InstanceType: "m5.large",
+		Provider:     "AWS",
+		PoolName:     "p1",
+		Region:       "eu-west-1",
+		Zone:         "eu-west-1a",
+	}
+	mcc.Hash = mcc.GetHash()
+	_, err := store.StoreMachineClassInfo(mcc)
+	assert.Nil(t, err)
+	// x1 and x2 take their cloud provider from the machine class, g1 from its provider ID
+	for _, node := range []struct {
+		name         string
+		creationTime time.Time
+		providerID   string
+		labels       map[string]string
+	}{
+		{"x1", startTime.Add(-30 * time.Minute), "", map[string]string{gsh.PoolLabel: "p1", "topology.ebs.csi.aws.com/zone": "eu-west-1a"}},
+		{"x2", startTime.Add(20 * time.Minute), "", map[string]string{gsh.PoolLabel: "p1", "topology.ebs.csi.aws.com/zone": "eu-west-1a"}},
+		{"g1", startTime.Add(10 * time.Minute), "aws:///eu-west-1b/i-3", map[string]string{gsh.PoolLabel: "gpu", "topology.kubernetes.io/zone": "eu-west-1b",
+			"topology.kubernetes.io/region": "eu-west-1", "node.kubernetes.io/instance-type": "p3.2xlarge"}},
+	} {
+		nodeInfo := gst.NodeInfo{
+			SnapshotMeta: gst.SnapshotMeta{Name: node.name, CreationTimestamp: node.creationTime, SnapshotTimestamp: node.creationTime},
+			ProviderID:   node.providerID,
+			Labels:       node.labels,
+		}
+		nodeInfo.Hash = nodeInfo.GetHash()
+		_, err = store.StoreNodeInfo(nodeInfo)
+		assert.Nil(t, err)
+	}
+	_, err = store.UpdateNodeInfoDeletionTimestamp("x1", startTime.Add(40*time.Minute))
+	assert.Nil(t, err)
+
+	catalog, err := NewPriceCatalog("EUR", []gsh.InstancePrice{
+		{Provider: gsh.AWSCloudProvider, Region: "eu-west-1", InstanceType: "m5.large", HourlyPrice: 0.1},
+		{Provider: gsh.AWSCloudProvider, Region: "eu-west-1", InstanceType: "p3.2xlarge", HourlyPrice: 3},
+		{Provider: gsh.GCPCloudProvider, Region: "eu-west-1", InstanceType: "p3.2xlarge", HourlyPrice: 30},
+	})
+	assert.Nil(t, err)
+	report, err := ComputeRecordedCost(store, catalog, startTime, startTime.Add(time.Hour), 30*time.Minute)
+	assert.Nil(t, err)
+	assert.Nil(t, report.UnpricedInstanceTypes)
+	assert.Equal(t, []gsh.CostEntry{
+		{WindowStart: startTime, Pool: "gpu", Zone: "eu-west-1b", Provider: gsh.AWSCloudProvider, Region: "eu-west-1", InstanceType: "p3.2xlarge", NodeHours: 0.333333, Cost: 1},
+		{WindowStart: startTime, Pool: "p1", Zone: "eu-west-1a", Provider: gsh.AWSCloudProvider, Region: "eu-west-1", InstanceType: "m5.large", NodeHours: 0.666667, Cost: 0.066667},
+		{WindowStart: startTime.Add(30 * time.Minute), Pool: "gpu", Zone: "eu-west-1b", Provider: gsh.AWSCloudProvider, Region: "eu-west-1", InstanceType: "p3.2xlarge", NodeHours: 0.5, Cost: 1.5},
+		{WindowStart: startTime.Add(30 * time.Minute), Pool: "p1", Zone: "eu-west-1a", Provider: gsh.AWSCloudProvider, Region: "eu-west-1", InstanceType: "m5.large", NodeHours: 0.666667, Cost: 0.066667},
+	}, roundEntries(report.Entries))
+	assert.Equal(t, startTime.Add(time.Hour), report.EndTime)
+
+	reportDir := t.TempDir()
+	assert.Nil(t, WriteReport(report, filepath.Join(reportDir, ReportFileName), filepath.Join(reportDir, EntriesFileName)))
+	entries, err := os.ReadFile(filepath.Join(reportDir, EntriesFileName))
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(entries)), "\n")
+	assert.Len(t, lines, 5)
+	assert.Equal(t, "2024-06-01T10:00:00Z,gpu,eu-west-1b,aws,eu-west-1,p3.2xlarge,0.33,1.00,EUR", lines[1])
+}
+
+func TestGetCloudProvider(t *testing.T) {
+	for name, want := range map[string]gsh.CloudProvider{
+		"AWS":                    gsh.AWSCloudProvider,
+		"GCP":                    gsh.GCPCloudProvider,
+		"Azure":                  gsh.AzureCloudProvider,
+		"aws:///eu-west-1a/i-1":  gsh.AWSCloudProvider,
+		"gce://project/zone/n-1": gsh.GCPCloudProvider,
+		"azure:///subscriptions/s-1/resourceGroups/g-1/providers/Microsoft.Compute/virtualMachines/v-1": gsh.AzureCloudProvider,
+		"openstack:///i-1": "",
+		"":                 "",
+	} {
+		assert.Equal(t, want, GetCloudProvider(name), name)
+	}
+	assert.Equal(t, gsh.GCPCloudProvider, GetShootCloudProvider([]gsh.MachineClassInfo{{}, {Provider: "GCP"}}))
+	assert.Empty(t, GetShootCloudProvider(nil))
+}
+
+func roundEntries(entries []gsh.CostEntry) []gsh.CostEntry {
+	for i := range entries {
+		entries[i].NodeHours = math.Round(entries[i].NodeHours*1e6) / 1e6
+		entries[i].Cost = math.Round(entries[i].Cost*1e6) / 1e6
+	}
+	return entries
+}
@@ -0,0 +1,317 @@
+package cost
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	gsh "github.com/elankath/gardener-scaling-history"
+	"github.com/elankath/gardener-scaling-history/db"
+	gst "github.com/elankath/gardener-scaling-types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReportFileName is the name of the JSON cost report written into a report dir. EntriesFileName is the name of the
+// entries of the same report as CSV.
+const (
+	ReportFileName  = "cost-report.json"
+	EntriesFileName = "cost-report.csv"
+)
+
+// UnknownInstanceType is the instance type of nodes whose instance type can neither be found in their labels nor in
+// the recorded machine classes.
+const UnknownInstanceType = "unknown"
+
+// NodeAttributes are the attributes of a node that its cost is reported by.
+type NodeAttributes struct {
+	Pool         string
+	Zone         string
+	Provider     gsh.CloudProvider
+	Region       string
+	InstanceType string
+}
+
+// cloudProvidersByName maps the lower-case provider names of the machine classes and the schemes of the node provider
+// IDs to their CloudProvider.
+var cloudProvidersByName = map[string]gsh.CloudProvider{
+	"aws":   gsh.AWSCloudProvider,
+	"gcp":   gsh.GCPCloudProvider,
+	"gce":   gsh.GCPCloudProvider,
+	"azure": gsh.AzureCloudProvider,
+}
+
+// GetCloudProvider returns the CloudProvider of the given machine class provider, such as AWS, or of the given node
+// provider ID, such as aws:///eu-west-1a/i-1. It returns an empty CloudProvider if the provider is unknown.
+func GetCloudProvider(name string) gsh.CloudProvider {
+	name, _, _ = strings.Cut(name, "://")
+	return cloudProvidersByName[strings.ToLower(name)]
+}
+
+// GetShootCloudProvider returns the CloudProvider of the first of the given machine classes with a known provider. All
+// machine classes of a shoot have the provider type of the shoot.
+func GetShootCloudProvider(mccs []gsh.MachineClassInfo) gsh.CloudProvider {
+	for _, mcc := range mccs {
+		if provider := GetCloudProvider(mcc.Provider); provider != "" {
+			return provider
+		}
+	}
+	return ""
+}
+
+// GetNodeAttributes returns the NodeAttributes found in the given node labels. Attributes without a label are empty.
+func GetNodeAttributes(labels map[string]string) (attrs NodeAttributes) {
+	attrs.Pool = labels[gsh.PoolLabel]
+	if attrs.Pool == "" {
+		attrs.Pool = labels[gsh.PoolLabelAlt]
+	}
+	for _, label := range append([]string{corev1.LabelTopologyZone, corev1.LabelFailureDomainBetaZone}, gsh.ZoneLabels...) {
+		if attrs.Zone = labels[label]; attrs.Zone != "" {
+			break
+		}
+	}
+	for _, label := range []string{corev1.LabelTopologyRegion, corev1.LabelFailureDomainBetaRegion} {
+		if attrs.Region = labels[label]; attrs.Region != "" {
+			break
+		}
+	}
+	for _, label := range []string{corev1.LabelInstanceTypeStable, corev1.LabelInstanceType} {
+		if attrs.InstanceType = labels[label]; attrs.InstanceType != "" {
+			break
+		}
+	}
+	return
+}
+
+type entryKey struct {
+	windowStart time.Time
+	NodeAttributes
+}
+
+// Accumulator sums up the node-hours and cost of nodes per time window, worker pool, zone and instance type.
+type Accumulator struct {
+	catalog   gsh.PriceCatalog
+	startTime time.Time
+	endTime   time.Time
+	window    time.Duration
+	entries   map[entryKey]*gsh.CostEntry
+	unpriced  sets.Set[string]
+}
+
+// NewAccumulator creates an Accumulator of the time windows of the given length from the given start time. All time is
+// accumulated into a single window if the window is not positive. All nodes are unpriced if the catalog is nil.
+func NewAccumulator(catalog gsh.PriceCatalog, startTime time.Time, window time.Duration) *Accumulator {
+	return &Accumulator{
+		catalog:   catalog,
+		startTime: startTime.UTC(),
+		endTime:   startTime.UTC(),
+		window:    window,
+		entries:   make(map[entryKey]*gsh.CostEntry),
+		unpriced:  sets.New[string](),
+	}
+}
+
+// AddNode adds the time between from and to, during which a node with the given attributes existed. The time before
+// the start time of the accumulator is ignored.
+func (a *Accumulator) AddNode(attrs NodeAttributes, from, to time.Time) {
+	from, to = from.UTC(), to.UTC()
+	if from.Before(a.startTime) {
+		from = a.startTime
+	}
+	if !to.After(from) {
+		return
+	}
+	if to.After(a.endTime) {
+		a.endTime = to
+	}
+	if attrs.InstanceType == "" {
+		attrs.InstanceType = UnknownInstanceType
+	}
+	price, priced := 0.0, false
+	if a.catalog != nil {
+		price, priced = a.catalog.GetHourlyPrice(attrs.Provider, attrs.Region, attrs.InstanceType)
+	}
+	if !priced {
+		a.unpriced.Insert(attrs.InstanceType)
+	}
+	for from.Before(to) {
+		windowStart, windowEnd := a.startTime, to
+		if a.window > 0 {
+			windowStart = a.startTime.Add(from.Sub(a.startTime).Truncate(a.window))
+			if end := windowStart.Add(a.window); end.Before(to) {
+				windowEnd = end
+			}
+		}
+		key := entryKey{windowStart: windowStart, NodeAttributes: attrs}
+		entry, ok := a.entries[key]
+		if !ok {
+			entry = &gsh.CostEntry{
+				WindowStart:  windowStart,
+				Pool:         attrs.Pool,
+				Zone:         attrs.Zone,
+				Provider:     attrs.Provider,
+				Region:       attrs.Region,
+				InstanceType: attrs.InstanceType,
+			}
+			a.entries[key] = entry
+		}
+		hours := windowEnd.Sub(from).Hours()
+		entry.NodeHours += hours
+		entry.Cost += price * hours
+		from = windowEnd
+	}
+}
+
+// Report returns the CostReport of the accumulated node time, with the entries sorted by window, pool, zone and
+// instance type.
+func (a *Accumulator) Report() (report gsh.CostReport) {
+	if a.catalog != nil {
+		report.Currency = a.catalog.Currency()
+	}
+	report.StartTime = a.startTime
+	report.EndTime = a.endTime
+	report.Window = a.window
+	report.Entries = make([]gsh.CostEntry, 0, len(a.entries))
+	for _, entry := range a.entries {
+		report.NodeHours += entry.NodeHours
+		report.Cost += entry.Cost
+		report.Entries = append(report.Entries, *entry)
+	}
+	slices.SortFunc(report.Entries, func(a, b gsh.CostEntry) int {
+		return cmp.Or(
+			a.WindowStart.Compare(b.WindowStart),
+			cmp.Compare(a.Pool, b.Pool),
+			cmp.Compare(a.Zone, b.Zone),
+			cmp.Compare(a.Provider, b.Provider),
+			cmp.Compare(a.Region, b.Region),
+			cmp.Compare(a.InstanceType, b.InstanceType),
+		)
+	})
+	if a.unpriced.Len() > 0 {
+		report.UnpricedInstanceTypes = sets.List(a.unpriced)
+	}
+	return
+}
+
+// ComputeRecordedCost computes the CostReport of the nodes recorded in the given store between the given start and end
+// time. Node attributes missing in the node labels are taken from the recorded machine class of the pool and zone of
+// the node. The cloud provider of a node is taken from its provider ID, or else from the recorded machine classes.
+func ComputeRecordedCost(store db.Store, catalog gsh.PriceCatalog, startTime, endTime time.Time, window time.Duration) (gsh.CostReport, error) {
+	nodeInfos, err := store.LoadNodeInfosBefore(endTime)
+	if err != nil {
+		return gsh.CostReport{}, fmt.Errorf("cannot load the recorded nodes: %w", err)
+	}
+	mccs, err := store.LoadMachineClassInfosBefore(endTime)
+	if err != nil {
+		return gsh.CostReport{}, fmt.Errorf("cannot load the recorded machine classes: %w", err)
+	}
+	shootProvider := GetShootCloudProvider(mccs)
+	acc := NewAccumulator(catalog, startTime, window)
+	for _, nodeInfo := range latestNodeInfos(nodeInfos) {
+		to := endTime
+		if !nodeInfo.DeletionTimestamp.IsZero() && nodeInfo.DeletionTimestamp.Before(endTime) {
+			to = nodeInfo.DeletionTimestamp
+		}
+		acc.AddNode(getRecordedNodeAttributes(nodeInfo, mccs, shootProvider), nodeInfo.CreationTimestamp, to)
+	}
+	report := acc.Report()
+	report.EndTime = endTime.UTC()
+	return report, nil
+}
+
+// latestNodeInfos returns the latest NodeInfo of every recorded node, with the earliest creation and the latest
+// deletion timestamp of all its NodeInfos.
+func latestNodeInfos(nodeInfos []gst.NodeInfo) []gst.NodeInfo {
+	latest := make(map[string]gst.NodeInfo)
+	for _, n := range nodeInfos {
+		l, ok := latest[n.Name]
+		if !ok {
+			latest[n.Name] = n
+			continue
+		}
+		if n.SnapshotTimestamp.After(l.SnapshotTimestamp) {
+			n, l = l, n
+		}
+		if n.CreationTimestamp.Before(l.CreationTimestamp) {
+			l.CreationTimestamp = n.CreationTimestamp
+		}
+		if n.DeletionTimestamp.After(l.DeletionTimestamp) {
+			l.DeletionTimestamp = n.DeletionTimestamp
+		}
+		latest[l.Name] = l
+	}
+	names := make([]string, 0, len(latest))
+	for name := range latest {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	result := make([]gst.NodeInfo, 0, len(names))
+	for _, name := range names {
+		result = append(result, latest[name])
+	}
+	return result
+}
+
+func getRecordedNodeAttributes(nodeInfo gst.NodeInfo, mccs []gsh.MachineClassInfo, shootProvider gsh.CloudProvider) NodeAttributes {
+	attrs := GetNodeAttributes(nodeInfo.Labels)
+	attrs.Provider = cmp.Or(GetCloudProvider(nodeInfo.ProviderID), shootProvider)
+	if attrs.Region != "" && attrs.InstanceType != "" {
+		return attrs
+	}
+	for _, mcc := range mccs {
+		if mcc.PoolName != attrs.Pool || (attrs.Zone != "" && mcc.Zone != attrs.Zone) {
+			continue
+		}
+		attrs.Zone = cmp.Or(attrs.Zone, mcc.Zone)
+		attrs.Region = cmp.Or(attrs.Region, mcc.Region)
+		attrs.InstanceType = cmp.Or(attrs.InstanceType, mcc.InstanceType)
+		break
+	}
+	return attrs
+}
+
+// WriteReport writes the given report as JSON to the given JSON path and its entries as CSV to the given CSV path.
+func WriteReport(report gsh.CostReport, jsonPath, csvPath string) error {
+	bytes, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(jsonPath, bytes, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot write cost report to %q: %w", jsonPath, err)
+	}
+	f, err := os.Create(csvPath)
+	if err != nil {
+		return fmt.Errorf("cannot create cost report %q: %w", csvPath, err)
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	_ = w.Write([]string{"WindowStart", "Pool", "Zone", "Provider", "Region", "InstanceType", "NodeHours", "Cost", "Currency"})
+	for _, e := range report.Entries {
+		_ = w.Write([]string{
+			e.WindowStart.Format(time.RFC3339),
+			e.Pool,
+			e.Zone,
+			string(e.Provider),
+			e.Region,
+			e.InstanceType,
+			strconv.FormatFloat(e.NodeHours, 'f', 2, 64),
+			strconv.FormatFloat(e.Cost, 'f', 2, 64),
+			report.Currency,
+		})
+	}
+	w.Flush()
+	err = w.Error()
+	if err != nil {
+		return fmt.Errorf("cannot write cost report %q: %w", csvPath, err)
+	}
+	slog.Info("wrote cost report", "reportPath", jsonPath, "entriesPath", csvPath, "nodeHours", report.NodeHours,
+		"cost", report.Cost, "currency", report.Currency)
+	return nil
+}
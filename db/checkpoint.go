@@ -0,0 +1,160 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	gsh "github.com/elankath/gardener-scaling-history"
+	"log/slog"
+	"time"
+)
+
+// StoreCheckpoint stores a checkpoint of the latest rows of the checkpointed tables. The SnapshotTime of the
+// checkpoint is the given time, or the latest SnapshotTimestamp of the checkpointed rows if that is later. The rows are
+// read from the read pool, so the checkpoint does not hold up the write pipeline. Rows written while the checkpoint is
+// taken come after the high water RowIDs and are found by the queries starting from the checkpoint.
+func (d *DataAccess) StoreCheckpoint(now time.Time) (checkpoint gsh.CheckpointInfo, err error) {
+	if d.readOnly {
+		return checkpoint, fmt.Errorf("cannot store checkpoint in read-only data db %q", d.name())
+	}
+	var podRowID, mcdRowID, mccRowID, workerPoolRowID, pcRowID, caNodeGroupStatusRowID int64
+	err = d.selectCheckpointHighWaterRowIDs.QueryRow().Scan(&podRowID, &mcdRowID, &mccRowID, &workerPoolRowID, &pcRowID, &caNodeGroupStatusRowID)
+	if err != nil {
+		return checkpoint, fmt.Errorf("cannot get high water RowIDs for checkpoint: %w", err)
+	}
+	nowMillis := now.UTC().UnixMilli()
+	tables := []struct {
+		name           string
+		highWaterRowID int64
+		selectRows     *statement
+		params         []any
+	}{
+		{"pod_info", podRowID, d.selectPodCheckpointRows, []any{podRowID, nowMillis}},
+		{"mcd_info", mcdRowID, d.selectMCDCheckpointRows, []any{mcdRowID}},
+		{"mcc_info", mccRowID, d.selectMCCCheckpointRows, []any{mccRowID}},
+		{"worker_pool_info", workerPoolRowID, d.selectWorkerPoolCheckpointRows, []any{workerPoolRowID}},
+		{"pc_info", pcRowID, d.selectPriorityClassCheckpointRows, []any{pcRowID, nowMillis}},
+		{"ca_nodegroup_status_info", caNodeGroupStatusRowID, d.selectCANodeGroupStatusCheckpointRows, []any{caNodeGroupStatusRowID}},
+	}
+	snapshotMillis := nowMillis
+	tableRows := make([]checkpointTableRow, 0, len(tables))
+	var rowCount int
+	for _, table := range tables {
+		rowRefs, err := queryRows[checkpointRowRef](table.selectRows, table.params...)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return checkpoint, fmt.Errorf("cannot select %s rows for checkpoint: %w", table.name, err)
+		}
+		rowIDs := make([]int64, len(rowRefs))
+		for i, rowRef := range rowRefs {
+			rowIDs[i] = rowRef.RowID
+			snapshotMillis = max(snapshotMillis, rowRef.SnapshotTimestamp)
+		}
+		rowIDsText, err := json.Marshal(rowIDs)
+		if err != nil {
+			return checkpoint, fmt.Errorf("cannot serialize %s RowIDs for checkpoint: %w", table.name, err)
+		}
+		tableRows = append(tableRows, checkpointTableRow{
+			TableName:      table.name,
+			HighWaterRowID: table.highWaterRowID,
+			RowIDs:         string(rowIDsText),
+		})
+		rowCount += len(rowIDs)
+	}
+
+	tx, err := d.dataDB.Begin()
+	if err != nil {
+		return checkpoint, fmt.Errorf("cannot begin transaction for checkpoint: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	result, err := d.insertCheckpointInfo.execOn(tx.Stmt(d.insertCheckpointInfo.Stmt), []any{snapshotMillis, rowCount})
+	if err != nil {
+		return checkpoint, fmt.Errorf("cannot insert checkpoint: %w", err)
+	}
+	checkpointID, err := result.LastInsertId()
+	if err != nil {
+		return checkpoint, fmt.Errorf("cannot retrieve ID of checkpoint: %w", err)
+	}
+	insertTableInfo := tx.Stmt(d.insertCheckpointTableInfo.Stmt)
+	for _, tableRow := range tableRows {
+		_, err = d.insertCheckpointTableInfo.execOn(insertTableInfo, []any{checkpointID, tableRow.TableName, tableRow.HighWaterRowID, tableRow.RowIDs})
+		if err != nil {
+			return checkpoint, fmt.Errorf("cannot insert %s rows of checkpoint %d: %w", tableRow.TableName, checkpointID, err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return checkpoint, fmt.Errorf("cannot commit checkpoint: %w", err)
+	}
+	checkpoint = gsh.CheckpointInfo{
+		CheckpointID: checkpointID,
+		SnapshotTime: timeFromMillis(snapshotMillis),
+		RowCount:     rowCount,
+	}
+	slog.Info("stored checkpoint", "dataDB", d.name(), "CheckpointID", checkpointID, "SnapshotTime", checkpoint.SnapshotTime, "RowCount", rowCount)
+	return
+}
+
+// PruneCheckpoints deletes all but the latest retention checkpoints and returns the number of deleted checkpoints.
+// The checkpoints store the RowIDs of all latest rows, so keeping all of them grows the data db with the square of
+// the recording time. Point-in-time queries at times before the oldest retained checkpoint read the whole history.
+func (d *DataAccess) PruneCheckpoints(retention int) (deleted int64, err error) {
+	if d.readOnly {
+		return 0, fmt.Errorf("cannot prune checkpoints in read-only data db %q", d.name())
+	}
+	if retention < 1 {
+		return 0, fmt.Errorf("cannot prune checkpoints to retention %d, at least 1 must be retained", retention)
+	}
+	tx, err := d.dataDB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("cannot begin transaction for pruning checkpoints: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	_, err = d.deleteCheckpointTableInfosBeyondRetention.execOn(tx.Stmt(d.deleteCheckpointTableInfosBeyondRetention.Stmt), []any{retention})
+	if err != nil {
+		return 0, fmt.Errorf("cannot delete table rows of checkpoints beyond retention %d: %w", retention, err)
+	}
+	result, err := d.deleteCheckpointInfosBeyondRetention.execOn(tx.Stmt(d.deleteCheckpointInfosBeyondRetention.Stmt), []any{retention})
+	if err != nil {
+		return 0, fmt.Errorf("cannot delete checkpoints beyond retention %d: %w", retention, err)
+	}
+	deleted, err = result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("cannot retrieve number of deleted checkpoints: %w", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("cannot commit pruning of checkpoints: %w", err)
+	}
+	if deleted > 0 {
+		slog.Info("pruned checkpoints", "dataDB", d.name(), "deleted", deleted, "retention", retention)
+	}
+	return deleted, nil
+}
+
+// LoadCheckpointInfos returns all checkpoints ordered by SnapshotTime.
+func (d *DataAccess) LoadCheckpointInfos() ([]gsh.CheckpointInfo, error) {
+	checkpointInfos, err := queryAndMapToInfos[gsh.CheckpointInfo, checkpointRow](d.selectCheckpointInfos)
+	if err != nil {
+		return nil, fmt.Errorf("LoadCheckpointInfos could not scan rows: %w", err)
+	}
+	return checkpointInfos, nil
+}
+
+// latestBeforeQuery returns the statement and parameters of a point-in-time query of the given table at the given
+// time. If a checkpoint at or before the time exists, these are the checkpointStmt and the checkpoint RowIDs and
+// high water RowID followed by the given params, else the stmt and the given params.
+func (d *DataAccess) latestBeforeQuery(table string, timestamp time.Time, stmt, checkpointStmt *statement, params ...any) (*statement, []any, error) {
+	tableRows, err := queryRows[checkpointTableRow](d.selectLatestCheckpointTableInfoBefore, table, timestamp)
+	if errors.Is(err, sql.ErrNoRows) {
+		return stmt, params, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot load %s checkpoint before %q: %w", table, timestamp, err)
+	}
+	return checkpointStmt, append([]any{tableRows[0].RowIDs, tableRows[0].HighWaterRowID}, params...), nil
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -14,291 +15,770 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
 	"log/slog"
+	"slices"
 	"strings"
 	"time"
 )
 
 type DataAccess struct {
 	io.Closer
-	dataDBPath                                           string
-	dataDB                                               *sql.DB
-	insertWorkerPoolInfo                                 *sql.Stmt
-	selectWorkerPoolInfosBefore                          *sql.Stmt
-	selectAllWorkerPoolInfoHashes                        *sql.Stmt
-	insertMCDInfo                                        *sql.Stmt
-	updateMCDInfoDeletionTimeStamp                       *sql.Stmt
-	selectMCDInfoHash                                    *sql.Stmt
-	selectLatestMCDInfoBefore                            *sql.Stmt
-	selectLatestMCDInfo                                  *sql.Stmt
-	insertMCCInfo                                        *sql.Stmt
-	updateMCCInfoDeletionTimeStamp                       *sql.Stmt
-	selectMCCInfoHash                                    *sql.Stmt
-	selectLatestMCCInfoBefore                            *sql.Stmt
-	selectLatestMCCInfo                                  *sql.Stmt
-	insertEvent                                          *sql.Stmt
-	insertNodeInfo                                       *sql.Stmt
-	updateNodeInfoDeletionTimeStamp                      *sql.Stmt
-	insertPodInfo                                        *sql.Stmt
-	insertPriorityClassInfo                              *sql.Stmt
-	insertPDB                                            *sql.Stmt
-	updatePodDeletionTimeStamp                           *sql.Stmt
-	updatePdbDeletionTimeStamp                           *sql.Stmt
-	selectLatestPodInfoWithName                          *sql.Stmt
-	selectPodCountWithUIDAndHash                         *sql.Stmt
-	selectEventWithUID                                   *sql.Stmt
-	selectAllEvents                                      *sql.Stmt
-	selectUnscheduledPodsBeforeSnapshotTimestamp         *sql.Stmt
-	selectScheduledPodsBeforeSnapshotTimestamp           *sql.Stmt
-	selectPriorityClassInfoWithUIDAndHash                *sql.Stmt
-	selectLatestPodInfosBeforeSnapshotTimestamp          *sql.Stmt
-	selectLatestPriorityClassInfoBeforeSnapshotTimestamp *sql.Stmt
-	selectNodeInfosBefore                                *sql.Stmt
-	selectNodeCountWithNameAndHash                       *sql.Stmt
-	selectLatestCASettingsInfo                           *sql.Stmt
-	insertCADeployment                                   *sql.Stmt
-	selectCADeploymentByHash                             *sql.Stmt
-	selectLatestNodesBeforeAndNotDeleted                 *sql.Stmt
-	selectLatestCASettingsInfoBefore                     *sql.Stmt
-	selectInitialRecorderStateInfo                       *sql.Stmt
+	dataDBPath                                                         string
+	label                                                              string
+	readOnly                                                           bool
+	dataDB                                                             *sql.DB
+	readDB                                                             *sql.DB
+	writes                                                             *writePipeline
+	writeQueueSize                                                     int
+	writeBatchSize                                                     int
+	writeFlushInterval                                                 time.Duration
+	insertRecorderStateInfo                                            *statement
+	insertPodInfoIfNew                                                 *statement
+	insertNodeInfoIfNew                                                *statement
+	insertWorkerPoolInfo                                               *statement
+	selectWorkerPoolInfosBefore                                        *statement
+	selectAllWorkerPoolInfoHashes                                      *statement
+	insertMCDInfo                                                      *statement
+	updateMCDInfoDeletionTimeStamp                                     *statement
+	selectMCDInfoHash                                                  *statement
+	selectLatestMCDInfoBefore                                          *statement
+	selectLatestMCDInfo                                                *statement
+	insertMCCInfo                                                      *statement
+	updateMCCInfoDeletionTimeStamp                                     *statement
+	selectMCCInfoHash                                                  *statement
+	selectLatestMCCInfoBefore                                          *statement
+	selectLatestMCCInfo                                                *statement
+	insertEvent                                                        *statement
+	insertNodeInfo                                                     *statement
+	updateNodeInfoDeletionTimeStamp                                    *statement
+	insertPodInfo                                                      *statement
+	insertPodSpec                                                      *statement
+	insertPriorityClassInfo                                            *statement
+	insertPDB                                                          *statement
+	updatePodDeletionTimeStamp                                         *statement
+	updatePdbDeletionTimeStamp                                         *statement
+	selectLatestPodInfoWithName                                        *statement
+	selectPodCountWithUIDAndHash                                       *statement
+	selectEventWithUID                                                 *statement
+	selectAllEvents                                                    *statement
+	selectUnscheduledPodsBeforeSnapshotTimestamp                       *statement
+	selectScheduledPodsBeforeSnapshotTimestamp                         *statement
+	selectPriorityClassInfoWithUIDAndHash                              *statement
+	selectLatestPodInfosBeforeSnapshotTimestamp                        *statement
+	selectLatestPriorityClassInfoBeforeSnapshotTimestamp               *statement
+	selectNodeInfosBefore                                              *statement
+	selectNodeCountWithNameAndHash                                     *statement
+	selectLatestCASettingsInfo                                         *statement
+	insertCADeployment                                                 *statement
+	selectCADeploymentByHash                                           *statement
+	selectLatestNodesBeforeAndNotDeleted                               *statement
+	selectLatestCASettingsInfoBefore                                   *statement
+	selectInitialRecorderStateInfo                                     *statement
+	insertCAArgsInfo                                                   *statement
+	selectLatestCAArgsInfo                                             *statement
+	selectLatestCAArgsInfoBefore                                       *statement
+	insertCANodeGroupStatusInfo                                        *statement
+	selectLatestCANodeGroupStatusInfoHashes                            *statement
+	selectLatestCANodeGroupStatusInfosBefore                           *statement
+	insertNodeScaleDownActivityInfo                                    *statement
+	selectNodeScaleDownActivityInfosBetween                            *statement
+	selectNodeDeletionTimestamps                                       *statement
+	insertFailedSchedulingReasonInfo                                   *statement
+	selectFailedSchedulingReasonInfosBetween                           *statement
+	selectFailedSchedulingReasonInfosForPod                            *statement
+	selectFailedSchedulingReasonCountsBetween                          *statement
+	insertCAScalingEventInfo                                           *statement
+	selectCAScalingEventInfosBetween                                   *statement
+	selectCAScalingEventInfosForPod                                    *statement
+	selectCAScalingEventInfosForNodeGroup                              *statement
+	insertRecorderSessionInfo                                          *statement
+	updateRecorderSessionHeartbeat                                     *statement
+	updateRecorderSessionEnd                                           *statement
+	selectRecorderSessionInfos                                         *statement
+	insertWatchDisconnectInfo                                          *statement
+	selectWatchDisconnectInfos                                         *statement
+	selectPodUIDsNotDeleted                                            *statement
+	selectNodeNamesNotDeleted                                          *statement
+	selectMCDNamesNotDeleted                                           *statement
+	updatePodDeletionInferredAtRestart                                 *statement
+	updateNodeDeletionInferredAtRestart                                *statement
+	updateMCDDeletionInferredAtRestart                                 *statement
+	updateMCDInfoInferredAtRestart                                     *statement
+	insertCheckpointInfo                                               *statement
+	insertCheckpointTableInfo                                          *statement
+	selectCheckpointInfos                                              *statement
+	selectLatestCheckpointTableInfoBefore                              *statement
+	deleteCheckpointTableInfosBeyondRetention                          *statement
+	deleteCheckpointInfosBeyondRetention                               *statement
+	selectCheckpointHighWaterRowIDs                                    *statement
+	selectPodCheckpointRows                                            *statement
+	selectMCDCheckpointRows                                            *statement
+	selectMCCCheckpointRows                                            *statement
+	selectWorkerPoolCheckpointRows                                     *statement
+	selectPriorityClassCheckpointRows                                  *statement
+	selectCANodeGroupStatusCheckpointRows                              *statement
+	selectLatestPodInfosBeforeSnapshotTimestampFromCheckpoint          *statement
+	selectLatestMCDInfoBeforeFromCheckpoint                            *statement
+	selectLatestMCCInfoBeforeFromCheckpoint                            *statement
+	selectWorkerPoolInfosBeforeFromCheckpoint                          *statement
+	selectLatestPriorityClassInfoBeforeSnapshotTimestampFromCheckpoint *statement
+	selectLatestCANodeGroupStatusInfosBeforeFromCheckpoint             *statement
 }
 
 func NewDataAccess(dataDBPath string) *DataAccess {
 	access := &DataAccess{
-		dataDBPath: dataDBPath,
+		dataDBPath:         dataDBPath,
+		label:              dataDBPath,
+		writeQueueSize:     DefaultWriteQueueSize,
+		writeBatchSize:     DefaultWriteBatchSize,
+		writeFlushInterval: DefaultWriteFlushInterval,
 	}
 	return access
 }
 
+// NewReadOnlyDataAccess creates a DataAccess that only reads the data db at the given path, for instance while a
+// recorder is writing to it. Init neither creates the schema nor writes to the db, so the db must have been created
+// by a recorder of this version. All writes fail.
+func NewReadOnlyDataAccess(dataDBPath string) *DataAccess {
+	return &DataAccess{
+		dataDBPath: dataDBPath,
+		label:      dataDBPath,
+		readOnly:   true,
+	}
+}
+
+// Init opens the data db. The db is switched to WAL mode so that readers, also in other processes, do not block the
+// writer and vice versa. Writes go through a pool with a single connection and reads through a separate read-only
+// pool. Both wait for locks held by other connections instead of failing with SQLITE_BUSY.
 func (d *DataAccess) Init() error {
-	db, err := sql.Open("sqlite", d.dataDBPath)
+	readDB, err := sql.Open("sqlite", "file:"+d.dataDBPath+"?mode=ro&_pragma=busy_timeout(10000)&_pragma=query_only(1)")
+	if err != nil {
+		return fmt.Errorf("cannot open db for reading: %w", err)
+	}
+	if d.readOnly {
+		d.readDB = readDB
+		d.dataDB = readDB
+		err = d.prepareStatements(d.prepare)
+		if err != nil {
+			return fmt.Errorf("error preparing statements: %w", err)
+		}
+		return nil
+	}
+	writeDB, err := sql.Open("sqlite", d.dataDBPath+"?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=busy_timeout(10000)")
 	if err != nil {
+		_ = readDB.Close()
 		return fmt.Errorf("cannot open db: %w", err)
 	}
-	d.dataDB = db
+	writeDB.SetMaxOpenConns(1)
+	d.dataDB = writeDB
+	d.readDB = readDB
 	err = d.createSchema()
 	if err != nil {
 		return fmt.Errorf("error creating db schema: %w", err)
 	}
-	err = d.prepareStatements()
+	err = d.migrateSchema()
+	if err != nil {
+		return err
+	}
+	err = d.prepareStatements(d.prepare)
 	if err != nil {
 		return fmt.Errorf("error preparing statements: %w", err)
 	}
+	d.writes = newWritePipeline(writeDB, d.writeQueueSize, d.writeBatchSize, d.writeFlushInterval, false)
 	return nil
 }
 
+// name returns the name of the data db for logs and errors.
+func (d *DataAccess) name() string {
+	return d.label
+}
+
+// prepare prepares the given query on the read pool if it is a SELECT and on the write pool otherwise.
+func (d *DataAccess) prepare(query string) (*statement, error) {
+	var stmt *sql.Stmt
+	var err error
+	if strings.HasPrefix(strings.TrimSpace(query), "SELECT") {
+		stmt, err = d.readDB.Prepare(query)
+	} else {
+		stmt, err = d.dataDB.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &statement{Stmt: stmt}, nil
+}
+
+// statement is a prepared statement of a DataAccess. Its Exec, Query and QueryRow take the parameters of the SQLite
+// query. For a PostgreSQL data db, which holds the rows of many clusters, the cluster identifier is passed as first
+// parameter, and inserts return the RowID of the inserted row since lib/pq does not support LastInsertId.
+type statement struct {
+	*sql.Stmt
+	clusterID    string
+	returnsRowID bool
+}
+
+func (s *statement) Exec(args ...any) (sql.Result, error) {
+	return s.execOn(s.Stmt, args)
+}
+
+func (s *statement) Query(args ...any) (*sql.Rows, error) {
+	return s.Stmt.Query(s.withClusterID(args)...)
+}
+
+func (s *statement) QueryRow(args ...any) *sql.Row {
+	return s.Stmt.QueryRow(s.withClusterID(args)...)
+}
+
+// execOn executes the given stmt, which is this statement or this statement bound to a transaction.
+func (s *statement) execOn(stmt *sql.Stmt, args []any) (sql.Result, error) {
+	if !s.returnsRowID {
+		return stmt.Exec(s.withClusterID(args)...)
+	}
+	var rowID int64
+	err := stmt.QueryRow(s.withClusterID(args)...).Scan(&rowID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the insert was skipped by ON CONFLICT DO NOTHING or a WHERE NOT EXISTS clause
+		return insertResult{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return insertResult{rowID: rowID, rowsAffected: 1}, nil
+}
+
+func (s *statement) withClusterID(args []any) []any {
+	if s.clusterID == "" {
+		return args
+	}
+	return append([]any{s.clusterID}, args...)
+}
+
+// insertResult is the sql.Result of an insert returning the RowID.
+type insertResult struct {
+	rowID        int64
+	rowsAffected int64
+}
+
+func (r insertResult) LastInsertId() (int64, error) {
+	return r.rowID, nil
+}
+
+func (r insertResult) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
 func (d *DataAccess) Close() error {
 	if d.dataDB == nil {
 		return nil
 	}
-	slog.Info("stopping data db", "dataDBPath", d.dataDBPath)
+	slog.Info("stopping data db", "dataDB", d.name())
+	if d.writes != nil {
+		d.writes.close()
+		slog.Info("flushed pending writes", "dataDB", d.name(), "writeStats", d.writes.stats())
+	}
+	// The read pool is closed first so that the write pool holds the last connection, which checkpoints the WAL.
+	if d.readDB != d.dataDB {
+		err := d.readDB.Close()
+		if err != nil {
+			slog.Warn("cannot close data db read pool", "error", err)
+		}
+	}
 	err := d.dataDB.Close()
 	if err != nil {
 		slog.Warn("cannot close data db", "error", err)
 		return err
 	}
 	d.dataDB = nil
+	d.readDB = nil
 	return nil
 }
 
 func (d *DataAccess) InsertRecorderStartTime(startTime time.Time) error {
-	_, err := d.dataDB.Exec(InsertRecorderStateInfo, startTime.UTC().UnixMilli())
+	_, err := d.insertRecorderStateInfo.Exec(startTime.UTC().UnixMilli())
 	if err != nil {
 		return fmt.Errorf("cannot execute the InsertRecorderStateInfo statement: %w", err)
 	}
 	return nil
 }
 
-func (d *DataAccess) prepareStatements() (err error) {
-	db := d.dataDB
-	d.insertWorkerPoolInfo, err = db.Prepare(InsertWorkerPoolInfo)
+// CheckWritable checks that the data db is open and writable by executing a no-op write in a transaction that is
+// rolled back.
+func (d *DataAccess) CheckWritable(ctx context.Context) error {
+	return d.checkWritable(ctx, CheckWritableRecorderStateInfo)
+}
+
+// checkWritable executes the given no-op write query in a transaction that is rolled back.
+func (d *DataAccess) checkWritable(ctx context.Context, query string) error {
+	if d.dataDB == nil {
+		return fmt.Errorf("data db %q is not open", d.name())
+	}
+	tx, err := d.dataDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("cannot begin transaction on data db %q: %w", d.name(), err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	_, err = tx.ExecContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("cannot write to data db %q: %w", d.name(), err)
+	}
+	return nil
+}
+
+func (d *DataAccess) prepareStatements(prepare func(query string) (*statement, error)) (err error) {
+	d.insertRecorderStateInfo, err = prepare(InsertRecorderStateInfo)
+	if err != nil {
+		return fmt.Errorf("cannot prepare insertRecorderStateInfo statement: %w", err)
+	}
+
+	d.insertWorkerPoolInfo, err = prepare(InsertWorkerPoolInfo)
 	if err != nil {
 		return fmt.Errorf("cannot prepare insertWorkerPoolInfo statement: %w", err)
 	}
 
-	d.selectWorkerPoolInfosBefore, err = db.Prepare(SelectWorkerPoolInfoBefore)
+	d.selectWorkerPoolInfosBefore, err = prepare(SelectWorkerPoolInfoBefore)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectWorkerPoolInfosBefore statement: %w", err)
 	}
 
-	d.selectAllWorkerPoolInfoHashes, err = db.Prepare(SelectAllWorkerPoolInfoHashes)
+	d.selectAllWorkerPoolInfoHashes, err = prepare(SelectAllWorkerPoolInfoHashes)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectAllWorkerPoolInfoHashes statement: %w", err)
 	}
 
-	d.insertNodeInfo, err = db.Prepare(InsertNodeInfo)
+	d.insertNodeInfo, err = prepare(InsertNodeInfo)
 	if err != nil {
 		return fmt.Errorf("cannot prepare insertNodeInfo statement: %w", err)
 	}
-	d.updateNodeInfoDeletionTimeStamp, err = db.Prepare(UpdateNodeInfoDeletionTimestamp)
+	d.updateNodeInfoDeletionTimeStamp, err = prepare(UpdateNodeInfoDeletionTimestamp)
 	if err != nil {
 		return fmt.Errorf("cannot prepare updateNodeInfoDeletionTimeStamp: %w", err)
 	}
 
-	d.selectNodeInfosBefore, err = db.Prepare(SelectNodeInfoBefore)
+	d.selectNodeInfosBefore, err = prepare(SelectNodeInfoBefore)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectNodeInfosBefore statement: %w", err)
 	}
 
-	pdbInsertStmt, err := db.Prepare("INSERT INTO pdb_info(uid,name,generation,creationTimestamp,minAvailable,maxUnAvailable,spec) VALUES(?,?,?,?,?,?,?)")
+	pdbInsertStmt, err := prepare(InsertPDBInfo)
 	if err != nil {
 		return fmt.Errorf("cannot prepare pdb insert statement : %w", err)
 	}
 	d.insertPDB = pdbInsertStmt
 
-	d.updatePdbDeletionTimeStamp, err = db.Prepare("UPDATE pdb_info SET DeletionTimeStamp=? WHERE uid=?")
+	d.updatePdbDeletionTimeStamp, err = prepare(UpdatePDBInfoDeletionTimestamp)
 	if err != nil {
 		return fmt.Errorf("cannot prepare updatePdbDeletionTimeStamp: %w", err)
 	}
 
-	d.insertMCDInfo, err = db.Prepare(InsertMCDInfo)
+	d.insertMCDInfo, err = prepare(InsertMCDInfo)
 	if err != nil {
 		return fmt.Errorf("cannot prepare insertMCDInfo: %w", err)
 	}
 
-	d.updateMCDInfoDeletionTimeStamp, err = db.Prepare(UpdateMCDInfoDeletionTimestamp)
+	d.updateMCDInfoDeletionTimeStamp, err = prepare(UpdateMCDInfoDeletionTimestamp)
 	if err != nil {
 		return fmt.Errorf("cannot prepare updateMCDInfoDeletionTimeStamp: %w", err)
 	}
 
-	d.selectLatestMCDInfoBefore, err = db.Prepare(SelectLatestMCDInfoBefore)
+	d.selectLatestMCDInfoBefore, err = prepare(SelectLatestMCDInfoBefore)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectLatestMCDInfoBefore statement: %w", err)
 	}
 
-	d.selectLatestMCDInfo, err = db.Prepare(SelectLatestMCDInfo)
+	d.selectLatestMCDInfo, err = prepare(SelectLatestMCDInfo)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectLatestMCDInfo: %w", err)
 	}
 
-	d.selectMCDInfoHash, err = db.Prepare(SelectMCDInfoHash)
+	d.selectMCDInfoHash, err = prepare(SelectMCDInfoHash)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectMCDInfoHash: %w", err)
 	}
 
-	d.insertMCCInfo, err = db.Prepare(InsertMCCInfo)
+	d.insertMCCInfo, err = prepare(InsertMCCInfo)
 	if err != nil {
 		return fmt.Errorf("cannot prepare insertMCCInfo: %w", err)
 	}
 
-	d.updateMCCInfoDeletionTimeStamp, err = db.Prepare(UpdateMCCInfoDeletionTimestamp)
+	d.updateMCCInfoDeletionTimeStamp, err = prepare(UpdateMCCInfoDeletionTimestamp)
 	if err != nil {
 		return fmt.Errorf("cannot prepare updateMCCInfoDeletionTimeStamp: %w", err)
 	}
 
-	d.selectLatestMCCInfoBefore, err = db.Prepare(SelectLatestMCCInfoBefore)
+	d.selectLatestMCCInfoBefore, err = prepare(SelectLatestMCCInfoBefore)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectLatestMCCInfoBefore statement: %w", err)
 	}
 
-	d.selectLatestMCCInfo, err = db.Prepare(SelectLatestMCCInfo)
+	d.selectLatestMCCInfo, err = prepare(SelectLatestMCCInfo)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectLatestMCCInfo: %w", err)
 	}
 
-	d.selectMCCInfoHash, err = db.Prepare(SelectMCCInfoHash)
+	d.selectMCCInfoHash, err = prepare(SelectMCCInfoHash)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectMCCInfoHash: %w", err)
 	}
 
-	d.selectLatestPodInfoWithName, err = db.Prepare("SELECT * FROM pod_info WHERE Name=? ORDER BY CreationTimestamp DESC LIMIT 1")
+	d.selectLatestPodInfoWithName, err = prepare(SelectLatestPodInfoWithName)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectLatestPodInfoWithName: %w", err)
 	}
-	d.insertEvent, err = db.Prepare(InsertEvent)
+	d.insertEvent, err = prepare(InsertEvent)
 	if err != nil {
 		return fmt.Errorf("cannot prepare events insert statement: %w", err)
 	}
-	d.insertPodInfo, err = db.Prepare(InsertPodInfo)
+	d.insertPodInfo, err = prepare(InsertPodInfo)
 	if err != nil {
 		return fmt.Errorf("cannot prepare pod insert statement: %w", err)
 	}
-	d.insertPriorityClassInfo, err = db.Prepare(InsertPriorityClassInfo)
+	d.insertPriorityClassInfo, err = prepare(InsertPriorityClassInfo)
 	if err != nil {
 		return fmt.Errorf("cannot prepare InsertPriorityClassInfo statement: %w", err)
 	}
 
-	//TODO: must create indexes
-	d.selectPodCountWithUIDAndHash, err = db.Prepare(SelectPodCountWithUIDAndHash)
+	d.selectPodCountWithUIDAndHash, err = prepare(SelectPodCountWithUIDAndHash)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectPodCountWithUIDAndHash: %w", err)
 	}
 
-	d.updatePodDeletionTimeStamp, err = db.Prepare(UpdatePodDeletionTimestamp)
+	d.updatePodDeletionTimeStamp, err = prepare(UpdatePodDeletionTimestamp)
 	if err != nil {
 		return fmt.Errorf("cannot prepare updatePodDeletionTimeStamp: %w", err)
 	}
 
-	d.selectEventWithUID, err = db.Prepare("SELECT * from event_info where UID = ?")
+	d.selectEventWithUID, err = prepare(SelectEventInfoWithUID)
 
-	d.selectAllEvents, err = db.Prepare("SELECT * from event_info ORDER BY EventTime")
+	d.selectAllEvents, err = prepare(SelectAllEventInfos)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectAllEvents statement: %w", err)
 	}
 
-	d.selectUnscheduledPodsBeforeSnapshotTimestamp, err = db.Prepare(SelectUnscheduledPodsBeforeSnapshotTimestamp)
+	d.selectUnscheduledPodsBeforeSnapshotTimestamp, err = prepare(SelectUnscheduledPodsBeforeSnapshotTimestamp)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectUnscheduledPodsBeforeSnapshotTimestamp statement: %w", err)
 	}
 
-	d.selectScheduledPodsBeforeSnapshotTimestamp, err = db.Prepare(SelectLatestScheduledPodsBeforeSnapshotTimestamp)
+	d.selectScheduledPodsBeforeSnapshotTimestamp, err = prepare(SelectLatestScheduledPodsBeforeSnapshotTimestamp)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectScheduledPodsBeforeSnapshotTimestamp statement: %w", err)
 	}
 
-	d.selectLatestPodInfosBeforeSnapshotTimestamp, err = db.Prepare(SelectLatestPodsBeforeSnapshotTimestamp)
+	d.selectLatestPodInfosBeforeSnapshotTimestamp, err = prepare(SelectLatestPodsBeforeSnapshotTimestamp)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectLatestPodInfosBeforeSnapshotTimestamp statement: %w", err)
 	}
 
-	d.selectLatestPriorityClassInfoBeforeSnapshotTimestamp, err = db.Prepare(SelectLatestPriorityClassInfoBeforeSnapshotTimestamp)
+	d.selectLatestPriorityClassInfoBeforeSnapshotTimestamp, err = prepare(SelectLatestPriorityClassInfoBeforeSnapshotTimestamp)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectLatestPriorityClassInfoBeforeSnapshotTimestamp statement: %w", err)
 	}
 
-	d.selectPodCountWithUIDAndHash, err = db.Prepare(SelectPodCountWithUIDAndHash)
+	d.selectPodCountWithUIDAndHash, err = prepare(SelectPodCountWithUIDAndHash)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectPodCountWithUIDAndHash: %w", err)
 	}
 
-	d.selectPriorityClassInfoWithUIDAndHash, err = db.Prepare(SelectPriorityClassInfoCountWithUIDAndHash)
+	d.selectPriorityClassInfoWithUIDAndHash, err = prepare(SelectPriorityClassInfoCountWithUIDAndHash)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectPriorityClassInfoWithUIDAndHash: %w", err)
 	}
 
-	d.selectNodeCountWithNameAndHash, err = db.Prepare(SelectNodeCountWithNameAndHash)
+	d.selectNodeCountWithNameAndHash, err = prepare(SelectNodeCountWithNameAndHash)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectNodeCountWithNameAndHash: %w", err)
 	}
 
-	d.selectCADeploymentByHash, err = db.Prepare(SelectCADeploymentByHash)
+	d.selectCADeploymentByHash, err = prepare(SelectCADeploymentByHash)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectCADeploymentByHash: %w", err)
 	}
 
-	d.selectLatestCASettingsInfo, err = db.Prepare(SelectLatestCASettingsInfo)
+	d.selectLatestCASettingsInfo, err = prepare(SelectLatestCASettingsInfo)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectLatestCASettingsInfo")
 	}
 
-	d.selectLatestCASettingsInfoBefore, err = db.Prepare(SelectLatestCASettingsBefore)
+	d.selectLatestCASettingsInfoBefore, err = prepare(SelectLatestCASettingsBefore)
 	if err != nil {
 		return fmt.Errorf("cannot prepare selectLatestCASettingsInfoBefore")
 	}
 
-	d.insertCADeployment, err = db.Prepare(InsertCASettingsInfo)
+	d.insertCADeployment, err = prepare(InsertCASettingsInfo)
 	if err != nil {
 		return fmt.Errorf("cannot prepare insertCADeployment statement")
 	}
 
-	d.selectLatestNodesBeforeAndNotDeleted, err = db.Prepare(SelectLatestNodesBeforeAndNotDeleted)
+	d.selectLatestNodesBeforeAndNotDeleted, err = prepare(SelectLatestNodesBeforeAndNotDeleted)
 	if err != nil {
 		return fmt.Errorf("cannot prepare ")
 	}
 
-	d.selectInitialRecorderStateInfo, err = d.dataDB.Prepare(SelectInitialRecorderStateInfo)
+	d.selectInitialRecorderStateInfo, err = prepare(SelectInitialRecorderStateInfo)
 	if err != nil {
 		return
 	}
+
+	d.insertCAArgsInfo, err = prepare(InsertCAArgsInfo)
+	if err != nil {
+		return fmt.Errorf("cannot prepare insertCAArgsInfo statement: %w", err)
+	}
+
+	d.selectLatestCAArgsInfo, err = prepare(SelectLatestCAArgsInfo)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectLatestCAArgsInfo statement: %w", err)
+	}
+
+	d.selectLatestCAArgsInfoBefore, err = prepare(SelectLatestCAArgsInfoBefore)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectLatestCAArgsInfoBefore statement: %w", err)
+	}
+
+	d.insertCANodeGroupStatusInfo, err = prepare(InsertCANodeGroupStatusInfo)
+	if err != nil {
+		return fmt.Errorf("cannot prepare insertCANodeGroupStatusInfo statement: %w", err)
+	}
+
+	d.selectLatestCANodeGroupStatusInfoHashes, err = prepare(SelectLatestCANodeGroupStatusInfoHashes)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectLatestCANodeGroupStatusInfoHashes statement: %w", err)
+	}
+
+	d.selectLatestCANodeGroupStatusInfosBefore, err = prepare(SelectLatestCANodeGroupStatusInfosBefore)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectLatestCANodeGroupStatusInfosBefore statement: %w", err)
+	}
+
+	d.insertNodeScaleDownActivityInfo, err = prepare(InsertNodeScaleDownActivityInfo)
+	if err != nil {
+		return fmt.Errorf("cannot prepare insertNodeScaleDownActivityInfo statement: %w", err)
+	}
+
+	d.selectNodeScaleDownActivityInfosBetween, err = prepare(SelectNodeScaleDownActivityInfosBetween)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectNodeScaleDownActivityInfosBetween statement: %w", err)
+	}
+
+	d.selectNodeDeletionTimestamps, err = prepare(SelectNodeDeletionTimestamps)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectNodeDeletionTimestamps statement: %w", err)
+	}
+
+	d.insertFailedSchedulingReasonInfo, err = prepare(InsertFailedSchedulingReasonInfo)
+	if err != nil {
+		return fmt.Errorf("cannot prepare insertFailedSchedulingReasonInfo statement: %w", err)
+	}
+
+	d.selectFailedSchedulingReasonInfosBetween, err = prepare(SelectFailedSchedulingReasonInfosBetween)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectFailedSchedulingReasonInfosBetween statement: %w", err)
+	}
+
+	d.selectFailedSchedulingReasonInfosForPod, err = prepare(SelectFailedSchedulingReasonInfosForPod)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectFailedSchedulingReasonInfosForPod statement: %w", err)
+	}
+
+	d.selectFailedSchedulingReasonCountsBetween, err = prepare(SelectFailedSchedulingReasonCountsBetween)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectFailedSchedulingReasonCountsBetween statement: %w", err)
+	}
+
+	d.insertCAScalingEventInfo, err = prepare(InsertCAScalingEventInfo)
+	if err != nil {
+		return fmt.Errorf("cannot prepare insertCAScalingEventInfo statement: %w", err)
+	}
+
+	d.selectCAScalingEventInfosBetween, err = prepare(SelectCAScalingEventInfosBetween)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectCAScalingEventInfosBetween statement: %w", err)
+	}
+
+	d.selectCAScalingEventInfosForPod, err = prepare(SelectCAScalingEventInfosForPod)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectCAScalingEventInfosForPod statement: %w", err)
+	}
+
+	d.selectCAScalingEventInfosForNodeGroup, err = prepare(SelectCAScalingEventInfosForNodeGroup)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectCAScalingEventInfosForNodeGroup statement: %w", err)
+	}
+
+	d.insertPodSpec, err = prepare(InsertPodSpec)
+	if err != nil {
+		return fmt.Errorf("cannot prepare insertPodSpec statement: %w", err)
+	}
+
+	d.insertPodInfoIfNew, err = prepare(InsertPodInfoIfNew)
+	if err != nil {
+		return fmt.Errorf("cannot prepare insertPodInfoIfNew statement: %w", err)
+	}
+
+	d.insertNodeInfoIfNew, err = prepare(InsertNodeInfoIfNew)
+	if err != nil {
+		return fmt.Errorf("cannot prepare insertNodeInfoIfNew statement: %w", err)
+	}
+
+	d.insertRecorderSessionInfo, err = prepare(InsertRecorderSessionInfo)
+	if err != nil {
+		return fmt.Errorf("cannot prepare insertRecorderSessionInfo statement: %w", err)
+	}
+
+	d.updateRecorderSessionHeartbeat, err = prepare(UpdateRecorderSessionHeartbeat)
+	if err != nil {
+		return fmt.Errorf("cannot prepare updateRecorderSessionHeartbeat statement: %w", err)
+	}
+
+	d.updateRecorderSessionEnd, err = prepare(UpdateRecorderSessionEnd)
+	if err != nil {
+		return fmt.Errorf("cannot prepare updateRecorderSessionEnd statement: %w", err)
+	}
+
+	d.selectRecorderSessionInfos, err = prepare(SelectRecorderSessionInfos)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectRecorderSessionInfos statement: %w", err)
+	}
+
+	d.insertWatchDisconnectInfo, err = prepare(InsertWatchDisconnectInfo)
+	if err != nil {
+		return fmt.Errorf("cannot prepare insertWatchDisconnectInfo statement: %w", err)
+	}
+
+	d.selectWatchDisconnectInfos, err = prepare(SelectWatchDisconnectInfos)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectWatchDisconnectInfos statement: %w", err)
+	}
+
+	d.insertCheckpointInfo, err = prepare(InsertCheckpointInfo)
+	if err != nil {
+		return fmt.Errorf("cannot prepare insertCheckpointInfo statement: %w", err)
+	}
+
+	d.insertCheckpointTableInfo, err = prepare(InsertCheckpointTableInfo)
+	if err != nil {
+		return fmt.Errorf("cannot prepare insertCheckpointTableInfo statement: %w", err)
+	}
+
+	d.selectCheckpointInfos, err = prepare(SelectCheckpointInfos)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectCheckpointInfos statement: %w", err)
+	}
+
+	d.selectLatestCheckpointTableInfoBefore, err = prepare(SelectLatestCheckpointTableInfoBefore)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectLatestCheckpointTableInfoBefore statement: %w", err)
+	}
+
+	d.deleteCheckpointTableInfosBeyondRetention, err = prepare(DeleteCheckpointTableInfosBeyondRetention)
+	if err != nil {
+		return fmt.Errorf("cannot prepare deleteCheckpointTableInfosBeyondRetention statement: %w", err)
+	}
+
+	d.deleteCheckpointInfosBeyondRetention, err = prepare(DeleteCheckpointInfosBeyondRetention)
+	if err != nil {
+		return fmt.Errorf("cannot prepare deleteCheckpointInfosBeyondRetention statement: %w", err)
+	}
+
+	d.selectCheckpointHighWaterRowIDs, err = prepare(SelectCheckpointHighWaterRowIDs)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectCheckpointHighWaterRowIDs statement: %w", err)
+	}
+
+	d.selectPodCheckpointRows, err = prepare(SelectPodCheckpointRows)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectPodCheckpointRows statement: %w", err)
+	}
+
+	d.selectMCDCheckpointRows, err = prepare(SelectMCDCheckpointRows)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectMCDCheckpointRows statement: %w", err)
+	}
+
+	d.selectMCCCheckpointRows, err = prepare(SelectMCCCheckpointRows)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectMCCCheckpointRows statement: %w", err)
+	}
+
+	d.selectWorkerPoolCheckpointRows, err = prepare(SelectWorkerPoolCheckpointRows)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectWorkerPoolCheckpointRows statement: %w", err)
+	}
+
+	d.selectPriorityClassCheckpointRows, err = prepare(SelectPriorityClassCheckpointRows)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectPriorityClassCheckpointRows statement: %w", err)
+	}
+
+	d.selectCANodeGroupStatusCheckpointRows, err = prepare(SelectCANodeGroupStatusCheckpointRows)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectCANodeGroupStatusCheckpointRows statement: %w", err)
+	}
+
+	d.selectLatestPodInfosBeforeSnapshotTimestampFromCheckpoint, err = prepare(SelectLatestPodsBeforeSnapshotTimestampFromCheckpoint)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectLatestPodInfosBeforeSnapshotTimestampFromCheckpoint statement: %w", err)
+	}
+
+	d.selectLatestMCDInfoBeforeFromCheckpoint, err = prepare(SelectLatestMCDInfoBeforeFromCheckpoint)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectLatestMCDInfoBeforeFromCheckpoint statement: %w", err)
+	}
+
+	d.selectLatestMCCInfoBeforeFromCheckpoint, err = prepare(SelectLatestMCCInfoBeforeFromCheckpoint)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectLatestMCCInfoBeforeFromCheckpoint statement: %w", err)
+	}
+
+	d.selectWorkerPoolInfosBeforeFromCheckpoint, err = prepare(SelectWorkerPoolInfoBeforeFromCheckpoint)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectWorkerPoolInfosBeforeFromCheckpoint statement: %w", err)
+	}
+
+	d.selectLatestPriorityClassInfoBeforeSnapshotTimestampFromCheckpoint, err = prepare(SelectLatestPriorityClassInfoBeforeSnapshotTimestampFromCheckpoint)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectLatestPriorityClassInfoBeforeSnapshotTimestampFromCheckpoint statement: %w", err)
+	}
+
+	d.selectLatestCANodeGroupStatusInfosBeforeFromCheckpoint, err = prepare(SelectLatestCANodeGroupStatusInfosBeforeFromCheckpoint)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectLatestCANodeGroupStatusInfosBeforeFromCheckpoint statement: %w", err)
+	}
+
+	d.selectPodUIDsNotDeleted, err = prepare(SelectPodUIDsNotDeleted)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectPodUIDsNotDeleted statement: %w", err)
+	}
+
+	d.selectNodeNamesNotDeleted, err = prepare(SelectNodeNamesNotDeleted)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectNodeNamesNotDeleted statement: %w", err)
+	}
+
+	d.selectMCDNamesNotDeleted, err = prepare(SelectMCDNamesNotDeleted)
+	if err != nil {
+		return fmt.Errorf("cannot prepare selectMCDNamesNotDeleted statement: %w", err)
+	}
+
+	d.updatePodDeletionInferredAtRestart, err = prepare(UpdatePodDeletionInferredAtRestart)
+	if err != nil {
+		return fmt.Errorf("cannot prepare updatePodDeletionInferredAtRestart statement: %w", err)
+	}
+
+	d.updateNodeDeletionInferredAtRestart, err = prepare(UpdateNodeDeletionInferredAtRestart)
+	if err != nil {
+		return fmt.Errorf("cannot prepare updateNodeDeletionInferredAtRestart statement: %w", err)
+	}
+
+	d.updateMCDDeletionInferredAtRestart, err = prepare(UpdateMCDDeletionInferredAtRestart)
+	if err != nil {
+		return fmt.Errorf("cannot prepare updateMCDDeletionInferredAtRestart statement: %w", err)
+	}
+
+	d.updateMCDInfoInferredAtRestart, err = prepare(UpdateMCDInfoInferredAtRestart)
+	if err != nil {
+		return fmt.Errorf("cannot prepare updateMCDInfoInferredAtRestart statement: %w", err)
+	}
 	return err
 }
 
@@ -356,22 +836,19 @@ func (d *DataAccess) createSchema() error {
 	}
 	slog.Info("successfully created pod_info table", "result", result)
 
+	result, err = db.Exec(CreatePodSpecTable)
+	if err != nil {
+		return fmt.Errorf("cannot create pod_spec table: %w", err)
+	}
+	slog.Info("successfully created pod_spec table", "result", result)
+
 	result, err = db.Exec(CreatePriorityClassInfoTable)
 	if err != nil {
 		return fmt.Errorf("cannot create pc_info table: %w", err)
 	}
 	slog.Info("successfully created pc_info table", "result", result)
 
-	result, err = db.Exec(`CREATE TABLE IF NOT EXISTS pdb_info(
-    							id INTEGER PRIMARY KEY AUTOINCREMENT,
-    							uid TEXT,
-    							name TEXT,
-    							generation INT,
-    							creationTimestamp DATETIME,
-    							deletionTimestamp DATETIME,
-    							minAvailable TEXT,
-    							maxUnAvailable TEXT,
-    							spec TEXT)`) // TODO: maxUnAvailable -> maxUnavailable
+	result, err = db.Exec(CreatePDBInfoTable)
 	if err != nil {
 		return fmt.Errorf("cannot create pdb_info table: %w", err)
 	}
@@ -383,40 +860,98 @@ func (d *DataAccess) createSchema() error {
 	}
 	slog.Info("successfully created the ca_settings_info table")
 
-	return nil
-}
+	result, err = db.Exec(CreateCAArgsInfoTable)
+	if err != nil {
+		return fmt.Errorf("cannot create ca_args_info table: %w", err)
+	}
+	slog.Info("successfully created the ca_args_info table", "result", result)
 
-func (d *DataAccess) CountPCInfoWithSpecHash(uid, hash string) (int, error) {
-	var count sql.NullInt32
-	err := d.selectPriorityClassInfoWithUIDAndHash.QueryRow(uid, hash).Scan(&count)
-	if count.Valid {
-		return int(count.Int32), nil
+	result, err = db.Exec(CreateCANodeGroupStatusInfoTable)
+	if err != nil {
+		return fmt.Errorf("cannot create ca_nodegroup_status_info table: %w", err)
 	}
+	slog.Info("successfully created the ca_nodegroup_status_info table", "result", result)
+
+	result, err = db.Exec(CreateNodeScaleDownActivityInfoTable)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return -1, nil
-		}
+		return fmt.Errorf("cannot create node_scale_down_activity_info table: %w", err)
 	}
-	return -1, err
-}
+	slog.Info("successfully created the node_scale_down_activity_info table", "result", result)
 
-func (d *DataAccess) CountPodInfoWithSpecHash(uid, hash string) (int, error) {
-	var count sql.NullInt32
-	err := d.selectPodCountWithUIDAndHash.QueryRow(uid, hash).Scan(&count)
-	if count.Valid {
-		return int(count.Int32), nil
+	result, err = db.Exec(CreateFailedSchedulingReasonInfoTable)
+	if err != nil {
+		return fmt.Errorf("cannot create failed_scheduling_reason_info table: %w", err)
 	}
+	slog.Info("successfully created the failed_scheduling_reason_info table", "result", result)
+
+	result, err = db.Exec(CreateCAScalingEventInfoTable)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return -1, nil
-		}
+		return fmt.Errorf("cannot create ca_scaling_event_info table: %w", err)
 	}
-	return -1, err
-}
+	slog.Info("successfully created the ca_scaling_event_info table", "result", result)
 
-func (d *DataAccess) CountNodeInfoWithHash(name, hash string) (int, error) {
-	var count sql.NullInt32
-	err := d.selectNodeCountWithNameAndHash.QueryRow(name, hash).Scan(&count)
+	result, err = db.Exec(CreateRecorderSessionInfoTable)
+	if err != nil {
+		return fmt.Errorf("cannot create recorder_session_info table: %w", err)
+	}
+	slog.Info("successfully created the recorder_session_info table", "result", result)
+
+	result, err = db.Exec(CreateWatchDisconnectInfoTable)
+	if err != nil {
+		return fmt.Errorf("cannot create watch_disconnect_info table: %w", err)
+	}
+	slog.Info("successfully created the watch_disconnect_info table", "result", result)
+
+	result, err = db.Exec(CreateCheckpointInfoTable)
+	if err != nil {
+		return fmt.Errorf("cannot create checkpoint_info table: %w", err)
+	}
+	slog.Info("successfully created the checkpoint_info table", "result", result)
+
+	result, err = db.Exec(CreateCheckpointTableInfoTable)
+	if err != nil {
+		return fmt.Errorf("cannot create checkpoint_table_info table: %w", err)
+	}
+	slog.Info("successfully created the checkpoint_table_info table", "result", result)
+
+	// pod_info, node_info and mcd_info tables created before restart reconciliation lack the inferred flags.
+	for _, table := range []string{"pod_info", "node_info", "mcd_info"} {
+		for _, column := range []string{"InferredAtRestart", "DeletionInferredAtRestart"} {
+			err = d.addColumnIfNotExists(table, column, "INT NOT NULL DEFAULT 0")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// addColumnIfNotExists adds the column with the given name and definition to the given table unless it is present.
+func (d *DataAccess) addColumnIfNotExists(table, column, definition string) error {
+	rows, err := d.dataDB.Query(fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", table))
+	if err != nil {
+		return fmt.Errorf("cannot get the columns of table %s: %w", table, err)
+	}
+	var columns []string
+	err = scan.RowsStrict(&columns, rows)
+	if err != nil {
+		return fmt.Errorf("cannot scan the columns of table %s: %w", table, err)
+	}
+	if slices.Contains(columns, column) {
+		return nil
+	}
+	_, err = d.dataDB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("cannot add column %s to table %s: %w", column, table, err)
+	}
+	slog.Info("added column to table", "table", table, "column", column)
+	return nil
+}
+
+func (d *DataAccess) CountPCInfoWithSpecHash(uid, hash string) (int, error) {
+	var count sql.NullInt32
+	err := d.selectPriorityClassInfoWithUIDAndHash.QueryRow(uid, hash).Scan(&count)
 	if count.Valid {
 		return int(count.Int32), nil
 	}
@@ -428,7 +963,35 @@ func (d *DataAccess) CountNodeInfoWithHash(name, hash string) (int, error) {
 	return -1, err
 }
 
-func updateDeletionTimestamp(stmt *sql.Stmt, name string, deletionTimestamp time.Time) (updated int64, err error) {
+func (d *DataAccess) CountPodInfoWithSpecHash(uid, hash string) (int, error) {
+	var count sql.NullInt32
+	err := d.selectPodCountWithUIDAndHash.QueryRow(uid, hash).Scan(&count)
+	if count.Valid {
+		return int(count.Int32), nil
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return -1, nil
+		}
+	}
+	return -1, err
+}
+
+func (d *DataAccess) CountNodeInfoWithHash(name, hash string) (int, error) {
+	var count sql.NullInt32
+	err := d.selectNodeCountWithNameAndHash.QueryRow(name, hash).Scan(&count)
+	if count.Valid {
+		return int(count.Int32), nil
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return -1, nil
+		}
+	}
+	return -1, err
+}
+
+func updateDeletionTimestamp(stmt *statement, name string, deletionTimestamp time.Time) (updated int64, err error) {
 	result, err := stmt.Exec(deletionTimestamp.UTC().UnixMilli(), name)
 	if err != nil {
 		return -1, err
@@ -458,7 +1021,13 @@ func (d *DataAccess) UpdateMCCInfoDeletionTimestamp(name string, deletionTimesta
 
 func (d *DataAccess) StoreEventInfo(event gst.EventInfo) error {
 	//eventsStmt, err := db.Prepare("INSERT INTO event_info(UID, EventTime, ReportingController, Reason, Message, InvolvedObjectKind, InvolvedObjectName, InvolvedObjectNamespace, InvolvedObjectUID) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)")
-	_, err := d.insertEvent.Exec(
+	_, err := d.insertEvent.Exec(eventInfoArgs(event)...)
+	return err
+}
+
+// eventInfoArgs returns the arguments of the InsertEvent statement for the given EventInfo.
+func eventInfoArgs(event gst.EventInfo) []any {
+	return []any{
 		event.UID,
 		event.EventTime,
 		event.ReportingController,
@@ -468,8 +1037,7 @@ func (d *DataAccess) StoreEventInfo(event gst.EventInfo) error {
 		event.InvolvedObjectName,
 		event.InvolvedObjectNamespace,
 		event.InvolvedObjectUID,
-	)
-	return err
+	}
 }
 
 func (d *DataAccess) GetMachineDeploymentInfoHash(name string) (string, error) {
@@ -541,6 +1109,7 @@ func (d *DataAccess) StoreMachineClassInfo(m gsh.MachineClassInfo) (rowID int64,
 		m.Name,
 		m.Namespace,
 		m.InstanceType,
+		m.Provider,
 		m.PoolName,
 		m.Region,
 		m.Zone,
@@ -622,7 +1191,11 @@ func (d *DataAccess) LoadAllWorkerPoolInfoHashes() (map[string]string, error) {
 }
 
 func (d *DataAccess) LoadWorkerPoolInfosBefore(snapshotTimestamp time.Time) ([]gst.WorkerPoolInfo, error) {
-	workerPoolInfos, err := queryAndMapToInfos[gst.WorkerPoolInfo, workerPoolRow](d.selectWorkerPoolInfosBefore, snapshotTimestamp)
+	stmt, params, err := d.latestBeforeQuery("worker_pool_info", snapshotTimestamp, d.selectWorkerPoolInfosBefore, d.selectWorkerPoolInfosBeforeFromCheckpoint, snapshotTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	workerPoolInfos, err := queryAndMapToInfos[gst.WorkerPoolInfo, workerPoolRow](stmt, params...)
 	if err != nil {
 		return nil, fmt.Errorf("LoadWorkerPoolInfosBefore could not scan rows: %w", err)
 	}
@@ -630,7 +1203,11 @@ func (d *DataAccess) LoadWorkerPoolInfosBefore(snapshotTimestamp time.Time) ([]g
 }
 
 func (d *DataAccess) LoadMachineDeploymentInfosBefore(snapshotTimestamp time.Time) ([]gst.MachineDeploymentInfo, error) {
-	mcdInfos, err := queryAndMapToInfos[gst.MachineDeploymentInfo, mcdRow](d.selectLatestMCDInfoBefore, snapshotTimestamp)
+	stmt, params, err := d.latestBeforeQuery("mcd_info", snapshotTimestamp, d.selectLatestMCDInfoBefore, d.selectLatestMCDInfoBeforeFromCheckpoint, snapshotTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	mcdInfos, err := queryAndMapToInfos[gst.MachineDeploymentInfo, mcdRow](stmt, params...)
 	if err != nil {
 		return nil, fmt.Errorf("LoadMachineDeploymentInfosBefore could not scan rows: %w", err)
 	}
@@ -642,7 +1219,11 @@ func (d *DataAccess) LoadLatestMachineClassInfo(name string) (mccInfo gsh.Machin
 }
 
 func (d *DataAccess) LoadMachineClassInfosBefore(snapshotTimestamp time.Time) ([]gsh.MachineClassInfo, error) {
-	mccInfos, err := queryAndMapToInfos[gsh.MachineClassInfo, mccRow](d.selectLatestMCCInfoBefore, snapshotTimestamp)
+	stmt, params, err := d.latestBeforeQuery("mcc_info", snapshotTimestamp, d.selectLatestMCCInfoBefore, d.selectLatestMCCInfoBeforeFromCheckpoint, snapshotTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	mccInfos, err := queryAndMapToInfos[gsh.MachineClassInfo, mccRow](stmt, params...)
 	if err != nil {
 		return nil, fmt.Errorf("LoadMachineClassInfosBefore could not scan rows: %w", err)
 	}
@@ -681,7 +1262,11 @@ func (d *DataAccess) GetLatestUnscheduledPodsBeforeTimestamp(timeStamp time.Time
 }
 
 func (d *DataAccess) GetLatestPodInfosBeforeSnapshotTime(snapshotTime time.Time) (pods []gst.PodInfo, err error) {
-	return queryAndMapToInfos[gst.PodInfo, podRow](d.selectLatestPodInfosBeforeSnapshotTimestamp, snapshotTime, snapshotTime)
+	stmt, params, err := d.latestBeforeQuery("pod_info", snapshotTime, d.selectLatestPodInfosBeforeSnapshotTimestamp, d.selectLatestPodInfosBeforeSnapshotTimestampFromCheckpoint, snapshotTime, snapshotTime)
+	if err != nil {
+		return nil, err
+	}
+	return queryAndMapToInfos[gst.PodInfo, podRow](stmt, params...)
 }
 
 func (d *DataAccess) GetLatestScheduledPodsBeforeTimestamp(timestamp time.Time) (pods []gst.PodInfo, err error) {
@@ -690,7 +1275,11 @@ func (d *DataAccess) GetLatestScheduledPodsBeforeTimestamp(timestamp time.Time)
 }
 
 func (d *DataAccess) LoadLatestPriorityClassInfoBeforeSnapshotTime(snapshotTime time.Time) (pcInfos []gst.PriorityClassInfo, err error) {
-	return queryAndMapToInfos[gst.PriorityClassInfo, priorityClassRow](d.selectLatestPriorityClassInfoBeforeSnapshotTimestamp, snapshotTime, snapshotTime)
+	stmt, params, err := d.latestBeforeQuery("pc_info", snapshotTime, d.selectLatestPriorityClassInfoBeforeSnapshotTimestamp, d.selectLatestPriorityClassInfoBeforeSnapshotTimestampFromCheckpoint, snapshotTime, snapshotTime)
+	if err != nil {
+		return nil, err
+	}
+	return queryAndMapToInfos[gst.PriorityClassInfo, priorityClassRow](stmt, params...)
 }
 
 func (d *DataAccess) LoadCASettingsBefore(timestamp time.Time) (caSettings gst.CASettingsInfo, err error) {
@@ -733,23 +1322,173 @@ func (d *DataAccess) GetCADeploymentWithHash(Hash string) (caDeployment *gst.CAS
 	return
 }
 
-func (d *DataAccess) StorePodInfo(podInfo gst.PodInfo) (int64, error) {
+// QueuePodInfo queues the given podInfo for insertion into pod_info by the write pipeline, preceded by the insertion
+// of its spec into pod_spec unless the spec is stored. The row is flagged with inferredAtRestart. It blocks while the
+// write queue is full. The insert is skipped as duplicate if a row with the same UID and Hash is stored. The optional
+// onDone is invoked on the writer goroutine once the batch holding the insert has been committed.
+func (d *DataAccess) QueuePodInfo(podInfo gst.PodInfo, inferredAtRestart bool, onDone func(WriteResult)) error {
 	if podInfo.Hash == "" {
 		podInfo.Hash = podInfo.GetHash()
 	}
+	args, spec, err := podInfoArgs(podInfo)
+	if err != nil {
+		return err
+	}
+	return d.enqueue(writeOp{
+		table:  "pod_info",
+		stmt:   d.insertPodInfoIfNew,
+		args:   append(args, boolToInt(inferredAtRestart), podInfo.UID, podInfo.Hash),
+		onDone: onDone,
+		pre: &writeOp{
+			table: "pod_spec",
+			stmt:  d.insertPodSpec,
+			args:  []any{spec.Hash, spec.Spec},
+		},
+	})
+}
+
+// QueueNodeInfo queues the given NodeInfo for insertion into node_info by the write pipeline. The row is flagged with
+// inferredAtRestart. It blocks while the write queue is full. The insert is skipped as duplicate if a row with the same
+// Name and Hash is stored. The optional onDone is invoked on the writer goroutine once the batch holding the insert has
+// been committed.
+func (d *DataAccess) QueueNodeInfo(n gst.NodeInfo, inferredAtRestart bool, onDone func(WriteResult)) error {
+	if n.Hash == "" {
+		n.Hash = n.GetHash()
+	}
+	args, err := nodeInfoArgs(n)
+	if err != nil {
+		return err
+	}
+	return d.enqueue(writeOp{
+		table:  "node_info",
+		stmt:   d.insertNodeInfoIfNew,
+		args:   append(args, boolToInt(inferredAtRestart), n.Name, n.Hash),
+		onDone: onDone,
+	})
+}
+
+// QueueEventInfo queues the given EventInfo for insertion into event_info by the write pipeline. It blocks while the
+// write queue is full. The insert is skipped as duplicate if an event with the same UID is stored. The optional onDone
+// is invoked on the writer goroutine once the batch holding the insert has been committed.
+func (d *DataAccess) QueueEventInfo(event gst.EventInfo, onDone func(WriteResult)) error {
+	return d.enqueue(writeOp{
+		table:  "event_info",
+		stmt:   d.insertEvent,
+		args:   eventInfoArgs(event),
+		onDone: onDone,
+	})
+}
+
+// QueueNodeScaleDownActivityInfo queues the given NodeScaleDownActivityInfo for insertion into
+// node_scale_down_activity_info by the write pipeline. It blocks while the write queue is full. The optional onDone is
+// invoked on the writer goroutine once the batch holding the insert has been committed.
+func (d *DataAccess) QueueNodeScaleDownActivityInfo(a gsh.NodeScaleDownActivityInfo, onDone func(WriteResult)) error {
+	return d.enqueue(writeOp{
+		table:  "node_scale_down_activity_info",
+		stmt:   d.insertNodeScaleDownActivityInfo,
+		args:   nodeScaleDownActivityInfoArgs(a),
+		onDone: onDone,
+	})
+}
+
+// QueueFailedSchedulingInfo queues the insertion of a row into failed_scheduling_reason_info for each reason of the
+// given FailedSchedulingInfo by the write pipeline. It blocks while the write queue is full. Reasons already stored for
+// the same occurrence of the event are skipped as duplicate. The optional onDone is invoked on the writer goroutine for
+// each reason once the batch holding its insert has been committed.
+func (d *DataAccess) QueueFailedSchedulingInfo(f gsh.FailedSchedulingInfo, onDone func(WriteResult)) error {
+	for reason, nodeCount := range f.ReasonCounts {
+		err := d.enqueue(writeOp{
+			table:  "failed_scheduling_reason_info",
+			stmt:   d.insertFailedSchedulingReasonInfo,
+			args:   failedSchedulingReasonInfoArgs(f, reason, nodeCount),
+			onDone: onDone,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueueCAScalingEventInfo queues the given CAScalingEventInfo for insertion into ca_scaling_event_info by the write
+// pipeline. It blocks while the write queue is full. The insert is skipped as duplicate if a CAScalingEventInfo for the
+// same event, node group and not-trigger reason is stored. The optional onDone is invoked on the writer goroutine once
+// the batch holding the insert has been committed.
+func (d *DataAccess) QueueCAScalingEventInfo(c gsh.CAScalingEventInfo, onDone func(WriteResult)) error {
+	return d.enqueue(writeOp{
+		table:  "ca_scaling_event_info",
+		stmt:   d.insertCAScalingEventInfo,
+		args:   caScalingEventInfoArgs(c),
+		onDone: onDone,
+	})
+}
+
+// QueuePodDeletionTimestamp queues the update of the deletion timestamp of the pod with the given UID. Since the
+// write pipeline executes writes in order, the update also applies to pod_info rows queued before.
+func (d *DataAccess) QueuePodDeletionTimestamp(podUID types.UID, deletionTimestamp time.Time, onDone func(WriteResult)) error {
+	return d.enqueue(writeOp{
+		table:  "pod_info",
+		stmt:   d.updatePodDeletionTimeStamp,
+		args:   []any{deletionTimestamp.UTC().UnixMilli(), string(podUID)},
+		update: true,
+		onDone: onDone,
+	})
+}
+
+// QueueNodeInfoDeletionTimestamp queues the update of the deletion timestamp of the node with the given name. Since
+// the write pipeline executes writes in order, the update also applies to node_info rows queued before.
+func (d *DataAccess) QueueNodeInfoDeletionTimestamp(name string, deletionTimestamp time.Time, onDone func(WriteResult)) error {
+	return d.enqueue(writeOp{
+		table:  "node_info",
+		stmt:   d.updateNodeInfoDeletionTimeStamp,
+		args:   []any{deletionTimestamp.UTC().UnixMilli(), name},
+		update: true,
+		onDone: onDone,
+	})
+}
+
+func (d *DataAccess) enqueue(op writeOp) error {
+	if d.readOnly {
+		return fmt.Errorf("cannot queue write into %s: data db %q is opened read-only", op.table, d.name())
+	}
+	if d.writes == nil {
+		return fmt.Errorf("cannot queue write into %s: data db %q is not open", op.table, d.name())
+	}
+	return d.writes.enqueue(op)
+}
+
+// FlushWrites blocks until all writes queued before it have been committed or failed.
+func (d *DataAccess) FlushWrites() error {
+	if d.writes == nil {
+		return fmt.Errorf("cannot flush writes: data db %q is not open", d.name())
+	}
+	return d.writes.sync()
+}
+
+// WritePipelineStats returns the statistics of the write pipeline.
+func (d *DataAccess) WritePipelineStats() WritePipelineStats {
+	if d.writes == nil {
+		return WritePipelineStats{}
+	}
+	return d.writes.stats()
+}
+
+// podInfoArgs returns the arguments of the InsertPodInfo statement for the given podInfo and its normalized spec.
+func podInfoArgs(podInfo gst.PodInfo) ([]any, normalizedPodSpec, error) {
+	var spec normalizedPodSpec
 	labels, err := labelsToText(podInfo.Labels)
 	if err != nil {
-		return -1, err
+		return nil, spec, err
 	}
 	requests, err := resourcesToText(podInfo.Requests)
 	if err != nil {
-		return -1, err
+		return nil, spec, err
 	}
-	podSpec, err := specToJson(podInfo.Spec)
+	spec, err = normalizePodSpec(podInfo.Spec)
 	if err != nil {
-		return -1, err
+		return nil, spec, err
 	}
-	result, err := d.insertPodInfo.Exec(
+	return []any{
 		podInfo.CreationTimestamp.UTC().UnixMilli(),
 		podInfo.SnapshotTimestamp.UTC().UnixMilli(),
 		podInfo.Name,
@@ -759,9 +1498,26 @@ func (d *DataAccess) StorePodInfo(podInfo gst.PodInfo) (int64, error) {
 		podInfo.NominatedNodeName,
 		labels,
 		requests,
-		podSpec,
+		spec.Hash,
+		spec.NodeName,
+		spec.TokenVolumeName,
 		podInfo.PodScheduleStatus,
-		podInfo.Hash)
+		podInfo.Hash}, spec, nil
+}
+
+func (d *DataAccess) StorePodInfo(podInfo gst.PodInfo) (int64, error) {
+	if podInfo.Hash == "" {
+		podInfo.Hash = podInfo.GetHash()
+	}
+	args, spec, err := podInfoArgs(podInfo)
+	if err != nil {
+		return -1, err
+	}
+	_, err = d.insertPodSpec.Exec(spec.Hash, spec.Spec)
+	if err != nil {
+		return -1, fmt.Errorf("could not persist spec of podinfo %s: %w", podInfo, err)
+	}
+	result, err := d.insertPodInfo.Exec(args...)
 	if err != nil {
 		return -1, fmt.Errorf("could not persist podinfo %s: %w", podInfo, err)
 	}
@@ -801,25 +1557,40 @@ func (d *DataAccess) StoreNodeInfo(n gst.NodeInfo) (rowID int64, err error) {
 	if n.Hash == "" {
 		n.Hash = n.GetHash()
 	}
+	args, err := nodeInfoArgs(n)
+	if err != nil {
+		return
+	}
+	result, err := d.insertNodeInfo.Exec(args...)
+	if err != nil {
+		slog.Error("cannot insert node_info in the node_info table", "error", err, "node", n)
+		return
+	}
+	slog.Info("inserted new row into the node_info table", "node.Name", n.Name)
+	return result.LastInsertId()
+}
+
+// nodeInfoArgs returns the arguments of the InsertNodeInfo statement for the given NodeInfo.
+func nodeInfoArgs(n gst.NodeInfo) ([]any, error) {
 	// Removing this label as it just takes useless space: "node.machine.sapcloud.io/last-applied-anno-labels-taints"
 	delete(n.Labels, "node.machine.sapcloud.io/last-applied-anno-labels-taints")
 	labelsText, err := labelsToText(n.Labels)
 	if err != nil {
-		return
+		return nil, err
 	}
 	taintsText, err := taintsToText(n.Taints)
 	if err != nil {
-		return
+		return nil, err
 	}
 	allocatableText, err := resourcesToText(n.Allocatable)
 	if err != nil {
-		return
+		return nil, err
 	}
 	capacityText, err := resourcesToText(n.Capacity)
 	if err != nil {
-		return
+		return nil, err
 	}
-	_, err = d.insertNodeInfo.Exec(
+	return []any{
 		n.CreationTimestamp.UTC().UnixMilli(),
 		n.SnapshotTimestamp.UTC().UnixMilli(),
 		n.Name,
@@ -830,13 +1601,7 @@ func (d *DataAccess) StoreNodeInfo(n gst.NodeInfo) (rowID int64, err error) {
 		taintsText,
 		allocatableText,
 		capacityText,
-		n.Hash)
-	if err != nil {
-		slog.Error("cannot insert node_info in the node_info table", "error", err, "node", n)
-		return
-	}
-	slog.Info("inserted new row into the node_info table", "node.Name", n.Name)
-	return
+		n.Hash}, nil
 }
 
 func (d *DataAccess) LoadNodeInfosBefore(creationTimestamp time.Time) ([]gst.NodeInfo, error) {
@@ -851,8 +1616,8 @@ func (d *DataAccess) StoreCASettingsInfo(caSettings gst.CASettingsInfo) (int64,
 	result, err := d.insertCADeployment.Exec(
 		caSettings.SnapshotTimestamp.UTC().UnixMilli(),
 		caSettings.Expander,
-		caSettings.MaxNodeProvisionTime.Milliseconds(),
 		caSettings.ScanInterval.Milliseconds(),
+		caSettings.MaxNodeProvisionTime.Milliseconds(),
 		caSettings.MaxGracefulTerminationSeconds,
 		caSettings.NewPodScaleUpDelay.Milliseconds(),
 		caSettings.MaxEmptyBulkDelete,
@@ -866,6 +1631,309 @@ func (d *DataAccess) StoreCASettingsInfo(caSettings gst.CASettingsInfo) (int64,
 	return result.LastInsertId()
 }
 
+func (d *DataAccess) StoreCAArgsInfo(caArgs gsh.CAArgsInfo) (int64, error) {
+	if caArgs.Hash == "" {
+		caArgs.Hash = caArgs.GetHash()
+	}
+	argsText, err := labelsToText(caArgs.Args)
+	if err != nil {
+		return -1, err
+	}
+	result, err := d.insertCAArgsInfo.Exec(
+		caArgs.SnapshotTimestamp.UTC().UnixMilli(),
+		argsText,
+		caArgs.Hash)
+	if err != nil {
+		return -1, fmt.Errorf("could not persist CAArgsInfo %s: %w", caArgs, err)
+	}
+	slog.Info("stored row into ca_args_info.", "SnapshotTimestamp", caArgs.SnapshotTimestamp, "Hash", caArgs.Hash)
+	return result.LastInsertId()
+}
+
+// GetLatestCAArgsInfo returns the latest CAArgsInfo or nil if no CAArgsInfo has been recorded yet.
+func (d *DataAccess) GetLatestCAArgsInfo() (*gsh.CAArgsInfo, error) {
+	caArgsInfos, err := queryAndMapToInfos[gsh.CAArgsInfo, caArgsRow](d.selectLatestCAArgsInfo)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("GetLatestCAArgsInfo could not scan rows: %w", err)
+	}
+	return &caArgsInfos[0], nil
+}
+
+// LoadCAArgsInfoBefore returns the latest CAArgsInfo recorded at or before the given timestamp. If there is no such
+// CAArgsInfo, sql.ErrNoRows is returned.
+func (d *DataAccess) LoadCAArgsInfoBefore(timestamp time.Time) (gsh.CAArgsInfo, error) {
+	caArgsInfos, err := queryAndMapToInfos[gsh.CAArgsInfo, caArgsRow](d.selectLatestCAArgsInfoBefore, timestamp)
+	if err != nil {
+		return gsh.CAArgsInfo{}, err
+	}
+	return caArgsInfos[0], nil
+}
+
+func (d *DataAccess) StoreCANodeGroupStatusInfo(s gsh.CANodeGroupStatusInfo) (rowID int64, err error) {
+	if s.Hash == "" {
+		s.Hash = s.GetHash()
+	}
+	result, err := d.insertCANodeGroupStatusInfo.Exec(
+		s.SnapshotTimestamp.UTC().UnixMilli(),
+		s.Name,
+		s.Health,
+		s.Ready,
+		s.Unready,
+		s.NotStarted,
+		s.LongNotStarted,
+		s.Registered,
+		s.LongUnregistered,
+		s.CloudProviderTarget,
+		s.MinSize,
+		s.MaxSize,
+		s.ScaleUp,
+		s.Backoff,
+		s.ScaleDown,
+		s.ScaleDownCandidates,
+		s.Hash)
+	if err != nil {
+		return -1, fmt.Errorf("could not persist CANodeGroupStatusInfo %s: %w", s, err)
+	}
+	rowID, err = result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("cannot retrieve rowID for CANodeGroupStatusInfo %q: %w", s.Name, err)
+	}
+	slog.Info("stored row into ca_nodegroup_status_info.", "Name", s.Name, "RowID", rowID, "Health", s.Health,
+		"ScaleUp", s.ScaleUp, "ScaleDown", s.ScaleDown, "Hash", s.Hash)
+	return
+}
+
+// LoadLatestCANodeGroupStatusInfoHashes returns a map of node group name to the hash of the latest CANodeGroupStatusInfo
+// stored for the node group.
+func (d *DataAccess) LoadLatestCANodeGroupStatusInfoHashes() (map[string]string, error) {
+	statusHashes := make(map[string]string)
+	hashRows, err := queryRows[hashRow](d.selectLatestCANodeGroupStatusInfoHashes)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return statusHashes, nil //empty table is not an error
+		}
+		return nil, fmt.Errorf("LoadLatestCANodeGroupStatusInfoHashes could not scan rows: %w", err)
+	}
+	for _, v := range hashRows {
+		statusHashes[v.Name] = v.Hash
+	}
+	return statusHashes, nil
+}
+
+func (d *DataAccess) LoadCANodeGroupStatusInfosBefore(snapshotTimestamp time.Time) ([]gsh.CANodeGroupStatusInfo, error) {
+	stmt, params, err := d.latestBeforeQuery("ca_nodegroup_status_info", snapshotTimestamp, d.selectLatestCANodeGroupStatusInfosBefore, d.selectLatestCANodeGroupStatusInfosBeforeFromCheckpoint, snapshotTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	statusInfos, err := queryAndMapToInfos[gsh.CANodeGroupStatusInfo, caNodeGroupStatusRow](stmt, params...)
+	if err != nil {
+		return nil, fmt.Errorf("LoadCANodeGroupStatusInfosBefore could not scan rows: %w", err)
+	}
+	return statusInfos, nil
+}
+
+func (d *DataAccess) StoreNodeScaleDownActivityInfo(a gsh.NodeScaleDownActivityInfo) (rowID int64, err error) {
+	result, err := d.insertNodeScaleDownActivityInfo.Exec(nodeScaleDownActivityInfoArgs(a)...)
+	if err != nil {
+		return -1, fmt.Errorf("could not persist %s: %w", a, err)
+	}
+	rowID, err = result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("cannot retrieve rowID for NodeScaleDownActivityInfo of node %q: %w", a.NodeName, err)
+	}
+	slog.Info("stored row into node_scale_down_activity_info.", "NodeName", a.NodeName, "Activity", a.Activity,
+		"ActivityTime", a.ActivityTime, "RowID", rowID)
+	return
+}
+
+// nodeScaleDownActivityInfoArgs returns the arguments of the InsertNodeScaleDownActivityInfo statement for the given
+// NodeScaleDownActivityInfo.
+func nodeScaleDownActivityInfoArgs(a gsh.NodeScaleDownActivityInfo) []any {
+	return []any{
+		a.ActivityTime.UTC().UnixMilli(),
+		a.NodeName,
+		a.Activity,
+		a.Detail,
+	}
+}
+
+// LoadNodeScaleDownActivityInfosBetween returns the NodeScaleDownActivityInfos with ActivityTime in the given
+// inclusive range ordered by ActivityTime.
+func (d *DataAccess) LoadNodeScaleDownActivityInfosBetween(startTime, endTime time.Time) ([]gsh.NodeScaleDownActivityInfo, error) {
+	activityInfos, err := queryAndMapToInfos[gsh.NodeScaleDownActivityInfo, nodeScaleDownActivityRow](d.selectNodeScaleDownActivityInfosBetween, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("LoadNodeScaleDownActivityInfosBetween could not scan rows: %w", err)
+	}
+	return activityInfos, nil
+}
+
+// LoadNodeDeletionTimestamps returns a map of node name to the DeletionTimestamp of all deleted nodes.
+func (d *DataAccess) LoadNodeDeletionTimestamps() (map[string]time.Time, error) {
+	deletionTimestamps := make(map[string]time.Time)
+	deletionRows, err := queryRows[nodeDeletionRow](d.selectNodeDeletionTimestamps)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return deletionTimestamps, nil
+		}
+		return nil, fmt.Errorf("LoadNodeDeletionTimestamps could not scan rows: %w", err)
+	}
+	for _, r := range deletionRows {
+		deletionTimestamps[r.Name] = timeFromMillis(r.DeletionTimestamp)
+	}
+	return deletionTimestamps, nil
+}
+
+// StoreFailedSchedulingInfo stores a row into failed_scheduling_reason_info for each reason of the given
+// FailedSchedulingInfo in a single transaction. Reasons already stored for the same occurrence of the event are ignored.
+func (d *DataAccess) StoreFailedSchedulingInfo(f gsh.FailedSchedulingInfo) error {
+	tx, err := d.dataDB.Begin()
+	if err != nil {
+		return fmt.Errorf("cannot begin transaction for %s: %w", f, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	insert := tx.Stmt(d.insertFailedSchedulingReasonInfo.Stmt)
+	for reason, nodeCount := range f.ReasonCounts {
+		_, err = d.insertFailedSchedulingReasonInfo.execOn(insert, failedSchedulingReasonInfoArgs(f, reason, nodeCount))
+		if err != nil {
+			return fmt.Errorf("could not persist reason %q of %s: %w", reason, f, err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("cannot commit %s: %w", f, err)
+	}
+	slog.Debug("stored rows into failed_scheduling_reason_info.", "EventUID", f.EventUID, "Count", f.Count, "PodName", f.PodName, "numReasons", len(f.ReasonCounts))
+	return nil
+}
+
+// failedSchedulingReasonInfoArgs returns the arguments of the InsertFailedSchedulingReasonInfo statement for the given
+// reason of the given FailedSchedulingInfo.
+func failedSchedulingReasonInfoArgs(f gsh.FailedSchedulingInfo, reason string, nodeCount int) []any {
+	return []any{
+		f.EventUID,
+		f.EventTime.UTC().UnixMilli(),
+		max(f.Count, 1),
+		f.PodUID,
+		f.PodName,
+		f.PodNamespace,
+		f.Zone,
+		f.TotalNodes,
+		reason,
+		nodeCount,
+	}
+}
+
+// LoadFailedSchedulingInfosBetween returns the FailedSchedulingInfos with EventTime in the given inclusive range
+// ordered by EventTime.
+func (d *DataAccess) LoadFailedSchedulingInfosBetween(startTime, endTime time.Time) ([]gsh.FailedSchedulingInfo, error) {
+	rows, err := queryRows[failedSchedulingReasonRow](d.selectFailedSchedulingReasonInfosBetween, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("LoadFailedSchedulingInfosBetween could not scan rows: %w", err)
+	}
+	return failedSchedulingInfosFromRows(rows), nil
+}
+
+// LoadFailedSchedulingInfosForPod returns the FailedSchedulingInfos of the pod with the given UID ordered by EventTime.
+func (d *DataAccess) LoadFailedSchedulingInfosForPod(podUID string) ([]gsh.FailedSchedulingInfo, error) {
+	rows, err := queryRows[failedSchedulingReasonRow](d.selectFailedSchedulingReasonInfosForPod, podUID)
+	if err != nil {
+		return nil, fmt.Errorf("LoadFailedSchedulingInfosForPod could not scan rows: %w", err)
+	}
+	return failedSchedulingInfosFromRows(rows), nil
+}
+
+// LoadFailedSchedulingReasonCountsBetween returns, for each failure reason of FailedScheduling event occurrences in the
+// given inclusive range, the number of distinct pods and the total number of nodes that failed for the reason. Only
+// the occurrences of pods restricted to the given zone are counted unless the zone is empty. The counts are ordered by
+// descending PodCount.
+func (d *DataAccess) LoadFailedSchedulingReasonCountsBetween(startTime, endTime time.Time, zone string) ([]gsh.FailedSchedulingReasonCount, error) {
+	rows, err := queryRows[failedSchedulingReasonCountRow](d.selectFailedSchedulingReasonCountsBetween, startTime, endTime, zone, zone)
+	if errors.Is(err, sql.ErrNoRows) {
+		return []gsh.FailedSchedulingReasonCount{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("LoadFailedSchedulingReasonCountsBetween could not scan rows: %w", err)
+	}
+	reasonCounts := make([]gsh.FailedSchedulingReasonCount, 0, len(rows))
+	for _, r := range rows {
+		reasonCounts = append(reasonCounts, gsh.FailedSchedulingReasonCount{
+			Reason:    r.Reason,
+			PodCount:  r.PodCount,
+			NodeCount: r.NodeCount,
+		})
+	}
+	return reasonCounts, nil
+}
+
+// StoreCAScalingEventInfo stores the given CAScalingEventInfo into ca_scaling_event_info. A CAScalingEventInfo already
+// stored for the same event, node group and not-trigger reason is ignored.
+func (d *DataAccess) StoreCAScalingEventInfo(c gsh.CAScalingEventInfo) (rowID int64, err error) {
+	result, err := d.insertCAScalingEventInfo.Exec(caScalingEventInfoArgs(c)...)
+	if err != nil {
+		return -1, fmt.Errorf("could not persist %s: %w", c, err)
+	}
+	rowID, err = result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("cannot retrieve rowID for CAScalingEventInfo of event %q: %w", c.EventUID, err)
+	}
+	slog.Debug("stored row into ca_scaling_event_info.", "EventUID", c.EventUID, "Reason", c.Reason, "NodeGroupName", c.NodeGroupName, "RowID", rowID)
+	return
+}
+
+// caScalingEventInfoArgs returns the arguments of the InsertCAScalingEventInfo statement for the given
+// CAScalingEventInfo.
+func caScalingEventInfoArgs(c gsh.CAScalingEventInfo) []any {
+	return []any{
+		c.EventUID,
+		c.EventTime.UTC().UnixMilli(),
+		c.Reason,
+		c.PodUID,
+		c.PodName,
+		c.PodNamespace,
+		c.NodeGroupName,
+		c.CurrentSize,
+		c.TargetSize,
+		c.MaxSize,
+		c.NodeName,
+		c.NotTriggerReason,
+		c.NodeGroupCount,
+	}
+}
+
+// LoadCAScalingEventInfosBetween returns the CAScalingEventInfos with EventTime in the given inclusive range ordered
+// by EventTime.
+func (d *DataAccess) LoadCAScalingEventInfosBetween(startTime, endTime time.Time) ([]gsh.CAScalingEventInfo, error) {
+	scalingEventInfos, err := queryAndMapToInfos[gsh.CAScalingEventInfo, caScalingEventRow](d.selectCAScalingEventInfosBetween, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("LoadCAScalingEventInfosBetween could not scan rows: %w", err)
+	}
+	return scalingEventInfos, nil
+}
+
+// LoadCAScalingEventInfosForPod returns the CAScalingEventInfos of the pod with the given UID ordered by EventTime.
+func (d *DataAccess) LoadCAScalingEventInfosForPod(podUID string) ([]gsh.CAScalingEventInfo, error) {
+	scalingEventInfos, err := queryAndMapToInfos[gsh.CAScalingEventInfo, caScalingEventRow](d.selectCAScalingEventInfosForPod, podUID)
+	if err != nil {
+		return nil, fmt.Errorf("LoadCAScalingEventInfosForPod could not scan rows: %w", err)
+	}
+	return scalingEventInfos, nil
+}
+
+// LoadCAScalingEventInfosForNodeGroup returns the CAScalingEventInfos of the node group with the given name ordered
+// by EventTime.
+func (d *DataAccess) LoadCAScalingEventInfosForNodeGroup(nodeGroupName string) ([]gsh.CAScalingEventInfo, error) {
+	scalingEventInfos, err := queryAndMapToInfos[gsh.CAScalingEventInfo, caScalingEventRow](d.selectCAScalingEventInfosForNodeGroup, nodeGroupName)
+	if err != nil {
+		return nil, fmt.Errorf("LoadCAScalingEventInfosForNodeGroup could not scan rows: %w", err)
+	}
+	return scalingEventInfos, nil
+}
+
 func (d *DataAccess) GetLatestNodesBeforeAndNotDeleted(timestamp time.Time) ([]gst.NodeInfo, error) {
 	nodeInfos, err := queryAndMapToInfos[gst.NodeInfo, nodeRow](d.selectLatestNodesBeforeAndNotDeleted, timestamp)
 	if err != nil {
@@ -883,6 +1951,184 @@ func (d *DataAccess) GetInitialRecorderStartTime() (startTime time.Time, err err
 	return time.UnixMilli(rows[0].BeginTimestamp).UTC(), nil
 }
 
+// StartRecorderSession stores a new recorder session beginning at the given time and returns its session ID.
+func (d *DataAccess) StartRecorderSession(beginTime time.Time) (sessionID int64, err error) {
+	result, err := d.insertRecorderSessionInfo.Exec(beginTime.UTC().UnixMilli(), beginTime.UTC().UnixMilli())
+	if err != nil {
+		return -1, fmt.Errorf("cannot start recorder session at %q: %w", beginTime, err)
+	}
+	sessionID, err = result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("cannot retrieve ID of recorder session started at %q: %w", beginTime, err)
+	}
+	slog.Info("started recorder session", "SessionID", sessionID, "BeginTime", beginTime)
+	return
+}
+
+// UpdateRecorderSessionHeartbeat sets the heartbeat time of the recorder session with the given ID.
+func (d *DataAccess) UpdateRecorderSessionHeartbeat(sessionID int64, heartbeatTime time.Time) error {
+	_, err := d.updateRecorderSessionHeartbeat.Exec(heartbeatTime.UTC().UnixMilli(), sessionID)
+	if err != nil {
+		return fmt.Errorf("cannot update heartbeat of recorder session %d: %w", sessionID, err)
+	}
+	return nil
+}
+
+// EndRecorderSession sets the end time of the recorder session with the given ID.
+func (d *DataAccess) EndRecorderSession(sessionID int64, endTime time.Time) error {
+	_, err := d.updateRecorderSessionEnd.Exec(endTime.UTC().UnixMilli(), endTime.UTC().UnixMilli(), sessionID)
+	if err != nil {
+		return fmt.Errorf("cannot end recorder session %d: %w", sessionID, err)
+	}
+	slog.Info("ended recorder session", "SessionID", sessionID, "EndTime", endTime)
+	return nil
+}
+
+// LoadRecorderSessionInfos returns all recorder sessions ordered by BeginTime.
+func (d *DataAccess) LoadRecorderSessionInfos() ([]gsh.RecorderSessionInfo, error) {
+	sessionInfos, err := queryAndMapToInfos[gsh.RecorderSessionInfo, recorderSessionRow](d.selectRecorderSessionInfos)
+	if err != nil {
+		return nil, fmt.Errorf("LoadRecorderSessionInfos could not scan rows: %w", err)
+	}
+	return sessionInfos, nil
+}
+
+func (d *DataAccess) StoreWatchDisconnectInfo(w gsh.WatchDisconnectInfo) (rowID int64, err error) {
+	result, err := d.insertWatchDisconnectInfo.Exec(
+		w.SessionID,
+		w.Informer,
+		w.BeginTime.UTC().UnixMilli(),
+		w.EndTime.UTC().UnixMilli(),
+		w.Error)
+	if err != nil {
+		return -1, fmt.Errorf("could not persist WatchDisconnectInfo of informer %q: %w", w.Informer, err)
+	}
+	rowID, err = result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("cannot retrieve rowID for WatchDisconnectInfo of informer %q: %w", w.Informer, err)
+	}
+	slog.Info("stored row into watch_disconnect_info.", "Informer", w.Informer, "BeginTime", w.BeginTime, "EndTime", w.EndTime, "RowID", rowID)
+	return
+}
+
+// LoadWatchDisconnectInfos returns all watch disconnects ordered by BeginTime.
+func (d *DataAccess) LoadWatchDisconnectInfos() ([]gsh.WatchDisconnectInfo, error) {
+	disconnectInfos, err := queryAndMapToInfos[gsh.WatchDisconnectInfo, watchDisconnectRow](d.selectWatchDisconnectInfos)
+	if err != nil {
+		return nil, fmt.Errorf("LoadWatchDisconnectInfos could not scan rows: %w", err)
+	}
+	return disconnectInfos, nil
+}
+
+// GetRecordingGaps returns the gaps in the recording ordered by BeginTime: the periods between recorder sessions and
+// the watch disconnects. The gap after a session that did not stop cleanly begins at its last heartbeat. The end of
+// the last session is the end of the recording and not a gap.
+func (d *DataAccess) GetRecordingGaps() ([]gsh.RecordingGap, error) {
+	sessionInfos, err := d.LoadRecorderSessionInfos()
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	disconnectInfos, err := d.LoadWatchDisconnectInfos()
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return computeRecordingGaps(sessionInfos, disconnectInfos), nil
+}
+
+func computeRecordingGaps(sessionInfos []gsh.RecorderSessionInfo, disconnectInfos []gsh.WatchDisconnectInfo) (gaps []gsh.RecordingGap) {
+	for i := 1; i < len(sessionInfos); i++ {
+		prev, next := sessionInfos[i-1], sessionInfos[i]
+		gap := gsh.RecordingGap{
+			BeginTime: prev.EndTime,
+			EndTime:   next.BeginTime,
+			Cause:     gsh.RecorderStoppedGap,
+		}
+		if prev.EndTime.IsZero() {
+			gap.BeginTime = prev.HeartbeatTime
+			gap.Cause = gsh.RecorderCrashedGap
+		}
+		if gap.EndTime.After(gap.BeginTime) {
+			gaps = append(gaps, gap)
+		}
+	}
+	for _, w := range disconnectInfos {
+		gaps = append(gaps, gsh.RecordingGap{
+			BeginTime: w.BeginTime,
+			EndTime:   w.EndTime,
+			Cause:     gsh.WatchDisconnectedGap,
+			Informer:  w.Informer,
+		})
+	}
+	slices.SortStableFunc(gaps, func(a, b gsh.RecordingGap) int {
+		return a.BeginTime.Compare(b.BeginTime)
+	})
+	return
+}
+
+// LoadPodUIDsNotDeleted returns the UIDs of the pods in pod_info without a DeletionTimestamp.
+func (d *DataAccess) LoadPodUIDsNotDeleted() ([]string, error) {
+	return queryStrings(d.selectPodUIDsNotDeleted)
+}
+
+// LoadNodeNamesNotDeleted returns the names of the nodes in node_info without a DeletionTimestamp.
+func (d *DataAccess) LoadNodeNamesNotDeleted() ([]string, error) {
+	return queryStrings(d.selectNodeNamesNotDeleted)
+}
+
+// LoadMCDNamesNotDeleted returns the names of the machine deployments in mcd_info without a DeletionTimestamp.
+func (d *DataAccess) LoadMCDNamesNotDeleted() ([]string, error) {
+	return queryStrings(d.selectMCDNamesNotDeleted)
+}
+
+// UpdatePodDeletionInferredAtRestart sets the DeletionTimestamp of the not deleted rows of the pod with the given UID
+// to the given restart time and flags the deletion as inferred at restart.
+func (d *DataAccess) UpdatePodDeletionInferredAtRestart(podUID string, restartTime time.Time) (updated int64, err error) {
+	return updateDeletionTimestamp(d.updatePodDeletionInferredAtRestart, podUID, restartTime)
+}
+
+// UpdateNodeDeletionInferredAtRestart sets the DeletionTimestamp of the not deleted rows of the node with the given
+// name to the given restart time and flags the deletion as inferred at restart.
+func (d *DataAccess) UpdateNodeDeletionInferredAtRestart(name string, restartTime time.Time) (updated int64, err error) {
+	return updateDeletionTimestamp(d.updateNodeDeletionInferredAtRestart, name, restartTime)
+}
+
+// UpdateMCDDeletionInferredAtRestart sets the DeletionTimestamp of the not deleted rows of the machine deployment
+// with the given name to the given restart time and flags the deletion as inferred at restart.
+func (d *DataAccess) UpdateMCDDeletionInferredAtRestart(name string, restartTime time.Time) (updated int64, err error) {
+	return updateDeletionTimestamp(d.updateMCDDeletionInferredAtRestart, name, restartTime)
+}
+
+// MarkMCDInfoInferredAtRestart flags the mcd_info row with the given rowID as stored while replaying the cluster
+// after a restart.
+func (d *DataAccess) MarkMCDInfoInferredAtRestart(rowID int64) error {
+	return markInferredAtRestart(d.updateMCDInfoInferredAtRestart, "mcd_info", rowID)
+}
+
+// boolToInt returns 1 for true and 0 for false, which is how the flag columns store booleans.
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func markInferredAtRestart(stmt *statement, table string, rowID int64) error {
+	_, err := stmt.Exec(rowID)
+	if err != nil {
+		return fmt.Errorf("cannot flag row %d of %s as inferred at restart: %w", rowID, table, err)
+	}
+	return nil
+}
+
+func queryStrings(stmt *statement) (values []string, err error) {
+	rows, err := stmt.Query()
+	if err != nil {
+		return
+	}
+	err = scan.RowsStrict(&values, rows)
+	return
+}
+
 func labelsToText(valMap map[string]string) (textVal string, err error) {
 	if len(valMap) == 0 {
 		return "", nil
@@ -1029,7 +2275,7 @@ func resourcesFromText(textValue string) (resources corev1.ResourceList, err err
 	return
 }
 
-func getHash(selectHashStmt *sql.Stmt, name string) (string, error) {
+func getHash(selectHashStmt *statement, name string) (string, error) {
 	row := selectHashStmt.QueryRow(name)
 	var hash sql.NullString
 	err := row.Scan(&hash)
@@ -1049,7 +2295,7 @@ func getHash(selectHashStmt *sql.Stmt, name string) (string, error) {
 // queryAndMapToInfo executes the given prepared stmt with the given params and scans the db rows into rowObjs of specific generic type T.
 // It is expected that field names of T are mapped to column names of the query via blockloop/scan `db` annotation
 // if there are no rows returns sql.ErrNoRows as an error. Caller should check this.
-func queryRows[T any](stmt *sql.Stmt, params ...any) (rowObjs []T, err error) {
+func queryRows[T any](stmt *statement, params ...any) (rowObjs []T, err error) {
 	var rows *sql.Rows
 
 	var adjustedParams = make([]any, len(params))
@@ -1077,7 +2323,7 @@ func queryRows[T any](stmt *sql.Stmt, params ...any) (rowObjs []T, err error) {
 }
 
 // queryAndMapToInfo executes the given prepared stmt with the given params and maps the rows to infoObjs which is a []I slice
-func queryAndMapToInfos[I any, T row[I]](stmt *sql.Stmt, params ...any) (infoObjs []I, err error) {
+func queryAndMapToInfos[I any, T row[I]](stmt *statement, params ...any) (infoObjs []I, err error) {
 	rowObjs, err := queryRows[T](stmt, params...)
 	if err != nil {
 		return nil, err
@@ -1100,7 +2346,7 @@ func adjustParam(p any) any {
 }
 
 // queryAndMapToInfo executes the given prepared stmt with the given params and maps the first row to a single infoObj of type I
-func queryAndMapToInfo[I any, T row[I]](stmt *sql.Stmt, param ...any) (infoObj I, err error) {
+func queryAndMapToInfo[I any, T row[I]](stmt *statement, param ...any) (infoObj I, err error) {
 	rows, err := stmt.Query(param...)
 	if err != nil {
 		return
@@ -37,14 +37,14 @@ type workerPoolRow struct {
 	MaxSurge          string `db:"MaxSurge"`
 	MaxUnavailable    string `db:"MaxUnavailable"`
 	Zones             string
-	DeletionTimeStamp sql.NullInt64 `db:"DeletionTimeStamp"`
+	DeletionTimeStamp sql.NullInt64 `db:"DeletionTimestamp"`
 	Hash              string
 }
 
 func (r workerPoolRow) AsInfo() (mcdInfo gst.WorkerPoolInfo, err error) {
 	var delTimeStamp time.Time
 	if r.DeletionTimeStamp.Valid {
-		delTimeStamp = time.UnixMilli(r.DeletionTimeStamp.Int64)
+		delTimeStamp = timeFromMillis(r.DeletionTimeStamp.Int64)
 	}
 	var zones []string
 	if strings.TrimSpace(r.Zones) != "" {
@@ -85,14 +85,14 @@ type mcdRow struct {
 	MachineClassName  string `db:"MachineClassName"`
 	Labels            string
 	Taints            string
-	DeletionTimeStamp sql.NullInt64 `db:"DeletionTimeStamp"`
+	DeletionTimeStamp sql.NullInt64 `db:"DeletionTimestamp"`
 	Hash              string
 }
 
 func (r mcdRow) AsInfo() (mcdInfo gst.MachineDeploymentInfo, err error) {
 	var delTimeStamp time.Time
 	if r.DeletionTimeStamp.Valid {
-		delTimeStamp = time.UnixMilli(r.DeletionTimeStamp.Int64)
+		delTimeStamp = timeFromMillis(r.DeletionTimeStamp.Int64)
 	}
 	labels, err := labelsFromText(r.Labels)
 	if err != nil {
@@ -131,19 +131,20 @@ type mccRow struct {
 	Name              string
 	Namespace         string
 	InstanceType      string `db:"InstanceType"`
+	Provider          string
 	PoolName          string `db:"PoolName"`
 	Region            string
 	Zone              string
 	Labels            string
 	Capacity          string
-	DeletionTimeStamp sql.NullInt64 `db:"DeletionTimeStamp"`
+	DeletionTimeStamp sql.NullInt64 `db:"DeletionTimestamp"`
 	Hash              string
 }
 
 func (r mccRow) AsInfo() (mccInfo gsh.MachineClassInfo, err error) {
 	var delTimeStamp time.Time
 	if r.DeletionTimeStamp.Valid {
-		delTimeStamp = time.UnixMilli(r.DeletionTimeStamp.Int64)
+		delTimeStamp = timeFromMillis(r.DeletionTimeStamp.Int64)
 	}
 	labels, err := labelsFromText(r.Labels)
 	if err != nil {
@@ -162,6 +163,7 @@ func (r mccRow) AsInfo() (mccInfo gsh.MachineClassInfo, err error) {
 			Namespace:         r.Namespace,
 		},
 		InstanceType:      r.InstanceType,
+		Provider:          r.Provider,
 		PoolName:          r.PoolName,
 		Region:            r.Region,
 		Zone:              r.Zone,
@@ -185,7 +187,7 @@ type nodeRow struct {
 	Taints             string
 	Allocatable        string
 	Capacity           string
-	DeletionTimeStamp  sql.NullInt64
+	DeletionTimeStamp  sql.NullInt64 `db:"DeletionTimestamp"`
 	Hash               string
 }
 
@@ -208,7 +210,7 @@ func (r nodeRow) AsInfo() (nodeInfo gst.NodeInfo, err error) {
 	}
 	var delTimeStamp time.Time
 	if r.DeletionTimeStamp.Valid {
-		delTimeStamp = time.UnixMilli(r.DeletionTimeStamp.Int64)
+		delTimeStamp = timeFromMillis(r.DeletionTimeStamp.Int64)
 	}
 	nodeInfo = gst.NodeInfo{
 		SnapshotMeta: gst.SnapshotMeta{
@@ -241,16 +243,19 @@ type podRow struct {
 	NominatedNodeName string `db:"NominatedNodeName"`
 	Labels            string
 	Requests          string
-	Spec              string
-	ScheduleStatus    int `db:"ScheduleStatus"`
-	DeletionTimeStamp sql.NullInt64
-	Hash              string
+	// Spec is the normalized spec joined from pod_spec. SpecNodeName and SpecTokenVolumeName restore the spec of the pod.
+	Spec                string
+	SpecNodeName        string        `db:"SpecNodeName"`
+	SpecTokenVolumeName string        `db:"SpecTokenVolumeName"`
+	ScheduleStatus      int           `db:"ScheduleStatus"`
+	DeletionTimeStamp   sql.NullInt64 `db:"DeletionTimestamp"`
+	Hash                string
 }
 
 func (r podRow) AsInfo() (podInfo gst.PodInfo, err error) {
 	var delTimeStamp time.Time
 	if r.DeletionTimeStamp.Valid {
-		delTimeStamp = time.UnixMilli(r.DeletionTimeStamp.Int64)
+		delTimeStamp = timeFromMillis(r.DeletionTimeStamp.Int64)
 	}
 	labels, err := labelsFromText(r.Labels)
 	if err != nil {
@@ -264,6 +269,7 @@ func (r podRow) AsInfo() (podInfo gst.PodInfo, err error) {
 	if err != nil {
 		return
 	}
+	denormalizePodSpec(&spec, r.SpecNodeName, r.SpecTokenVolumeName)
 	podInfo = gst.PodInfo{
 		SnapshotMeta: gst.SnapshotMeta{
 			RowID:             r.RowID,
@@ -296,14 +302,14 @@ type priorityClassRow struct {
 	PreemptionPolicy  string `db:"PreemptionPolicy"`
 	Description       string
 	Labels            string
-	DeletionTimeStamp sql.NullInt64
+	DeletionTimeStamp sql.NullInt64 `db:"DeletionTimestamp"`
 	Hash              string
 }
 
 func (r priorityClassRow) AsInfo() (info gst.PriorityClassInfo, err error) {
 	var delTimeStamp *metav1.Time
 	if r.DeletionTimeStamp.Valid {
-		delTimeStamp = &metav1.Time{Time: time.UnixMilli(r.DeletionTimeStamp.Int64)}
+		delTimeStamp = &metav1.Time{Time: timeFromMillis(r.DeletionTimeStamp.Int64)}
 	}
 	var preemptionPolicy corev1.PreemptionPolicy
 	if r.PreemptionPolicy != "" {
@@ -336,10 +342,51 @@ type stateInfoRow struct {
 	BeginTimestamp int64 `db:"BeginTimestamp"`
 }
 
+type recorderSessionRow struct {
+	SessionID          int64 `db:"SessionID"`
+	BeginTimestamp     int64 `db:"BeginTimestamp"`
+	HeartbeatTimestamp int64 `db:"HeartbeatTimestamp"`
+	EndTimestamp       int64 `db:"EndTimestamp"`
+}
+
+func (r recorderSessionRow) AsInfo() (sessionInfo gsh.RecorderSessionInfo, err error) {
+	sessionInfo = gsh.RecorderSessionInfo{
+		SessionID:     r.SessionID,
+		BeginTime:     timeFromMillis(r.BeginTimestamp),
+		HeartbeatTime: timeFromMillis(r.HeartbeatTimestamp),
+	}
+	if r.EndTimestamp != 0 {
+		sessionInfo.EndTime = timeFromMillis(r.EndTimestamp)
+	}
+	return
+}
+
+type watchDisconnectRow struct {
+	RowID          int64          `db:"RowID"`
+	SessionID      int64          `db:"SessionID"`
+	Informer       string         `db:"Informer"`
+	BeginTimestamp int64          `db:"BeginTimestamp"`
+	EndTimestamp   int64          `db:"EndTimestamp"`
+	Error          sql.NullString `db:"Error"`
+}
+
+func (r watchDisconnectRow) AsInfo() (disconnectInfo gsh.WatchDisconnectInfo, err error) {
+	disconnectInfo = gsh.WatchDisconnectInfo{
+		RowID:     r.RowID,
+		SessionID: r.SessionID,
+		Informer:  r.Informer,
+		BeginTime: timeFromMillis(r.BeginTimestamp),
+		EndTime:   timeFromMillis(r.EndTimestamp),
+		Error:     r.Error.String,
+	}
+	return
+}
+
 type caSettingsRow struct {
-	RowID                         int64 `db:"RowID"`
-	SnapshotTimestamp             int64 `db:"SnapshotTimestamp"`
-	Expander                      string
+	RowID             int64 `db:"RowID"`
+	SnapshotTimestamp int64 `db:"SnapshotTimestamp"`
+	Expander          string
+	// the durations are stored in milliseconds
 	MaxNodeProvisionTime          int64 `db:"MaxNodeProvisionTime"`
 	ScanInterval                  int64 `db:"ScanInterval"`
 	MaxGracefulTerminationSeconds int   `db:"MaxGracefulTerminationSeconds"`
@@ -361,10 +408,10 @@ func (r caSettingsRow) AsInfo() (caSettingsInfo gst.CASettingsInfo, err error) {
 		SnapshotTimestamp:             timeFromMillis(r.SnapshotTimestamp),
 		Expander:                      r.Expander,
 		NodeGroupsMinMax:              nil,
-		MaxNodeProvisionTime:          time.Duration(r.MaxNodeProvisionTime),
-		ScanInterval:                  time.Duration(r.ScanInterval),
+		MaxNodeProvisionTime:          time.Duration(r.MaxNodeProvisionTime) * time.Millisecond,
+		ScanInterval:                  time.Duration(r.ScanInterval) * time.Millisecond,
 		MaxGracefulTerminationSeconds: r.MaxGracefulTerminationSeconds,
-		NewPodScaleUpDelay:            time.Duration(r.NewPodScaleUpDelay),
+		NewPodScaleUpDelay:            time.Duration(r.NewPodScaleUpDelay) * time.Millisecond,
 		MaxEmptyBulkDelete:            r.MaxEmptyBulkDelete,
 		IgnoreDaemonSetUtilization:    r.IgnoreDaemonSetUtilization,
 		MaxNodesTotal:                 r.MaxNodesTotal,
@@ -373,3 +420,210 @@ func (r caSettingsRow) AsInfo() (caSettingsInfo gst.CASettingsInfo, err error) {
 	}
 	return
 }
+
+type caArgsRow struct {
+	RowID             int64 `db:"RowID"`
+	SnapshotTimestamp int64 `db:"SnapshotTimestamp"`
+	Args              string
+	Hash              string
+}
+
+func (r caArgsRow) AsInfo() (caArgsInfo gsh.CAArgsInfo, err error) {
+	args, err := labelsFromText(r.Args)
+	if err != nil {
+		return
+	}
+	caArgsInfo = gsh.CAArgsInfo{
+		RowID:             r.RowID,
+		SnapshotTimestamp: timeFromMillis(r.SnapshotTimestamp),
+		Args:              args,
+		Hash:              r.Hash,
+	}
+	return
+}
+
+type caNodeGroupStatusRow struct {
+	RowID               int64 `db:"RowID"`
+	SnapshotTimestamp   int64 `db:"SnapshotTimestamp"`
+	Name                string
+	Health              string
+	Ready               int
+	Unready             int
+	NotStarted          int `db:"NotStarted"`
+	LongNotStarted      int `db:"LongNotStarted"`
+	Registered          int
+	LongUnregistered    int    `db:"LongUnregistered"`
+	CloudProviderTarget int    `db:"CloudProviderTarget"`
+	MinSize             int    `db:"MinSize"`
+	MaxSize             int    `db:"MaxSize"`
+	ScaleUp             string `db:"ScaleUp"`
+	Backoff             bool
+	ScaleDown           string `db:"ScaleDown"`
+	ScaleDownCandidates int    `db:"ScaleDownCandidates"`
+	Hash                string
+}
+
+func (r caNodeGroupStatusRow) AsInfo() (statusInfo gsh.CANodeGroupStatusInfo, err error) {
+	statusInfo = gsh.CANodeGroupStatusInfo{
+		RowID:               r.RowID,
+		SnapshotTimestamp:   timeFromMillis(r.SnapshotTimestamp),
+		Name:                r.Name,
+		Health:              r.Health,
+		Ready:               r.Ready,
+		Unready:             r.Unready,
+		NotStarted:          r.NotStarted,
+		LongNotStarted:      r.LongNotStarted,
+		Registered:          r.Registered,
+		LongUnregistered:    r.LongUnregistered,
+		CloudProviderTarget: r.CloudProviderTarget,
+		MinSize:             r.MinSize,
+		MaxSize:             r.MaxSize,
+		ScaleUp:             r.ScaleUp,
+		Backoff:             r.Backoff,
+		ScaleDown:           r.ScaleDown,
+		ScaleDownCandidates: r.ScaleDownCandidates,
+		Hash:                r.Hash,
+	}
+	return
+}
+
+type nodeScaleDownActivityRow struct {
+	RowID        int64  `db:"RowID"`
+	ActivityTime int64  `db:"ActivityTime"`
+	NodeName     string `db:"NodeName"`
+	Activity     string
+	Detail       string
+}
+
+func (r nodeScaleDownActivityRow) AsInfo() (activityInfo gsh.NodeScaleDownActivityInfo, err error) {
+	activityInfo = gsh.NodeScaleDownActivityInfo{
+		RowID:        r.RowID,
+		ActivityTime: timeFromMillis(r.ActivityTime),
+		NodeName:     r.NodeName,
+		Activity:     gsh.ScaleDownActivity(r.Activity),
+		Detail:       r.Detail,
+	}
+	return
+}
+
+type nodeDeletionRow struct {
+	Name              string
+	DeletionTimestamp int64 `db:"DeletionTimestamp"`
+}
+
+type failedSchedulingReasonRow struct {
+	RowID        int64  `db:"RowID"`
+	EventUID     string `db:"EventUID"`
+	EventTime    int64  `db:"EventTime"`
+	EventCount   int    `db:"EventCount"`
+	PodUID       string `db:"PodUID"`
+	PodName      string `db:"PodName"`
+	PodNamespace string `db:"PodNamespace"`
+	Zone         string `db:"Zone"`
+	TotalNodes   int    `db:"TotalNodes"`
+	Reason       string
+	NodeCount    int `db:"NodeCount"`
+}
+
+// failedSchedulingInfosFromRows groups the given failedSchedulingReasonRows by EventUID and EventCount into
+// FailedSchedulingInfos preserving the order of the rows.
+func failedSchedulingInfosFromRows(rows []failedSchedulingReasonRow) (failedSchedulingInfos []gsh.FailedSchedulingInfo) {
+	type occurrenceKey struct {
+		eventUID   string
+		eventCount int
+	}
+	indexByOccurrence := make(map[occurrenceKey]int)
+	for _, r := range rows {
+		key := occurrenceKey{eventUID: r.EventUID, eventCount: r.EventCount}
+		idx, ok := indexByOccurrence[key]
+		if !ok {
+			failedSchedulingInfos = append(failedSchedulingInfos, gsh.FailedSchedulingInfo{
+				EventUID:     r.EventUID,
+				EventTime:    timeFromMillis(r.EventTime),
+				Count:        r.EventCount,
+				PodUID:       r.PodUID,
+				PodName:      r.PodName,
+				PodNamespace: r.PodNamespace,
+				Zone:         r.Zone,
+				TotalNodes:   r.TotalNodes,
+				ReasonCounts: make(map[string]int),
+			})
+			idx = len(failedSchedulingInfos) - 1
+			indexByOccurrence[key] = idx
+		}
+		failedSchedulingInfos[idx].ReasonCounts[r.Reason] = r.NodeCount
+	}
+	return
+}
+
+type failedSchedulingReasonCountRow struct {
+	Reason    string
+	PodCount  int `db:"PodCount"`
+	NodeCount int `db:"NodeCount"`
+}
+
+type caScalingEventRow struct {
+	RowID            int64  `db:"RowID"`
+	EventUID         string `db:"EventUID"`
+	EventTime        int64  `db:"EventTime"`
+	Reason           string
+	PodUID           string `db:"PodUID"`
+	PodName          string `db:"PodName"`
+	PodNamespace     string `db:"PodNamespace"`
+	NodeGroupName    string `db:"NodeGroupName"`
+	CurrentSize      int    `db:"CurrentSize"`
+	TargetSize       int    `db:"TargetSize"`
+	MaxSize          int    `db:"MaxSize"`
+	NodeName         string `db:"NodeName"`
+	NotTriggerReason string `db:"NotTriggerReason"`
+	NodeGroupCount   int    `db:"NodeGroupCount"`
+}
+
+func (r caScalingEventRow) AsInfo() (scalingEventInfo gsh.CAScalingEventInfo, err error) {
+	scalingEventInfo = gsh.CAScalingEventInfo{
+		RowID:            r.RowID,
+		EventUID:         r.EventUID,
+		EventTime:        timeFromMillis(r.EventTime),
+		Reason:           r.Reason,
+		PodUID:           r.PodUID,
+		PodName:          r.PodName,
+		PodNamespace:     r.PodNamespace,
+		NodeGroupName:    r.NodeGroupName,
+		CurrentSize:      r.CurrentSize,
+		TargetSize:       r.TargetSize,
+		MaxSize:          r.MaxSize,
+		NodeName:         r.NodeName,
+		NotTriggerReason: r.NotTriggerReason,
+		NodeGroupCount:   r.NodeGroupCount,
+	}
+	return
+}
+
+type checkpointRow struct {
+	CheckpointID      int64 `db:"CheckpointID"`
+	SnapshotTimestamp int64 `db:"SnapshotTimestamp"`
+	RowCount          int   `db:"RowCount"`
+}
+
+func (r checkpointRow) AsInfo() (checkpointInfo gsh.CheckpointInfo, err error) {
+	checkpointInfo = gsh.CheckpointInfo{
+		CheckpointID: r.CheckpointID,
+		SnapshotTime: timeFromMillis(r.SnapshotTimestamp),
+		RowCount:     r.RowCount,
+	}
+	return
+}
+
+// checkpointTableRow holds the RowIDs of the latest rows of a table stored in a checkpoint as JSON array.
+type checkpointTableRow struct {
+	CheckpointID   int64  `db:"CheckpointID"`
+	TableName      string `db:"TableName"`
+	HighWaterRowID int64  `db:"HighWaterRowID"`
+	RowIDs         string `db:"RowIDs"`
+}
+
+// checkpointRowRef is a row stored in a checkpoint.
+type checkpointRowRef struct {
+	RowID             int64 `db:"RowID"`
+	SnapshotTimestamp int64 `db:"SnapshotTimestamp"`
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// schemaMigration changes the schema of a SQLite or PostgreSQL data db created by an earlier version of the recorder.
+// The statements are executed before migrate, which moves data that cannot be moved in SQL. If vacuum is set, a SQLite
+// data db is vacuumed after the migration to return the space freed by it to the file system.
+type schemaMigration struct {
+	description string
+	statements  []string
+	migrate     func(tx *sql.Tx) error
+	vacuum      bool
+}
+
+// schemaMigrations are applied in order to a SQLite data db. The user_version pragma of the db holds the number of
+// applied migrations, so new migrations must be appended and applied ones never changed or reordered.
+var schemaMigrations = []schemaMigration{
+	{
+		description: "add indexes for the point-in-time queries, hash lookups and deletion queries",
+		statements: []string{
+			CreatePodInfoUIDHashIndex,
+			CreatePodInfoUIDSnapshotIndex,
+			CreatePodInfoNameSnapshotIndex,
+			CreatePodInfoDeletionIndex,
+			CreateNodeInfoNameHashIndex,
+			CreateNodeInfoCreationIndex,
+			CreateNodeInfoDeletionIndex,
+			CreateMCDInfoNameSnapshotIndex,
+			CreateMCDInfoDeletionIndex,
+			CreateMCCInfoNameSnapshotIndex,
+			CreateWorkerPoolInfoNameSnapshotIndex,
+			CreatePriorityClassInfoNameSnapshotIndex,
+			CreatePriorityClassInfoUIDHashIndex,
+			CreateCANodeGroupStatusInfoNameSnapshotIndex,
+			CreateCASettingsInfoSnapshotIndex,
+			CreateCAArgsInfoSnapshotIndex,
+		},
+	},
+	{
+		description: "move the pod_info specs into pod_spec",
+		statements:  []string{CreatePodSpecTable},
+		migrate:     migratePodSpecs,
+		vacuum:      true,
+	},
+	{
+		description: "store every occurrence of a FailedScheduling event with its count and the zone of the pod",
+		statements: []string{
+			CreateFailedSchedulingReasonInfoOccurrenceTable,
+			CopyFailedSchedulingReasonInfoToOccurrenceTable,
+			DropFailedSchedulingReasonInfoTable,
+			RenameFailedSchedulingReasonInfoOccurrenceTable,
+		},
+	},
+	{
+		description: "swap the ScanInterval and MaxNodeProvisionTime of the ca_settings_info rows",
+		statements:  []string{SwapCASettingsInfoDurationColumns},
+	},
+	{
+		description: "add the provider of the machine classes to the mcc_info rows",
+		statements:  []string{AddMCCInfoProviderColumn},
+	},
+}
+
+// migrateSchema applies the schemaMigrations not yet applied to the data db, each in its own transaction.
+func (d *DataAccess) migrateSchema() error {
+	var version int
+	err := d.dataDB.QueryRow("PRAGMA user_version").Scan(&version)
+	if err != nil {
+		return fmt.Errorf("cannot get schema version of data db %q: %w", d.name(), err)
+	}
+	for ; version < len(schemaMigrations); version++ {
+		migration := schemaMigrations[version]
+		err = d.applySchemaMigration(version+1, migration)
+		if err != nil {
+			return fmt.Errorf("cannot migrate data db %q to schema version %d (%s): %w", d.name(), version+1, migration.description, err)
+		}
+		slog.Info("migrated data db schema", "dataDB", d.name(), "version", version+1, "description", migration.description)
+		if migration.vacuum {
+			_, err = d.dataDB.Exec("VACUUM")
+			if err != nil {
+				return fmt.Errorf("cannot vacuum data db %q after migration to schema version %d: %w", d.name(), version+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *DataAccess) applySchemaMigration(version int, migration schemaMigration) error {
+	tx, err := d.dataDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	for _, statement := range migration.statements {
+		_, err = tx.Exec(statement)
+		if err != nil {
+			return fmt.Errorf("cannot execute %q: %w", firstLine(statement), err)
+		}
+	}
+	if migration.migrate != nil {
+		err = migration.migrate(tx)
+		if err != nil {
+			return err
+		}
+	}
+	// the pragma does not accept bound parameters
+	_, err = tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
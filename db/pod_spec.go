@@ -0,0 +1,239 @@
+package db
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// podSpecTokenVolumeName is the name of the projected service account token volume in a normalized pod spec. The
+// volume of every pod is named kube-api-access-<random suffix>, which would keep apart the specs of pods that are
+// otherwise identical.
+const podSpecTokenVolumeName = "kube-api-access"
+
+// podSpecMigrationBatchSize is the number of pod_info rows read at once by migratePodSpecs.
+const podSpecMigrationBatchSize = 1000
+
+// normalizedPodSpec is a pod spec without the fields that differ between the pods of a ReplicaSet, which is stored
+// once in pod_spec, together with the values of these fields that are stored in the pod_info row.
+type normalizedPodSpec struct {
+	Hash            string
+	Spec            string
+	NodeName        string
+	TokenVolumeName string
+}
+
+// normalizePodSpec clears the NodeName of the given spec and renames its projected service account token volume to
+// podSpecTokenVolumeName. The Hash of the normalized spec is the hex SHA-256 of its JSON.
+func normalizePodSpec(podSpec corev1.PodSpec) (n normalizedPodSpec, err error) {
+	spec := podSpec.DeepCopy()
+	n.NodeName = spec.NodeName
+	spec.NodeName = ""
+	if !slices.ContainsFunc(spec.Volumes, func(v corev1.Volume) bool { return v.Name == podSpecTokenVolumeName }) {
+		for _, v := range spec.Volumes {
+			if v.Projected != nil && strings.HasPrefix(v.Name, podSpecTokenVolumeName+"-") {
+				n.TokenVolumeName = v.Name
+				renamePodSpecVolume(spec, v.Name, podSpecTokenVolumeName)
+				break
+			}
+		}
+	}
+	n.Spec, err = specToJson(*spec)
+	if err != nil {
+		return
+	}
+	hash := sha256.Sum256([]byte(n.Spec))
+	n.Hash = hex.EncodeToString(hash[:])
+	return
+}
+
+// denormalizePodSpec restores the NodeName and the projected service account token volume name of a spec normalized
+// by normalizePodSpec.
+func denormalizePodSpec(spec *corev1.PodSpec, nodeName, tokenVolumeName string) {
+	spec.NodeName = nodeName
+	if tokenVolumeName != "" {
+		renamePodSpecVolume(spec, podSpecTokenVolumeName, tokenVolumeName)
+	}
+}
+
+// renamePodSpecVolume renames the volume with the given name and its mounts in all containers of the spec.
+func renamePodSpecVolume(spec *corev1.PodSpec, name, newName string) {
+	for i := range spec.Volumes {
+		if spec.Volumes[i].Name == name {
+			spec.Volumes[i].Name = newName
+		}
+	}
+	renameMounts := func(mounts []corev1.VolumeMount) {
+		for i := range mounts {
+			if mounts[i].Name == name {
+				mounts[i].Name = newName
+			}
+		}
+	}
+	for i := range spec.InitContainers {
+		renameMounts(spec.InitContainers[i].VolumeMounts)
+	}
+	for i := range spec.Containers {
+		renameMounts(spec.Containers[i].VolumeMounts)
+	}
+	for i := range spec.EphemeralContainers {
+		renameMounts(spec.EphemeralContainers[i].VolumeMounts)
+	}
+}
+
+// podSpecMigration holds the queries of the migration of pod_info rows storing their Spec to rows referencing a
+// pod_spec in a SQLite or a PostgreSQL data db.
+type podSpecMigration struct {
+	countSpecColumns string
+	addColumns       []string
+	// selectSpecsAfterRowID selects the RowID, ClusterID and Spec of a batch of pod_info rows after a RowID.
+	selectSpecsAfterRowID string
+	insertSpec            string
+	// insertSpecArgs returns the parameters of insertSpec for the given spec of a row of the given cluster.
+	insertSpecArgs func(clusterID string, n normalizedPodSpec) []any
+	updateSpecHash string
+	dropSpecColumn string
+	countSpecs     string
+}
+
+var sqlitePodSpecMigration = podSpecMigration{
+	countSpecColumns: "SELECT COUNT(*) FROM pragma_table_info('pod_info') WHERE name = 'Spec'",
+	addColumns: []string{
+		"ALTER TABLE pod_info ADD COLUMN SpecHash TEXT",
+		"ALTER TABLE pod_info ADD COLUMN SpecNodeName TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE pod_info ADD COLUMN SpecTokenVolumeName TEXT NOT NULL DEFAULT ''",
+	},
+	selectSpecsAfterRowID: SelectPodInfoSpecsAfterRowID,
+	insertSpec:            InsertPodSpec,
+	insertSpecArgs: func(_ string, n normalizedPodSpec) []any {
+		return []any{n.Hash, n.Spec}
+	},
+	updateSpecHash: UpdatePodInfoSpecHash,
+	dropSpecColumn: "ALTER TABLE pod_info DROP COLUMN Spec",
+	countSpecs:     SelectPodSpecCount,
+}
+
+var postgresPodSpecMigration = podSpecMigration{
+	countSpecColumns: PostgresSelectPodInfoSpecColumnCount,
+	addColumns: []string{
+		`ALTER TABLE pod_info ADD COLUMN "SpecHash" TEXT`,
+		`ALTER TABLE pod_info ADD COLUMN "SpecNodeName" TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE pod_info ADD COLUMN "SpecTokenVolumeName" TEXT NOT NULL DEFAULT ''`,
+	},
+	selectSpecsAfterRowID: PostgresSelectPodInfoSpecsAfterRowID,
+	insertSpec:            PostgresInsertPodSpec,
+	insertSpecArgs: func(clusterID string, n normalizedPodSpec) []any {
+		return []any{clusterID, n.Hash, n.Spec}
+	},
+	updateSpecHash: PostgresUpdatePodInfoSpecHash,
+	dropSpecColumn: `ALTER TABLE pod_info DROP COLUMN "Spec"`,
+	countSpecs:     PostgresSelectPodSpecCount,
+}
+
+// migratePodSpecs moves the specs of the pod_info rows of a SQLite data db created before pod_spec into pod_spec and
+// drops the Spec column of pod_info.
+func migratePodSpecs(tx *sql.Tx) error {
+	return sqlitePodSpecMigration.apply(tx)
+}
+
+// migratePostgresPodSpecs moves the specs of the pod_info rows of all clusters of a PostgreSQL data db created before
+// pod_spec into pod_spec and drops the Spec column of pod_info.
+func migratePostgresPodSpecs(tx *sql.Tx) error {
+	return postgresPodSpecMigration.apply(tx)
+}
+
+// apply moves the specs of the pod_info rows into pod_spec and drops the Spec column of pod_info. It does nothing for a
+// data db whose pod_info table was created without Spec.
+func (m podSpecMigration) apply(tx *sql.Tx) error {
+	var specColumns int
+	err := tx.QueryRow(m.countSpecColumns).Scan(&specColumns)
+	if err != nil {
+		return fmt.Errorf("cannot get the columns of table pod_info: %w", err)
+	}
+	if specColumns == 0 {
+		return nil
+	}
+	for _, addColumn := range m.addColumns {
+		_, err = tx.Exec(addColumn)
+		if err != nil {
+			return fmt.Errorf("cannot execute %q: %w", addColumn, err)
+		}
+	}
+	insertSpec, err := tx.Prepare(m.insertSpec)
+	if err != nil {
+		return fmt.Errorf("cannot prepare %q: %w", firstLine(m.insertSpec), err)
+	}
+	defer insertSpec.Close()
+	updateSpecHash, err := tx.Prepare(m.updateSpecHash)
+	if err != nil {
+		return fmt.Errorf("cannot prepare %q: %w", firstLine(m.updateSpecHash), err)
+	}
+	defer updateSpecHash.Close()
+
+	type rowSpec struct {
+		rowID     int64
+		clusterID string
+		spec      string
+	}
+	var lastRowID int64
+	var numRows int
+	for {
+		rows, err := tx.Query(m.selectSpecsAfterRowID, lastRowID, podSpecMigrationBatchSize)
+		if err != nil {
+			return fmt.Errorf("cannot select pod_info specs after RowID %d: %w", lastRowID, err)
+		}
+		var batch []rowSpec
+		for rows.Next() {
+			var r rowSpec
+			err = rows.Scan(&r.rowID, &r.clusterID, &r.spec)
+			if err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("cannot scan pod_info spec after RowID %d: %w", lastRowID, err)
+			}
+			batch = append(batch, r)
+		}
+		err = rows.Close()
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for _, r := range batch {
+			spec, err := speccFromJson(r.spec)
+			if err != nil {
+				return fmt.Errorf("cannot migrate spec of pod_info row %d: %w", r.rowID, err)
+			}
+			n, err := normalizePodSpec(spec)
+			if err != nil {
+				return fmt.Errorf("cannot migrate spec of pod_info row %d: %w", r.rowID, err)
+			}
+			_, err = insertSpec.Exec(m.insertSpecArgs(r.clusterID, n)...)
+			if err != nil {
+				return fmt.Errorf("cannot insert spec of pod_info row %d: %w", r.rowID, err)
+			}
+			_, err = updateSpecHash.Exec(n.Hash, n.NodeName, n.TokenVolumeName, r.rowID)
+			if err != nil {
+				return fmt.Errorf("cannot update spec hash of pod_info row %d: %w", r.rowID, err)
+			}
+		}
+		lastRowID = batch[len(batch)-1].rowID
+		numRows += len(batch)
+	}
+	_, err = tx.Exec(m.dropSpecColumn)
+	if err != nil {
+		return fmt.Errorf("cannot drop column Spec of table pod_info: %w", err)
+	}
+	var numSpecs int
+	err = tx.QueryRow(m.countSpecs).Scan(&numSpecs)
+	if err != nil {
+		return fmt.Errorf("cannot count pod specs: %w", err)
+	}
+	slog.Info("moved pod_info specs into pod_spec", "numRows", numRows, "numSpecs", numSpecs)
+	return nil
+}
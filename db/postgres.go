@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	_ "github.com/lib/pq"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// PostgresDataAccess is the Store of a cluster in a PostgreSQL data db. The db can hold the rows of many clusters: every
+// row carries its cluster identifier and a PostgresDataAccess only reads and writes the rows of its own cluster. It
+// shares the mapping of rows and the write pipeline with DataAccess, but has its own schema, migrations and queries.
+type PostgresDataAccess struct {
+	DataAccess
+	dsn       string
+	clusterID string
+}
+
+var _ Store = (*PostgresDataAccess)(nil)
+
+// postgresSchemaMigrations are applied in order to a PostgreSQL data db. The schema_migration_info table holds a row per
+// applied migration, so new migrations must be appended and applied ones never changed or reordered. Every migration
+// must also do nothing on a schema created by postgresCreateTables, since the migrations are applied to new dbs too.
+var postgresSchemaMigrations = []schemaMigration{
+	{
+		description: "move the pod_info specs into pod_spec",
+		migrate:     migratePostgresPodSpecs,
+	},
+	{
+		description: "store every occurrence of a FailedScheduling event with its count and the zone of the pod",
+		statements: []string{
+			PostgresAddFailedSchedulingReasonInfoEventCountColumn,
+			PostgresAddFailedSchedulingReasonInfoZoneColumn,
+			PostgresDropFailedSchedulingReasonInfoEventUniqueConstraint,
+			PostgresCreateFailedSchedulingReasonInfoOccurrenceIndex,
+		},
+	},
+	{
+		description: "swap the ScanInterval and MaxNodeProvisionTime of the ca_settings_info rows",
+		statements:  []string{PostgresSwapCASettingsInfoDurationColumns},
+	},
+	{
+		description: "add the provider of the machine classes to the mcc_info rows",
+		statements:  []string{PostgresAddMCCInfoProviderColumn},
+	},
+}
+
+// NewPostgresDataAccess creates a PostgresDataAccess that stores the rows of the cluster with the given identifier in
+// the PostgreSQL database with the given connection string.
+func NewPostgresDataAccess(dsn, clusterID string) *PostgresDataAccess {
+	return &PostgresDataAccess{
+		DataAccess: DataAccess{
+			label:              "postgres cluster " + clusterID,
+			writeQueueSize:     DefaultWriteQueueSize,
+			writeBatchSize:     DefaultWriteBatchSize,
+			writeFlushInterval: DefaultWriteFlushInterval,
+		},
+		dsn:       dsn,
+		clusterID: clusterID,
+	}
+}
+
+// Init opens the PostgreSQL data db, creates the schema and applies the postgresSchemaMigrations not yet applied.
+func (p *PostgresDataAccess) Init() error {
+	if p.clusterID == "" {
+		return errors.New("cannot open postgres data db: cluster identifier must be set")
+	}
+	db, err := sql.Open("postgres", p.dsn)
+	if err != nil {
+		return fmt.Errorf("cannot open postgres db: %w", err)
+	}
+	p.dataDB = db
+	p.readDB = db
+	err = p.createSchema()
+	if err != nil {
+		return fmt.Errorf("error creating db schema: %w", err)
+	}
+	err = p.migrateSchema()
+	if err != nil {
+		return err
+	}
+	err = p.prepareStatements(p.prepare)
+	if err != nil {
+		return fmt.Errorf("error preparing statements: %w", err)
+	}
+	p.writes = newWritePipeline(db, p.writeQueueSize, p.writeBatchSize, p.writeFlushInterval, true)
+	return nil
+}
+
+// CheckWritable checks that the data db is open and writable by executing a no-op write in a transaction that is
+// rolled back.
+func (p *PostgresDataAccess) CheckWritable(ctx context.Context) error {
+	return p.checkWritable(ctx, PostgresCheckWritableRecorderStateInfo)
+}
+
+func (p *PostgresDataAccess) createSchema() error {
+	for _, createTable := range postgresCreateTables {
+		_, err := p.dataDB.Exec(createTable)
+		if err != nil {
+			return fmt.Errorf("cannot create table with %q: %w", firstLine(createTable), err)
+		}
+	}
+	slog.Info("successfully created the postgres tables", "clusterID", p.clusterID)
+	return nil
+}
+
+// migrateSchema applies the postgresSchemaMigrations not yet applied to the data db in a single transaction, which
+// holds an advisory lock so that concurrently starting recorders do not migrate the shared schema twice.
+func (p *PostgresDataAccess) migrateSchema() error {
+	tx, err := p.dataDB.Begin()
+	if err != nil {
+		return fmt.Errorf("cannot begin schema migration of data db %q: %w", p.name(), err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	_, err = tx.Exec(PostgresLockSchemaMigration)
+	if err != nil {
+		return fmt.Errorf("cannot lock schema migration of data db %q: %w", p.name(), err)
+	}
+	var version int
+	err = tx.QueryRow(PostgresSelectSchemaVersion).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("cannot get schema version of data db %q: %w", p.name(), err)
+	}
+	if version >= len(postgresSchemaMigrations) {
+		return nil
+	}
+	for ; version < len(postgresSchemaMigrations); version++ {
+		migration := postgresSchemaMigrations[version]
+		err = applyPostgresSchemaMigration(tx, version+1, migration)
+		if err != nil {
+			return fmt.Errorf("cannot migrate data db %q to schema version %d (%s): %w", p.name(), version+1, migration.description, err)
+		}
+		slog.Info("migrated data db schema", "dataDB", p.name(), "version", version+1, "description", migration.description)
+	}
+	return tx.Commit()
+}
+
+func applyPostgresSchemaMigration(tx *sql.Tx, version int, migration schemaMigration) error {
+	for _, statement := range migration.statements {
+		_, err := tx.Exec(statement)
+		if err != nil {
+			return fmt.Errorf("cannot execute %q: %w", firstLine(statement), err)
+		}
+	}
+	if migration.migrate != nil {
+		err := migration.migrate(tx)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec(PostgresInsertSchemaMigrationInfo, version, migration.description, time.Now().UTC().UnixMilli())
+	return err
+}
+
+// prepare prepares the PostgreSQL variant of the given SQLite query.
+func (p *PostgresDataAccess) prepare(query string) (*statement, error) {
+	postgresQuery, ok := postgresQueries[query]
+	if !ok {
+		return nil, fmt.Errorf("no postgres variant of query %q", firstLine(query))
+	}
+	stmt, err := p.dataDB.Prepare(postgresQuery)
+	if err != nil {
+		return nil, err
+	}
+	return &statement{
+		Stmt:         stmt,
+		clusterID:    p.clusterID,
+		returnsRowID: strings.Contains(postgresQuery, "RETURNING"),
+	}, nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
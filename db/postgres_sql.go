@@ -0,0 +1,846 @@
+package db
+
+// PostgreSQL variants of the queries in sql.go. A PostgreSQL data db holds the rows of many clusters: every table has
+// a ClusterID column and every query takes the cluster identifier as first parameter, followed by the parameters of
+// the SQLite query. Identifiers are quoted to keep the column names of the SQLite schema. Inserts into tables with a
+// RowID return it. The SQLite "GROUP BY Name HAVING max(SnapshotTimestamp)" idiom becomes DISTINCT ON.
+
+const PostgresCreateRecorderStateInfo = `CREATE TABLE IF NOT EXISTS recorder_state_info(
+    "ClusterID" TEXT NOT NULL,
+    "BeginTimestamp" BIGINT NOT NULL)`
+const PostgresInsertRecorderStateInfo = `INSERT INTO recorder_state_info("ClusterID", "BeginTimestamp") VALUES ($1, $2)`
+const PostgresSelectInitialRecorderStateInfo = `SELECT "BeginTimestamp" FROM recorder_state_info WHERE "ClusterID" = $1
+    ORDER BY "BeginTimestamp" LIMIT 1`
+
+// PostgresCheckWritableRecorderStateInfo deletes no rows. It fails if the data db is read-only or unreachable.
+const PostgresCheckWritableRecorderStateInfo = `DELETE FROM recorder_state_info WHERE "BeginTimestamp" < 0`
+
+const PostgresCreateRecorderSessionInfoTable = `CREATE TABLE IF NOT EXISTS recorder_session_info(
+    "SessionID" BIGSERIAL PRIMARY KEY,
+    "ClusterID" TEXT NOT NULL,
+    "BeginTimestamp" BIGINT NOT NULL,
+    "HeartbeatTimestamp" BIGINT NOT NULL,
+    "EndTimestamp" BIGINT NOT NULL DEFAULT 0)`
+const PostgresInsertRecorderSessionInfo = `INSERT INTO recorder_session_info("ClusterID", "BeginTimestamp", "HeartbeatTimestamp")
+    VALUES ($1, $2, $3) RETURNING "SessionID"`
+const PostgresUpdateRecorderSessionHeartbeat = `UPDATE recorder_session_info SET "HeartbeatTimestamp" = $2
+    WHERE "ClusterID" = $1 AND "SessionID" = $3`
+const PostgresUpdateRecorderSessionEnd = `UPDATE recorder_session_info SET "HeartbeatTimestamp" = $2, "EndTimestamp" = $3
+    WHERE "ClusterID" = $1 AND "SessionID" = $4`
+const PostgresSelectRecorderSessionInfos = `SELECT * FROM recorder_session_info WHERE "ClusterID" = $1
+    ORDER BY "BeginTimestamp", "SessionID"`
+
+const PostgresCreateWatchDisconnectInfoTable = `CREATE TABLE IF NOT EXISTS watch_disconnect_info(
+    "RowID" BIGSERIAL PRIMARY KEY,
+    "ClusterID" TEXT NOT NULL,
+    "SessionID" BIGINT NOT NULL,
+    "Informer" TEXT NOT NULL,
+    "BeginTimestamp" BIGINT NOT NULL,
+    "EndTimestamp" BIGINT NOT NULL,
+    "Error" TEXT)`
+const PostgresInsertWatchDisconnectInfo = `INSERT INTO watch_disconnect_info(
+    "ClusterID",
+    "SessionID",
+    "Informer",
+    "BeginTimestamp",
+    "EndTimestamp",
+    "Error"
+) VALUES ($1, $2, $3, $4, $5, $6) RETURNING "RowID"`
+const PostgresSelectWatchDisconnectInfos = `SELECT * FROM watch_disconnect_info WHERE "ClusterID" = $1
+    ORDER BY "BeginTimestamp", "RowID"`
+
+const PostgresSelectPodUIDsNotDeleted = `SELECT DISTINCT "UID" FROM pod_info
+    WHERE "ClusterID" = $1 AND ("DeletionTimestamp" IS NULL OR "DeletionTimestamp" = 0)`
+const PostgresSelectNodeNamesNotDeleted = `SELECT DISTINCT "Name" FROM node_info
+    WHERE "ClusterID" = $1 AND ("DeletionTimestamp" IS NULL OR "DeletionTimestamp" = 0)`
+const PostgresSelectMCDNamesNotDeleted = `SELECT DISTINCT "Name" FROM mcd_info
+    WHERE "ClusterID" = $1 AND ("DeletionTimestamp" IS NULL OR "DeletionTimestamp" = 0)`
+const PostgresUpdatePodDeletionInferredAtRestart = `UPDATE pod_info SET "DeletionTimestamp" = $2, "DeletionInferredAtRestart" = 1
+    WHERE "ClusterID" = $1 AND "UID" = $3 AND ("DeletionTimestamp" IS NULL OR "DeletionTimestamp" = 0)`
+const PostgresUpdateNodeDeletionInferredAtRestart = `UPDATE node_info SET "DeletionTimestamp" = $2, "DeletionInferredAtRestart" = 1
+    WHERE "ClusterID" = $1 AND "Name" = $3 AND ("DeletionTimestamp" IS NULL OR "DeletionTimestamp" = 0)`
+const PostgresUpdateMCDDeletionInferredAtRestart = `UPDATE mcd_info SET "DeletionTimestamp" = $2, "DeletionInferredAtRestart" = 1
+    WHERE "ClusterID" = $1 AND "Name" = $3 AND ("DeletionTimestamp" IS NULL OR "DeletionTimestamp" = 0)`
+const PostgresUpdateMCDInfoInferredAtRestart = `UPDATE mcd_info SET "InferredAtRestart" = 1 WHERE "ClusterID" = $1 AND "RowID" = $2`
+
+const PostgresCreateWorkerPoolInfo = `CREATE TABLE IF NOT EXISTS worker_pool_info(
+    "RowID" BIGSERIAL PRIMARY KEY,
+    "ClusterID" TEXT NOT NULL,
+    "CreationTimestamp" BIGINT NOT NULL,
+    "SnapshotTimestamp" BIGINT NOT NULL,
+    "Name" TEXT,
+    "Namespace" TEXT,
+    "MachineType" TEXT,
+    "Architecture" TEXT,
+    "Minimum" INT,
+    "Maximum" INT,
+    "MaxSurge" TEXT,
+    "MaxUnavailable" TEXT,
+    "Zones" TEXT,
+    "DeletionTimestamp" BIGINT,
+    "Hash" TEXT)`
+const PostgresInsertWorkerPoolInfo = `INSERT INTO worker_pool_info(
+    "ClusterID",
+    "CreationTimestamp",
+    "SnapshotTimestamp",
+    "Name",
+    "Namespace",
+    "MachineType",
+    "Architecture",
+    "Minimum",
+    "Maximum",
+    "MaxSurge",
+    "MaxUnavailable",
+    "Zones",
+    "Hash"
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING "RowID"`
+const PostgresSelectWorkerPoolInfoBefore = `SELECT DISTINCT ON ("Name") * FROM worker_pool_info
+    WHERE "ClusterID" = $1 AND "SnapshotTimestamp" <= $2 ORDER BY "Name", "SnapshotTimestamp" DESC, "RowID" DESC`
+const PostgresSelectAllWorkerPoolInfoHashes = `SELECT "RowID", "Name", "SnapshotTimestamp", "Hash" FROM worker_pool_info
+    WHERE "ClusterID" = $1 ORDER BY "RowID" DESC`
+
+const PostgresCreateMCDInfoTable = `CREATE TABLE IF NOT EXISTS mcd_info(
+    "RowID" BIGSERIAL PRIMARY KEY,
+    "ClusterID" TEXT NOT NULL,
+    "CreationTimestamp" BIGINT NOT NULL,
+    "SnapshotTimestamp" BIGINT NOT NULL,
+    "Name" TEXT,
+    "Namespace" TEXT,
+    "Replicas" INT,
+    "PoolName" TEXT,
+    "Zone" TEXT,
+    "MaxSurge" TEXT,
+    "MaxUnavailable" TEXT,
+    "MachineClassName" TEXT,
+    "Labels" TEXT,
+    "Taints" TEXT,
+    "DeletionTimestamp" BIGINT,
+    "Hash" TEXT,
+    "InferredAtRestart" INT NOT NULL DEFAULT 0,
+    "DeletionInferredAtRestart" INT NOT NULL DEFAULT 0)`
+const PostgresInsertMCDInfo = `INSERT INTO mcd_info(
+    "ClusterID",
+    "CreationTimestamp",
+    "SnapshotTimestamp",
+    "Name",
+    "Namespace",
+    "Replicas",
+    "PoolName",
+    "Zone",
+    "MaxSurge",
+    "MaxUnavailable",
+    "MachineClassName",
+    "Labels",
+    "Taints",
+    "Hash"
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING "RowID"`
+const PostgresSelectLatestMCDInfoBefore = `SELECT DISTINCT ON ("Name") * FROM mcd_info
+    WHERE "ClusterID" = $1 AND "SnapshotTimestamp" <= $2 ORDER BY "Name", "SnapshotTimestamp" DESC, "RowID" DESC`
+const PostgresUpdateMCDInfoDeletionTimestamp = `UPDATE mcd_info SET "DeletionTimestamp" = $2 WHERE "ClusterID" = $1 AND "Name" = $3`
+const PostgresSelectMCDInfoHash = `SELECT "Hash" FROM mcd_info WHERE "ClusterID" = $1 AND "Name" = $2 ORDER BY "RowID" DESC LIMIT 1`
+const PostgresSelectLatestMCDInfo = `SELECT * FROM mcd_info WHERE "ClusterID" = $1 AND "Name" = $2 ORDER BY "RowID" DESC LIMIT 1`
+
+const PostgresCreateMCCInfoTable = `CREATE TABLE IF NOT EXISTS mcc_info(
+    "RowID" BIGSERIAL PRIMARY KEY,
+    "ClusterID" TEXT NOT NULL,
+    "CreationTimestamp" BIGINT NOT NULL,
+    "SnapshotTimestamp" BIGINT NOT NULL,
+    "Name" TEXT,
+    "Namespace" TEXT,
+    "InstanceType" TEXT,
+    "PoolName" TEXT,
+    "Region" TEXT,
+    "Zone" TEXT,
+    "Labels" TEXT,
+    "Capacity" TEXT,
+    "DeletionTimestamp" BIGINT,
+    "Hash" TEXT)`
+const PostgresInsertMCCInfo = `INSERT INTO mcc_info(
+    "ClusterID",
+    "CreationTimestamp",
+    "SnapshotTimestamp",
+    "Name",
+    "Namespace",
+    "InstanceType",
+    "Provider",
+    "PoolName",
+    "Region",
+    "Zone",
+    "Labels",
+    "Capacity",
+    "Hash"
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING "RowID"`
+
+// PostgresAddMCCInfoProviderColumn adds the Provider column, which is missing in PostgresCreateMCCInfoTable, to the
+// mcc_info table.
+const PostgresAddMCCInfoProviderColumn = `ALTER TABLE mcc_info ADD COLUMN IF NOT EXISTS "Provider" TEXT NOT NULL DEFAULT ''`
+const PostgresSelectLatestMCCInfoBefore = `SELECT DISTINCT ON ("Name") * FROM mcc_info
+    WHERE "ClusterID" = $1 AND "SnapshotTimestamp" <= $2 ORDER BY "Name", "SnapshotTimestamp" DESC, "RowID" DESC`
+const PostgresUpdateMCCInfoDeletionTimestamp = `UPDATE mcc_info SET "DeletionTimestamp" = $2 WHERE "ClusterID" = $1 AND "Name" = $3`
+const PostgresSelectMCCInfoHash = `SELECT "Hash" FROM mcc_info WHERE "ClusterID" = $1 AND "Name" = $2 ORDER BY "RowID" DESC LIMIT 1`
+const PostgresSelectLatestMCCInfo = `SELECT * FROM mcc_info WHERE "ClusterID" = $1 AND "Name" = $2 ORDER BY "RowID" DESC LIMIT 1`
+
+const PostgresCreateNodeInfoTable = `CREATE TABLE IF NOT EXISTS node_info(
+    "RowID" BIGSERIAL PRIMARY KEY,
+    "ClusterID" TEXT NOT NULL,
+    "CreationTimestamp" BIGINT NOT NULL,
+    "SnapshotTimestamp" BIGINT NOT NULL,
+    "Name" TEXT,
+    "Namespace" TEXT,
+    "ProviderID" TEXT,
+    "AllocatableVolumes" INT,
+    "Labels" TEXT,
+    "Taints" TEXT,
+    "Allocatable" TEXT,
+    "Capacity" TEXT,
+    "DeletionTimestamp" BIGINT,
+    "Hash" TEXT,
+    "InferredAtRestart" INT NOT NULL DEFAULT 0,
+    "DeletionInferredAtRestart" INT NOT NULL DEFAULT 0)`
+const PostgresInsertNodeInfo = `INSERT INTO node_info(
+    "ClusterID",
+    "CreationTimestamp",
+    "SnapshotTimestamp",
+    "Name",
+    "Namespace",
+    "ProviderID",
+    "AllocatableVolumes",
+    "Labels",
+    "Taints",
+    "Allocatable",
+    "Capacity",
+    "Hash"
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING "RowID"`
+
+// PostgresInsertNodeInfoIfNew casts the selected parameters since their types cannot be inferred from the columns.
+const PostgresInsertNodeInfoIfNew = `INSERT INTO node_info(
+    "ClusterID",
+    "CreationTimestamp",
+    "SnapshotTimestamp",
+    "Name",
+    "Namespace",
+    "ProviderID",
+    "AllocatableVolumes",
+    "Labels",
+    "Taints",
+    "Allocatable",
+    "Capacity",
+    "Hash",
+    "InferredAtRestart"
+) SELECT $1, $2::BIGINT, $3::BIGINT, $4::TEXT, $5::TEXT, $6::TEXT, $7::INT, $8::TEXT, $9::TEXT, $10::TEXT, $11::TEXT, $12::TEXT,
+    $13::INT
+    WHERE NOT EXISTS (SELECT 1 FROM node_info WHERE "ClusterID" = $1 AND "Name" = $14 AND "Hash" = $15)
+    RETURNING "RowID"`
+const PostgresSelectNodeInfoBefore = `SELECT * FROM node_info WHERE "ClusterID" = $1 AND "CreationTimestamp" < $2
+    ORDER BY "CreationTimestamp" DESC, "RowID"`
+const PostgresSelectNodeCountWithNameAndHash = `SELECT COUNT(*) FROM node_info WHERE "ClusterID" = $1 AND "Name" = $2 AND "Hash" = $3`
+const PostgresUpdateNodeInfoDeletionTimestamp = `UPDATE node_info SET "DeletionTimestamp" = $2 WHERE "ClusterID" = $1 AND "Name" = $3`
+const PostgresSelectLatestNodesBeforeAndNotDeleted = `SELECT DISTINCT ON ("Name") * FROM node_info
+    WHERE "ClusterID" = $1 AND "CreationTimestamp" <= $2 AND "DeletionTimestamp" = 0 ORDER BY "Name", "RowID" DESC`
+const PostgresSelectNodeDeletionTimestamps = `SELECT "Name", max("DeletionTimestamp") AS "DeletionTimestamp" FROM node_info
+    WHERE "ClusterID" = $1 AND "DeletionTimestamp" IS NOT NULL GROUP BY "Name"`
+
+const PostgresCreatePodInfoTable = `CREATE TABLE IF NOT EXISTS pod_info(
+    "RowID" BIGSERIAL PRIMARY KEY,
+    "ClusterID" TEXT NOT NULL,
+    "CreationTimestamp" BIGINT NOT NULL,
+    "SnapshotTimestamp" BIGINT NOT NULL,
+    "Name" TEXT,
+    "Namespace" TEXT,
+    "UID" TEXT NOT NULL,
+    "NodeName" TEXT,
+    "NominatedNodeName" TEXT,
+    "Labels" TEXT,
+    "Requests" TEXT,
+    "SpecHash" TEXT,
+    "SpecNodeName" TEXT NOT NULL DEFAULT '',
+    "SpecTokenVolumeName" TEXT NOT NULL DEFAULT '',
+    "ScheduleStatus" INT,
+    "DeletionTimestamp" BIGINT,
+    "Hash" TEXT,
+    "InferredAtRestart" INT NOT NULL DEFAULT 0,
+    "DeletionInferredAtRestart" INT NOT NULL DEFAULT 0)`
+const PostgresInsertPodInfo = `INSERT INTO pod_info(
+    "ClusterID",
+    "CreationTimestamp",
+    "SnapshotTimestamp",
+    "Name",
+    "Namespace",
+    "UID",
+    "NodeName",
+    "NominatedNodeName",
+    "Labels",
+    "Requests",
+    "SpecHash",
+    "SpecNodeName",
+    "SpecTokenVolumeName",
+    "ScheduleStatus",
+    "Hash"
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING "RowID"`
+
+// PostgresInsertPodInfoIfNew casts the selected parameters since their types cannot be inferred from the columns.
+const PostgresInsertPodInfoIfNew = `INSERT INTO pod_info(
+    "ClusterID",
+    "CreationTimestamp",
+    "SnapshotTimestamp",
+    "Name",
+    "Namespace",
+    "UID",
+    "NodeName",
+    "NominatedNodeName",
+    "Labels",
+    "Requests",
+    "SpecHash",
+    "SpecNodeName",
+    "SpecTokenVolumeName",
+    "ScheduleStatus",
+    "Hash",
+    "InferredAtRestart"
+) SELECT $1, $2::BIGINT, $3::BIGINT, $4::TEXT, $5::TEXT, $6::TEXT, $7::TEXT, $8::TEXT, $9::TEXT, $10::TEXT, $11::TEXT,
+    $12::TEXT, $13::TEXT, $14::INT, $15::TEXT, $16::INT
+    WHERE NOT EXISTS (SELECT 1 FROM pod_info WHERE "ClusterID" = $1 AND "UID" = $17 AND "Hash" = $18)
+    RETURNING "RowID"`
+const PostgresUpdatePodDeletionTimestamp = `UPDATE pod_info SET "DeletionTimestamp" = $2 WHERE "ClusterID" = $1 AND "UID" = $3`
+const PostgresSelectLatestPodInfoWithName = `SELECT pod_info.*, pod_spec."Spec" FROM pod_info
+    JOIN pod_spec USING ("ClusterID", "SpecHash") WHERE "ClusterID" = $1 AND "Name" = $2
+    ORDER BY "CreationTimestamp" DESC, "RowID" DESC LIMIT 1`
+const PostgresSelectPodCountWithUIDAndHash = `SELECT COUNT(*) FROM pod_info WHERE "ClusterID" = $1 AND "UID" = $2 AND "Hash" = $3`
+const PostgresSelectUnscheduledPodsBeforeSnapshotTimestamp = `SELECT DISTINCT ON ("Name") pod_info.*, pod_spec."Spec"
+    FROM pod_info JOIN pod_spec USING ("ClusterID", "SpecHash")
+    WHERE "ClusterID" = $1 AND "ScheduleStatus" = 0 AND "SnapshotTimestamp" <= $2
+    AND ("DeletionTimestamp" IS NULL OR "DeletionTimestamp" >= $3)
+    ORDER BY "Name", "SnapshotTimestamp" DESC, "RowID" DESC`
+const PostgresSelectLatestScheduledPodsBeforeSnapshotTimestamp = `SELECT DISTINCT ON ("Name") pod_info.*, pod_spec."Spec"
+    FROM pod_info JOIN pod_spec USING ("ClusterID", "SpecHash")
+    WHERE "ClusterID" = $1 AND "ScheduleStatus" = 1 AND "SnapshotTimestamp" <= $2
+    AND ("DeletionTimestamp" IS NULL OR "DeletionTimestamp" >= $3)
+    ORDER BY "Name", "SnapshotTimestamp" DESC, "RowID" DESC`
+const PostgresSelectLatestPodsBeforeSnapshotTimestamp = `SELECT DISTINCT ON ("UID") pod_info.*, pod_spec."Spec"
+    FROM pod_info JOIN pod_spec USING ("ClusterID", "SpecHash")
+    WHERE "ClusterID" = $1 AND "SnapshotTimestamp" <= $2 AND ("DeletionTimestamp" IS NULL OR "DeletionTimestamp" >= $3)
+    ORDER BY "UID", "SnapshotTimestamp" DESC, "RowID" DESC`
+
+const PostgresCreatePodSpecTable = `CREATE TABLE IF NOT EXISTS pod_spec(
+    "ClusterID" TEXT NOT NULL,
+    "SpecHash" TEXT NOT NULL,
+    "Spec" TEXT NOT NULL,
+    PRIMARY KEY ("ClusterID", "SpecHash"))`
+const PostgresInsertPodSpec = `INSERT INTO pod_spec("ClusterID", "SpecHash", "Spec") VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
+const PostgresSelectPodSpecCount = `SELECT COUNT(*) FROM pod_spec`
+
+// The queries of the migration of pod_info rows storing their Spec to rows referencing a pod_spec. The migration moves
+// the rows of all clusters.
+const PostgresSelectPodInfoSpecColumnCount = `SELECT COUNT(*) FROM information_schema.columns
+    WHERE table_schema = current_schema() AND table_name = 'pod_info' AND column_name = 'Spec'`
+const PostgresSelectPodInfoSpecsAfterRowID = `SELECT "RowID", "ClusterID", coalesce("Spec", '') FROM pod_info
+    WHERE "RowID" > $1 ORDER BY "RowID" LIMIT $2`
+const PostgresUpdatePodInfoSpecHash = `UPDATE pod_info SET "SpecHash" = $1, "SpecNodeName" = $2, "SpecTokenVolumeName" = $3 WHERE "RowID" = $4`
+
+const PostgresCreatePDBInfoTable = `CREATE TABLE IF NOT EXISTS pdb_info(
+    id BIGSERIAL PRIMARY KEY,
+    "ClusterID" TEXT NOT NULL,
+    uid TEXT,
+    name TEXT,
+    generation BIGINT,
+    creationTimestamp TIMESTAMPTZ,
+    deletionTimestamp TIMESTAMPTZ,
+    minAvailable TEXT,
+    maxUnAvailable TEXT,
+    spec TEXT)`
+const PostgresInsertPDBInfo = `INSERT INTO pdb_info("ClusterID", uid, name, generation, creationTimestamp, minAvailable, maxUnAvailable, spec)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+const PostgresUpdatePDBInfoDeletionTimestamp = `UPDATE pdb_info SET deletionTimestamp = $2 WHERE "ClusterID" = $1 AND uid = $3`
+
+const PostgresCreatePriorityClassInfoTable = `CREATE TABLE IF NOT EXISTS pc_info(
+    "RowID" BIGSERIAL PRIMARY KEY,
+    "ClusterID" TEXT NOT NULL,
+    "CreationTimestamp" BIGINT NOT NULL,
+    "SnapshotTimestamp" BIGINT NOT NULL,
+    "Name" TEXT,
+    "UID" TEXT,
+    "Value" INT NOT NULL,
+    "GlobalDefault" BOOLEAN,
+    "PreemptionPolicy" TEXT,
+    "Description" TEXT,
+    "Labels" TEXT,
+    "DeletionTimestamp" BIGINT,
+    "Hash" TEXT)`
+const PostgresInsertPriorityClassInfo = `INSERT INTO pc_info(
+    "ClusterID",
+    "CreationTimestamp",
+    "SnapshotTimestamp",
+    "Name",
+    "UID",
+    "Value",
+    "GlobalDefault",
+    "PreemptionPolicy",
+    "Description",
+    "Labels",
+    "Hash"
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING "RowID"`
+const PostgresSelectLatestPriorityClassInfoBeforeSnapshotTimestamp = `SELECT DISTINCT ON ("Name") * FROM pc_info
+    WHERE "ClusterID" = $1 AND "SnapshotTimestamp" <= $2 AND ("DeletionTimestamp" IS NULL OR "DeletionTimestamp" >= $3)
+    ORDER BY "Name", "SnapshotTimestamp" DESC, "RowID" DESC`
+const PostgresSelectPriorityClassInfoCountWithUIDAndHash = `SELECT COUNT(*) FROM pc_info WHERE "ClusterID" = $1 AND "UID" = $2 AND "Hash" = $3`
+
+const PostgresCreateEventInfoTable = `CREATE TABLE IF NOT EXISTS event_info(
+    "ClusterID" TEXT NOT NULL,
+    "UID" VARCHAR(128) NOT NULL,
+    "EventTime" TIMESTAMPTZ NOT NULL,
+    "ReportingController" VARCHAR(256),
+    "Reason" VARCHAR(128),
+    "Message" TEXT,
+    "InvolvedObjectKind" VARCHAR(128),
+    "InvolvedObjectName" VARCHAR(128),
+    "InvolvedObjectNamespace" VARCHAR(128),
+    "InvolvedObjectUID" VARCHAR(128),
+    PRIMARY KEY ("ClusterID", "UID"))`
+const PostgresInsertEvent = `INSERT INTO event_info(
+    "ClusterID",
+    "UID",
+    "EventTime",
+    "ReportingController",
+    "Reason",
+    "Message",
+    "InvolvedObjectKind",
+    "InvolvedObjectName",
+    "InvolvedObjectNamespace",
+    "InvolvedObjectUID"
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT ("ClusterID", "UID") DO NOTHING`
+const PostgresSelectEventInfoWithUID = `SELECT * FROM event_info WHERE "ClusterID" = $1 AND "UID" = $2`
+const PostgresSelectAllEventInfos = `SELECT * FROM event_info WHERE "ClusterID" = $1 ORDER BY "EventTime"`
+
+const PostgresCreateCASettingsInfoTable = `CREATE TABLE IF NOT EXISTS ca_settings_info(
+    "RowID" BIGSERIAL PRIMARY KEY,
+    "ClusterID" TEXT NOT NULL,
+    "SnapshotTimestamp" BIGINT NOT NULL,
+    "Expander" TEXT,
+    "ScanInterval" BIGINT,
+    "MaxNodeProvisionTime" BIGINT,
+    "MaxGracefulTerminationSeconds" INT,
+    "NewPodScaleUpDelay" BIGINT,
+    "MaxEmptyBulkDelete" INT,
+    "IgnoreDaemonSetUtilization" BOOLEAN,
+    "MaxNodesTotal" INT,
+    "Priorities" TEXT,
+    "Hash" TEXT)`
+const PostgresSelectCADeploymentByHash = `SELECT * FROM ca_settings_info WHERE "ClusterID" = $1 AND "Hash" = $2`
+const PostgresSelectLatestCASettingsInfo = `SELECT * FROM ca_settings_info WHERE "ClusterID" = $1 ORDER BY "RowID" DESC LIMIT 1`
+const PostgresInsertCASettingsInfo = `INSERT INTO ca_settings_info(
+    "ClusterID",
+    "SnapshotTimestamp",
+    "Expander",
+    "ScanInterval",
+    "MaxNodeProvisionTime",
+    "MaxGracefulTerminationSeconds",
+    "NewPodScaleUpDelay",
+    "MaxEmptyBulkDelete",
+    "IgnoreDaemonSetUtilization",
+    "MaxNodesTotal",
+    "Priorities",
+    "Hash"
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING "RowID"`
+const PostgresSelectLatestCASettingsBefore = `SELECT * FROM ca_settings_info WHERE "ClusterID" = $1 AND "SnapshotTimestamp" <= $2
+    ORDER BY "SnapshotTimestamp" DESC LIMIT 1`
+const PostgresSwapCASettingsInfoDurationColumns = `UPDATE ca_settings_info
+    SET "ScanInterval" = "MaxNodeProvisionTime", "MaxNodeProvisionTime" = "ScanInterval"`
+
+const PostgresCreateCAArgsInfoTable = `CREATE TABLE IF NOT EXISTS ca_args_info(
+    "RowID" BIGSERIAL PRIMARY KEY,
+    "ClusterID" TEXT NOT NULL,
+    "SnapshotTimestamp" BIGINT NOT NULL,
+    "Args" TEXT,
+    "Hash" TEXT)`
+const PostgresInsertCAArgsInfo = `INSERT INTO ca_args_info("ClusterID", "SnapshotTimestamp", "Args", "Hash")
+    VALUES ($1, $2, $3, $4) RETURNING "RowID"`
+const PostgresSelectLatestCAArgsInfo = `SELECT * FROM ca_args_info WHERE "ClusterID" = $1 ORDER BY "RowID" DESC LIMIT 1`
+const PostgresSelectLatestCAArgsInfoBefore = `SELECT * FROM ca_args_info WHERE "ClusterID" = $1 AND "SnapshotTimestamp" <= $2
+    ORDER BY "SnapshotTimestamp" DESC LIMIT 1`
+
+const PostgresCreateCANodeGroupStatusInfoTable = `CREATE TABLE IF NOT EXISTS ca_nodegroup_status_info(
+    "RowID" BIGSERIAL PRIMARY KEY,
+    "ClusterID" TEXT NOT NULL,
+    "SnapshotTimestamp" BIGINT NOT NULL,
+    "Name" TEXT,
+    "Health" TEXT,
+    "Ready" INT,
+    "Unready" INT,
+    "NotStarted" INT,
+    "LongNotStarted" INT,
+    "Registered" INT,
+    "LongUnregistered" INT,
+    "CloudProviderTarget" INT,
+    "MinSize" INT,
+    "MaxSize" INT,
+    "ScaleUp" TEXT,
+    "Backoff" BOOLEAN,
+    "ScaleDown" TEXT,
+    "ScaleDownCandidates" INT,
+    "Hash" TEXT)`
+const PostgresInsertCANodeGroupStatusInfo = `INSERT INTO ca_nodegroup_status_info(
+    "ClusterID",
+    "SnapshotTimestamp",
+    "Name",
+    "Health",
+    "Ready",
+    "Unready",
+    "NotStarted",
+    "LongNotStarted",
+    "Registered",
+    "LongUnregistered",
+    "CloudProviderTarget",
+    "MinSize",
+    "MaxSize",
+    "ScaleUp",
+    "Backoff",
+    "ScaleDown",
+    "ScaleDownCandidates",
+    "Hash"
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18) RETURNING "RowID"`
+const PostgresSelectLatestCANodeGroupStatusInfoHashes = `SELECT DISTINCT ON ("Name") "RowID", "Name", "SnapshotTimestamp", "Hash"
+    FROM ca_nodegroup_status_info WHERE "ClusterID" = $1 ORDER BY "Name", "RowID" DESC`
+const PostgresSelectLatestCANodeGroupStatusInfosBefore = `SELECT DISTINCT ON ("Name") * FROM ca_nodegroup_status_info
+    WHERE "ClusterID" = $1 AND "SnapshotTimestamp" <= $2 ORDER BY "Name", "RowID" DESC`
+
+const PostgresCreateNodeScaleDownActivityInfoTable = `CREATE TABLE IF NOT EXISTS node_scale_down_activity_info(
+    "RowID" BIGSERIAL PRIMARY KEY,
+    "ClusterID" TEXT NOT NULL,
+    "ActivityTime" BIGINT NOT NULL,
+    "NodeName" TEXT,
+    "Activity" TEXT,
+    "Detail" TEXT)`
+const PostgresInsertNodeScaleDownActivityInfo = `INSERT INTO node_scale_down_activity_info("ClusterID", "ActivityTime", "NodeName", "Activity", "Detail")
+    VALUES ($1, $2, $3, $4, $5) RETURNING "RowID"`
+const PostgresSelectNodeScaleDownActivityInfosBetween = `SELECT * FROM node_scale_down_activity_info
+    WHERE "ClusterID" = $1 AND "ActivityTime" >= $2 AND "ActivityTime" <= $3 ORDER BY "ActivityTime", "RowID"`
+
+const PostgresCreateFailedSchedulingReasonInfoTable = `CREATE TABLE IF NOT EXISTS failed_scheduling_reason_info(
+    "RowID" BIGSERIAL PRIMARY KEY,
+    "ClusterID" TEXT NOT NULL,
+    "EventUID" TEXT NOT NULL,
+    "EventTime" BIGINT NOT NULL,
+    "EventCount" INT NOT NULL DEFAULT 1,
+    "PodUID" TEXT,
+    "PodName" TEXT,
+    "PodNamespace" TEXT,
+    "Zone" TEXT NOT NULL DEFAULT '',
+    "TotalNodes" INT,
+    "Reason" TEXT NOT NULL,
+    "NodeCount" INT,
+    CONSTRAINT failed_scheduling_reason_info_occurrence UNIQUE ("ClusterID", "EventUID", "EventCount", "Reason"))`
+
+// PostgresAddFailedSchedulingReasonInfoEventCountColumn, PostgresAddFailedSchedulingReasonInfoZoneColumn,
+// PostgresDropFailedSchedulingReasonInfoEventUniqueConstraint and PostgresCreateFailedSchedulingReasonInfoOccurrenceIndex
+// migrate a failed_scheduling_reason_info table, which only held the first occurrence of an event, to hold every
+// occurrence. They do nothing on a table created with PostgresCreateFailedSchedulingReasonInfoTable.
+const PostgresAddFailedSchedulingReasonInfoEventCountColumn = `ALTER TABLE failed_scheduling_reason_info ADD COLUMN IF NOT EXISTS "EventCount" INT NOT NULL DEFAULT 1`
+const PostgresAddFailedSchedulingReasonInfoZoneColumn = `ALTER TABLE failed_scheduling_reason_info ADD COLUMN IF NOT EXISTS "Zone" TEXT NOT NULL DEFAULT ''`
+const PostgresDropFailedSchedulingReasonInfoEventUniqueConstraint = `ALTER TABLE failed_scheduling_reason_info
+    DROP CONSTRAINT IF EXISTS "failed_scheduling_reason_info_ClusterID_EventUID_Reason_key"`
+const PostgresCreateFailedSchedulingReasonInfoOccurrenceIndex = `CREATE UNIQUE INDEX IF NOT EXISTS failed_scheduling_reason_info_occurrence
+    ON failed_scheduling_reason_info("ClusterID", "EventUID", "EventCount", "Reason")`
+const PostgresInsertFailedSchedulingReasonInfo = `INSERT INTO failed_scheduling_reason_info(
+    "ClusterID",
+    "EventUID",
+    "EventTime",
+    "EventCount",
+    "PodUID",
+    "PodName",
+    "PodNamespace",
+    "Zone",
+    "TotalNodes",
+    "Reason",
+    "NodeCount"
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT ("ClusterID", "EventUID", "EventCount", "Reason") DO NOTHING RETURNING "RowID"`
+const PostgresSelectFailedSchedulingReasonInfosBetween = `SELECT * FROM failed_scheduling_reason_info
+    WHERE "ClusterID" = $1 AND "EventTime" >= $2 AND "EventTime" <= $3 ORDER BY "EventTime", "RowID"`
+const PostgresSelectFailedSchedulingReasonInfosForPod = `SELECT * FROM failed_scheduling_reason_info
+    WHERE "ClusterID" = $1 AND "PodUID" = $2 ORDER BY "EventTime", "RowID"`
+const PostgresSelectFailedSchedulingReasonCountsBetween = `SELECT "Reason", COUNT(DISTINCT "PodUID") AS "PodCount", SUM("NodeCount") AS "NodeCount"
+    FROM failed_scheduling_reason_info WHERE "ClusterID" = $1 AND "EventTime" >= $2 AND "EventTime" <= $3
+    AND ($4 = '' OR "Zone" = $5)
+    GROUP BY "Reason" ORDER BY "PodCount" DESC, "Reason"`
+
+const PostgresCreateCAScalingEventInfoTable = `CREATE TABLE IF NOT EXISTS ca_scaling_event_info(
+    "RowID" BIGSERIAL PRIMARY KEY,
+    "ClusterID" TEXT NOT NULL,
+    "EventUID" TEXT NOT NULL,
+    "EventTime" BIGINT NOT NULL,
+    "Reason" TEXT,
+    "PodUID" TEXT,
+    "PodName" TEXT,
+    "PodNamespace" TEXT,
+    "NodeGroupName" TEXT,
+    "CurrentSize" INT,
+    "TargetSize" INT,
+    "MaxSize" INT,
+    "NodeName" TEXT,
+    "NotTriggerReason" TEXT,
+    "NodeGroupCount" INT,
+    UNIQUE ("ClusterID", "EventUID", "NodeGroupName", "NotTriggerReason"))`
+const PostgresInsertCAScalingEventInfo = `INSERT INTO ca_scaling_event_info(
+    "ClusterID",
+    "EventUID",
+    "EventTime",
+    "Reason",
+    "PodUID",
+    "PodName",
+    "PodNamespace",
+    "NodeGroupName",
+    "CurrentSize",
+    "TargetSize",
+    "MaxSize",
+    "NodeName",
+    "NotTriggerReason",
+    "NodeGroupCount"
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+    ON CONFLICT ("ClusterID", "EventUID", "NodeGroupName", "NotTriggerReason") DO NOTHING RETURNING "RowID"`
+const PostgresSelectCAScalingEventInfosBetween = `SELECT * FROM ca_scaling_event_info
+    WHERE "ClusterID" = $1 AND "EventTime" >= $2 AND "EventTime" <= $3 ORDER BY "EventTime", "RowID"`
+const PostgresSelectCAScalingEventInfosForPod = `SELECT * FROM ca_scaling_event_info
+    WHERE "ClusterID" = $1 AND "PodUID" = $2 ORDER BY "EventTime", "RowID"`
+const PostgresSelectCAScalingEventInfosForNodeGroup = `SELECT * FROM ca_scaling_event_info
+    WHERE "ClusterID" = $1 AND "NodeGroupName" = $2 ORDER BY "EventTime", "RowID"`
+
+const PostgresCreateCheckpointInfoTable = `CREATE TABLE IF NOT EXISTS checkpoint_info(
+    "CheckpointID" BIGSERIAL PRIMARY KEY,
+    "ClusterID" TEXT NOT NULL,
+    "SnapshotTimestamp" BIGINT NOT NULL,
+    "RowCount" INT NOT NULL)`
+const PostgresCreateCheckpointTableInfoTable = `CREATE TABLE IF NOT EXISTS checkpoint_table_info(
+    "ClusterID" TEXT NOT NULL,
+    "CheckpointID" BIGINT NOT NULL,
+    "TableName" TEXT NOT NULL,
+    "HighWaterRowID" BIGINT NOT NULL,
+    "RowIDs" TEXT NOT NULL,
+    PRIMARY KEY ("CheckpointID", "TableName"))`
+const PostgresInsertCheckpointInfo = `INSERT INTO checkpoint_info("ClusterID", "SnapshotTimestamp", "RowCount")
+    VALUES ($1, $2, $3) RETURNING "CheckpointID"`
+const PostgresInsertCheckpointTableInfo = `INSERT INTO checkpoint_table_info("ClusterID", "CheckpointID", "TableName", "HighWaterRowID", "RowIDs")
+    VALUES ($1, $2, $3, $4, $5)`
+const PostgresSelectCheckpointInfos = `SELECT "CheckpointID", "SnapshotTimestamp", "RowCount" FROM checkpoint_info
+    WHERE "ClusterID" = $1 ORDER BY "SnapshotTimestamp", "CheckpointID"`
+const PostgresDeleteCheckpointTableInfosBeyondRetention = `DELETE FROM checkpoint_table_info
+    WHERE "ClusterID" = $1 AND "CheckpointID" NOT IN (SELECT "CheckpointID" FROM checkpoint_info WHERE "ClusterID" = $1
+    ORDER BY "SnapshotTimestamp" DESC, "CheckpointID" DESC LIMIT $2)`
+const PostgresDeleteCheckpointInfosBeyondRetention = `DELETE FROM checkpoint_info
+    WHERE "ClusterID" = $1 AND "CheckpointID" NOT IN (SELECT "CheckpointID" FROM checkpoint_info WHERE "ClusterID" = $1
+    ORDER BY "SnapshotTimestamp" DESC, "CheckpointID" DESC LIMIT $2)`
+const PostgresSelectLatestCheckpointTableInfoBefore = `SELECT t."CheckpointID", t."TableName", t."HighWaterRowID", t."RowIDs"
+    FROM checkpoint_table_info AS t JOIN checkpoint_info AS c ON c."CheckpointID" = t."CheckpointID"
+    WHERE t."ClusterID" = $1 AND t."TableName" = $2 AND c."SnapshotTimestamp" <= $3
+    ORDER BY c."SnapshotTimestamp" DESC, c."CheckpointID" DESC LIMIT 1`
+const PostgresSelectCheckpointHighWaterRowIDs = `SELECT
+    (SELECT coalesce(max("RowID"), 0) FROM pod_info WHERE "ClusterID" = $1),
+    (SELECT coalesce(max("RowID"), 0) FROM mcd_info WHERE "ClusterID" = $1),
+    (SELECT coalesce(max("RowID"), 0) FROM mcc_info WHERE "ClusterID" = $1),
+    (SELECT coalesce(max("RowID"), 0) FROM worker_pool_info WHERE "ClusterID" = $1),
+    (SELECT coalesce(max("RowID"), 0) FROM pc_info WHERE "ClusterID" = $1),
+    (SELECT coalesce(max("RowID"), 0) FROM ca_nodegroup_status_info WHERE "ClusterID" = $1)`
+const PostgresSelectPodCheckpointRows = `SELECT "RowID", "SnapshotTimestamp" FROM (
+    SELECT DISTINCT ON ("UID") "RowID", "SnapshotTimestamp", "DeletionTimestamp" FROM pod_info
+    WHERE "ClusterID" = $1 AND "RowID" <= $2 ORDER BY "UID", "SnapshotTimestamp" DESC, "RowID" DESC) AS latest
+    WHERE "DeletionTimestamp" IS NULL OR "DeletionTimestamp" >= $3`
+const PostgresSelectMCDCheckpointRows = `SELECT DISTINCT ON ("Name") "RowID", "SnapshotTimestamp" FROM mcd_info
+    WHERE "ClusterID" = $1 AND "RowID" <= $2 ORDER BY "Name", "SnapshotTimestamp" DESC, "RowID" DESC`
+const PostgresSelectMCCCheckpointRows = `SELECT DISTINCT ON ("Name") "RowID", "SnapshotTimestamp" FROM mcc_info
+    WHERE "ClusterID" = $1 AND "RowID" <= $2 ORDER BY "Name", "SnapshotTimestamp" DESC, "RowID" DESC`
+const PostgresSelectWorkerPoolCheckpointRows = `SELECT DISTINCT ON ("Name") "RowID", "SnapshotTimestamp" FROM worker_pool_info
+    WHERE "ClusterID" = $1 AND "RowID" <= $2 ORDER BY "Name", "SnapshotTimestamp" DESC, "RowID" DESC`
+const PostgresSelectPriorityClassCheckpointRows = `SELECT "RowID", "SnapshotTimestamp" FROM (
+    SELECT DISTINCT ON ("Name") "RowID", "SnapshotTimestamp", "DeletionTimestamp" FROM pc_info
+    WHERE "ClusterID" = $1 AND "RowID" <= $2 ORDER BY "Name", "SnapshotTimestamp" DESC, "RowID" DESC) AS latest
+    WHERE "DeletionTimestamp" IS NULL OR "DeletionTimestamp" >= $3`
+const PostgresSelectCANodeGroupStatusCheckpointRows = `SELECT DISTINCT ON ("Name") "RowID", "SnapshotTimestamp"
+    FROM ca_nodegroup_status_info WHERE "ClusterID" = $1 AND "RowID" <= $2 ORDER BY "Name", "RowID" DESC`
+const PostgresSelectLatestPodsBeforeSnapshotTimestampFromCheckpoint = `SELECT latest.*, pod_spec."Spec" FROM (
+    SELECT DISTINCT ON ("UID") * FROM pod_info WHERE "ClusterID" = $1
+    AND ("RowID" IN (SELECT jsonb_array_elements_text($2::jsonb)::BIGINT) OR ("RowID" > $3 AND "SnapshotTimestamp" <= $4))
+    ORDER BY "UID", "SnapshotTimestamp" DESC, "RowID" DESC) AS latest
+    JOIN pod_spec USING ("ClusterID", "SpecHash")
+    WHERE "DeletionTimestamp" IS NULL OR "DeletionTimestamp" >= $5 ORDER BY "UID"`
+const PostgresSelectLatestMCDInfoBeforeFromCheckpoint = `SELECT DISTINCT ON ("Name") * FROM mcd_info WHERE "ClusterID" = $1
+    AND ("RowID" IN (SELECT jsonb_array_elements_text($2::jsonb)::BIGINT) OR ("RowID" > $3 AND "SnapshotTimestamp" <= $4))
+    ORDER BY "Name", "SnapshotTimestamp" DESC, "RowID" DESC`
+const PostgresSelectLatestMCCInfoBeforeFromCheckpoint = `SELECT DISTINCT ON ("Name") * FROM mcc_info WHERE "ClusterID" = $1
+    AND ("RowID" IN (SELECT jsonb_array_elements_text($2::jsonb)::BIGINT) OR ("RowID" > $3 AND "SnapshotTimestamp" <= $4))
+    ORDER BY "Name", "SnapshotTimestamp" DESC, "RowID" DESC`
+const PostgresSelectWorkerPoolInfoBeforeFromCheckpoint = `SELECT DISTINCT ON ("Name") * FROM worker_pool_info WHERE "ClusterID" = $1
+    AND ("RowID" IN (SELECT jsonb_array_elements_text($2::jsonb)::BIGINT) OR ("RowID" > $3 AND "SnapshotTimestamp" <= $4))
+    ORDER BY "Name", "SnapshotTimestamp" DESC, "RowID" DESC`
+const PostgresSelectLatestPriorityClassInfoBeforeSnapshotTimestampFromCheckpoint = `SELECT * FROM (
+    SELECT DISTINCT ON ("Name") * FROM pc_info WHERE "ClusterID" = $1
+    AND ("RowID" IN (SELECT jsonb_array_elements_text($2::jsonb)::BIGINT) OR ("RowID" > $3 AND "SnapshotTimestamp" <= $4))
+    ORDER BY "Name", "SnapshotTimestamp" DESC, "RowID" DESC) AS latest
+    WHERE "DeletionTimestamp" IS NULL OR "DeletionTimestamp" >= $5 ORDER BY "Name"`
+const PostgresSelectLatestCANodeGroupStatusInfosBeforeFromCheckpoint = `SELECT DISTINCT ON ("Name") * FROM ca_nodegroup_status_info
+    WHERE "ClusterID" = $1
+    AND ("RowID" IN (SELECT jsonb_array_elements_text($2::jsonb)::BIGINT) OR ("RowID" > $3 AND "SnapshotTimestamp" <= $4))
+    ORDER BY "Name", "RowID" DESC`
+
+// PostgreSQL variants of the indexes in sql.go, led by the ClusterID column every query filters on.
+const PostgresCreatePodInfoUIDHashIndex = `CREATE INDEX IF NOT EXISTS pod_info_uid_hash ON pod_info("ClusterID", "UID", "Hash")`
+const PostgresCreatePodInfoUIDSnapshotIndex = `CREATE INDEX IF NOT EXISTS pod_info_uid_snapshot ON pod_info("ClusterID", "UID", "SnapshotTimestamp")`
+const PostgresCreatePodInfoNameSnapshotIndex = `CREATE INDEX IF NOT EXISTS pod_info_name_snapshot ON pod_info("ClusterID", "Name", "SnapshotTimestamp")`
+const PostgresCreatePodInfoDeletionIndex = `CREATE INDEX IF NOT EXISTS pod_info_deletion ON pod_info("ClusterID", "DeletionTimestamp")`
+const PostgresCreateNodeInfoNameHashIndex = `CREATE INDEX IF NOT EXISTS node_info_name_hash ON node_info("ClusterID", "Name", "Hash")`
+const PostgresCreateNodeInfoCreationIndex = `CREATE INDEX IF NOT EXISTS node_info_creation ON node_info("ClusterID", "CreationTimestamp")`
+const PostgresCreateNodeInfoDeletionIndex = `CREATE INDEX IF NOT EXISTS node_info_deletion ON node_info("ClusterID", "DeletionTimestamp")`
+const PostgresCreateMCDInfoNameSnapshotIndex = `CREATE INDEX IF NOT EXISTS mcd_info_name_snapshot ON mcd_info("ClusterID", "Name", "SnapshotTimestamp")`
+const PostgresCreateMCDInfoDeletionIndex = `CREATE INDEX IF NOT EXISTS mcd_info_deletion ON mcd_info("ClusterID", "DeletionTimestamp")`
+const PostgresCreateMCCInfoNameSnapshotIndex = `CREATE INDEX IF NOT EXISTS mcc_info_name_snapshot ON mcc_info("ClusterID", "Name", "SnapshotTimestamp")`
+const PostgresCreateWorkerPoolInfoNameSnapshotIndex = `CREATE INDEX IF NOT EXISTS worker_pool_info_name_snapshot ON worker_pool_info("ClusterID", "Name", "SnapshotTimestamp")`
+const PostgresCreatePriorityClassInfoNameSnapshotIndex = `CREATE INDEX IF NOT EXISTS pc_info_name_snapshot ON pc_info("ClusterID", "Name", "SnapshotTimestamp")`
+const PostgresCreatePriorityClassInfoUIDHashIndex = `CREATE INDEX IF NOT EXISTS pc_info_uid_hash ON pc_info("ClusterID", "UID", "Hash")`
+const PostgresCreateCANodeGroupStatusInfoNameSnapshotIndex = `CREATE INDEX IF NOT EXISTS ca_nodegroup_status_info_name_snapshot ON ca_nodegroup_status_info("ClusterID", "Name", "SnapshotTimestamp")`
+const PostgresCreateCASettingsInfoSnapshotIndex = `CREATE INDEX IF NOT EXISTS ca_settings_info_snapshot ON ca_settings_info("ClusterID", "SnapshotTimestamp")`
+const PostgresCreateCAArgsInfoSnapshotIndex = `CREATE INDEX IF NOT EXISTS ca_args_info_snapshot ON ca_args_info("ClusterID", "SnapshotTimestamp")`
+
+// PostgresCreateSchemaMigrationInfoTable creates the schema_migration_info table, which holds a row per applied
+// postgresSchemaMigrations. A PostgreSQL data db has no user_version pragma.
+const PostgresCreateSchemaMigrationInfoTable = `CREATE TABLE IF NOT EXISTS schema_migration_info(
+    "Version" INT PRIMARY KEY,
+    "Description" TEXT NOT NULL,
+    "MigrationTime" BIGINT NOT NULL)`
+const PostgresSelectSchemaVersion = `SELECT coalesce(max("Version"), 0) FROM schema_migration_info`
+const PostgresInsertSchemaMigrationInfo = `INSERT INTO schema_migration_info("Version", "Description", "MigrationTime") VALUES ($1, $2, $3)`
+
+// PostgresLockSchemaMigration takes a transaction level advisory lock, so that recorders of many clusters starting at
+// the same time migrate the shared schema only once. The key is arbitrary but must not change.
+const PostgresLockSchemaMigration = `SELECT pg_advisory_xact_lock(4242)`
+
+// postgresCreateTables are the statements creating the schema of a PostgreSQL data db.
+var postgresCreateTables = []string{
+	PostgresCreateSchemaMigrationInfoTable,
+	PostgresCreateRecorderStateInfo,
+	PostgresCreateRecorderSessionInfoTable,
+	PostgresCreateWatchDisconnectInfoTable,
+	PostgresCreateWorkerPoolInfo,
+	PostgresCreateMCDInfoTable,
+	PostgresCreateMCCInfoTable,
+	PostgresCreateNodeInfoTable,
+	PostgresCreatePodInfoTable,
+	PostgresCreatePodSpecTable,
+	PostgresCreatePDBInfoTable,
+	PostgresCreatePriorityClassInfoTable,
+	PostgresCreateEventInfoTable,
+	PostgresCreateCASettingsInfoTable,
+	PostgresCreateCAArgsInfoTable,
+	PostgresCreateCANodeGroupStatusInfoTable,
+	PostgresCreateNodeScaleDownActivityInfoTable,
+	PostgresCreateFailedSchedulingReasonInfoTable,
+	PostgresCreateCAScalingEventInfoTable,
+	PostgresCreateCheckpointInfoTable,
+	PostgresCreateCheckpointTableInfoTable,
+	PostgresCreatePodInfoUIDHashIndex,
+	PostgresCreatePodInfoUIDSnapshotIndex,
+	PostgresCreatePodInfoNameSnapshotIndex,
+	PostgresCreatePodInfoDeletionIndex,
+	PostgresCreateNodeInfoNameHashIndex,
+	PostgresCreateNodeInfoCreationIndex,
+	PostgresCreateNodeInfoDeletionIndex,
+	PostgresCreateMCDInfoNameSnapshotIndex,
+	PostgresCreateMCDInfoDeletionIndex,
+	PostgresCreateMCCInfoNameSnapshotIndex,
+	PostgresCreateWorkerPoolInfoNameSnapshotIndex,
+	PostgresCreatePriorityClassInfoNameSnapshotIndex,
+	PostgresCreatePriorityClassInfoUIDHashIndex,
+	PostgresCreateCANodeGroupStatusInfoNameSnapshotIndex,
+	PostgresCreateCASettingsInfoSnapshotIndex,
+	PostgresCreateCAArgsInfoSnapshotIndex,
+}
+
+// postgresQueries maps the SQLite queries prepared by prepareStatements to their PostgreSQL variants.
+var postgresQueries = map[string]string{
+	InsertRecorderStateInfo:                                            PostgresInsertRecorderStateInfo,
+	SelectInitialRecorderStateInfo:                                     PostgresSelectInitialRecorderStateInfo,
+	InsertRecorderSessionInfo:                                          PostgresInsertRecorderSessionInfo,
+	UpdateRecorderSessionHeartbeat:                                     PostgresUpdateRecorderSessionHeartbeat,
+	UpdateRecorderSessionEnd:                                           PostgresUpdateRecorderSessionEnd,
+	SelectRecorderSessionInfos:                                         PostgresSelectRecorderSessionInfos,
+	InsertWatchDisconnectInfo:                                          PostgresInsertWatchDisconnectInfo,
+	SelectWatchDisconnectInfos:                                         PostgresSelectWatchDisconnectInfos,
+	SelectPodUIDsNotDeleted:                                            PostgresSelectPodUIDsNotDeleted,
+	SelectNodeNamesNotDeleted:                                          PostgresSelectNodeNamesNotDeleted,
+	SelectMCDNamesNotDeleted:                                           PostgresSelectMCDNamesNotDeleted,
+	UpdatePodDeletionInferredAtRestart:                                 PostgresUpdatePodDeletionInferredAtRestart,
+	UpdateNodeDeletionInferredAtRestart:                                PostgresUpdateNodeDeletionInferredAtRestart,
+	UpdateMCDDeletionInferredAtRestart:                                 PostgresUpdateMCDDeletionInferredAtRestart,
+	UpdateMCDInfoInferredAtRestart:                                     PostgresUpdateMCDInfoInferredAtRestart,
+	InsertWorkerPoolInfo:                                               PostgresInsertWorkerPoolInfo,
+	SelectWorkerPoolInfoBefore:                                         PostgresSelectWorkerPoolInfoBefore,
+	SelectAllWorkerPoolInfoHashes:                                      PostgresSelectAllWorkerPoolInfoHashes,
+	InsertMCDInfo:                                                      PostgresInsertMCDInfo,
+	SelectLatestMCDInfoBefore:                                          PostgresSelectLatestMCDInfoBefore,
+	UpdateMCDInfoDeletionTimestamp:                                     PostgresUpdateMCDInfoDeletionTimestamp,
+	SelectMCDInfoHash:                                                  PostgresSelectMCDInfoHash,
+	SelectLatestMCDInfo:                                                PostgresSelectLatestMCDInfo,
+	InsertMCCInfo:                                                      PostgresInsertMCCInfo,
+	SelectLatestMCCInfoBefore:                                          PostgresSelectLatestMCCInfoBefore,
+	UpdateMCCInfoDeletionTimestamp:                                     PostgresUpdateMCCInfoDeletionTimestamp,
+	SelectMCCInfoHash:                                                  PostgresSelectMCCInfoHash,
+	SelectLatestMCCInfo:                                                PostgresSelectLatestMCCInfo,
+	InsertNodeInfo:                                                     PostgresInsertNodeInfo,
+	InsertNodeInfoIfNew:                                                PostgresInsertNodeInfoIfNew,
+	SelectNodeInfoBefore:                                               PostgresSelectNodeInfoBefore,
+	SelectNodeCountWithNameAndHash:                                     PostgresSelectNodeCountWithNameAndHash,
+	UpdateNodeInfoDeletionTimestamp:                                    PostgresUpdateNodeInfoDeletionTimestamp,
+	SelectLatestNodesBeforeAndNotDeleted:                               PostgresSelectLatestNodesBeforeAndNotDeleted,
+	SelectNodeDeletionTimestamps:                                       PostgresSelectNodeDeletionTimestamps,
+	InsertPodInfo:                                                      PostgresInsertPodInfo,
+	InsertPodInfoIfNew:                                                 PostgresInsertPodInfoIfNew,
+	InsertPodSpec:                                                      PostgresInsertPodSpec,
+	UpdatePodDeletionTimestamp:                                         PostgresUpdatePodDeletionTimestamp,
+	SelectLatestPodInfoWithName:                                        PostgresSelectLatestPodInfoWithName,
+	SelectPodCountWithUIDAndHash:                                       PostgresSelectPodCountWithUIDAndHash,
+	SelectUnscheduledPodsBeforeSnapshotTimestamp:                       PostgresSelectUnscheduledPodsBeforeSnapshotTimestamp,
+	SelectLatestScheduledPodsBeforeSnapshotTimestamp:                   PostgresSelectLatestScheduledPodsBeforeSnapshotTimestamp,
+	SelectLatestPodsBeforeSnapshotTimestamp:                            PostgresSelectLatestPodsBeforeSnapshotTimestamp,
+	InsertPDBInfo:                                                      PostgresInsertPDBInfo,
+	UpdatePDBInfoDeletionTimestamp:                                     PostgresUpdatePDBInfoDeletionTimestamp,
+	InsertPriorityClassInfo:                                            PostgresInsertPriorityClassInfo,
+	SelectLatestPriorityClassInfoBeforeSnapshotTimestamp:               PostgresSelectLatestPriorityClassInfoBeforeSnapshotTimestamp,
+	SelectPriorityClassInfoCountWithUIDAndHash:                         PostgresSelectPriorityClassInfoCountWithUIDAndHash,
+	InsertEvent:                                                        PostgresInsertEvent,
+	SelectEventInfoWithUID:                                             PostgresSelectEventInfoWithUID,
+	SelectAllEventInfos:                                                PostgresSelectAllEventInfos,
+	SelectCADeploymentByHash:                                           PostgresSelectCADeploymentByHash,
+	SelectLatestCASettingsInfo:                                         PostgresSelectLatestCASettingsInfo,
+	InsertCASettingsInfo:                                               PostgresInsertCASettingsInfo,
+	SelectLatestCASettingsBefore:                                       PostgresSelectLatestCASettingsBefore,
+	InsertCAArgsInfo:                                                   PostgresInsertCAArgsInfo,
+	SelectLatestCAArgsInfo:                                             PostgresSelectLatestCAArgsInfo,
+	SelectLatestCAArgsInfoBefore:                                       PostgresSelectLatestCAArgsInfoBefore,
+	InsertCANodeGroupStatusInfo:                                        PostgresInsertCANodeGroupStatusInfo,
+	SelectLatestCANodeGroupStatusInfoHashes:                            PostgresSelectLatestCANodeGroupStatusInfoHashes,
+	SelectLatestCANodeGroupStatusInfosBefore:                           PostgresSelectLatestCANodeGroupStatusInfosBefore,
+	InsertNodeScaleDownActivityInfo:                                    PostgresInsertNodeScaleDownActivityInfo,
+	SelectNodeScaleDownActivityInfosBetween:                            PostgresSelectNodeScaleDownActivityInfosBetween,
+	InsertFailedSchedulingReasonInfo:                                   PostgresInsertFailedSchedulingReasonInfo,
+	SelectFailedSchedulingReasonInfosBetween:                           PostgresSelectFailedSchedulingReasonInfosBetween,
+	SelectFailedSchedulingReasonInfosForPod:                            PostgresSelectFailedSchedulingReasonInfosForPod,
+	SelectFailedSchedulingReasonCountsBetween:                          PostgresSelectFailedSchedulingReasonCountsBetween,
+	InsertCAScalingEventInfo:                                           PostgresInsertCAScalingEventInfo,
+	SelectCAScalingEventInfosBetween:                                   PostgresSelectCAScalingEventInfosBetween,
+	SelectCAScalingEventInfosForPod:                                    PostgresSelectCAScalingEventInfosForPod,
+	SelectCAScalingEventInfosForNodeGroup:                              PostgresSelectCAScalingEventInfosForNodeGroup,
+	InsertCheckpointInfo:                                               PostgresInsertCheckpointInfo,
+	InsertCheckpointTableInfo:                                          PostgresInsertCheckpointTableInfo,
+	SelectCheckpointInfos:                                              PostgresSelectCheckpointInfos,
+	SelectLatestCheckpointTableInfoBefore:                              PostgresSelectLatestCheckpointTableInfoBefore,
+	DeleteCheckpointTableInfosBeyondRetention:                          PostgresDeleteCheckpointTableInfosBeyondRetention,
+	DeleteCheckpointInfosBeyondRetention:                               PostgresDeleteCheckpointInfosBeyondRetention,
+	SelectCheckpointHighWaterRowIDs:                                    PostgresSelectCheckpointHighWaterRowIDs,
+	SelectPodCheckpointRows:                                            PostgresSelectPodCheckpointRows,
+	SelectMCDCheckpointRows:                                            PostgresSelectMCDCheckpointRows,
+	SelectMCCCheckpointRows:                                            PostgresSelectMCCCheckpointRows,
+	SelectWorkerPoolCheckpointRows:                                     PostgresSelectWorkerPoolCheckpointRows,
+	SelectPriorityClassCheckpointRows:                                  PostgresSelectPriorityClassCheckpointRows,
+	SelectCANodeGroupStatusCheckpointRows:                              PostgresSelectCANodeGroupStatusCheckpointRows,
+	SelectLatestPodsBeforeSnapshotTimestampFromCheckpoint:              PostgresSelectLatestPodsBeforeSnapshotTimestampFromCheckpoint,
+	SelectLatestMCDInfoBeforeFromCheckpoint:                            PostgresSelectLatestMCDInfoBeforeFromCheckpoint,
+	SelectLatestMCCInfoBeforeFromCheckpoint:                            PostgresSelectLatestMCCInfoBeforeFromCheckpoint,
+	SelectWorkerPoolInfoBeforeFromCheckpoint:                           PostgresSelectWorkerPoolInfoBeforeFromCheckpoint,
+	SelectLatestPriorityClassInfoBeforeSnapshotTimestampFromCheckpoint: PostgresSelectLatestPriorityClassInfoBeforeSnapshotTimestampFromCheckpoint,
+	SelectLatestCANodeGroupStatusInfosBeforeFromCheckpoint:             PostgresSelectLatestCANodeGroupStatusInfosBeforeFromCheckpoint,
+}
@@ -9,6 +9,59 @@ const InsertRecorderStateInfo = `INSERT INTO recorder_state_info(
 
 const SelectInitialRecorderStateInfo = `SELECT * FROM recorder_state_info ORDER BY BeginTimestamp  LIMIT 1`
 
+const CreateRecorderSessionInfoTable = `CREATE TABLE IF NOT EXISTS recorder_session_info(
+    SessionID INTEGER PRIMARY KEY AUTOINCREMENT,
+    BeginTimestamp INT NOT NULL,
+    HeartbeatTimestamp INT NOT NULL,
+    EndTimestamp INT NOT NULL DEFAULT 0)`
+
+const InsertRecorderSessionInfo = `INSERT INTO recorder_session_info(
+    BeginTimestamp,
+    HeartbeatTimestamp
+) VALUES (?, ?)`
+
+const UpdateRecorderSessionHeartbeat = `UPDATE recorder_session_info SET HeartbeatTimestamp = ? WHERE SessionID = ?`
+
+const UpdateRecorderSessionEnd = `UPDATE recorder_session_info SET HeartbeatTimestamp = ?, EndTimestamp = ? WHERE SessionID = ?`
+
+const SelectRecorderSessionInfos = `SELECT * FROM recorder_session_info ORDER BY BeginTimestamp, SessionID`
+
+const CreateWatchDisconnectInfoTable = `CREATE TABLE IF NOT EXISTS watch_disconnect_info(
+    RowID INTEGER PRIMARY KEY AUTOINCREMENT,
+    SessionID INT NOT NULL,
+    Informer TEXT NOT NULL,
+    BeginTimestamp INT NOT NULL,
+    EndTimestamp INT NOT NULL,
+    Error TEXT)`
+
+const InsertWatchDisconnectInfo = `INSERT INTO watch_disconnect_info(
+    SessionID,
+    Informer,
+    BeginTimestamp,
+    EndTimestamp,
+    Error
+) VALUES (?, ?, ?, ?, ?)`
+
+const SelectWatchDisconnectInfos = `SELECT * FROM watch_disconnect_info ORDER BY BeginTimestamp, RowID`
+
+// Restart reconciliation. InferredAtRestart flags a row stored while the informers replayed the cluster after a
+// recorder restart: its SnapshotTimestamp is the time of the restart and not the time of the change.
+// DeletionInferredAtRestart flags a DeletionTimestamp set to the restart time since the object vanished while the
+// recorder was down.
+const SelectPodUIDsNotDeleted = `SELECT DISTINCT UID FROM pod_info WHERE DeletionTimestamp IS NULL OR DeletionTimestamp = 0`
+const SelectNodeNamesNotDeleted = `SELECT DISTINCT Name FROM node_info WHERE DeletionTimestamp IS NULL OR DeletionTimestamp = 0`
+const SelectMCDNamesNotDeleted = `SELECT DISTINCT Name FROM mcd_info WHERE DeletionTimestamp IS NULL OR DeletionTimestamp = 0`
+const UpdatePodDeletionInferredAtRestart = `UPDATE pod_info SET DeletionTimestamp = ?, DeletionInferredAtRestart = 1
+    WHERE UID = ? AND (DeletionTimestamp IS NULL OR DeletionTimestamp = 0)`
+const UpdateNodeDeletionInferredAtRestart = `UPDATE node_info SET DeletionTimestamp = ?, DeletionInferredAtRestart = 1
+    WHERE Name = ? AND (DeletionTimestamp IS NULL OR DeletionTimestamp = 0)`
+const UpdateMCDDeletionInferredAtRestart = `UPDATE mcd_info SET DeletionTimestamp = ?, DeletionInferredAtRestart = 1
+    WHERE Name = ? AND (DeletionTimestamp IS NULL OR DeletionTimestamp = 0)`
+const UpdateMCDInfoInferredAtRestart = `UPDATE mcd_info SET InferredAtRestart = 1 WHERE RowID = ?`
+
+// CheckWritableRecorderStateInfo deletes no rows but acquires the database write lock.
+const CheckWritableRecorderStateInfo = `DELETE FROM recorder_state_info WHERE BeginTimestamp < 0`
+
 const CreateWorkerPoolInfo = `CREATE TABLE IF NOT EXISTS worker_pool_info(
 	RowID INTEGER PRIMARY KEY AUTOINCREMENT,
 	CreationTimestamp INT NOT NULL,
@@ -40,7 +93,10 @@ const InsertWorkerPoolInfo = `INSERT INTO worker_pool_info(
 	Hash
 ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-const SelectWorkerPoolInfoBefore = `SELECT * FROM worker_pool_info where SnapshotTimestamp <= ?  GROUP BY Name HAVING max(SnapshotTimestamp)`
+const SelectWorkerPoolInfoBefore = `SELECT * FROM worker_pool_info WHERE RowID IN (
+    SELECT (SELECT RowID FROM worker_pool_info AS w WHERE w.Name = names.Name AND w.SnapshotTimestamp <= ?
+        ORDER BY w.SnapshotTimestamp DESC, w.RowID DESC LIMIT 1)
+    FROM (SELECT DISTINCT Name FROM worker_pool_info) AS names) ORDER BY Name`
 const SelectAllWorkerPoolInfoHashes = "SELECT RowID, Name, SnapshotTimestamp, Hash FROM worker_pool_info ORDER BY RowID desc"
 
 const CreateMCDInfoTable = `CREATE TABLE IF NOT EXISTS mcd_info(
@@ -58,7 +114,9 @@ const CreateMCDInfoTable = `CREATE TABLE IF NOT EXISTS mcd_info(
 	Labels TEXT,
 	Taints TEXT,
 	DeletionTimestamp INT,
-	Hash TEXT)`
+	Hash TEXT,
+	InferredAtRestart INT NOT NULL DEFAULT 0,
+	DeletionInferredAtRestart INT NOT NULL DEFAULT 0)`
 const InsertMCDInfo = `INSERT INTO mcd_info(
 	CreationTimestamp,
 	SnapshotTimestamp,
@@ -73,7 +131,10 @@ const InsertMCDInfo = `INSERT INTO mcd_info(
 	Labels,
 	Taints,
 	Hash) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-const SelectLatestMCDInfoBefore = `SELECT * FROM mcd_info where SnapshotTimestamp <= ?  GROUP BY Name HAVING max(SnapshotTimestamp)`
+const SelectLatestMCDInfoBefore = `SELECT * FROM mcd_info WHERE RowID IN (
+    SELECT (SELECT RowID FROM mcd_info AS m WHERE m.Name = names.Name AND m.SnapshotTimestamp <= ?
+        ORDER BY m.SnapshotTimestamp DESC, m.RowID DESC LIMIT 1)
+    FROM (SELECT DISTINCT Name FROM mcd_info) AS names) ORDER BY Name`
 const UpdateMCDInfoDeletionTimestamp = `UPDATE mcd_info SET DeletionTimestamp = ? where Name = ?`
 const SelectMCDInfoHash = "SELECT Hash FROM mcd_info WHERE name=? ORDER BY RowID desc LIMIT 1"
 const SelectLatestMCDInfo = "SELECT * FROM mcd_info WHERE name=? ORDER BY RowID DESC LIMIT 1"
@@ -98,13 +159,20 @@ const InsertMCCInfo = `INSERT INTO mcc_info(
 	Name,
 	Namespace,
 	InstanceType,
+	Provider,
 	PoolName,
 	Region,
 	Zone,
 	Labels,
 	Capacity,
-	Hash) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-const SelectLatestMCCInfoBefore = `SELECT * FROM mcc_info where SnapshotTimestamp <= ?  GROUP BY Name HAVING max(SnapshotTimestamp)`
+	Hash) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// AddMCCInfoProviderColumn adds the Provider column, which is missing in CreateMCCInfoTable, to the mcc_info table.
+const AddMCCInfoProviderColumn = `ALTER TABLE mcc_info ADD COLUMN Provider TEXT NOT NULL DEFAULT ''`
+const SelectLatestMCCInfoBefore = `SELECT * FROM mcc_info WHERE RowID IN (
+    SELECT (SELECT RowID FROM mcc_info AS m WHERE m.Name = names.Name AND m.SnapshotTimestamp <= ?
+        ORDER BY m.SnapshotTimestamp DESC, m.RowID DESC LIMIT 1)
+    FROM (SELECT DISTINCT Name FROM mcc_info) AS names) ORDER BY Name`
 const UpdateMCCInfoDeletionTimestamp = `UPDATE mcc_info SET DeletionTimestamp = ? where Name = ?`
 const SelectMCCInfoHash = "SELECT Hash FROM mcc_info WHERE name=? ORDER BY RowID desc LIMIT 1"
 const SelectLatestMCCInfo = "SELECT * FROM mcc_info WHERE name=? ORDER BY RowID DESC LIMIT 1"
@@ -122,7 +190,9 @@ const CreateNodeInfoTable = `CREATE TABLE IF NOT EXISTS node_info (
 	Allocatable TEXT, 
 	Capacity TEXT, 
 	DeletionTimestamp DATETIME,
-	Hash TEXT)`
+	Hash TEXT,
+	InferredAtRestart INT NOT NULL DEFAULT 0,
+	DeletionInferredAtRestart INT NOT NULL DEFAULT 0)`
 
 const InsertNodeInfo = `INSERT INTO node_info(
     CreationTimestamp,
@@ -138,7 +208,25 @@ const InsertNodeInfo = `INSERT INTO node_info(
 	Hash) 
 	VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-const SelectNodeInfoBefore = `SELECT * FROM node_info WHERE CreationTimestamp < ? ORDER BY CreationTimestamp DESC`
+// InsertNodeInfoIfNew is InsertNodeInfo for the write pipeline which also sets InferredAtRestart. It skips the insert
+// if a row with the same Name and Hash exists, which is given as the last 2 parameters.
+const InsertNodeInfoIfNew = `INSERT INTO node_info(
+    CreationTimestamp,
+	SnapshotTimestamp,
+	Name,
+	Namespace,
+	ProviderID,
+    AllocatableVolumes,
+	Labels,
+	Taints,
+	Allocatable,
+	Capacity,
+	Hash,
+	InferredAtRestart)
+	SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+	WHERE NOT EXISTS (SELECT 1 FROM node_info WHERE Name = ? AND Hash = ?)`
+
+const SelectNodeInfoBefore = `SELECT * FROM node_info WHERE CreationTimestamp < ? ORDER BY CreationTimestamp DESC, RowID`
 const SelectNodeCountWithNameAndHash = "SELECT COUNT(*) from node_info where Name=? and Hash=?"
 const UpdateNodeInfoDeletionTimestamp = `UPDATE node_info SET DeletionTimestamp = ? where Name = ?`
 
@@ -153,10 +241,14 @@ const CreatePodInfoTable = `CREATE TABLE IF NOT EXISTS pod_info (
 	NominatedNodeName TEXT,
 	Labels TEXT,
 	Requests TEXT,
-	Spec TEXT,
+	SpecHash TEXT,
+	SpecNodeName TEXT NOT NULL DEFAULT '',
+	SpecTokenVolumeName TEXT NOT NULL DEFAULT '',
 	ScheduleStatus INTEGER,
 	DeletionTimestamp INT,
-	Hash TEXT)`
+	Hash TEXT,
+	InferredAtRestart INT NOT NULL DEFAULT 0,
+	DeletionInferredAtRestart INT NOT NULL DEFAULT 0)`
 const InsertPodInfo = `INSERT INTO pod_info(
     CreationTimestamp,
 	SnapshotTimestamp,
@@ -167,20 +259,80 @@ const InsertPodInfo = `INSERT INTO pod_info(
     NominatedNodeName,
 	Labels,
 	Requests,
-    Spec,
+    SpecHash,
+    SpecNodeName,
+    SpecTokenVolumeName,
+    ScheduleStatus,
+	Hash) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// InsertPodInfoIfNew is InsertPodInfo for the write pipeline which also sets InferredAtRestart. It skips the insert if
+// a row with the same UID and Hash exists, which is given as the last 2 parameters.
+const InsertPodInfoIfNew = `INSERT INTO pod_info(
+    CreationTimestamp,
+	SnapshotTimestamp,
+	Name, 
+	Namespace,
+	UID, 
+	NodeName,
+    NominatedNodeName,
+	Labels,
+	Requests,
+    SpecHash,
+    SpecNodeName,
+    SpecTokenVolumeName,
     ScheduleStatus,
-	Hash) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	Hash,
+	InferredAtRestart) SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+	WHERE NOT EXISTS (SELECT 1 FROM pod_info WHERE UID = ? AND Hash = ?)`
 const UpdatePodDeletionTimestamp = "UPDATE pod_info SET DeletionTimestamp=? WHERE UID=?"
+const SelectLatestPodInfoWithName = `SELECT pod_info.*, pod_spec.Spec FROM pod_info JOIN pod_spec USING (SpecHash)
+    WHERE Name=? ORDER BY CreationTimestamp DESC, RowID DESC LIMIT 1`
 const SelectPodCountWithUIDAndHash = "SELECT COUNT(*) from pod_info where UID=? and Hash=?"
-const SelectUnscheduledPodsBeforeSnapshotTimestamp = `SELECT * FROM (SELECT * from pod_info
-               WHERE ScheduleStatus = 0 AND SnapshotTimestamp <= ? AND (DeletionTimestamp is null OR DeletionTimestamp >= ?)
-               ORDER BY SnapshotTimestamp DESC) GROUP BY Name;`
 
-const SelectLatestScheduledPodsBeforeSnapshotTimestamp = `SELECT * from (SELECT * FROM pod_info WHERE (ScheduleStatus = 1)  
-                AND SnapshotTimestamp <= ? AND (DeletionTimestamp is null OR DeletionTimestamp >=  ?)  ORDER BY SnapshotTimestamp DESC) 
-                GROUP BY Name;`
-const SelectLatestPodsBeforeSnapshotTimestamp = `SELECT * FROM pod_info WHERE
-                SnapshotTimestamp <= ? AND (DeletionTimestamp is null OR DeletionTimestamp >=  ?)   GROUP BY pod_info.UID HAVING max(SnapshotTimestamp);`
+// The point-in-time queries select the latest row of every Name or UID before the given SnapshotTimestamp. The
+// correlated sub-queries walk the (Name|UID, SnapshotTimestamp) indexes backwards from the SnapshotTimestamp and only
+// read the selected rows from the table. The pod queries join the selected rows with their pod_spec.
+const SelectUnscheduledPodsBeforeSnapshotTimestamp = `SELECT pod_info.*, pod_spec.Spec FROM pod_info JOIN pod_spec USING (SpecHash)
+    WHERE pod_info.RowID IN (
+    SELECT (SELECT RowID FROM pod_info AS p WHERE p.Name = names.Name AND p.ScheduleStatus = 0 AND p.SnapshotTimestamp <= ?
+        AND (p.DeletionTimestamp IS NULL OR p.DeletionTimestamp >= ?) ORDER BY p.SnapshotTimestamp DESC, p.RowID DESC LIMIT 1)
+    FROM (SELECT DISTINCT Name FROM pod_info) AS names) ORDER BY Name`
+
+const SelectLatestScheduledPodsBeforeSnapshotTimestamp = `SELECT pod_info.*, pod_spec.Spec FROM pod_info JOIN pod_spec USING (SpecHash)
+    WHERE pod_info.RowID IN (
+    SELECT (SELECT RowID FROM pod_info AS p WHERE p.Name = names.Name AND p.ScheduleStatus = 1 AND p.SnapshotTimestamp <= ?
+        AND (p.DeletionTimestamp IS NULL OR p.DeletionTimestamp >= ?) ORDER BY p.SnapshotTimestamp DESC, p.RowID DESC LIMIT 1)
+    FROM (SELECT DISTINCT Name FROM pod_info) AS names) ORDER BY Name`
+const SelectLatestPodsBeforeSnapshotTimestamp = `SELECT pod_info.*, pod_spec.Spec FROM pod_info JOIN pod_spec USING (SpecHash)
+    WHERE pod_info.RowID IN (
+    SELECT (SELECT RowID FROM pod_info AS p WHERE p.UID = uids.UID AND p.SnapshotTimestamp <= ?
+        AND (p.DeletionTimestamp IS NULL OR p.DeletionTimestamp >= ?) ORDER BY p.SnapshotTimestamp DESC, p.RowID DESC LIMIT 1)
+    FROM (SELECT DISTINCT UID FROM pod_info) AS uids) ORDER BY UID`
+
+// CreatePodSpecTable creates the pod_spec table, which stores every distinct normalized pod spec once. pod_info rows
+// reference their spec by SpecHash.
+const CreatePodSpecTable = `CREATE TABLE IF NOT EXISTS pod_spec (
+	SpecHash TEXT PRIMARY KEY,
+	Spec TEXT NOT NULL) WITHOUT ROWID`
+const InsertPodSpec = `INSERT OR IGNORE INTO pod_spec(SpecHash, Spec) VALUES(?, ?)`
+const SelectPodSpecCount = `SELECT COUNT(*) FROM pod_spec`
+
+// The queries of the migration of pod_info rows storing their Spec to rows referencing a pod_spec.
+const SelectPodInfoSpecsAfterRowID = `SELECT RowID, '' AS ClusterID, coalesce(Spec, '') FROM pod_info WHERE RowID > ? ORDER BY RowID LIMIT ?`
+const UpdatePodInfoSpecHash = `UPDATE pod_info SET SpecHash = ?, SpecNodeName = ?, SpecTokenVolumeName = ? WHERE RowID = ?`
+
+const CreatePDBInfoTable = `CREATE TABLE IF NOT EXISTS pdb_info(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	uid TEXT,
+	name TEXT,
+	generation INT,
+	creationTimestamp DATETIME,
+	deletionTimestamp DATETIME,
+	minAvailable TEXT,
+	maxUnAvailable TEXT,
+	spec TEXT)` // TODO: maxUnAvailable -> maxUnavailable
+const InsertPDBInfo = `INSERT INTO pdb_info(uid,name,generation,creationTimestamp,minAvailable,maxUnAvailable,spec) VALUES(?,?,?,?,?,?,?)`
+const UpdatePDBInfoDeletionTimestamp = `UPDATE pdb_info SET DeletionTimeStamp=? WHERE uid=?`
 
 const CreatePriorityClassInfoTable = `CREATE TABLE IF NOT EXISTS pc_info (
 	RowID INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -208,8 +360,10 @@ const InsertPriorityClassInfo = `INSERT INTO pc_info(
     Labels,
 	Hash) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-const SelectLatestPriorityClassInfoBeforeSnapshotTimestamp = `SELECT * FROM pc_info WHERE
-                SnapshotTimestamp <= ? AND (DeletionTimestamp is null OR DeletionTimestamp >=  ?)   GROUP BY pc_info.Name HAVING max(SnapshotTimestamp);`
+const SelectLatestPriorityClassInfoBeforeSnapshotTimestamp = `SELECT * FROM pc_info WHERE RowID IN (
+    SELECT (SELECT RowID FROM pc_info AS p WHERE p.Name = names.Name AND p.SnapshotTimestamp <= ?
+        AND (p.DeletionTimestamp IS NULL OR p.DeletionTimestamp >= ?) ORDER BY p.SnapshotTimestamp DESC, p.RowID DESC LIMIT 1)
+    FROM (SELECT DISTINCT Name FROM pc_info) AS names) ORDER BY Name`
 const UpdatePriorityClassInfoDeletionTimestamp = "UPDATE pc_info SET DeletionTimestamp=? WHERE UID=?"
 const SelectPriorityClassInfoCountWithUIDAndHash = "SELECT COUNT(*) from pc_info where UID=? and Hash=?"
 
@@ -235,6 +389,9 @@ const InsertEvent = `INSERT INTO event_info(
 	InvolvedObjectNamespace,
 	InvolvedObjectUID) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT(UID) DO NOTHING`
 
+const SelectEventInfoWithUID = "SELECT * from event_info where UID = ?"
+const SelectAllEventInfos = "SELECT * from event_info ORDER BY EventTime"
+
 const CreateCASettingsInfoTable = `CREATE TABLE IF NOT EXISTS ca_settings_info(
     RowID INTEGER PRIMARY KEY AUTOINCREMENT,
     SnapshotTimestamp INT NOT NULL,
@@ -268,4 +425,293 @@ const InsertCASettingsInfo = `INSERT INTO ca_settings_info (
 ) VALUES (? ,? , ? ,?, ?, ?, ? , ? , ? , ? , ?)`
 
 const SelectLatestCASettingsBefore = `SELECT * from ca_settings_info WHERE SnapshotTimestamp <= ? ORDER BY SnapshotTimestamp DESC LIMIT 1`
-const SelectLatestNodesBeforeAndNotDeleted = `SELECT * from (select * from node_info where node_info.CreationTimestamp <= ? and node_info.DeletionTimestamp = 0 order by RowID DESC) GROUP by Name`
+
+// SwapCASettingsInfoDurationColumns swaps the ScanInterval and MaxNodeProvisionTime of the ca_settings_info rows stored
+// by earlier versions of the recorder, which stored each in the column of the other.
+const SwapCASettingsInfoDurationColumns = `UPDATE ca_settings_info SET ScanInterval = MaxNodeProvisionTime, MaxNodeProvisionTime = ScanInterval`
+const SelectLatestNodesBeforeAndNotDeleted = `SELECT * FROM node_info WHERE RowID IN (
+    SELECT max(RowID) FROM node_info WHERE CreationTimestamp <= ? AND DeletionTimestamp = 0 GROUP BY Name) ORDER BY Name`
+
+const CreateCAArgsInfoTable = `CREATE TABLE IF NOT EXISTS ca_args_info(
+    RowID INTEGER PRIMARY KEY AUTOINCREMENT,
+    SnapshotTimestamp INT NOT NULL,
+    Args TEXT,
+    Hash TEXT)`
+
+const InsertCAArgsInfo = `INSERT INTO ca_args_info (
+    SnapshotTimestamp,
+    Args,
+    Hash
+) VALUES (?, ?, ?)`
+
+const SelectLatestCAArgsInfo = `SELECT * FROM ca_args_info ORDER BY RowID DESC LIMIT 1`
+const SelectLatestCAArgsInfoBefore = `SELECT * FROM ca_args_info WHERE SnapshotTimestamp <= ? ORDER BY SnapshotTimestamp DESC LIMIT 1`
+
+const CreateCANodeGroupStatusInfoTable = `CREATE TABLE IF NOT EXISTS ca_nodegroup_status_info(
+    RowID INTEGER PRIMARY KEY AUTOINCREMENT,
+    SnapshotTimestamp INT NOT NULL,
+    Name TEXT,
+    Health TEXT,
+    Ready INT,
+    Unready INT,
+    NotStarted INT,
+    LongNotStarted INT,
+    Registered INT,
+    LongUnregistered INT,
+    CloudProviderTarget INT,
+    MinSize INT,
+    MaxSize INT,
+    ScaleUp TEXT,
+    Backoff BOOLEAN,
+    ScaleDown TEXT,
+    ScaleDownCandidates INT,
+    Hash TEXT)`
+
+const InsertCANodeGroupStatusInfo = `INSERT INTO ca_nodegroup_status_info(
+    SnapshotTimestamp,
+    Name,
+    Health,
+    Ready,
+    Unready,
+    NotStarted,
+    LongNotStarted,
+    Registered,
+    LongUnregistered,
+    CloudProviderTarget,
+    MinSize,
+    MaxSize,
+    ScaleUp,
+    Backoff,
+    ScaleDown,
+    ScaleDownCandidates,
+    Hash
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+const SelectLatestCANodeGroupStatusInfoHashes = `SELECT RowID, Name, SnapshotTimestamp, Hash FROM ca_nodegroup_status_info WHERE RowID IN (
+    SELECT max(RowID) FROM ca_nodegroup_status_info GROUP BY Name) ORDER BY Name`
+const SelectLatestCANodeGroupStatusInfosBefore = `SELECT * FROM ca_nodegroup_status_info WHERE RowID IN (
+    SELECT max(RowID) FROM ca_nodegroup_status_info WHERE SnapshotTimestamp <= ? GROUP BY Name) ORDER BY Name`
+
+const CreateNodeScaleDownActivityInfoTable = `CREATE TABLE IF NOT EXISTS node_scale_down_activity_info(
+    RowID INTEGER PRIMARY KEY AUTOINCREMENT,
+    ActivityTime INT NOT NULL,
+    NodeName TEXT,
+    Activity TEXT,
+    Detail TEXT)`
+
+const InsertNodeScaleDownActivityInfo = `INSERT INTO node_scale_down_activity_info(
+    ActivityTime,
+    NodeName,
+    Activity,
+    Detail) VALUES (?, ?, ?, ?)`
+
+const SelectNodeScaleDownActivityInfosBetween = `SELECT * FROM node_scale_down_activity_info WHERE ActivityTime >= ? AND ActivityTime <= ? ORDER BY ActivityTime`
+
+const SelectNodeDeletionTimestamps = `SELECT Name, max(DeletionTimestamp) AS DeletionTimestamp FROM node_info WHERE DeletionTimestamp IS NOT NULL GROUP BY Name`
+
+const CreateFailedSchedulingReasonInfoTable = `CREATE TABLE IF NOT EXISTS failed_scheduling_reason_info(
+    RowID INTEGER PRIMARY KEY AUTOINCREMENT,
+    EventUID TEXT NOT NULL,
+    EventTime INT NOT NULL,
+    EventCount INT NOT NULL DEFAULT 1,
+    PodUID TEXT,
+    PodName TEXT,
+    PodNamespace TEXT,
+    Zone TEXT NOT NULL DEFAULT '',
+    TotalNodes INT,
+    Reason TEXT NOT NULL,
+    NodeCount INT,
+    UNIQUE(EventUID, EventCount, Reason))`
+
+// CreateFailedSchedulingReasonInfoOccurrenceTable, CopyFailedSchedulingReasonInfoToOccurrenceTable,
+// DropFailedSchedulingReasonInfoTable and RenameFailedSchedulingReasonInfoOccurrenceTable rebuild a
+// failed_scheduling_reason_info table, which only held the first occurrence of an event, with the EventCount and Zone
+// columns, since SQLite cannot change the UNIQUE constraint of a table.
+const CreateFailedSchedulingReasonInfoOccurrenceTable = `CREATE TABLE failed_scheduling_reason_info_occurrence(
+    RowID INTEGER PRIMARY KEY AUTOINCREMENT,
+    EventUID TEXT NOT NULL,
+    EventTime INT NOT NULL,
+    EventCount INT NOT NULL DEFAULT 1,
+    PodUID TEXT,
+    PodName TEXT,
+    PodNamespace TEXT,
+    Zone TEXT NOT NULL DEFAULT '',
+    TotalNodes INT,
+    Reason TEXT NOT NULL,
+    NodeCount INT,
+    UNIQUE(EventUID, EventCount, Reason))`
+const CopyFailedSchedulingReasonInfoToOccurrenceTable = `INSERT INTO failed_scheduling_reason_info_occurrence(
+    RowID, EventUID, EventTime, PodUID, PodName, PodNamespace, TotalNodes, Reason, NodeCount)
+    SELECT RowID, EventUID, EventTime, PodUID, PodName, PodNamespace, TotalNodes, Reason, NodeCount FROM failed_scheduling_reason_info`
+const DropFailedSchedulingReasonInfoTable = `DROP TABLE failed_scheduling_reason_info`
+const RenameFailedSchedulingReasonInfoOccurrenceTable = `ALTER TABLE failed_scheduling_reason_info_occurrence RENAME TO failed_scheduling_reason_info`
+
+const InsertFailedSchedulingReasonInfo = `INSERT INTO failed_scheduling_reason_info(
+    EventUID,
+    EventTime,
+    EventCount,
+    PodUID,
+    PodName,
+    PodNamespace,
+    Zone,
+    TotalNodes,
+    Reason,
+    NodeCount) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT(EventUID, EventCount, Reason) DO NOTHING`
+
+const SelectFailedSchedulingReasonInfosBetween = `SELECT * FROM failed_scheduling_reason_info WHERE EventTime >= ? AND EventTime <= ? ORDER BY EventTime, RowID`
+const SelectFailedSchedulingReasonInfosForPod = `SELECT * FROM failed_scheduling_reason_info WHERE PodUID = ? ORDER BY EventTime, RowID`
+const SelectFailedSchedulingReasonCountsBetween = `SELECT Reason, COUNT(DISTINCT PodUID) AS PodCount, SUM(NodeCount) AS NodeCount 
+	FROM failed_scheduling_reason_info WHERE EventTime >= ? AND EventTime <= ? AND (? = '' OR Zone = ?)
+	GROUP BY Reason ORDER BY PodCount DESC, Reason`
+
+const CreateCAScalingEventInfoTable = `CREATE TABLE IF NOT EXISTS ca_scaling_event_info(
+    RowID INTEGER PRIMARY KEY AUTOINCREMENT,
+    EventUID TEXT NOT NULL,
+    EventTime INT NOT NULL,
+    Reason TEXT,
+    PodUID TEXT,
+    PodName TEXT,
+    PodNamespace TEXT,
+    NodeGroupName TEXT,
+    CurrentSize INT,
+    TargetSize INT,
+    MaxSize INT,
+    NodeName TEXT,
+    NotTriggerReason TEXT,
+    NodeGroupCount INT,
+    UNIQUE(EventUID, NodeGroupName, NotTriggerReason))`
+
+const InsertCAScalingEventInfo = `INSERT INTO ca_scaling_event_info(
+    EventUID,
+    EventTime,
+    Reason,
+    PodUID,
+    PodName,
+    PodNamespace,
+    NodeGroupName,
+    CurrentSize,
+    TargetSize,
+    MaxSize,
+    NodeName,
+    NotTriggerReason,
+    NodeGroupCount) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT(EventUID, NodeGroupName, NotTriggerReason) DO NOTHING`
+
+const SelectCAScalingEventInfosBetween = `SELECT * FROM ca_scaling_event_info WHERE EventTime >= ? AND EventTime <= ? ORDER BY EventTime, RowID`
+const SelectCAScalingEventInfosForPod = `SELECT * FROM ca_scaling_event_info WHERE PodUID = ? ORDER BY EventTime, RowID`
+const SelectCAScalingEventInfosForNodeGroup = `SELECT * FROM ca_scaling_event_info WHERE NodeGroupName = ? ORDER BY EventTime, RowID`
+
+// Indexes of the data db, created by the schema migrations in migrations.go. The (Name|UID, SnapshotTimestamp)
+// indexes serve the point-in-time queries, the (Name|UID, Hash) indexes the duplicate checks on every informer update
+// and the DeletionTimestamp indexes the queries for objects not deleted.
+const CreatePodInfoUIDHashIndex = `CREATE INDEX IF NOT EXISTS pod_info_uid_hash ON pod_info(UID, Hash)`
+const CreatePodInfoUIDSnapshotIndex = `CREATE INDEX IF NOT EXISTS pod_info_uid_snapshot ON pod_info(UID, SnapshotTimestamp)`
+const CreatePodInfoNameSnapshotIndex = `CREATE INDEX IF NOT EXISTS pod_info_name_snapshot ON pod_info(Name, SnapshotTimestamp)`
+const CreatePodInfoDeletionIndex = `CREATE INDEX IF NOT EXISTS pod_info_deletion ON pod_info(DeletionTimestamp)`
+const CreateNodeInfoNameHashIndex = `CREATE INDEX IF NOT EXISTS node_info_name_hash ON node_info(Name, Hash)`
+const CreateNodeInfoCreationIndex = `CREATE INDEX IF NOT EXISTS node_info_creation ON node_info(CreationTimestamp)`
+const CreateNodeInfoDeletionIndex = `CREATE INDEX IF NOT EXISTS node_info_deletion ON node_info(DeletionTimestamp)`
+const CreateMCDInfoNameSnapshotIndex = `CREATE INDEX IF NOT EXISTS mcd_info_name_snapshot ON mcd_info(Name, SnapshotTimestamp)`
+const CreateMCDInfoDeletionIndex = `CREATE INDEX IF NOT EXISTS mcd_info_deletion ON mcd_info(DeletionTimestamp)`
+const CreateMCCInfoNameSnapshotIndex = `CREATE INDEX IF NOT EXISTS mcc_info_name_snapshot ON mcc_info(Name, SnapshotTimestamp)`
+const CreateWorkerPoolInfoNameSnapshotIndex = `CREATE INDEX IF NOT EXISTS worker_pool_info_name_snapshot ON worker_pool_info(Name, SnapshotTimestamp)`
+const CreatePriorityClassInfoNameSnapshotIndex = `CREATE INDEX IF NOT EXISTS pc_info_name_snapshot ON pc_info(Name, SnapshotTimestamp)`
+const CreatePriorityClassInfoUIDHashIndex = `CREATE INDEX IF NOT EXISTS pc_info_uid_hash ON pc_info(UID, Hash)`
+const CreateCANodeGroupStatusInfoNameSnapshotIndex = `CREATE INDEX IF NOT EXISTS ca_nodegroup_status_info_name_snapshot ON ca_nodegroup_status_info(Name, SnapshotTimestamp)`
+const CreateCASettingsInfoSnapshotIndex = `CREATE INDEX IF NOT EXISTS ca_settings_info_snapshot ON ca_settings_info(SnapshotTimestamp)`
+const CreateCAArgsInfoSnapshotIndex = `CREATE INDEX IF NOT EXISTS ca_args_info_snapshot ON ca_args_info(SnapshotTimestamp)`
+
+// Checkpoints. A checkpoint stores for each checkpointed table the RowIDs of the latest row of every UID or Name
+// up to the HighWaterRowID, the highest RowID of the table when the checkpoint was taken. All these rows have a
+// SnapshotTimestamp at or before the SnapshotTimestamp of the checkpoint, so the latest rows at a later time are the
+// latest of the checkpointed rows and of the rows after the HighWaterRowID. Rows deleted before the checkpoint are not
+// stored for the tables whose point-in-time queries filter them.
+const CreateCheckpointInfoTable = `CREATE TABLE IF NOT EXISTS checkpoint_info(
+    CheckpointID INTEGER PRIMARY KEY AUTOINCREMENT,
+    SnapshotTimestamp INT NOT NULL,
+    RowCount INT NOT NULL)`
+
+const CreateCheckpointTableInfoTable = `CREATE TABLE IF NOT EXISTS checkpoint_table_info(
+    CheckpointID INT NOT NULL,
+    TableName TEXT NOT NULL,
+    HighWaterRowID INT NOT NULL,
+    RowIDs TEXT NOT NULL,
+    PRIMARY KEY (CheckpointID, TableName))`
+
+const InsertCheckpointInfo = `INSERT INTO checkpoint_info(SnapshotTimestamp, RowCount) VALUES (?, ?)`
+const InsertCheckpointTableInfo = `INSERT INTO checkpoint_table_info(CheckpointID, TableName, HighWaterRowID, RowIDs) VALUES (?, ?, ?, ?)`
+const SelectCheckpointInfos = `SELECT * FROM checkpoint_info ORDER BY SnapshotTimestamp, CheckpointID`
+
+// The delete checkpoint queries delete all but the latest checkpoints, whose number is given as parameter.
+const DeleteCheckpointTableInfosBeyondRetention = `DELETE FROM checkpoint_table_info WHERE CheckpointID NOT IN (
+    SELECT CheckpointID FROM checkpoint_info ORDER BY SnapshotTimestamp DESC, CheckpointID DESC LIMIT ?)`
+const DeleteCheckpointInfosBeyondRetention = `DELETE FROM checkpoint_info WHERE CheckpointID NOT IN (
+    SELECT CheckpointID FROM checkpoint_info ORDER BY SnapshotTimestamp DESC, CheckpointID DESC LIMIT ?)`
+
+const SelectLatestCheckpointTableInfoBefore = `SELECT t.* FROM checkpoint_table_info AS t
+    JOIN checkpoint_info AS c ON c.CheckpointID = t.CheckpointID
+    WHERE t.TableName = ? AND c.SnapshotTimestamp <= ? ORDER BY c.SnapshotTimestamp DESC, c.CheckpointID DESC LIMIT 1`
+
+const SelectCheckpointHighWaterRowIDs = `SELECT
+    (SELECT coalesce(max(RowID), 0) FROM pod_info),
+    (SELECT coalesce(max(RowID), 0) FROM mcd_info),
+    (SELECT coalesce(max(RowID), 0) FROM mcc_info),
+    (SELECT coalesce(max(RowID), 0) FROM worker_pool_info),
+    (SELECT coalesce(max(RowID), 0) FROM pc_info),
+    (SELECT coalesce(max(RowID), 0) FROM ca_nodegroup_status_info)`
+
+// The checkpoint row queries select the latest rows up to the HighWaterRowID given as first parameter. The pod and
+// priority class queries skip the rows deleted before the checkpoint SnapshotTimestamp given as second parameter.
+const SelectPodCheckpointRows = `SELECT RowID, SnapshotTimestamp FROM pod_info WHERE RowID IN (
+    SELECT (SELECT RowID FROM pod_info AS p WHERE p.UID = uids.UID AND p.RowID <= ?
+        ORDER BY p.SnapshotTimestamp DESC, p.RowID DESC LIMIT 1)
+    FROM (SELECT DISTINCT UID FROM pod_info) AS uids) AND (DeletionTimestamp IS NULL OR DeletionTimestamp >= ?)`
+const SelectMCDCheckpointRows = `SELECT RowID, SnapshotTimestamp FROM mcd_info WHERE RowID IN (
+    SELECT (SELECT RowID FROM mcd_info AS m WHERE m.Name = names.Name AND m.RowID <= ?
+        ORDER BY m.SnapshotTimestamp DESC, m.RowID DESC LIMIT 1)
+    FROM (SELECT DISTINCT Name FROM mcd_info) AS names)`
+const SelectMCCCheckpointRows = `SELECT RowID, SnapshotTimestamp FROM mcc_info WHERE RowID IN (
+    SELECT (SELECT RowID FROM mcc_info AS m WHERE m.Name = names.Name AND m.RowID <= ?
+        ORDER BY m.SnapshotTimestamp DESC, m.RowID DESC LIMIT 1)
+    FROM (SELECT DISTINCT Name FROM mcc_info) AS names)`
+const SelectWorkerPoolCheckpointRows = `SELECT RowID, SnapshotTimestamp FROM worker_pool_info WHERE RowID IN (
+    SELECT (SELECT RowID FROM worker_pool_info AS w WHERE w.Name = names.Name AND w.RowID <= ?
+        ORDER BY w.SnapshotTimestamp DESC, w.RowID DESC LIMIT 1)
+    FROM (SELECT DISTINCT Name FROM worker_pool_info) AS names)`
+const SelectPriorityClassCheckpointRows = `SELECT RowID, SnapshotTimestamp FROM pc_info WHERE RowID IN (
+    SELECT (SELECT RowID FROM pc_info AS p WHERE p.Name = names.Name AND p.RowID <= ?
+        ORDER BY p.SnapshotTimestamp DESC, p.RowID DESC LIMIT 1)
+    FROM (SELECT DISTINCT Name FROM pc_info) AS names) AND (DeletionTimestamp IS NULL OR DeletionTimestamp >= ?)`
+const SelectCANodeGroupStatusCheckpointRows = `SELECT RowID, SnapshotTimestamp FROM ca_nodegroup_status_info WHERE RowID IN (
+    SELECT max(RowID) FROM ca_nodegroup_status_info WHERE RowID <= ? GROUP BY Name)`
+
+// The point-in-time queries starting from a checkpoint take the RowIDs of the checkpoint as JSON array and its
+// HighWaterRowID as first parameters, followed by the parameters of the point-in-time query.
+const SelectLatestPodsBeforeSnapshotTimestampFromCheckpoint = `SELECT pod_info.*, pod_spec.Spec FROM pod_info JOIN pod_spec USING (SpecHash)
+    WHERE pod_info.RowID IN (
+    SELECT RowID FROM (
+        SELECT RowID, row_number() OVER (PARTITION BY UID ORDER BY SnapshotTimestamp DESC, RowID DESC) AS RowNumber
+        FROM pod_info WHERE RowID IN (SELECT value FROM json_each(?)) OR (RowID > ? AND SnapshotTimestamp <= ?))
+    WHERE RowNumber = 1) AND (DeletionTimestamp IS NULL OR DeletionTimestamp >= ?) ORDER BY UID`
+const SelectLatestMCDInfoBeforeFromCheckpoint = `SELECT * FROM mcd_info WHERE RowID IN (
+    SELECT RowID FROM (
+        SELECT RowID, row_number() OVER (PARTITION BY Name ORDER BY SnapshotTimestamp DESC, RowID DESC) AS RowNumber
+        FROM mcd_info WHERE RowID IN (SELECT value FROM json_each(?)) OR (RowID > ? AND SnapshotTimestamp <= ?))
+    WHERE RowNumber = 1) ORDER BY Name`
+const SelectLatestMCCInfoBeforeFromCheckpoint = `SELECT * FROM mcc_info WHERE RowID IN (
+    SELECT RowID FROM (
+        SELECT RowID, row_number() OVER (PARTITION BY Name ORDER BY SnapshotTimestamp DESC, RowID DESC) AS RowNumber
+        FROM mcc_info WHERE RowID IN (SELECT value FROM json_each(?)) OR (RowID > ? AND SnapshotTimestamp <= ?))
+    WHERE RowNumber = 1) ORDER BY Name`
+const SelectWorkerPoolInfoBeforeFromCheckpoint = `SELECT * FROM worker_pool_info WHERE RowID IN (
+    SELECT RowID FROM (
+        SELECT RowID, row_number() OVER (PARTITION BY Name ORDER BY SnapshotTimestamp DESC, RowID DESC) AS RowNumber
+        FROM worker_pool_info WHERE RowID IN (SELECT value FROM json_each(?)) OR (RowID > ? AND SnapshotTimestamp <= ?))
+    WHERE RowNumber = 1) ORDER BY Name`
+const SelectLatestPriorityClassInfoBeforeSnapshotTimestampFromCheckpoint = `SELECT * FROM pc_info WHERE RowID IN (
+    SELECT RowID FROM (
+        SELECT RowID, row_number() OVER (PARTITION BY Name ORDER BY SnapshotTimestamp DESC, RowID DESC) AS RowNumber
+        FROM pc_info WHERE RowID IN (SELECT value FROM json_each(?)) OR (RowID > ? AND SnapshotTimestamp <= ?))
+    WHERE RowNumber = 1) AND (DeletionTimestamp IS NULL OR DeletionTimestamp >= ?) ORDER BY Name`
+const SelectLatestCANodeGroupStatusInfosBeforeFromCheckpoint = `SELECT * FROM ca_nodegroup_status_info WHERE RowID IN (
+    SELECT max(RowID) FROM ca_nodegroup_status_info
+    WHERE RowID IN (SELECT value FROM json_each(?)) OR (RowID > ? AND SnapshotTimestamp <= ?) GROUP BY Name) ORDER BY Name`
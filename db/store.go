@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	gsh "github.com/elankath/gardener-scaling-history"
+	gst "github.com/elankath/gardener-scaling-types"
+	"io"
+	"k8s.io/apimachinery/pkg/types"
+	"time"
+)
+
+// Store is the storage of the recorded scaling history of a cluster. DataAccess implements it with a SQLite data db
+// per cluster (NewDataAccess, NewReadOnlyDataAccess) and PostgresDataAccess with a PostgreSQL data db shared by many
+// clusters (NewPostgresDataAccess).
+type Store interface {
+	io.Closer
+	// Init opens the store and creates its schema unless the store is read-only.
+	Init() error
+	// CheckWritable checks that the store is open and writable.
+	CheckWritable(ctx context.Context) error
+
+	InsertRecorderStartTime(startTime time.Time) error
+	GetInitialRecorderStartTime() (startTime time.Time, err error)
+	StartRecorderSession(beginTime time.Time) (sessionID int64, err error)
+	UpdateRecorderSessionHeartbeat(sessionID int64, heartbeatTime time.Time) error
+	EndRecorderSession(sessionID int64, endTime time.Time) error
+	LoadRecorderSessionInfos() ([]gsh.RecorderSessionInfo, error)
+	StoreWatchDisconnectInfo(w gsh.WatchDisconnectInfo) (rowID int64, err error)
+	LoadWatchDisconnectInfos() ([]gsh.WatchDisconnectInfo, error)
+	GetRecordingGaps() ([]gsh.RecordingGap, error)
+	StoreCheckpoint(now time.Time) (gsh.CheckpointInfo, error)
+	PruneCheckpoints(retention int) (deleted int64, err error)
+	LoadCheckpointInfos() ([]gsh.CheckpointInfo, error)
+
+	QueuePodInfo(podInfo gst.PodInfo, inferredAtRestart bool, onDone func(WriteResult)) error
+	QueueNodeInfo(n gst.NodeInfo, inferredAtRestart bool, onDone func(WriteResult)) error
+	QueueEventInfo(event gst.EventInfo, onDone func(WriteResult)) error
+	QueueNodeScaleDownActivityInfo(a gsh.NodeScaleDownActivityInfo, onDone func(WriteResult)) error
+	QueueFailedSchedulingInfo(f gsh.FailedSchedulingInfo, onDone func(WriteResult)) error
+	QueueCAScalingEventInfo(c gsh.CAScalingEventInfo, onDone func(WriteResult)) error
+	QueuePodDeletionTimestamp(podUID types.UID, deletionTimestamp time.Time, onDone func(WriteResult)) error
+	QueueNodeInfoDeletionTimestamp(name string, deletionTimestamp time.Time, onDone func(WriteResult)) error
+	FlushWrites() error
+	WritePipelineStats() WritePipelineStats
+
+	StorePodInfo(podInfo gst.PodInfo) (int64, error)
+	CountPodInfoWithSpecHash(uid, hash string) (int, error)
+	UpdatePodDeletionTimestamp(podUID types.UID, deletionTimestamp time.Time) (updated int64, err error)
+	LoadLatestPodInfoWithName(podName string) (podInfo gst.PodInfo, err error)
+	GetLatestUnscheduledPodsBeforeTimestamp(timeStamp time.Time) (podInfos []gst.PodInfo, err error)
+	GetLatestPodInfosBeforeSnapshotTime(snapshotTime time.Time) (pods []gst.PodInfo, err error)
+	GetLatestScheduledPodsBeforeTimestamp(timestamp time.Time) (pods []gst.PodInfo, err error)
+
+	StoreNodeInfo(n gst.NodeInfo) (rowID int64, err error)
+	CountNodeInfoWithHash(name, hash string) (int, error)
+	UpdateNodeInfoDeletionTimestamp(name string, deletionTimestamp time.Time) (updated int64, err error)
+	LoadNodeInfosBefore(creationTimestamp time.Time) ([]gst.NodeInfo, error)
+	GetLatestNodesBeforeAndNotDeleted(timestamp time.Time) ([]gst.NodeInfo, error)
+	LoadNodeDeletionTimestamps() (map[string]time.Time, error)
+
+	StorePriorityClassInfo(pcInfo gst.PriorityClassInfo) (int64, error)
+	CountPCInfoWithSpecHash(uid, hash string) (int, error)
+	LoadLatestPriorityClassInfoBeforeSnapshotTime(snapshotTime time.Time) (pcInfos []gst.PriorityClassInfo, err error)
+
+	StoreEventInfo(event gst.EventInfo) error
+	LoadEventInfoWithUID(eventUID string) (eventInfo gst.EventInfo, err error)
+	LoadAllEvents() (events []gst.EventInfo, err error)
+
+	StoreWorkerPoolInfo(w gst.WorkerPoolInfo) (rowID int64, err error)
+	LoadAllWorkerPoolInfoHashes() (map[string]string, error)
+	LoadWorkerPoolInfosBefore(snapshotTimestamp time.Time) ([]gst.WorkerPoolInfo, error)
+
+	StoreMachineDeploymentInfo(m gst.MachineDeploymentInfo) (rowID int64, err error)
+	GetMachineDeploymentInfoHash(name string) (string, error)
+	UpdateMCDInfoDeletionTimestamp(name string, deletionTimestamp time.Time) (updated int64, err error)
+	LoadLatestMachineDeploymentInfo(name string) (mcdInfo gst.MachineDeploymentInfo, err error)
+	LoadMachineDeploymentInfosBefore(snapshotTimestamp time.Time) ([]gst.MachineDeploymentInfo, error)
+
+	StoreMachineClassInfo(m gsh.MachineClassInfo) (rowID int64, err error)
+	GetMachineClassInfoHash(name string) (string, error)
+	UpdateMCCInfoDeletionTimestamp(name string, deletionTimestamp time.Time) (updated int64, err error)
+	LoadLatestMachineClassInfo(name string) (mccInfo gsh.MachineClassInfo, err error)
+	LoadMachineClassInfosBefore(snapshotTimestamp time.Time) ([]gsh.MachineClassInfo, error)
+
+	StoreCASettingsInfo(caSettings gst.CASettingsInfo) (int64, error)
+	GetLatestCASettingsInfo() (caDeployment *gst.CASettingsInfo, err error)
+	GetCADeploymentWithHash(Hash string) (caDeployment *gst.CASettingsInfo, err error)
+	LoadCASettingsBefore(timestamp time.Time) (caSettings gst.CASettingsInfo, err error)
+
+	StoreCAArgsInfo(caArgs gsh.CAArgsInfo) (int64, error)
+	GetLatestCAArgsInfo() (*gsh.CAArgsInfo, error)
+	LoadCAArgsInfoBefore(timestamp time.Time) (gsh.CAArgsInfo, error)
+
+	StoreCANodeGroupStatusInfo(s gsh.CANodeGroupStatusInfo) (rowID int64, err error)
+	LoadLatestCANodeGroupStatusInfoHashes() (map[string]string, error)
+	LoadCANodeGroupStatusInfosBefore(snapshotTimestamp time.Time) ([]gsh.CANodeGroupStatusInfo, error)
+
+	StoreNodeScaleDownActivityInfo(a gsh.NodeScaleDownActivityInfo) (rowID int64, err error)
+	LoadNodeScaleDownActivityInfosBetween(startTime, endTime time.Time) ([]gsh.NodeScaleDownActivityInfo, error)
+
+	StoreFailedSchedulingInfo(f gsh.FailedSchedulingInfo) error
+	LoadFailedSchedulingInfosBetween(startTime, endTime time.Time) ([]gsh.FailedSchedulingInfo, error)
+	LoadFailedSchedulingInfosForPod(podUID string) ([]gsh.FailedSchedulingInfo, error)
+	LoadFailedSchedulingReasonCountsBetween(startTime, endTime time.Time, zone string) ([]gsh.FailedSchedulingReasonCount, error)
+
+	StoreCAScalingEventInfo(c gsh.CAScalingEventInfo) (rowID int64, err error)
+	LoadCAScalingEventInfosBetween(startTime, endTime time.Time) ([]gsh.CAScalingEventInfo, error)
+	LoadCAScalingEventInfosForPod(podUID string) ([]gsh.CAScalingEventInfo, error)
+	LoadCAScalingEventInfosForNodeGroup(nodeGroupName string) ([]gsh.CAScalingEventInfo, error)
+
+	LoadPodUIDsNotDeleted() ([]string, error)
+	LoadNodeNamesNotDeleted() ([]string, error)
+	LoadMCDNamesNotDeleted() ([]string, error)
+	UpdatePodDeletionInferredAtRestart(podUID string, restartTime time.Time) (updated int64, err error)
+	UpdateNodeDeletionInferredAtRestart(name string, restartTime time.Time) (updated int64, err error)
+	UpdateMCDDeletionInferredAtRestart(name string, restartTime time.Time) (updated int64, err error)
+	MarkMCDInfoInferredAtRestart(rowID int64) error
+}
+
+var _ Store = (*DataAccess)(nil)
+
+// GetRecordingEndTime returns the latest end or heartbeat time of the recorder sessions of the given store, or else the
+// current time.
+func GetRecordingEndTime(store Store) (time.Time, error) {
+	sessionInfos, err := store.LoadRecorderSessionInfos()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot load the recorder sessions: %w", err)
+	}
+	var endTime time.Time
+	for _, s := range sessionInfos {
+		for _, t := range []time.Time{s.HeartbeatTime, s.EndTime} {
+			if t.After(endTime) {
+				endTime = t
+			}
+		}
+	}
+	if endTime.IsZero() {
+		endTime = time.Now()
+	}
+	return endTime.UTC(), nil
+}
@@ -0,0 +1,310 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultWriteQueueSize is the number of queued writes after which queueing blocks.
+	DefaultWriteQueueSize = 1024
+	// DefaultWriteBatchSize is the maximum number of queued writes committed in a single transaction.
+	DefaultWriteBatchSize = 256
+	// DefaultWriteFlushInterval is the maximum time a queued write waits for its batch to fill up.
+	DefaultWriteFlushInterval = time.Second
+)
+
+// ErrWritePipelineClosed is returned when queueing a write after the DataAccess was closed.
+var ErrWritePipelineClosed = errors.New("write pipeline is closed")
+
+// WriteResult is the outcome of a queued write. For an insert, Duplicate is set if the row was skipped since an
+// identical row is already stored and RowID is set for a stored row. For an update, RowsAffected is the number of
+// updated rows.
+type WriteResult struct {
+	RowID        int64
+	RowsAffected int64
+	Duplicate    bool
+	Err          error
+}
+
+// WritePipelineStats are the cumulative statistics of the write pipeline of a DataAccess.
+type WritePipelineStats struct {
+	QueueLength   int
+	QueueCapacity int
+	// Queued is the number of writes queued so far.
+	Queued uint64
+	// Blocked is the number of writes which had to wait since the queue was full.
+	Blocked uint64
+	// BlockedDuration is the total time writes waited for space in the queue.
+	BlockedDuration time.Duration
+	// Batches is the number of committed or failed batches.
+	Batches uint64
+	// Written is the number of writes executed in committed batches, including duplicates.
+	Written uint64
+	// Failed is the number of writes which failed.
+	Failed uint64
+	// FlushDuration is the total time spent executing and committing batches.
+	FlushDuration time.Duration
+}
+
+type writeOp struct {
+	table  string
+	stmt   *statement
+	args   []any
+	update bool
+	onDone func(WriteResult)
+	// pre is executed before the write, for example to insert a row referenced by the write. If it fails, the write
+	// is not executed and fails.
+	pre *writeOp
+	// synced is set for a sync marker instead of a write. It is closed once the writes queued before it are flushed.
+	synced chan struct{}
+}
+
+// writePipeline executes queued writes on a single goroutine in batched transactions which are committed when the
+// batch is full or the flush interval has passed.
+type writePipeline struct {
+	db            *sql.DB
+	queue         chan writeOp
+	batchSize     int
+	flushInterval time.Duration
+	// savepoints is set if each write is executed in a savepoint so that a failed write does not abort the batch.
+	savepoints bool
+	// mu guards closed. Writers hold a read lock while queueing so that close waits for them.
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+
+	queued          atomic.Uint64
+	blocked         atomic.Uint64
+	blockedDuration atomic.Int64
+	batches         atomic.Uint64
+	written         atomic.Uint64
+	failed          atomic.Uint64
+	flushDuration   atomic.Int64
+}
+
+func newWritePipeline(db *sql.DB, queueSize, batchSize int, flushInterval time.Duration, savepoints bool) *writePipeline {
+	p := &writePipeline{
+		db:            db,
+		savepoints:    savepoints,
+		queue:         make(chan writeOp, queueSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		done:          make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// enqueue queues the given write, blocking while the queue is full.
+func (p *writePipeline) enqueue(op writeOp) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return fmt.Errorf("cannot queue write into %s: %w", op.table, ErrWritePipelineClosed)
+	}
+	p.queued.Add(1)
+	select {
+	case p.queue <- op:
+		return nil
+	default:
+	}
+	p.blocked.Add(1)
+	start := time.Now()
+	p.queue <- op
+	p.blockedDuration.Add(int64(time.Since(start)))
+	return nil
+}
+
+// sync blocks until all writes queued before it have been flushed.
+func (p *writePipeline) sync() error {
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return fmt.Errorf("cannot sync writes: %w", ErrWritePipelineClosed)
+	}
+	synced := make(chan struct{})
+	p.queue <- writeOp{synced: synced}
+	p.mu.RUnlock()
+	<-synced
+	return nil
+}
+
+// close flushes all queued writes and stops the pipeline. Further writes are rejected with ErrWritePipelineClosed.
+func (p *writePipeline) close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.queue)
+	p.mu.Unlock()
+	<-p.done
+}
+
+func (p *writePipeline) stats() WritePipelineStats {
+	return WritePipelineStats{
+		QueueLength:     len(p.queue),
+		QueueCapacity:   cap(p.queue),
+		Queued:          p.queued.Load(),
+		Blocked:         p.blocked.Load(),
+		BlockedDuration: time.Duration(p.blockedDuration.Load()),
+		Batches:         p.batches.Load(),
+		Written:         p.written.Load(),
+		Failed:          p.failed.Load(),
+		FlushDuration:   time.Duration(p.flushDuration.Load()),
+	}
+}
+
+func (p *writePipeline) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+	batch := make([]writeOp, 0, p.batchSize)
+	for {
+		select {
+		case op, ok := <-p.queue:
+			if !ok {
+				p.flush(batch)
+				return
+			}
+			if op.synced != nil {
+				p.flush(batch)
+				batch = batch[:0]
+				close(op.synced)
+				continue
+			}
+			batch = append(batch, op)
+			if len(batch) >= p.batchSize {
+				p.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				p.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// flush executes the given batch in a single transaction and reports the result of each write once the transaction
+// is committed. A failed write does not fail the others, but a failed commit fails all of them.
+func (p *writePipeline) flush(batch []writeOp) {
+	if len(batch) == 0 {
+		return
+	}
+	start := time.Now()
+	results := make([]WriteResult, len(batch))
+	err := p.execBatch(batch, results)
+	if err != nil {
+		slog.Error("cannot commit batch of writes", "batchSize", len(batch), "error", err)
+		for i := range results {
+			results[i] = WriteResult{Err: err}
+		}
+	}
+	p.batches.Add(1)
+	p.flushDuration.Add(int64(time.Since(start)))
+	for i, op := range batch {
+		if results[i].Err != nil {
+			p.failed.Add(1)
+		} else {
+			p.written.Add(1)
+		}
+		if op.onDone != nil {
+			op.onDone(results[i])
+		}
+	}
+	slog.Debug("flushed batch of writes", "batchSize", len(batch), "duration", time.Since(start))
+}
+
+func (p *writePipeline) execBatch(batch []writeOp, results []WriteResult) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("cannot begin transaction: %w", err)
+	}
+	txStmts := make(map[*statement]*sql.Stmt)
+	txStmt := func(stmt *statement) *sql.Stmt {
+		s, ok := txStmts[stmt]
+		if !ok {
+			s = tx.Stmt(stmt.Stmt)
+			txStmts[stmt] = s
+		}
+		return s
+	}
+	for i, op := range batch {
+		if p.savepoints {
+			results[i], err = execWriteInSavepoint(tx, txStmt, op)
+			if err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+			continue
+		}
+		results[i] = execWrite(txStmt, op)
+	}
+	err = tx.Commit()
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("cannot commit transaction: %w", err)
+	}
+	return nil
+}
+
+// execWriteInSavepoint executes the given write within a savepoint which is rolled back if the write fails. A failed
+// statement aborts a PostgreSQL transaction, so this keeps a failed write from failing the rest of the batch.
+func execWriteInSavepoint(tx *sql.Tx, txStmt func(*statement) *sql.Stmt, op writeOp) (result WriteResult, err error) {
+	_, err = tx.Exec("SAVEPOINT write_op")
+	if err != nil {
+		return result, fmt.Errorf("cannot create savepoint: %w", err)
+	}
+	result = execWrite(txStmt, op)
+	if result.Err != nil {
+		_, err = tx.Exec("ROLLBACK TO SAVEPOINT write_op")
+	} else {
+		_, err = tx.Exec("RELEASE SAVEPOINT write_op")
+	}
+	if err != nil {
+		return result, fmt.Errorf("cannot end savepoint: %w", err)
+	}
+	return result, nil
+}
+
+// execWrite executes the given write with the statements bound to the transaction by txStmt.
+func execWrite(txStmt func(*statement) *sql.Stmt, op writeOp) (result WriteResult) {
+	if op.pre != nil {
+		_, err := op.pre.stmt.execOn(txStmt(op.pre.stmt), op.pre.args)
+		if err != nil {
+			result.Err = fmt.Errorf("cannot write into %s: %w", op.pre.table, err)
+			return
+		}
+	}
+	sqlResult, err := op.stmt.execOn(txStmt(op.stmt), op.args)
+	if err != nil {
+		result.Err = fmt.Errorf("cannot write into %s: %w", op.table, err)
+		return
+	}
+	result.RowsAffected, err = sqlResult.RowsAffected()
+	if err != nil {
+		result.Err = fmt.Errorf("cannot get rows affected of write into %s: %w", op.table, err)
+		return
+	}
+	if op.update {
+		return
+	}
+	if result.RowsAffected == 0 {
+		result.Duplicate = true
+		return
+	}
+	result.RowID, err = sqlResult.LastInsertId()
+	if err != nil {
+		result.Err = fmt.Errorf("cannot get rowID of write into %s: %w", op.table, err)
+	}
+	return
+}
@@ -0,0 +1,734 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	gsh "github.com/elankath/gardener-scaling-history"
+	"github.com/elankath/gardener-scaling-history/db"
+	"github.com/elankath/gardener-scaling-history/recorder"
+	gst "github.com/elankath/gardener-scaling-types"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/utils/ptr"
+	"log/slog"
+	"maps"
+	"math"
+	"math/rand"
+	"os"
+	"slices"
+	"time"
+)
+
+const (
+	DefaultPodNamespace  = "default"
+	DefaultArchitecture  = "amd64"
+	DefaultDiurnalPeriod = 24 * time.Hour
+	DefaultDiurnalStep   = 5 * time.Minute
+	podImage             = "registry.k8s.io/pause:3.5"
+	// nameSuffixChars are the characters of the random suffix k8s appends to generated names.
+	nameSuffixChars = "bcdfghjklmnpqrstvwxz2456789"
+)
+
+// zoneLabel is the zone label of the machine deployments and nodes of the synthetic cluster.
+var zoneLabel = gsh.ZoneLabels[len(gsh.ZoneLabels)-1]
+
+var defaultPodsCapacity = resource.MustParse("110")
+
+// GenerateRecording loads the scenario at params.ScenarioPath and writes its recording into a new data db.
+func GenerateRecording(params gsh.GeneratorParams) error {
+	scenario, err := LoadScenario(params.ScenarioPath)
+	if err != nil {
+		return err
+	}
+	var store db.Store
+	if params.PostgresDSN != "" {
+		store = db.NewPostgresDataAccess(params.PostgresDSN, params.ClusterID)
+	} else {
+		if _, err = os.Stat(params.DBPath); err == nil {
+			return fmt.Errorf("data db %q already exists", params.DBPath)
+		}
+		store = db.NewDataAccess(params.DBPath)
+	}
+	err = store.Init()
+	if err != nil {
+		return fmt.Errorf("cannot initialize data db: %w", err)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+	return Generate(scenario, store)
+}
+
+// LoadScenario reads the WorkloadScenario from the YAML or JSON file at scenarioPath, applies its defaults and
+// validates it.
+func LoadScenario(scenarioPath string) (scenario gsh.WorkloadScenario, err error) {
+	data, err := os.ReadFile(scenarioPath)
+	if err != nil {
+		err = fmt.Errorf("cannot read scenario %q: %w", scenarioPath, err)
+		return
+	}
+	err = yaml.UnmarshalStrict(data, &scenario)
+	if err != nil {
+		err = fmt.Errorf("cannot parse scenario %q: %w", scenarioPath, err)
+		return
+	}
+	applyScenarioDefaults(&scenario)
+	err = validateScenario(scenario)
+	if err != nil {
+		err = fmt.Errorf("invalid scenario %q: %w", scenarioPath, err)
+	}
+	return
+}
+
+func applyScenarioDefaults(scenario *gsh.WorkloadScenario) {
+	scenario.StartTime = scenario.StartTime.UTC()
+	for i := range scenario.WorkerPools {
+		pool := &scenario.WorkerPools[i]
+		if pool.Architecture == "" {
+			pool.Architecture = DefaultArchitecture
+		}
+		if pool.Capacity != nil {
+			if _, ok := pool.Capacity[corev1.ResourcePods]; !ok {
+				pool.Capacity[corev1.ResourcePods] = defaultPodsCapacity
+			}
+		}
+		if pool.Allocatable == nil {
+			pool.Allocatable = pool.Capacity.DeepCopy()
+		}
+		if _, ok := pool.Allocatable[corev1.ResourcePods]; !ok && pool.Allocatable != nil {
+			pool.Allocatable[corev1.ResourcePods] = pool.Capacity[corev1.ResourcePods]
+		}
+	}
+	for i := range scenario.Workloads {
+		workload := &scenario.Workloads[i]
+		if workload.Namespace == "" {
+			workload.Namespace = DefaultPodNamespace
+		}
+		if workload.Arrival.Type == gsh.DiurnalArrival {
+			if workload.Arrival.Period.Duration == 0 {
+				workload.Arrival.Period.Duration = DefaultDiurnalPeriod
+			}
+			if workload.Arrival.Step.Duration == 0 {
+				workload.Arrival.Step.Duration = DefaultDiurnalStep
+			}
+		}
+		for name, distribution := range workload.Requests {
+			if distribution.Distribution == "" {
+				distribution.Distribution = gsh.UniformDistribution
+				workload.Requests[name] = distribution
+			}
+		}
+	}
+}
+
+func validateScenario(scenario gsh.WorkloadScenario) error {
+	if scenario.Name == "" || scenario.Namespace == "" {
+		return errors.New("name and namespace must be set")
+	}
+	if scenario.StartTime.IsZero() || scenario.Duration.Duration <= 0 {
+		return errors.New("startTime and a positive duration must be set")
+	}
+	if len(scenario.WorkerPools) == 0 {
+		return errors.New("at least one worker pool must be set")
+	}
+	priorityClassNames := make(map[string]bool)
+	for _, pc := range scenario.PriorityClasses {
+		priorityClassNames[pc.Name] = true
+	}
+	poolNames := make(map[string]bool)
+	for _, pool := range scenario.WorkerPools {
+		if pool.Name == "" || pool.MachineType == "" {
+			return fmt.Errorf("worker pool %q: name and machineType must be set", pool.Name)
+		}
+		if poolNames[pool.Name] {
+			return fmt.Errorf("worker pool %q is set twice", pool.Name)
+		}
+		poolNames[pool.Name] = true
+		if pool.Minimum < 0 || pool.Maximum < pool.Minimum {
+			return fmt.Errorf("worker pool %q: minimum %d and maximum %d are invalid", pool.Name, pool.Minimum, pool.Maximum)
+		}
+		if len(pool.Zones) == 0 {
+			return fmt.Errorf("worker pool %q: at least one zone must be set", pool.Name)
+		}
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			if _, ok := pool.Capacity[name]; !ok {
+				return fmt.Errorf("worker pool %q: capacity of %s must be set", pool.Name, name)
+			}
+		}
+	}
+	for _, workload := range scenario.Workloads {
+		if workload.Name == "" {
+			return errors.New("workload name must be set")
+		}
+		if workload.PoolName != "" && !poolNames[workload.PoolName] {
+			return fmt.Errorf("workload %q: unknown worker pool %q", workload.Name, workload.PoolName)
+		}
+		if workload.PriorityClassName != "" && !priorityClassNames[workload.PriorityClassName] {
+			return fmt.Errorf("workload %q: unknown priority class %q", workload.Name, workload.PriorityClassName)
+		}
+		if err := validateArrivalPattern(workload.Arrival); err != nil {
+			return fmt.Errorf("workload %q: %w", workload.Name, err)
+		}
+		for name, distribution := range workload.Requests {
+			if distribution.Distribution != gsh.UniformDistribution && distribution.Distribution != gsh.NormalDistribution {
+				return fmt.Errorf("workload %q: unknown distribution %q of %s requests", workload.Name, distribution.Distribution, name)
+			}
+			if !distribution.Max.IsZero() && distribution.Max.Cmp(distribution.Min) < 0 {
+				return fmt.Errorf("workload %q: max %s of %s requests is less than min %s", workload.Name, &distribution.Max, name, &distribution.Min)
+			}
+		}
+	}
+	return nil
+}
+
+func validateArrivalPattern(arrival gsh.ArrivalPattern) error {
+	switch arrival.Type {
+	case gsh.BurstArrival:
+		if arrival.Count <= 0 {
+			return errors.New("burst arrival needs a positive count")
+		}
+	case gsh.DiurnalArrival:
+		if arrival.MinReplicas < 0 || arrival.MaxReplicas < arrival.MinReplicas {
+			return fmt.Errorf("diurnal arrival minReplicas %d and maxReplicas %d are invalid", arrival.MinReplicas, arrival.MaxReplicas)
+		}
+	case gsh.BatchArrival:
+		if arrival.Count <= 0 || arrival.Lifetime.Duration <= 0 {
+			return errors.New("batch arrival needs a positive count and lifetime")
+		}
+	default:
+		return fmt.Errorf("unknown arrival type %q", arrival.Type)
+	}
+	return nil
+}
+
+// generator writes the recording of a scenario. It simulates the scheduling of the pods on the nodes of the synthetic
+// cluster by placing each pod on the first node it fits on. Pods that fit on no node are recorded as unscheduled and
+// are scheduled once enough pods leave. The synthetic cluster is never scaled, since that is up to the
+// cluster-autoscaler run against the recording.
+type generator struct {
+	scenario       gsh.WorkloadScenario
+	store          db.Store
+	rnd            *rand.Rand
+	endTime        time.Time
+	priorityValues map[string]int32
+	nodes          []*nodeState
+	pending        []*podState
+	podCount       int
+	// unscheduledCount is the number of pods that were unscheduled after their arrival.
+	unscheduledCount int
+}
+
+type nodeState struct {
+	info gst.NodeInfo
+	free corev1.ResourceList
+}
+
+type podState struct {
+	info      gst.PodInfo
+	arrival   time.Time
+	departure time.Time
+	node      *nodeState
+}
+
+type podEvent struct {
+	time    time.Time
+	arrival bool
+	pod     *podState
+}
+
+// Generate writes the recording of the given scenario into the given initialized store.
+func Generate(scenario gsh.WorkloadScenario, store db.Store) error {
+	g := &generator{
+		scenario:       scenario,
+		store:          store,
+		rnd:            rand.New(rand.NewSource(scenario.Seed)),
+		endTime:        scenario.StartTime.Add(scenario.Duration.Duration),
+		priorityValues: make(map[string]int32),
+	}
+	err := store.InsertRecorderStartTime(scenario.StartTime)
+	if err != nil {
+		return fmt.Errorf("cannot store recorder start time: %w", err)
+	}
+	sessionID, err := store.StartRecorderSession(scenario.StartTime)
+	if err != nil {
+		return fmt.Errorf("cannot store recorder session: %w", err)
+	}
+	err = g.storePriorityClasses()
+	if err != nil {
+		return err
+	}
+	err = g.storeCASettings()
+	if err != nil {
+		return err
+	}
+	err = g.storeWorkerPools()
+	if err != nil {
+		return err
+	}
+	err = g.storePods()
+	if err != nil {
+		return err
+	}
+	err = store.UpdateRecorderSessionHeartbeat(sessionID, g.endTime)
+	if err != nil {
+		return fmt.Errorf("cannot update recorder session heartbeat: %w", err)
+	}
+	err = store.EndRecorderSession(sessionID, g.endTime)
+	if err != nil {
+		return fmt.Errorf("cannot end recorder session: %w", err)
+	}
+	slog.Info("generated recording of scenario", "scenario", scenario.Name, "nodes", len(g.nodes), "pods", g.podCount,
+		"unscheduledPods", g.unscheduledCount, "unscheduledPodsAtEnd", len(g.pending))
+	return nil
+}
+
+func (g *generator) storePriorityClasses() error {
+	for _, pc := range g.scenario.PriorityClasses {
+		pcInfo := gst.PriorityClassInfo{
+			SnapshotTimestamp: g.scenario.StartTime,
+			PriorityClass: schedulingv1.PriorityClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              pc.Name,
+					UID:               g.newUID(),
+					CreationTimestamp: metav1.NewTime(g.scenario.StartTime),
+				},
+				Value:            pc.Value,
+				PreemptionPolicy: ptr.To(corev1.PreemptLowerPriority),
+			},
+		}
+		pcInfo.Hash = pcInfo.GetHash()
+		_, err := g.store.StorePriorityClassInfo(pcInfo)
+		if err != nil {
+			return fmt.Errorf("cannot store priority class %q: %w", pc.Name, err)
+		}
+		g.priorityValues[pc.Name] = pc.Value
+	}
+	return nil
+}
+
+func (g *generator) storeCASettings() error {
+	caArgs := gsh.CAArgsInfo{
+		SnapshotTimestamp: g.scenario.StartTime,
+		Args:              maps.Clone(g.scenario.CAArgs),
+	}
+	if caArgs.Args == nil {
+		caArgs.Args = make(map[string]string)
+	}
+	caArgs.Hash = caArgs.GetHash()
+	_, err := g.store.StoreCAArgsInfo(caArgs)
+	if err != nil {
+		return fmt.Errorf("cannot store ca args: %w", err)
+	}
+	caSettings, err := recorder.ParseCACommand(caArgs.Args)
+	if err != nil {
+		return fmt.Errorf("cannot parse ca args of scenario: %w", err)
+	}
+	caSettings.SnapshotTimestamp = g.scenario.StartTime
+	caSettings.Hash = caSettings.GetHash()
+	_, err = g.store.StoreCASettingsInfo(caSettings)
+	if err != nil {
+		return fmt.Errorf("cannot store ca settings: %w", err)
+	}
+	return nil
+}
+
+// storeWorkerPools stores the worker pools with a machine deployment and class per zone and the initial nodes of
+// the machine deployments.
+func (g *generator) storeWorkerPools() error {
+	ns := g.scenario.Namespace
+	startTime := g.scenario.StartTime
+	for _, pool := range g.scenario.WorkerPools {
+		wp := gst.WorkerPoolInfo{
+			SnapshotMeta: gst.SnapshotMeta{
+				CreationTimestamp: startTime,
+				SnapshotTimestamp: startTime,
+				Name:              pool.Name,
+				Namespace:         ns,
+			},
+			MachineType:    pool.MachineType,
+			Architecture:   pool.Architecture,
+			Minimum:        pool.Minimum,
+			Maximum:        pool.Maximum,
+			MaxSurge:       intstr.FromInt32(1),
+			MaxUnavailable: intstr.FromInt32(0),
+			Zones:          pool.Zones,
+		}
+		wp.Hash = wp.GetHash()
+		_, err := g.store.StoreWorkerPoolInfo(wp)
+		if err != nil {
+			return fmt.Errorf("cannot store worker pool %q: %w", pool.Name, err)
+		}
+		for i, zone := range pool.Zones {
+			mcdName := fmt.Sprintf("%s-%s-z%d", ns, pool.Name, i+1)
+			mccName := fmt.Sprintf("%s-%x", mcdName, sha256.Sum256([]byte(mcdName+pool.MachineType)))[:len(mcdName)+6]
+			mcc := gsh.MachineClassInfo{
+				SnapshotMeta: gst.SnapshotMeta{
+					CreationTimestamp: startTime,
+					SnapshotTimestamp: startTime,
+					Name:              mccName,
+					Namespace:         ns,
+				},
+				InstanceType: pool.MachineType,
+				PoolName:     pool.Name,
+				Region:       g.scenario.Region,
+				Zone:         zone,
+				Labels:       map[string]string{gsh.PoolLabelAlt: pool.Name},
+				Capacity:     pool.Capacity,
+			}
+			mcc.Hash = mcc.GetHash()
+			_, err = g.store.StoreMachineClassInfo(mcc)
+			if err != nil {
+				return fmt.Errorf("cannot store machine class %q: %w", mccName, err)
+			}
+			replicas := distributeOverZones(i, pool.Minimum, len(pool.Zones))
+			mcd := gst.MachineDeploymentInfo{
+				SnapshotMeta: gst.SnapshotMeta{
+					CreationTimestamp: startTime,
+					SnapshotTimestamp: startTime,
+					Name:              mcdName,
+					Namespace:         ns,
+				},
+				Replicas:         replicas,
+				PoolName:         pool.Name,
+				Zone:             zone,
+				MaxSurge:         intstr.FromInt32(1),
+				MaxUnavailable:   intstr.FromInt32(0),
+				MachineClassName: mccName,
+				Labels:           map[string]string{gsh.PoolLabel: pool.Name, zoneLabel: zone},
+			}
+			mcd.Hash = mcd.GetHash()
+			_, err = g.store.StoreMachineDeploymentInfo(mcd)
+			if err != nil {
+				return fmt.Errorf("cannot store machine deployment %q: %w", mcdName, err)
+			}
+			for range replicas {
+				err = g.storeNode(pool, zone, mccName)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (g *generator) storeNode(pool gsh.ScenarioWorkerPool, zone, mccName string) error {
+	name := mccName + "-" + g.nameSuffix()
+	n := gst.NodeInfo{
+		SnapshotMeta: gst.SnapshotMeta{
+			CreationTimestamp: g.scenario.StartTime,
+			SnapshotTimestamp: g.scenario.StartTime,
+			Name:              name,
+		},
+		ProviderID: fmt.Sprintf("synthetic:///%s/%s", zone, name),
+		Labels: map[string]string{
+			gsh.PoolLabel:                  pool.Name,
+			zoneLabel:                      zone,
+			corev1.LabelTopologyZone:       zone,
+			corev1.LabelTopologyRegion:     g.scenario.Region,
+			corev1.LabelInstanceTypeStable: pool.MachineType,
+			corev1.LabelArchStable:         pool.Architecture,
+			corev1.LabelHostname:           name,
+		},
+		Allocatable: pool.Allocatable,
+		Capacity:    pool.Capacity,
+	}
+	n.Hash = n.GetHash()
+	_, err := g.store.StoreNodeInfo(n)
+	if err != nil {
+		return fmt.Errorf("cannot store node %q: %w", name, err)
+	}
+	g.nodes = append(g.nodes, &nodeState{info: n, free: pool.Allocatable.DeepCopy()})
+	return nil
+}
+
+// storePods stores the arrivals, scheduling and departures of the pods of all workloads in time order. Departures
+// are stored before arrivals at the same time.
+func (g *generator) storePods() error {
+	var events []podEvent
+	for _, workload := range g.scenario.Workloads {
+		for _, p := range g.createPods(workload) {
+			events = append(events, podEvent{time: p.arrival, arrival: true, pod: p})
+			if !p.departure.IsZero() {
+				events = append(events, podEvent{time: p.departure, pod: p})
+			}
+		}
+	}
+	slices.SortStableFunc(events, func(a, b podEvent) int {
+		if c := a.time.Compare(b.time); c != 0 {
+			return c
+		}
+		if a.arrival == b.arrival {
+			return 0
+		}
+		if a.arrival {
+			return 1
+		}
+		return -1
+	})
+	for _, e := range events {
+		var err error
+		if e.arrival {
+			err = g.arrive(e.pod)
+		} else {
+			err = g.depart(e.pod)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createPods creates the pods of the given workload with their arrival and departure times. Pods that would arrive
+// after the end of the scenario are dropped and departures after it are cleared.
+func (g *generator) createPods(workload gsh.ScenarioWorkload) (pods []*podState) {
+	arrival := workload.Arrival
+	startTime := g.scenario.StartTime
+	addPod := func(name string, arrivalTime, departureTime time.Time) *podState {
+		if !arrivalTime.Before(g.endTime) {
+			return nil
+		}
+		if departureTime.After(g.endTime) {
+			departureTime = time.Time{}
+		}
+		p := g.newPod(workload, name, arrivalTime, departureTime)
+		pods = append(pods, p)
+		return p
+	}
+	lifetime := func(arrivalTime time.Time) time.Time {
+		if arrival.Lifetime.Duration <= 0 {
+			return time.Time{}
+		}
+		return arrivalTime.Add(arrival.Lifetime.Duration)
+	}
+	switch arrival.Type {
+	case gsh.BurstArrival:
+		for range arrival.Count {
+			arrivalTime := startTime.Add(arrival.At.Duration)
+			if arrival.Spread.Duration > 0 {
+				arrivalTime = arrivalTime.Add(time.Duration(g.rnd.Int63n(int64(arrival.Spread.Duration))).Truncate(time.Second))
+			}
+			addPod(workload.Name+"-"+g.nameSuffix(), arrivalTime, lifetime(arrivalTime))
+		}
+	case gsh.DiurnalArrival:
+		var alive []*podState
+		peakTime := startTime.Add(arrival.PeakAt.Duration)
+		for t := startTime; t.Before(g.endTime); t = t.Add(arrival.Step.Duration) {
+			phase := 2 * math.Pi * float64(t.Sub(peakTime)) / float64(arrival.Period.Duration)
+			replicas := arrival.MinReplicas + int(math.Round(float64(arrival.MaxReplicas-arrival.MinReplicas)*(1+math.Cos(phase))/2))
+			for len(alive) < replicas {
+				alive = append(alive, addPod(workload.Name+"-"+g.nameSuffix(), t, time.Time{}))
+			}
+			for len(alive) > replicas {
+				alive[len(alive)-1].departure = t
+				alive = alive[:len(alive)-1]
+			}
+		}
+	case gsh.BatchArrival:
+		for job, t := 0, startTime.Add(arrival.At.Duration); t.Before(g.endTime); job, t = job+1, t.Add(arrival.Every.Duration) {
+			for range arrival.Count {
+				addPod(fmt.Sprintf("%s-%d-%s", workload.Name, job, g.nameSuffix()), t, lifetime(t))
+			}
+			if arrival.Every.Duration <= 0 {
+				break
+			}
+		}
+	}
+	return
+}
+
+func (g *generator) newPod(workload gsh.ScenarioWorkload, name string, arrivalTime, departureTime time.Time) *podState {
+	resourceNames := make([]corev1.ResourceName, 0, len(workload.Requests))
+	for resourceName := range workload.Requests {
+		resourceNames = append(resourceNames, resourceName)
+	}
+	slices.Sort(resourceNames)
+	requests := make(corev1.ResourceList)
+	for _, resourceName := range resourceNames {
+		requests[resourceName] = g.sampleRequest(workload.Requests[resourceName])
+	}
+	spec := corev1.PodSpec{
+		Containers: []corev1.Container{{
+			Name:      "pause",
+			Image:     podImage,
+			Resources: corev1.ResourceRequirements{Requests: requests},
+		}},
+		TerminationGracePeriodSeconds: ptr.To[int64](0),
+		SchedulerName:                 corev1.DefaultSchedulerName,
+		PriorityClassName:             workload.PriorityClassName,
+	}
+	if workload.PriorityClassName != "" {
+		spec.Priority = ptr.To(g.priorityValues[workload.PriorityClassName])
+	}
+	if workload.PoolName != "" {
+		spec.NodeSelector = map[string]string{gsh.PoolLabel: workload.PoolName}
+	}
+	g.podCount++
+	return &podState{
+		info: gst.PodInfo{
+			SnapshotMeta: gst.SnapshotMeta{
+				CreationTimestamp: arrivalTime,
+				Name:              name,
+				Namespace:         workload.Namespace,
+			},
+			UID:      string(g.newUID()),
+			Labels:   map[string]string{"app": workload.Name},
+			Requests: gst.CumulatePodRequests(&corev1.Pod{Spec: spec}),
+			Spec:     spec,
+		},
+		arrival:   arrivalTime,
+		departure: departureTime,
+	}
+}
+
+// sampleRequest draws a request from the given distribution. Memory-like requests are rounded to MiB.
+func (g *generator) sampleRequest(distribution gsh.RequestDistribution) resource.Quantity {
+	if distribution.Max.IsZero() {
+		return distribution.Min.DeepCopy()
+	}
+	minVal, maxVal := float64(distribution.Min.MilliValue()), float64(distribution.Max.MilliValue())
+	var val float64
+	switch distribution.Distribution {
+	case gsh.NormalDistribution:
+		val = min(max((minVal+maxVal)/2+g.rnd.NormFloat64()*(maxVal-minVal)/6, minVal), maxVal)
+	default:
+		val = minVal + g.rnd.Float64()*(maxVal-minVal)
+	}
+	if distribution.Min.Format == resource.BinarySI {
+		const mebi = 1 << 20
+		return *resource.NewQuantity(int64(math.Round(val/1000/mebi))*mebi, resource.BinarySI)
+	}
+	return *resource.NewMilliQuantity(int64(val), distribution.Min.Format)
+}
+
+func (g *generator) arrive(p *podState) error {
+	for _, n := range g.nodes {
+		if fits(p, n) {
+			return g.bind(p, n, p.arrival)
+		}
+	}
+	p.info.SnapshotTimestamp = p.arrival
+	p.info.PodScheduleStatus = gst.PodUnscheduled
+	g.pending = append(g.pending, p)
+	g.unscheduledCount++
+	return g.storePod(p)
+}
+
+func (g *generator) depart(p *podState) error {
+	if p.node == nil {
+		g.pending = slices.DeleteFunc(g.pending, func(pending *podState) bool {
+			return pending == p
+		})
+	} else {
+		for name, quantity := range p.info.Requests {
+			free := p.node.free[name]
+			free.Add(quantity)
+			p.node.free[name] = free
+		}
+		pods := p.node.free[corev1.ResourcePods]
+		pods.Add(resource.MustParse("1"))
+		p.node.free[corev1.ResourcePods] = pods
+	}
+	_, err := g.store.UpdatePodDeletionTimestamp(types.UID(p.info.UID), p.departure)
+	if err != nil {
+		return fmt.Errorf("cannot store deletion of pod %q: %w", p.info.Name, err)
+	}
+	return g.schedulePending(p.departure)
+}
+
+// schedulePending schedules the pending pods that fit on a node in the order of their arrival.
+func (g *generator) schedulePending(t time.Time) error {
+	var stillPending []*podState
+	for _, p := range g.pending {
+		idx := slices.IndexFunc(g.nodes, func(n *nodeState) bool {
+			return fits(p, n)
+		})
+		if idx < 0 {
+			stillPending = append(stillPending, p)
+			continue
+		}
+		err := g.bind(p, g.nodes[idx], t)
+		if err != nil {
+			return err
+		}
+	}
+	g.pending = stillPending
+	return nil
+}
+
+func (g *generator) bind(p *podState, n *nodeState, t time.Time) error {
+	for name, quantity := range p.info.Requests {
+		free := n.free[name]
+		free.Sub(quantity)
+		n.free[name] = free
+	}
+	pods := n.free[corev1.ResourcePods]
+	pods.Sub(resource.MustParse("1"))
+	n.free[corev1.ResourcePods] = pods
+	p.node = n
+	p.info.SnapshotTimestamp = t
+	p.info.NodeName = n.info.Name
+	p.info.Spec.NodeName = n.info.Name
+	p.info.PodScheduleStatus = gst.PodScheduleCommited
+	return g.storePod(p)
+}
+
+func (g *generator) storePod(p *podState) error {
+	p.info.Hash = p.info.GetHash()
+	_, err := g.store.StorePodInfo(p.info)
+	if err != nil {
+		return fmt.Errorf("cannot store pod %q: %w", p.info.Name, err)
+	}
+	return nil
+}
+
+// fits checks if the requests of the given pod fit into the free resources of the given node and if the node
+// selector of the pod matches the node labels.
+func fits(p *podState, n *nodeState) bool {
+	for key, val := range p.info.Spec.NodeSelector {
+		if n.info.Labels[key] != val {
+			return false
+		}
+	}
+	if pods, ok := n.free[corev1.ResourcePods]; ok && pods.Value() < 1 {
+		return false
+	}
+	for name, quantity := range p.info.Requests {
+		free, ok := n.free[name]
+		if !ok || free.Cmp(quantity) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// distributeOverZones distributes the given size of a worker pool over its zones like gardener does for the minimum
+// and maximum of the machine deployments.
+func distributeOverZones(zoneIndex, size, zoneCount int) int {
+	zoneSize := size / zoneCount
+	if zoneIndex < size%zoneCount {
+		zoneSize++
+	}
+	return zoneSize
+}
+
+func (g *generator) nameSuffix() string {
+	suffix := make([]byte, 5)
+	for i := range suffix {
+		suffix[i] = nameSuffixChars[g.rnd.Intn(len(nameSuffixChars))]
+	}
+	return string(suffix)
+}
+
+func (g *generator) newUID() types.UID {
+	b := make([]byte, 16)
+	_, _ = g.rnd.Read(b)
+	return types.UID(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]))
+}
@@ -0,0 +1,177 @@
+package generator
+
+import (
+	gsh "github.com/elankath/gardener-scaling-history"
+	"github.com/elankath/gardener-scaling-history/db"
+	"github.com/elankath/gardener-scaling-history/replayer"
+	gst "github.com/elankath/gardener-scaling-types"
+	assert "github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const mixedScenarioPath = "testdata/scenarios/mixed.yaml"
+
+func generateTestDB(t *testing.T, scenarioPath string) *db.DataAccess {
+	scenario, err := LoadScenario(scenarioPath)
+	assert.Nil(t, err)
+	store := db.NewDataAccess(filepath.Join(t.TempDir(), scenario.Name+".db"))
+	assert.Nil(t, store.Init())
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+	assert.Nil(t, Generate(scenario, store))
+	return store
+}
+
+func podsAt(t *testing.T, store *db.DataAccess, timestamp time.Time) []gst.PodInfo {
+	pods, err := store.GetLatestPodInfosBeforeSnapshotTime(timestamp)
+	assert.Nil(t, err)
+	return pods
+}
+
+func countPods(pods []gst.PodInfo, app string, status gst.PodScheduleStatus) (count int) {
+	for _, p := range pods {
+		if p.Labels["app"] == app && p.PodScheduleStatus == status {
+			count++
+		}
+	}
+	return
+}
+
+func TestGenerateMixedScenario(t *testing.T) {
+	store := generateTestDB(t, mixedScenarioPath)
+	startTime := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time {
+		return startTime.Add(d)
+	}
+
+	recorderStartTime, err := store.GetInitialRecorderStartTime()
+	assert.Nil(t, err)
+	assert.Equal(t, startTime, recorderStartTime)
+	gaps, err := store.GetRecordingGaps()
+	assert.Nil(t, err)
+	assert.Empty(t, gaps)
+
+	mccs, err := store.LoadMachineClassInfosBefore(at(time.Minute))
+	assert.Nil(t, err)
+	mcds, err := store.LoadMachineDeploymentInfosBefore(at(time.Minute))
+	assert.Nil(t, err)
+	workerPools, err := store.LoadWorkerPoolInfosBefore(at(time.Minute))
+	assert.Nil(t, err)
+	nodeTemplates, err := replayer.GetNodeTemplates(mccs, mcds)
+	assert.Nil(t, err)
+	assert.Len(t, nodeTemplates, 3)
+	nodeGroups, err := replayer.GetNodeGroups(mcds, workerPools)
+	assert.Nil(t, err)
+	assert.Len(t, nodeGroups, 3)
+	p1z2 := nodeGroups["shoot--synthetic--mixed.shoot--synthetic--mixed-p1-z2"]
+	assert.Equal(t, "eu-west-1b", p1z2.Zone)
+	assert.Equal(t, 1, p1z2.TargetSize)
+	assert.Equal(t, 6, p1z2.MaxSize)
+
+	caSettings, err := store.LoadCASettingsBefore(at(time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, "least-waste", caSettings.Expander)
+	assert.Equal(t, 20, caSettings.MaxNodesTotal)
+
+	nodes, err := store.LoadNodeInfosBefore(at(time.Minute))
+	assert.Nil(t, err)
+	assert.Len(t, nodes, 3)
+	nodesByName := make(map[string]gst.NodeInfo)
+	for _, n := range nodes {
+		nodesByName[n.Name] = n
+	}
+
+	// the web pods follow the diurnal curve between 2 replicas at night and 10 at the peak at 14:00
+	assert.Equal(t, 2, countPods(podsAt(t, store, at(2*time.Hour)), "web", gst.PodScheduleCommited))
+	assert.Equal(t, 10, countPods(podsAt(t, store, at(14*time.Hour)), "web", gst.PodScheduleCommited))
+
+	// the report job pods run for 20m every hour from 00:30. They do not always fit on the single batch node.
+	countReportPods := func(pods []gst.PodInfo) int {
+		return countPods(pods, "report", gst.PodScheduleCommited) + countPods(pods, "report", gst.PodUnscheduled)
+	}
+	assert.Equal(t, 3, countReportPods(podsAt(t, store, at(40*time.Minute))))
+	assert.Equal(t, 0, countReportPods(podsAt(t, store, at(55*time.Minute))))
+	assert.Equal(t, 3, countReportPods(podsAt(t, store, at(23*time.Hour+35*time.Minute))))
+
+	// the api burst does not fit on the nodes of pool p1 and is partly unscheduled
+	burstPods := podsAt(t, store, at(8*time.Hour+15*time.Minute))
+	assert.Equal(t, 6, countPods(burstPods, "api", gst.PodScheduleCommited)+countPods(burstPods, "api", gst.PodUnscheduled))
+	assert.Positive(t, countPods(burstPods, "api", gst.PodUnscheduled))
+
+	for hour := range 24 {
+		used := make(map[string]corev1.ResourceList)
+		for _, p := range podsAt(t, store, at(time.Duration(hour)*time.Hour+time.Second)) {
+			if p.Labels["app"] == "web" {
+				cpu := p.Requests[corev1.ResourceCPU]
+				assert.True(t, cpu.Cmp(resource.MustParse("250m")) >= 0 && cpu.Cmp(resource.MustParse("500m")) <= 0, "web cpu request %s out of range", &cpu)
+			}
+			if p.PodScheduleStatus != gst.PodScheduleCommited {
+				assert.Empty(t, p.NodeName)
+				continue
+			}
+			node, ok := nodesByName[p.NodeName]
+			assert.True(t, ok, "pod %q is scheduled on unknown node %q", p.Name, p.NodeName)
+			assert.Equal(t, p.Spec.NodeSelector[gsh.PoolLabel], node.Labels[gsh.PoolLabel])
+			if used[p.NodeName] == nil {
+				used[p.NodeName] = make(corev1.ResourceList)
+			}
+			for name, quantity := range p.Requests {
+				sum := used[p.NodeName][name]
+				sum.Add(quantity)
+				used[p.NodeName][name] = sum
+			}
+		}
+		for nodeName, requests := range used {
+			for name, sum := range requests {
+				allocatable := nodesByName[nodeName].Allocatable[name]
+				assert.True(t, sum.Cmp(allocatable) <= 0, "%s requests %s on node %q exceed allocatable %s at hour %d", name, &sum, nodeName, &allocatable, hour)
+			}
+		}
+	}
+}
+
+func TestGenerateIsReproducible(t *testing.T) {
+	first := generateTestDB(t, mixedScenarioPath)
+	second := generateTestDB(t, mixedScenarioPath)
+	for _, timestamp := range []time.Time{
+		time.Date(2024, 6, 1, 8, 5, 0, 0, time.UTC),
+		time.Date(2024, 6, 1, 14, 0, 0, 0, time.UTC),
+	} {
+		firstPods, secondPods := podsAt(t, first, timestamp), podsAt(t, second, timestamp)
+		assert.Equal(t, len(firstPods), len(secondPods))
+		for i := range firstPods {
+			assert.Equal(t, firstPods[i].Hash, secondPods[i].Hash)
+			assert.Equal(t, firstPods[i].SnapshotTimestamp, secondPods[i].SnapshotTimestamp)
+		}
+	}
+}
+
+func TestLoadScenarioRejectsInvalidScenarios(t *testing.T) {
+	data, err := os.ReadFile(mixedScenarioPath)
+	assert.Nil(t, err)
+	tests := map[string]struct {
+		old, new string
+		errMsg   string
+	}{
+		"unknown field":          {old: "  minimum: 2", new: "  minimum: 2\n  minimun: 2", errMsg: "unknown field"},
+		"unknown priority class": {old: "priorityClassName: batch-low", new: "priorityClassName: batch-high", errMsg: "unknown priority class"},
+		"unknown worker pool":    {old: "poolName: batch", new: "poolName: gpu", errMsg: "unknown worker pool"},
+		"unknown arrival type":   {old: "type: diurnal", new: "type: weekly", errMsg: "unknown arrival type"},
+		"max below min":          {old: "max: 3Gi", new: "max: 1Gi", errMsg: "is less than min"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			scenarioPath := filepath.Join(t.TempDir(), "scenario.yaml")
+			assert.Nil(t, os.WriteFile(scenarioPath, []byte(strings.Replace(string(data), tc.old, tc.new, 1)), 0644))
+			_, err := LoadScenario(scenarioPath)
+			assert.ErrorContains(t, err, tc.errMsg)
+		})
+	}
+}
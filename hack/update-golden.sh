@@ -0,0 +1,7 @@
+#!/usr/bin/env bash
+# Regenerates the golden snapshots of replayer/testdata/timelines and fails if they changed.
+set -eo pipefail
+
+cd "$(dirname "$0")/.."
+go test ./replayer -count 1 -run TestGoldenSnapshots -update
+git diff --exit-code -- replayer/testdata/golden
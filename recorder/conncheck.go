@@ -2,47 +2,184 @@ package recorder
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
 )
 
+var namespaceGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
 type ConnChecker struct {
-	shootConfig      rest.Config
-	shootClientSet   *kubernetes.Clientset
-	controlConfig    rest.Config
-	controlClientSet *kubernetes.Clientset
+	shootConfig   *rest.Config
+	shootClient   kubernetes.Interface
+	controlConfig *rest.Config
+	controlClient dynamic.Interface
+	mu            sync.Mutex
+	shootStatus   connStatus
+	controlStatus connStatus
+}
+
+// connStatus is the outcome of the connection tests against one API server.
+type connStatus struct {
+	lastSuccess time.Time
+	lastErr     error
 }
 
 func NewConnChecker(shootConfig, controlConfig *rest.Config) (*ConnChecker, error) {
-	shootClientset, err := kubernetes.NewForConfig(shootConfig)
+	shootClient, err := kubernetes.NewForConfig(shootConfig)
 	if err != nil {
 		return nil, err
 	}
-	controlClientset, err := kubernetes.NewForConfig(controlConfig)
+	controlClient, err := dynamic.NewForConfig(controlConfig)
 	if err != nil {
 		return nil, err
 	}
+	return newConnChecker(shootClient, controlClient, shootConfig, controlConfig), nil
+}
+
+// newConnChecker returns a ConnChecker using the given clients. The configs are only used to find the expiry of the
+// kubeconfig tokens and may be nil.
+func newConnChecker(shootClient kubernetes.Interface, controlClient dynamic.Interface, shootConfig, controlConfig *rest.Config) *ConnChecker {
 	return &ConnChecker{
-		shootConfig:      *shootConfig,
-		controlConfig:    *controlConfig,
-		shootClientSet:   shootClientset,
-		controlClientSet: controlClientset,
-	}, nil
+		shootConfig:   shootConfig,
+		shootClient:   shootClient,
+		controlConfig: controlConfig,
+		controlClient: controlClient,
+	}
 }
 
+// TestConnection checks that both the shoot and the seed API servers can be reached with the configured credentials.
 func (c *ConnChecker) TestConnection(ctx context.Context) error {
-	//TODO do better test connection
-	_, err := c.shootClientSet.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return fmt.Errorf("shoot connection test failed : %w", err)
+	_, err := c.shootClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{Limit: 1})
+	shootErr := connectionError("shoot", err, c.shootConfig)
+	_, err = c.controlClient.Resource(namespaceGVR).List(ctx, metav1.ListOptions{Limit: 1})
+	controlErr := connectionError("seed", err, c.controlConfig)
+	now := time.Now()
+	c.mu.Lock()
+	c.shootStatus.record(now, shootErr)
+	c.controlStatus.record(now, controlErr)
+	c.mu.Unlock()
+	if shootErr != nil {
+		return shootErr
 	}
+	return controlErr
+}
 
-	_, err = c.controlClientSet.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return fmt.Errorf("seed connection test failed : %w", err)
+// RunPeriodically tests the connection every interval until the context is done.
+func (c *ConnChecker) RunPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := c.TestConnection(ctx)
+			if err != nil && ctx.Err() == nil {
+				slog.Warn("connection check failed", "error", err)
+			}
+		}
+	}
+}
+
+// Failures returns the reasons why the shoot or seed API server cannot be considered reachable at now. An API server
+// is unreachable if there was no successful connection test within maxAge.
+func (c *ConnChecker) Failures(now time.Time, maxAge time.Duration) (failures []string) {
+	c.mu.Lock()
+	shootStatus, controlStatus := c.shootStatus, c.controlStatus
+	c.mu.Unlock()
+	failures = append(failures, shootStatus.failures("shoot", now, maxAge)...)
+	failures = append(failures, controlStatus.failures("seed", now, maxAge)...)
+	return
+}
+
+// ExpiredTokenFailures returns the reasons why the shoot or seed kubeconfig tokens are known to be expired at now.
+func (c *ConnChecker) ExpiredTokenFailures(now time.Time) (failures []string) {
+	if expiry, ok := tokenExpiry(c.shootConfig); ok && !now.Before(expiry) {
+		failures = append(failures, fmt.Sprintf("shoot kubeconfig token expired at %s", expiry.UTC().Format(time.RFC3339)))
+	}
+	if expiry, ok := tokenExpiry(c.controlConfig); ok && !now.Before(expiry) {
+		failures = append(failures, fmt.Sprintf("seed kubeconfig token expired at %s", expiry.UTC().Format(time.RFC3339)))
+	}
+	return
+}
+
+func (s *connStatus) record(now time.Time, err error) {
+	s.lastErr = err
+	if err == nil {
+		s.lastSuccess = now
 	}
+}
+
+func (s connStatus) failures(kind string, now time.Time, maxAge time.Duration) (failures []string) {
+	if s.lastSuccess.IsZero() && s.lastErr == nil {
+		failures = append(failures, fmt.Sprintf("%s API server not checked yet", kind))
+	} else if s.lastSuccess.IsZero() {
+		failures = append(failures, fmt.Sprintf("%s API server not reached yet: %v", kind, s.lastErr))
+	} else if now.Sub(s.lastSuccess) > maxAge {
+		failures = append(failures, fmt.Sprintf("%s API server not reachable since %s: %v", kind,
+			s.lastSuccess.UTC().Format(time.RFC3339), s.lastErr))
+	}
+	return
+}
+
+// connectionError returns the error of a connection test against the shoot or seed API server with the given config.
+func connectionError(kind string, err error, config *rest.Config) error {
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsUnauthorized(err) {
+		return fmt.Errorf("%s connection test failed : %w", kind, err)
+	}
+	if expiry, ok := tokenExpiry(config); ok && !time.Now().Before(expiry) {
+		return fmt.Errorf("%s connection test failed: kubeconfig token expired at %s: %w", kind,
+			expiry.UTC().Format(time.RFC3339), err)
+	}
+	return fmt.Errorf("%s connection test failed: kubeconfig credentials rejected, token may be expired or revoked: %w", kind, err)
+}
 
-	return nil
+// tokenExpiry returns the expiry of the bearer token of the given config if the token is a JWT with an exp claim.
+func tokenExpiry(config *rest.Config) (expiry time.Time, ok bool) {
+	if config == nil {
+		return
+	}
+	token := config.BearerToken
+	if token == "" && config.BearerTokenFile != "" {
+		data, err := os.ReadFile(config.BearerTokenFile)
+		if err != nil {
+			return
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	return jwtExpiry(token)
+}
+
+// jwtExpiry returns the time of the exp claim of the given JWT. The signature is not verified.
+func jwtExpiry(token string) (expiry time.Time, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err = json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return
+	}
+	return time.Unix(claims.Exp, 0), true
 }
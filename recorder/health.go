@@ -0,0 +1,178 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/elankath/gardener-scaling-history"
+	"golang.org/x/exp/maps"
+	"io"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// connCheckInterval is the interval at which the shoot and seed API servers are checked.
+	connCheckInterval = time.Minute
+	// connCheckMaxAge is the maximum age of the last successful connection check for the recorder to be ready.
+	connCheckMaxAge = 3 * connCheckInterval
+	// watchErrorWindow is the maximum interval between two watch errors of an informer for them to count as one
+	// ongoing failure. The reflector backs off for at most 30s between retries.
+	watchErrorWindow = 2 * time.Minute
+	// watchStallThreshold is the duration after which an ongoing watch failure is considered a stalled watch.
+	watchStallThreshold = 10 * time.Minute
+	// nodeEventStallThreshold is the duration without node events after which the node watch is considered stalled
+	// although it reports no error. Kubelets update their node status at least every 5 minutes.
+	nodeEventStallThreshold = 15 * time.Minute
+)
+
+// watchFailure is an ongoing failure of the watch of an informer.
+type watchFailure struct {
+	since   time.Time
+	lastErr error
+	lastAt  time.Time
+}
+
+// recorderHealth tracks the informers of a recorder and the watch errors reported by them.
+type recorderHealth struct {
+	mu            sync.Mutex
+	started       bool
+	informers     map[string]cache.SharedIndexInformer
+	watchFailures map[string]watchFailure
+}
+
+func newRecorderHealth() *recorderHealth {
+	return &recorderHealth{
+		informers:     make(map[string]cache.SharedIndexInformer),
+		watchFailures: make(map[string]watchFailure),
+	}
+}
+
+// trackInformer sets a watch error handler on the given informer that records watch failures under the given name.
+// It must be called before the informer is started.
+func (h *recorderHealth) trackInformer(name string, informer cache.SharedIndexInformer) error {
+	h.mu.Lock()
+	h.informers[name] = informer
+	h.mu.Unlock()
+	return informer.SetWatchErrorHandler(func(reflector *cache.Reflector, err error) {
+		cache.DefaultWatchErrorHandler(reflector, err)
+		if isExpectedWatchError(err) {
+			return
+		}
+		h.onWatchError(name, time.Now(), err)
+	})
+}
+
+// isExpectedWatchError returns whether the given error is a regular end of a watch after which the reflector
+// relists immediately. These are logged at a low level by cache.DefaultWatchErrorHandler as well.
+func isExpectedWatchError(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || apierrors.IsResourceExpired(err) || apierrors.IsGone(err)
+}
+
+func (h *recorderHealth) onWatchError(name string, now time.Time, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	failure, ok := h.watchFailures[name]
+	if !ok || now.Sub(failure.lastAt) > watchErrorWindow {
+		failure.since = now
+	}
+	failure.lastErr = err
+	failure.lastAt = now
+	h.watchFailures[name] = failure
+}
+
+// takeEndedWatchFailures removes and returns the watch failures keyed by informer name which have ended at now since
+// there was no watch error within the watchErrorWindow. If all is set, ongoing watch failures are returned as well.
+func (h *recorderHealth) takeEndedWatchFailures(now time.Time, all bool) map[string]watchFailure {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ended := make(map[string]watchFailure)
+	for name, failure := range h.watchFailures {
+		if all || now.Sub(failure.lastAt) > watchErrorWindow {
+			ended[name] = failure
+			delete(h.watchFailures, name)
+		}
+	}
+	return ended
+}
+
+func (h *recorderHealth) markStarted() {
+	h.mu.Lock()
+	h.started = true
+	h.mu.Unlock()
+}
+
+func (h *recorderHealth) isStarted() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.started
+}
+
+// informerFailures returns the liveness and readiness failures of the tracked informers at now. lastEventTime returns
+// the time the named informer last delivered an event.
+func (h *recorderHealth) informerFailures(now time.Time, lastEventTime func(name string) (time.Time, bool)) (liveness, readiness []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	names := maps.Keys(h.informers)
+	slices.Sort(names)
+	for _, name := range names {
+		informer := h.informers[name]
+		if informer.IsStopped() {
+			liveness = append(liveness, fmt.Sprintf("informer %q stopped", name))
+			continue
+		}
+		if !informer.HasSynced() {
+			readiness = append(readiness, fmt.Sprintf("informer %q not synced", name))
+		}
+		if failure, ok := h.watchFailures[name]; ok && now.Sub(failure.lastAt) <= watchErrorWindow {
+			reason := describeWatchFailure(name, failure)
+			if now.Sub(failure.since) > watchStallThreshold {
+				liveness = append(liveness, "stalled "+reason)
+			} else {
+				readiness = append(readiness, reason)
+			}
+		}
+	}
+	if nodeInformer, ok := h.informers["nodes"]; ok && nodeInformer.HasSynced() && len(nodeInformer.GetStore().ListKeys()) > 0 {
+		if lastEvent, ok := lastEventTime("nodes"); ok && now.Sub(lastEvent) > nodeEventStallThreshold {
+			liveness = append(liveness, fmt.Sprintf("stalled watch of informer %q: no node events since %s",
+				"nodes", lastEvent.UTC().Format(time.RFC3339)))
+		}
+	}
+	return
+}
+
+func describeWatchFailure(name string, failure watchFailure) string {
+	if apierrors.IsUnauthorized(failure.lastErr) {
+		return fmt.Sprintf("watch of informer %q failing since %s: kubeconfig credentials rejected, token may be expired or revoked: %v",
+			name, failure.since.UTC().Format(time.RFC3339), failure.lastErr)
+	}
+	return fmt.Sprintf("watch of informer %q failing since %s: %v", name, failure.since.UTC().Format(time.RFC3339), failure.lastErr)
+}
+
+// CheckHealth returns the health of the recorder. The recorder is not live if an informer has stopped, a watch has
+// stalled or a kubeconfig token has expired, since these need a restart which also re-reads the kubeconfigs. The
+// recorder is not ready if an informer has not synced, a watch is failing, the data db is not writable or the shoot
+// or seed API server has not been reached recently.
+func (r *defaultRecorder) CheckHealth(ctx context.Context) gsh.HealthStatus {
+	now := time.Now()
+	status := gsh.HealthStatus{
+		Landscape: r.params.Landscape,
+		Cluster:   r.params.ShootNameSpace,
+		CheckTime: now,
+	}
+	if !r.health.isStarted() {
+		status.ReadinessFailures = []string{"recorder not started"}
+		return status
+	}
+	status.LivenessFailures, status.ReadinessFailures = r.health.informerFailures(now, r.metrics.lastEventTime)
+	status.LivenessFailures = append(status.LivenessFailures, r.connChecker.ExpiredTokenFailures(now)...)
+	status.ReadinessFailures = append(status.ReadinessFailures, r.connChecker.Failures(now, connCheckMaxAge)...)
+	if err := r.dataAccess.CheckWritable(ctx); err != nil {
+		status.ReadinessFailures = append(status.ReadinessFailures, fmt.Sprintf("data db not writable: %v", err))
+	}
+	return status
+}
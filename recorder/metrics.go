@@ -0,0 +1,219 @@
+package recorder
+
+import (
+	"errors"
+	"github.com/elankath/gardener-scaling-history"
+	"github.com/elankath/gardener-scaling-history/db"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/tools/cache"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const metricsNamespace = "scalehist"
+const metricsSubsystem = "recorder"
+
+// deferredRetries is the number of InvokeOrScheduleFunc invocations that are scheduled for a retry and have not yet
+// been re-invoked. It is process-wide since InvokeOrScheduleFunc is not bound to a recorder.
+var deferredRetries atomic.Int64
+
+// recorderMetrics holds the prometheus metrics of a single recorder. All metrics carry the landscape and cluster
+// (shoot namespace) of the recorder as constant labels.
+type recorderMetrics struct {
+	rowsInserted *prometheus.CounterVec
+	dedupSkips   *prometheus.CounterVec
+	insertErrors *prometheus.CounterVec
+
+	informerSyncedDesc  *prometheus.Desc
+	lastEventAgeDesc    *prometheus.Desc
+	dbSizeDesc          *prometheus.Desc
+	deferredRetriesDesc *prometheus.Desc
+
+	writeQueueLengthDesc     *prometheus.Desc
+	writeQueueCapacityDesc   *prometheus.Desc
+	writesBlockedDesc        *prometheus.Desc
+	writesBlockedSecondsDesc *prometheus.Desc
+	writeBatchesDesc         *prometheus.Desc
+	writesDesc               *prometheus.Desc
+	writeErrorsDesc          *prometheus.Desc
+	writeFlushSecondsDesc    *prometheus.Desc
+	writeStats               func() db.WritePipelineStats
+
+	// dbPath is the path of the SQLite data db. It is empty for a PostgreSQL store, whose size is not reported.
+	dbPath         string
+	startTime      time.Time
+	mu             sync.Mutex
+	informers      map[string]cache.SharedIndexInformer
+	lastEventTimes map[string]time.Time
+}
+
+var _ prometheus.Collector = (*recorderMetrics)(nil)
+
+func newRecorderMetrics(params *gsh.RecorderParams, dbPath string, startTime time.Time, writeStats func() db.WritePipelineStats) *recorderMetrics {
+	constLabels := prometheus.Labels{"landscape": params.Landscape, "cluster": params.ShootNameSpace}
+	newCounterVec := func(name, help string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Subsystem:   metricsSubsystem,
+			Name:        name,
+			Help:        help,
+			ConstLabels: constLabels,
+		}, []string{"table"})
+	}
+	newDesc := func(name, help string, variableLabels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, metricsSubsystem, name), help, variableLabels, constLabels)
+	}
+	m := &recorderMetrics{
+		rowsInserted:        newCounterVec("rows_inserted_total", "Number of rows inserted per table."),
+		dedupSkips:          newCounterVec("dedup_skips_total", "Number of inserts skipped per table since a row with the same hash is already present."),
+		insertErrors:        newCounterVec("insert_errors_total", "Number of failed inserts per table."),
+		informerSyncedDesc:  newDesc("informer_synced", "Whether the informer has synced (1) or not (0).", "informer"),
+		lastEventAgeDesc:    newDesc("informer_last_event_age_seconds", "Seconds since the informer last delivered an add, update or delete. Measured from recorder start if no event was delivered yet.", "informer"),
+		deferredRetriesDesc: newDesc("deferred_retries", "Number of deferred InvokeOrScheduleFunc retries that are pending."),
+
+		writeQueueLengthDesc:     newDesc("write_queue_length", "Number of writes queued for the data db."),
+		writeQueueCapacityDesc:   newDesc("write_queue_capacity", "Number of writes that can be queued for the data db before queueing blocks."),
+		writesBlockedDesc:        newDesc("writes_blocked_total", "Number of writes that had to wait since the write queue was full."),
+		writesBlockedSecondsDesc: newDesc("writes_blocked_seconds_total", "Total seconds writes waited for space in the write queue."),
+		writeBatchesDesc:         newDesc("write_batches_total", "Number of write batches flushed to the data db."),
+		writesDesc:               newDesc("writes_total", "Number of queued writes executed in committed batches, including skipped duplicates."),
+		writeErrorsDesc:          newDesc("write_errors_total", "Number of queued writes that failed."),
+		writeFlushSecondsDesc:    newDesc("write_flush_seconds_total", "Total seconds spent executing and committing write batches."),
+		writeStats:               writeStats,
+
+		dbPath:         dbPath,
+		startTime:      startTime,
+		informers:      make(map[string]cache.SharedIndexInformer),
+		lastEventTimes: make(map[string]time.Time),
+	}
+	if dbPath != "" {
+		m.dbSizeDesc = newDesc("db_size_bytes", "Size of the recorder database file and its write-ahead log in bytes.")
+	}
+	return m
+}
+
+// register registers the metrics with the given registerer. A recorder for the same cluster that is already registered
+// is replaced.
+func (m *recorderMetrics) register(registerer prometheus.Registerer) error {
+	err := registerer.Register(m)
+	if err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return err
+		}
+		registerer.Unregister(are.ExistingCollector)
+		return registerer.Register(m)
+	}
+	return nil
+}
+
+// trackInformer adds an event handler to the given informer which records the time of the last event and reports
+// the sync status of the informer under the given name.
+func (m *recorderMetrics) trackInformer(name string, informer cache.SharedIndexInformer) error {
+	m.mu.Lock()
+	m.informers[name] = informer
+	m.mu.Unlock()
+	onEvent := func() {
+		m.mu.Lock()
+		m.lastEventTimes[name] = time.Now()
+		m.mu.Unlock()
+	}
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(_ any) { onEvent() },
+		UpdateFunc: func(_, _ any) { onEvent() },
+		DeleteFunc: func(_ any) { onEvent() },
+	})
+	return err
+}
+
+// lastEventTime returns the time the informer with the given name last delivered an event.
+func (m *recorderMetrics) lastEventTime(name string) (lastEventTime time.Time, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lastEventTime, ok = m.lastEventTimes[name]
+	return
+}
+
+// observeStore counts a row inserted into the given table or an insert error if err is not nil.
+func (m *recorderMetrics) observeStore(table string, err error) {
+	if err != nil {
+		m.insertErrors.WithLabelValues(table).Inc()
+		return
+	}
+	m.rowsInserted.WithLabelValues(table).Inc()
+}
+
+// observeDedupSkip counts an insert into the given table that was skipped since a row with the same hash is present.
+func (m *recorderMetrics) observeDedupSkip(table string) {
+	m.dedupSkips.WithLabelValues(table).Inc()
+}
+
+func (m *recorderMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.rowsInserted.Describe(ch)
+	m.dedupSkips.Describe(ch)
+	m.insertErrors.Describe(ch)
+	ch <- m.informerSyncedDesc
+	ch <- m.lastEventAgeDesc
+	if m.dbSizeDesc != nil {
+		ch <- m.dbSizeDesc
+	}
+	ch <- m.deferredRetriesDesc
+	ch <- m.writeQueueLengthDesc
+	ch <- m.writeQueueCapacityDesc
+	ch <- m.writesBlockedDesc
+	ch <- m.writesBlockedSecondsDesc
+	ch <- m.writeBatchesDesc
+	ch <- m.writesDesc
+	ch <- m.writeErrorsDesc
+	ch <- m.writeFlushSecondsDesc
+}
+
+func (m *recorderMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.rowsInserted.Collect(ch)
+	m.dedupSkips.Collect(ch)
+	m.insertErrors.Collect(ch)
+
+	now := time.Now()
+	m.mu.Lock()
+	for name, informer := range m.informers {
+		var synced float64
+		if informer.HasSynced() {
+			synced = 1
+		}
+		ch <- prometheus.MustNewConstMetric(m.informerSyncedDesc, prometheus.GaugeValue, synced, name)
+		lastEventTime, ok := m.lastEventTimes[name]
+		if !ok {
+			lastEventTime = m.startTime
+		}
+		ch <- prometheus.MustNewConstMetric(m.lastEventAgeDesc, prometheus.GaugeValue, now.Sub(lastEventTime).Seconds(), name)
+	}
+	m.mu.Unlock()
+
+	if m.dbSizeDesc != nil {
+		ch <- prometheus.MustNewConstMetric(m.dbSizeDesc, prometheus.GaugeValue, float64(m.dbSize()))
+	}
+	ch <- prometheus.MustNewConstMetric(m.deferredRetriesDesc, prometheus.GaugeValue, float64(deferredRetries.Load()))
+
+	stats := m.writeStats()
+	ch <- prometheus.MustNewConstMetric(m.writeQueueLengthDesc, prometheus.GaugeValue, float64(stats.QueueLength))
+	ch <- prometheus.MustNewConstMetric(m.writeQueueCapacityDesc, prometheus.GaugeValue, float64(stats.QueueCapacity))
+	ch <- prometheus.MustNewConstMetric(m.writesBlockedDesc, prometheus.CounterValue, float64(stats.Blocked))
+	ch <- prometheus.MustNewConstMetric(m.writesBlockedSecondsDesc, prometheus.CounterValue, stats.BlockedDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(m.writeBatchesDesc, prometheus.CounterValue, float64(stats.Batches))
+	ch <- prometheus.MustNewConstMetric(m.writesDesc, prometheus.CounterValue, float64(stats.Written))
+	ch <- prometheus.MustNewConstMetric(m.writeErrorsDesc, prometheus.CounterValue, float64(stats.Failed))
+	ch <- prometheus.MustNewConstMetric(m.writeFlushSecondsDesc, prometheus.CounterValue, stats.FlushDuration.Seconds())
+}
+
+// dbSize returns the size of the data db file and its write-ahead log, which holds the writes not yet checkpointed
+// into the db file.
+func (m *recorderMetrics) dbSize() (size int64) {
+	for _, p := range []string{m.dbPath, m.dbPath + "-wal"} {
+		if fi, err := os.Stat(p); err == nil {
+			size += fi.Size()
+		}
+	}
+	return
+}
@@ -8,12 +8,15 @@ import (
 	"github.com/elankath/gardener-scaling-history"
 	"github.com/elankath/gardener-scaling-history/db"
 	"github.com/elankath/gardener-scaling-types"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
 	"golang.org/x/exp/maps"
 	corev1 "k8s.io/api/core/v1"
 	schedulingv1 "k8s.io/api/scheduling/v1"
 	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -30,14 +33,27 @@ import (
 	"log/slog"
 	"path"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const PoolLabel = "worker.gardener.cloud/pool"
 
+// heartbeatInterval is the interval at which the heartbeat of the recorder session is stored. The recording is
+// considered to have stopped at the last heartbeat if the recorder does not stop cleanly.
+const heartbeatInterval = 30 * time.Second
+
+// DefaultCheckpointInterval is the default RecorderParams.CheckpointInterval.
+const DefaultCheckpointInterval = 30 * time.Minute
+
+// DefaultCheckpointRetention is the default RecorderParams.CheckpointRetention, one week of checkpoints at the
+// DefaultCheckpointInterval.
+const DefaultCheckpointRetention = 7 * 24 * 2
+
 var ZoneLabels = []string{"topology.gke.io/zone", "topology.ebs.csi.aws.com/zone"}
 
 var machineDeploymentGVR = schema.GroupVersionResource{Group: "machine.sapcloud.io", Version: "v1alpha1", Resource: "machinedeployments"}
@@ -47,13 +63,26 @@ var deploymentGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Re
 var configmapGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
 var eventGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "events"}
 
-var caOptions = sets.New("expander", "max-nodes-total", "max-graceful-termination-sec", "max-node-provision-time", "scan-interval", "ignore-daemonsets-utilization", "new-pod-scale-up-delay", "max-empty-bulk-delete")
 var ErrKeyNotFound = errors.New("key not found")
 
-const podTriggerScaleUpPattern = `.*(shoot--\S+) (\d+)\->(\d+) .*max: (\d+).*`
+// scaleDownEventReasons are the reasons of scale-down related events that are recorded irrespective of the reporting
+// controller. Ex: `NodeNotSchedulable` is reported by the kubelet when a node is cordoned.
+var scaleDownEventReasons = sets.New("ScaleDown", "ScaleDownEmpty", "ScaleDownFailed", "NodeNotSafeToEvict",
+	"NodeNotSchedulable", "NodeSchedulable")
+
+// caScalingEventReasons are the reasons of cluster-autoscaler events that are parsed into CAScalingEventInfos.
+var caScalingEventReasons = sets.New(gsh.TriggeredScaleUpReason, gsh.NotTriggerScaleUpReason, gsh.ScaledUpGroupReason,
+	gsh.ScaleDownEmptyReason)
+
+// caBoolFlags are the boolean cluster-autoscaler flags. Like any Go boolean flag, they take a value only as
+// "--flag=value" so that a following token is not consumed as their value.
+var caBoolFlags = sets.New("ignore-daemonsets-utilization", "scale-down-enabled", "balance-similar-node-groups",
+	"skip-nodes-with-local-storage", "skip-nodes-with-system-pods", "skip-nodes-with-custom-controller-pods",
+	"write-status-configmap", "leader-elect", "scale-up-from-zero", "enforce-node-group-min-size",
+	"emit-per-nodegroup-metrics", "parallel-drain", "force-ds", "debugging-snapshot-enabled")
+
 const machineSetScaleUpPattern = `Scaled up.*? to (\d+)`
 
-var podTriggeredScaleUpRegex = regexp.MustCompile(podTriggerScaleUpPattern)
 var machineSetScaleUpRegex = regexp.MustCompile(machineSetScaleUpPattern)
 
 func NewDefaultRecorder(params gsh.RecorderParams, startTime time.Time) (gsh.Recorder, error) {
@@ -68,11 +97,6 @@ func NewDefaultRecorder(params gsh.RecorderParams, startTime time.Time) (gsh.Rec
 		return nil, fmt.Errorf("cannot create clientset: %w", err)
 	}
 
-	if params.SchedulerName == "" {
-		params.SchedulerName = "bin-packing-scheduler"
-		slog.Info("scheduler name un-specified. defaulting", "SchedulerName", params.SchedulerName)
-	}
-
 	controlConfig, err := clientcmd.BuildConfigFromFlags("", params.SeedKubeConfigPath)
 	if err != nil {
 		slog.Error("cannot create the client config for the control plane", "error", err)
@@ -82,61 +106,95 @@ func NewDefaultRecorder(params gsh.RecorderParams, startTime time.Time) (gsh.Rec
 	if err != nil {
 		return nil, fmt.Errorf("cannot create clientset for control plane: %w", err)
 	}
+	return newDefaultRecorder(params, startTime, clientset, controlClientSet, newConnChecker(clientset, controlClientSet, config, controlConfig)), nil
+}
 
-	connChecker, err := NewConnChecker(config, controlConfig)
-	if err != nil {
-		slog.Error("cannot create the conn checker", "error", err)
-		return nil, err
+// NewDefaultRecorderWithClients creates a recorder that watches the shoot and the seed through the given clients
+// instead of clients built from the kubeconfigs of the params, for example the fake clients of client-go. The
+// ShootKubeConfigPath of the params is only used to name the data db.
+func NewDefaultRecorderWithClients(params gsh.RecorderParams, startTime time.Time, shootClient kubernetes.Interface, seedClient dynamic.Interface) (gsh.Recorder, error) {
+	return newDefaultRecorder(params, startTime, shootClient, seedClient, newConnChecker(shootClient, seedClient, nil, nil)), nil
+}
+
+func newDefaultRecorder(params gsh.RecorderParams, startTime time.Time, clientset kubernetes.Interface, controlClientSet dynamic.Interface, connChecker *ConnChecker) *defaultRecorder {
+	if params.SchedulerName == "" {
+		params.SchedulerName = "bin-packing-scheduler"
+		slog.Info("scheduler name un-specified. defaulting", "SchedulerName", params.SchedulerName)
 	}
 
 	informerFactory := informers.NewSharedInformerFactory(clientset, 0)
+	caStatusInformerFactory := informers.NewSharedInformerFactoryWithOptions(clientset, 0,
+		informers.WithNamespace(gsh.CAStatusConfigMapNamespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", gsh.CAStatusConfigMapName).String()
+		}))
 	slog.Info("Building recorder", "recorder-params", params)
 	controlInformerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(controlClientSet, 0, params.ShootNameSpace, nil)
-	dataDBName := strings.TrimSuffix(strings.TrimPrefix(path.Base(params.ShootKubeConfigPath), "kubeconfig-"), ".yaml") + ".db"
-	dataDBPath := path.Join(params.DBDir, dataDBName)
-	slog.Info("data db path.", "dataDBPath", dataDBPath)
+	var dataDBPath string
+	var dataAccess db.Store
+	if params.PostgresDSN != "" {
+		clusterID := params.Landscape + "/" + params.ShootNameSpace
+		slog.Info("data db is postgres.", "clusterID", clusterID)
+		dataAccess = db.NewPostgresDataAccess(params.PostgresDSN, clusterID)
+	} else {
+		dataDBName := strings.TrimSuffix(strings.TrimPrefix(path.Base(params.ShootKubeConfigPath), "kubeconfig-"), ".yaml") + ".db"
+		dataDBPath = path.Join(params.DBDir, dataDBName)
+		slog.Info("data db path.", "dataDBPath", dataDBPath)
+		dataAccess = db.NewDataAccess(dataDBPath)
+	}
 	return &defaultRecorder{params: &params,
-		startTime:              startTime,
-		connChecker:            connChecker,
-		informerFactory:        informerFactory,
-		eventsInformer:         informerFactory.Core().V1().Events(),
-		controlEventsInformer:  controlInformerFactory.ForResource(eventGVR),
-		podsInformer:           informerFactory.Core().V1().Pods(),
-		pcInformer:             informerFactory.Scheduling().V1().PriorityClasses(),
-		pdbInformer:            informerFactory.Policy().V1().PodDisruptionBudgets(),
-		nodeInformer:           informerFactory.Core().V1().Nodes(),
-		csiInformer:            informerFactory.Storage().V1().CSINodes(),
-		controlInformerFactory: controlInformerFactory,
-		mcdInformer:            controlInformerFactory.ForResource(machineDeploymentGVR),
-		mccInformer:            controlInformerFactory.ForResource(machineClassGVR),
-		deploymentInformer:     controlInformerFactory.ForResource(deploymentGVR),
-		configmapInformer:      controlInformerFactory.ForResource(configmapGVR),
-		workerInformer:         controlInformerFactory.ForResource(workerGVR),
-		dataAccess:             db.NewDataAccess(dataDBPath),
-	}, nil
+		startTime:               startTime,
+		connChecker:             connChecker,
+		informerFactory:         informerFactory,
+		eventsInformer:          informerFactory.Core().V1().Events(),
+		controlEventsInformer:   controlInformerFactory.ForResource(eventGVR),
+		podsInformer:            informerFactory.Core().V1().Pods(),
+		pcInformer:              informerFactory.Scheduling().V1().PriorityClasses(),
+		pdbInformer:             informerFactory.Policy().V1().PodDisruptionBudgets(),
+		nodeInformer:            informerFactory.Core().V1().Nodes(),
+		csiInformer:             informerFactory.Storage().V1().CSINodes(),
+		caStatusInformerFactory: caStatusInformerFactory,
+		caStatusInformer:        caStatusInformerFactory.Core().V1().ConfigMaps(),
+		controlInformerFactory:  controlInformerFactory,
+		mcdInformer:             controlInformerFactory.ForResource(machineDeploymentGVR),
+		mccInformer:             controlInformerFactory.ForResource(machineClassGVR),
+		deploymentInformer:      controlInformerFactory.ForResource(deploymentGVR),
+		configmapInformer:       controlInformerFactory.ForResource(configmapGVR),
+		workerInformer:          controlInformerFactory.ForResource(workerGVR),
+		dataAccess:              dataAccess,
+		metrics:                 newRecorderMetrics(&params, dataDBPath, startTime, dataAccess.WritePipelineStats),
+		health:                  newRecorderHealth(),
+	}
 }
 
 var _ gsh.Recorder = (*defaultRecorder)(nil)
 
 type defaultRecorder struct {
-	params                 *gsh.RecorderParams
-	startTime              time.Time
-	connChecker            *ConnChecker
-	informerFactory        informers.SharedInformerFactory
-	eventsInformer         corev1informers.EventInformer
-	controlEventsInformer  informers.GenericInformer
-	podsInformer           corev1informers.PodInformer
-	pcInformer             schedulingv1informers.PriorityClassInformer
-	pdbInformer            policyv1informers.PodDisruptionBudgetInformer
-	nodeInformer           corev1informers.NodeInformer
-	csiInformer            storagev1informers.CSINodeInformer
-	controlInformerFactory dynamicinformer.DynamicSharedInformerFactory
-	mcdInformer            informers.GenericInformer
-	mccInformer            informers.GenericInformer
-	workerInformer         informers.GenericInformer
-	deploymentInformer     informers.GenericInformer
-	configmapInformer      informers.GenericInformer
-	dataAccess             *db.DataAccess
+	params                  *gsh.RecorderParams
+	startTime               time.Time
+	connChecker             *ConnChecker
+	informerFactory         informers.SharedInformerFactory
+	eventsInformer          corev1informers.EventInformer
+	controlEventsInformer   informers.GenericInformer
+	podsInformer            corev1informers.PodInformer
+	pcInformer              schedulingv1informers.PriorityClassInformer
+	pdbInformer             policyv1informers.PodDisruptionBudgetInformer
+	nodeInformer            corev1informers.NodeInformer
+	csiInformer             storagev1informers.CSINodeInformer
+	caStatusInformerFactory informers.SharedInformerFactory
+	caStatusInformer        corev1informers.ConfigMapInformer
+	controlInformerFactory  dynamicinformer.DynamicSharedInformerFactory
+	mcdInformer             informers.GenericInformer
+	mccInformer             informers.GenericInformer
+	workerInformer          informers.GenericInformer
+	deploymentInformer      informers.GenericInformer
+	configmapInformer       informers.GenericInformer
+	dataAccess              db.Store
+	metrics                 *recorderMetrics
+	health                  *recorderHealth
+	sessionID               int64
+	// restartResync is set while the informers replay the cluster after a restart of the recorder.
+	restartResync          atomic.Bool
 	nodeAllocatableVolumes sync.Map
 	stopCh                 <-chan struct{}
 }
@@ -152,17 +210,25 @@ func (m sizeLimits) String() string {
 }
 
 func (r *defaultRecorder) Close() error {
+	if r.sessionID != 0 {
+		now := time.Now()
+		r.storeWatchDisconnects(r.health.takeEndedWatchFailures(now, true))
+		err := r.dataAccess.EndRecorderSession(r.sessionID, now)
+		if err != nil {
+			slog.Error("cannot end recorder session", "sessionID", r.sessionID, "error", err)
+		}
+	}
 	err := r.dataAccess.Close()
 	if err != nil {
 		return err
 	}
 	r.informerFactory.Shutdown()
+	r.caStatusInformerFactory.Shutdown()
+	prometheus.DefaultRegisterer.Unregister(r.metrics)
 	r.controlInformerFactory.Shutdown()
 	return nil
 }
 
-var errCount = 0
-
 func GetInnerMapValue(parentMap map[string]any, keys ...string) (any, error) {
 	subkeys := keys[:len(keys)-1]
 	childMap, err := GetInnerMap(parentMap, subkeys...)
@@ -220,10 +286,12 @@ func (r *defaultRecorder) processPC(pcOld, pcNew *schedulingv1.PriorityClass) er
 
 	if pcCountWithSpecHash > 0 {
 		slog.Debug("pc is already inserted with hash", "pc.Name", pcNew.Name, "pc.uid", pcNew.UID, "pc.Hash", pcInfo.Hash)
+		r.metrics.observeDedupSkip("pc_info")
 		return err
 	}
 
 	_, err = r.dataAccess.StorePriorityClassInfo(pcInfo)
+	r.metrics.observeStore("pc_info", err)
 	if err != nil {
 		slog.Error("could not execute pc_info insert", "error", err, "pod.Name", pcInfo.Name, "pod.UID", pcInfo.UID, "pod.CreationTimestamp", pcInfo.CreationTimestamp, "pod.Hash", pcInfo.Hash)
 		return err
@@ -288,10 +356,15 @@ func (r *defaultRecorder) onDeletePod(obj any) {
 	if pod.DeletionTimestamp == nil {
 		return //sometimes this handler is invoked with null deletiontimestamp!
 	}
-	rowsUpdated, err := r.dataAccess.UpdatePodDeletionTimestamp(pod.UID, pod.DeletionTimestamp.Time.UTC())
-	slog.Info("updated deletionTimestamp of pod", "pod.name", pod.Name, "pod.uid", pod.UID, "pod.deletionTimestamp", pod.DeletionTimestamp, "rows.updated", rowsUpdated)
+	err := r.dataAccess.QueuePodDeletionTimestamp(pod.UID, pod.DeletionTimestamp.Time.UTC(), func(result db.WriteResult) {
+		if result.Err != nil {
+			slog.Error("could not execute pod deletion timestamp update", "error", result.Err, "pod.name", pod.Name, "pod.uid", pod.UID)
+			return
+		}
+		slog.Info("updated deletionTimestamp of pod", "pod.name", pod.Name, "pod.uid", pod.UID, "pod.deletionTimestamp", pod.DeletionTimestamp, "rows.updated", result.RowsAffected)
+	})
 	if err != nil {
-		slog.Error("could not execute pod deletion timestamp update", "error", err)
+		slog.Error("could not queue pod deletion timestamp update", "error", err)
 	}
 }
 
@@ -307,11 +380,18 @@ func (r *defaultRecorder) onUpdateNode(old, new any) {
 	}
 	slog.Debug("onUpdateNode invoked.", "nodeNew.name", nodeNew.Name)
 	var nodeOld *corev1.Node
-	if nodeOld != nil {
+	if old != nil {
 		nodeOld = old.(*corev1.Node)
 	}
+	for _, activity := range getScaleDownActivities(nodeOld, nodeNew, time.Now().UTC()) {
+		err := r.dataAccess.QueueNodeScaleDownActivityInfo(activity, r.onScaleDownActivityWritten(activity))
+		if err != nil {
+			slog.Error("cannot queue scale-down activity of node", "node.Name", nodeNew.Name, "activity", activity.Activity, "error", err)
+		}
+	}
 	allocatableVolumes := r.getAllocatableVolumes(nodeNew.Name)
 	nodeNewInfo := gsh.NodeInfoFromNode(nodeNew, allocatableVolumes)
+	inferredAtRestart := r.restartResync.Load()
 	InvokeOrScheduleFunc("onUpdateNode", 10*time.Second, nodeNewInfo, func(_ gst.NodeInfo) error {
 		allocatableVolumes := r.getAllocatableVolumes(nodeNew.Name)
 		if allocatableVolumes == 0 {
@@ -319,21 +399,19 @@ func (r *defaultRecorder) onUpdateNode(old, new any) {
 			return ErrKeyNotFound
 		}
 		nodeNewInfo := gsh.NodeInfoFromNode(nodeNew, allocatableVolumes)
-		countWithSameHash, err := r.dataAccess.CountNodeInfoWithHash(nodeNew.Name, nodeNewInfo.Hash)
-		if err != nil {
-			slog.Error("cannot CountPodInfoWithSpecHash", "node.Name", nodeNew.Name, "node.Hash", nodeNewInfo.Hash, "error", err)
-			return err
-		}
-
-		if countWithSameHash > 0 {
-			slog.Debug("NodeInfo is already present with same hash", "node.Name", nodeNew.Name, "node.Hash", nodeNewInfo.Hash, "count", countWithSameHash, "error", err)
-			return err
-		}
-		_, err = r.dataAccess.StoreNodeInfo(nodeNewInfo)
-		if err != nil {
-			return nil
-		}
-		return nil
+		return r.dataAccess.QueueNodeInfo(nodeNewInfo, inferredAtRestart, func(result db.WriteResult) {
+			if result.Duplicate {
+				slog.Debug("NodeInfo is already present with same hash", "node.Name", nodeNewInfo.Name, "node.Hash", nodeNewInfo.Hash)
+				r.metrics.observeDedupSkip("node_info")
+				return
+			}
+			r.metrics.observeStore("node_info", result.Err)
+			if result.Err != nil {
+				slog.Error("cannot insert node_info in the node_info table", "error", result.Err, "node.Name", nodeNewInfo.Name)
+				return
+			}
+			slog.Info("inserted new row into the node_info table", "node.Name", nodeNewInfo.Name, "RowID", result.RowID)
+		})
 	})
 }
 
@@ -349,34 +427,105 @@ func InvokeOrScheduleFunc[T any](label string, duration time.Duration, entity T,
 	err := fn(entity)
 	if err != nil {
 		if errors.Is(err, ErrKeyNotFound) {
-			time.AfterFunc(duration, func() { InvokeOrScheduleFunc(label, duration, entity, fn) })
+			deferredRetries.Add(1)
+			time.AfterFunc(duration, func() {
+				deferredRetries.Add(-1)
+				InvokeOrScheduleFunc(label, duration, entity, fn)
+			})
 		} else {
 			slog.Info("InvokeOrScheduleFunc", "label", label, "error", err)
 		}
 	}
 }
 
+// getScaleDownActivities returns the scale-down activities observed between nodeOld and nodeNew: cordoning and
+// uncordoning of the node and the addition of the cluster-autoscaler deletion candidate and to-be-deleted taints.
+// No activities are returned for a newly added node since its past activities cannot be timed.
+func getScaleDownActivities(nodeOld, nodeNew *corev1.Node, now time.Time) (activities []gsh.NodeScaleDownActivityInfo) {
+	if nodeOld == nil || nodeNew == nil {
+		return
+	}
+	if nodeNew.Spec.Unschedulable != nodeOld.Spec.Unschedulable {
+		activity := gsh.ScaleDownActivityUncordoned
+		if nodeNew.Spec.Unschedulable {
+			activity = gsh.ScaleDownActivityCordoned
+		}
+		activities = append(activities, gsh.NodeScaleDownActivityInfo{
+			ActivityTime: now,
+			NodeName:     nodeNew.Name,
+			Activity:     activity,
+		})
+	}
+	taintActivities := map[string]gsh.ScaleDownActivity{
+		gsh.DeletionCandidateTaint: gsh.ScaleDownActivityDeletionCandidate,
+		gsh.ToBeDeletedTaint:       gsh.ScaleDownActivityToBeDeleted,
+	}
+	for _, taint := range nodeNew.Spec.Taints {
+		activity, ok := taintActivities[taint.Key]
+		if !ok {
+			continue
+		}
+		if slices.ContainsFunc(nodeOld.Spec.Taints, func(t corev1.Taint) bool { return t.Key == taint.Key }) {
+			continue
+		}
+		activityTime := now
+		if taint.TimeAdded != nil {
+			activityTime = taint.TimeAdded.UTC()
+		}
+		activities = append(activities, gsh.NodeScaleDownActivityInfo{
+			ActivityTime: activityTime,
+			NodeName:     nodeNew.Name,
+			Activity:     activity,
+			Detail:       fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect),
+		})
+	}
+	return
+}
+
 func (r *defaultRecorder) onDeleteNode(obj interface{}) {
 	node := obj.(*corev1.Node)
 	delTimeStamp := time.Now().UTC() // shitty issue where sometimes >node.DeletionTimestamp is nil
 	if node.DeletionTimestamp != nil {
 		delTimeStamp = node.DeletionTimestamp.UTC()
 	}
-	rowsUpdated, err := r.dataAccess.UpdateNodeInfoDeletionTimestamp(node.Name, delTimeStamp)
-	slog.Info("updated DeletionTimestamp of Node.", "node.Name", node.Name, "node.DeletionTimestamp", delTimeStamp, "rows.updated", rowsUpdated)
+	err := r.dataAccess.QueueNodeInfoDeletionTimestamp(node.Name, delTimeStamp, func(result db.WriteResult) {
+		if result.Err != nil {
+			slog.Error("could not execute UpdateNodeInfoDeletionTimestamp ", "error", result.Err, "node.Name", node.Name, "node.DeletionTimestamp", delTimeStamp)
+			return
+		}
+		slog.Info("updated DeletionTimestamp of Node.", "node.Name", node.Name, "node.DeletionTimestamp", delTimeStamp, "rows.updated", result.RowsAffected)
+	})
 	if err != nil {
-		slog.Error("could not execute UpdateNodeInfoDeletionTimestamp ", "error", err, "node.Name", node.Name, "node.DeletionTimestamp", delTimeStamp)
+		slog.Error("could not queue UpdateNodeInfoDeletionTimestamp ", "error", err, "node.Name", node.Name)
+	}
+	activity := gsh.NodeScaleDownActivityInfo{
+		ActivityTime: delTimeStamp,
+		NodeName:     node.Name,
+		Activity:     gsh.ScaleDownActivityDeleted,
+	}
+	err = r.dataAccess.QueueNodeScaleDownActivityInfo(activity, r.onScaleDownActivityWritten(activity))
+	if err != nil {
+		slog.Error("cannot queue scale-down activity of node", "node.Name", node.Name, "activity", activity.Activity, "error", err)
+	}
+}
+
+func (r *defaultRecorder) onScaleDownActivityWritten(activity gsh.NodeScaleDownActivityInfo) func(db.WriteResult) {
+	return func(result db.WriteResult) {
+		r.metrics.observeStore("node_scale_down_activity_info", result.Err)
+		if result.Err != nil {
+			slog.Error("cannot store scale-down activity of node", "node.Name", activity.NodeName, "activity", activity.Activity, "error", result.Err)
+		}
 	}
 }
 
 func (r *defaultRecorder) onAddEvent(obj any) {
 	event := obj.(*corev1.Event)
 	isCAEvent := event.Source.Component == "cluster-autoscaler" || event.ReportingController == "cluster-autoscaler"
-	isSchedulerEvent := strings.Contains(event.Source.Component, "scheduler") ||
-		strings.Contains(event.ReportingController, "scheduler")
-	isTriggerScaleUp := strings.Contains(event.Reason, "TriggeredScaleUp")
+	isSchedulerEvent := isSchedulerEvent(event)
+	isTriggerScaleUp := strings.Contains(event.Reason, gsh.TriggeredScaleUpReason)
 	//isScaledUpNodeGroupEvent := strings.Contains(event.Reason, "ScaledUpGroup")
 	isNodeControllerEvent := strings.Contains(event.ReportingController, "node-controller")
+	isScaleDownEvent := scaleDownEventReasons.Has(event.Reason)
 	var eventTime time.Time
 	if !event.EventTime.IsZero() {
 		eventTime = event.EventTime.Time.UTC()
@@ -394,14 +543,64 @@ func (r *defaultRecorder) onAddEvent(obj any) {
 	if isTriggerScaleUp {
 		slog.Info("onAddEvent: TriggeredScaleUp.", "event.Message", event.Message, "event.CreationTimestamp", event.CreationTimestamp)
 	}
-	var reportingController string
-	if event.ReportingController != "" {
-		reportingController = event.ReportingController
-	} else {
-		reportingController = event.Source.Component
+	eventInfo := newEventInfo(event, eventTime)
+	if isCAEvent || isSchedulerEvent || isNodeControllerEvent || isScaleDownEvent {
+		err := r.dataAccess.QueueEventInfo(eventInfo, r.onEventInfoWritten)
+		if err != nil {
+			slog.Error("could not queue event insert", "error", err)
+		}
+	}
+	if isSchedulerEvent && event.Reason == gsh.FailedSchedulingReason {
+		count, lastTime := getEventOccurrence(event, eventTime)
+		r.storeFailedSchedulingInfo(newEventInfo(event, lastTime), count)
+	}
+	if isCAEvent && caScalingEventReasons.Has(event.Reason) {
+		r.storeCAScalingEventInfos(eventInfo)
+	}
+}
+
+// onUpdateEvent stores the new occurrence of an updated FailedScheduling event. The API server merges a repeated event
+// into the existing event by incrementing its count and updating its last timestamp, so every occurrence after the
+// first one is only observed as an update.
+func (r *defaultRecorder) onUpdateEvent(oldObj, newObj any) {
+	oldEvent, event := oldObj.(*corev1.Event), newObj.(*corev1.Event)
+	if !isSchedulerEvent(event) || event.Reason != gsh.FailedSchedulingReason {
+		return
+	}
+	count, lastTime := getEventOccurrence(event, time.Now().UTC())
+	oldCount, _ := getEventOccurrence(oldEvent, lastTime)
+	if count == oldCount || lastTime.Before(r.startTime) {
+		return
+	}
+	r.storeFailedSchedulingInfo(newEventInfo(event, lastTime), count)
+}
+
+func isSchedulerEvent(event *corev1.Event) bool {
+	return strings.Contains(event.Source.Component, "scheduler") || strings.Contains(event.ReportingController, "scheduler")
+}
+
+// getEventOccurrence returns the number of occurrences of the given event and the time of the latest one, falling back
+// to the given defaultTime if the event has no timestamp of its latest occurrence.
+func getEventOccurrence(event *corev1.Event, defaultTime time.Time) (count int, lastTime time.Time) {
+	count, lastTime = max(int(event.Count), 1), defaultTime
+	if event.Series != nil {
+		count = max(int(event.Series.Count), count)
+		if !event.Series.LastObservedTime.IsZero() {
+			return count, event.Series.LastObservedTime.Time.UTC()
+		}
+	}
+	if !event.LastTimestamp.IsZero() {
+		lastTime = event.LastTimestamp.Time.UTC()
 	}
+	return
+}
 
-	eventInfo := gst.EventInfo{
+func newEventInfo(event *corev1.Event, eventTime time.Time) gst.EventInfo {
+	reportingController := event.ReportingController
+	if reportingController == "" {
+		reportingController = event.Source.Component
+	}
+	return gst.EventInfo{
 		UID:                     string(event.UID),
 		EventTime:               eventTime,
 		ReportingController:     reportingController,
@@ -412,15 +611,70 @@ func (r *defaultRecorder) onAddEvent(obj any) {
 		InvolvedObjectNamespace: event.InvolvedObject.Namespace,
 		InvolvedObjectUID:       string(event.InvolvedObject.UID),
 	}
-	if isCAEvent || isSchedulerEvent || isNodeControllerEvent {
-		err := r.dataAccess.StoreEventInfo(eventInfo)
+}
+
+func (r *defaultRecorder) onEventInfoWritten(result db.WriteResult) {
+	if result.Duplicate {
+		r.metrics.observeDedupSkip("event_info")
+		return
+	}
+	r.metrics.observeStore("event_info", result.Err)
+	if result.Err != nil {
+		slog.Error("could not execute event insert", "error", result.Err)
+	}
+}
+
+func (r *defaultRecorder) storeCAScalingEventInfos(eventInfo gst.EventInfo) {
+	scalingEventInfos, err := gsh.CAScalingEventInfosFromEvent(eventInfo)
+	if err != nil {
+		slog.Warn("cannot parse CA scaling event", "event.UID", eventInfo.UID, "event.Reason", eventInfo.Reason, "event.Message", eventInfo.Message, "error", err)
+		return
+	}
+	for _, scalingEventInfo := range scalingEventInfos {
+		err = r.dataAccess.QueueCAScalingEventInfo(scalingEventInfo, func(result db.WriteResult) {
+			if result.Duplicate {
+				r.metrics.observeDedupSkip("ca_scaling_event_info")
+				return
+			}
+			r.metrics.observeStore("ca_scaling_event_info", result.Err)
+			if result.Err != nil {
+				slog.Error("cannot store CAScalingEventInfo", "event.UID", eventInfo.UID, "error", result.Err)
+			}
+		})
 		if err != nil {
-			slog.Error("could not execute event insert", "error", err)
-			errCount++
+			slog.Error("cannot queue CAScalingEventInfo", "event.UID", eventInfo.UID, "error", err)
 		}
 	}
 }
 
+// storeFailedSchedulingInfo stores the occurrence of the given FailedScheduling event with the given count. The zone is
+// taken from the node selector or node affinity of the pod if the pod is known to the pods informer.
+func (r *defaultRecorder) storeFailedSchedulingInfo(eventInfo gst.EventInfo, count int) {
+	failedSchedulingInfo, err := gsh.FailedSchedulingInfoFromEvent(eventInfo)
+	if err != nil {
+		slog.Warn("cannot parse FailedScheduling event", "event.UID", eventInfo.UID, "event.Message", eventInfo.Message, "error", err)
+		return
+	}
+	failedSchedulingInfo.Count = count
+	pod, err := r.podsInformer.Lister().Pods(eventInfo.InvolvedObjectNamespace).Get(eventInfo.InvolvedObjectName)
+	if err == nil {
+		failedSchedulingInfo.Zone = gsh.PodZone(pod.Spec)
+	}
+	err = r.dataAccess.QueueFailedSchedulingInfo(failedSchedulingInfo, func(result db.WriteResult) {
+		if result.Duplicate {
+			r.metrics.observeDedupSkip("failed_scheduling_reason_info")
+			return
+		}
+		r.metrics.observeStore("failed_scheduling_reason_info", result.Err)
+		if result.Err != nil {
+			slog.Error("cannot store FailedSchedulingInfo", "event.UID", eventInfo.UID, "error", result.Err)
+		}
+	})
+	if err != nil {
+		slog.Error("cannot queue FailedSchedulingInfo", "event.UID", eventInfo.UID, "error", err)
+	}
+}
+
 func parseMachineSetScaleUpMessage(msg string) (targetSize int, err error) {
 	groups := machineSetScaleUpRegex.FindStringSubmatch(msg)
 	targetSize, err = strconv.Atoi(groups[1])
@@ -464,9 +718,11 @@ func (r *defaultRecorder) processWorker(workerOld, workerNew *unstructured.Unstr
 		existingHash, ok := oldPoolInfoHashes[name]
 		if ok && existingHash == poolNew.Hash {
 			slog.Info("Skipping store of poolNew since it has same hash as poolOld.", "Name", name, "Hash", poolNew.Hash)
+			r.metrics.observeDedupSkip("worker_pool_info")
 			continue
 		}
 		_, err = r.dataAccess.StoreWorkerPoolInfo(poolNew)
+		r.metrics.observeStore("worker_pool_info", err)
 		if err != nil {
 			return fmt.Errorf("cannot store WorkerPoolInfo %q: %w", poolNew, err)
 		}
@@ -485,23 +741,20 @@ func (r *defaultRecorder) processPod(podOld, podNew *corev1.Pod) error {
 		return nil
 	}
 
-	podCountWithSpecHash, err := r.dataAccess.CountPodInfoWithSpecHash(string(podNew.UID), podInfo.Hash)
-	if err != nil {
-		slog.Error("CountPodInfoWithSpecHash failed", "error", err, "pod.Name", podNew.Name, "pod.uid", podNew.UID, "pod.hash", podInfo.Hash)
-		return err
-	}
-
-	if podCountWithSpecHash > 0 {
-		slog.Debug("pod is already inserted with hash", "pod.Name", podNew.Name, "pod.uid", podNew.UID, "pod.nodeName", podNew.Spec.NodeName, "pod.Hash", podInfo.Hash)
-		return err
-	}
-
-	_, err = r.dataAccess.StorePodInfo(podInfo)
-	if err != nil {
-		slog.Error("could not execute pod_info insert", "error", err, "pod.Name", podInfo.Name, "pod.UID", podInfo.UID, "pod.CreationTimestamp", podInfo.CreationTimestamp, "pod.Hash", podInfo.Hash)
-		return err
-	}
-	return nil
+	inferredAtRestart := r.restartResync.Load()
+	return r.dataAccess.QueuePodInfo(podInfo, inferredAtRestart, func(result db.WriteResult) {
+		if result.Duplicate {
+			slog.Debug("pod is already inserted with hash", "pod.Name", podInfo.Name, "pod.uid", podInfo.UID, "pod.nodeName", podInfo.NodeName, "pod.Hash", podInfo.Hash)
+			r.metrics.observeDedupSkip("pod_info")
+			return
+		}
+		r.metrics.observeStore("pod_info", result.Err)
+		if result.Err != nil {
+			slog.Error("could not execute pod_info insert", "error", result.Err, "pod.Name", podInfo.Name, "pod.UID", podInfo.UID, "pod.CreationTimestamp", podInfo.CreationTimestamp, "pod.Hash", podInfo.Hash)
+			return
+		}
+		slog.Info("stored row into pod_info.", "pod.Name", podInfo.Name, "pod.Namespace", podInfo.Namespace, "pod.Hash", podInfo.Hash, "RowID", result.RowID)
+	})
 }
 
 func (r *defaultRecorder) onAddWorker(obj interface{}) {
@@ -585,12 +838,24 @@ func (r *defaultRecorder) Start(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	_, err = r.dataAccess.GetInitialRecorderStartTime()
+	if err == nil {
+		slog.Info("recorder restarted, rows stored until the informers are synced are flagged as inferred at restart")
+		r.restartResync.Store(true)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("cannot get the initial recorder start time: %w", err)
+	}
 	err = r.dataAccess.InsertRecorderStartTime(r.startTime)
 	if err != nil {
 		return err
 	}
+	r.sessionID, err = r.dataAccess.StartRecorderSession(r.startTime)
+	if err != nil {
+		return err
+	}
 	_, err = r.eventsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: r.onAddEvent,
+		AddFunc:    r.onAddEvent,
+		UpdateFunc: r.onUpdateEvent,
 	})
 	if err != nil {
 		return fmt.Errorf("cannot add event handler on eventsInformer: %w", err)
@@ -604,7 +869,7 @@ func (r *defaultRecorder) Start(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("cannot add event handler on workerInformer: %w", err)
 	}
-	_, err = r.podsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+	podsRegistration, err := r.podsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    r.onAddPod,
 		UpdateFunc: r.onUpdatePod,
 		DeleteFunc: r.onDeletePod,
@@ -629,7 +894,7 @@ func (r *defaultRecorder) Start(ctx context.Context) error {
 		return fmt.Errorf("cannot add event handlers on pdbInformer: %w", err)
 	}
 
-	_, err = r.nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+	nodesRegistration, err := r.nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    r.onAddNode,
 		UpdateFunc: r.onUpdateNode,
 		DeleteFunc: r.onDeleteNode,
@@ -638,11 +903,14 @@ func (r *defaultRecorder) Start(ctx context.Context) error {
 		return fmt.Errorf("cannot add event handlers on pdbInformer: %w", err)
 	}
 
-	_, err = r.mcdInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+	mcdsRegistration, err := r.mcdInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    r.onAddMCD,
 		UpdateFunc: r.onUpdateMCD,
 		DeleteFunc: r.onDeleteMCD,
 	})
+	if err != nil {
+		return fmt.Errorf("cannot add event handlers on mcdInformer: %w", err)
+	}
 
 	_, err = r.mccInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    r.onAddMCC,
@@ -670,9 +938,28 @@ func (r *defaultRecorder) Start(ctx context.Context) error {
 		DeleteFunc: r.onDeleteCSINode,
 	})
 
+	_, err = r.caStatusInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    r.onAddCAStatus,
+		UpdateFunc: r.onUpdateCAStatus,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot add event handlers on caStatusInformer: %w", err)
+	}
+
+	err = r.trackInformers()
+	if err != nil {
+		return err
+	}
+
 	stopCh := ctx.Done()
 	r.stopCh = stopCh
 	r.runInformers(stopCh)
+	r.health.markStarted()
+	go r.connChecker.RunPeriodically(ctx, connCheckInterval)
+	go r.runHeartbeat(ctx)
+	if r.params.CheckpointInterval > 0 {
+		go r.runCheckpoints(ctx, r.params.CheckpointInterval)
+	}
 
 	slog.Info("Waiting for caches to be synced...")
 	if !cache.WaitForCacheSync(ctx.Done(),
@@ -681,23 +968,187 @@ func (r *defaultRecorder) Start(ctx context.Context) error {
 		r.mcdInformer.Informer().HasSynced,
 		r.podsInformer.Informer().HasSynced,
 		r.csiInformer.Informer().HasSynced,
+		r.caStatusInformer.Informer().HasSynced,
 		r.nodeInformer.Informer().HasSynced,
 		r.workerInformer.Informer().HasSynced,
 		r.eventsInformer.Informer().HasSynced,
-		r.controlEventsInformer.Informer().HasSynced) {
+		r.controlEventsInformer.Informer().HasSynced,
+		podsRegistration.HasSynced,
+		nodesRegistration.HasSynced,
+		mcdsRegistration.HasSynced) {
 		return fmt.Errorf("could not sync caches for informers")
 	}
 	slog.Info("Informer caches are synced")
+	r.restartResync.Store(false)
+	err = r.reconcileDeletions()
+	if err != nil {
+		return err
+	}
 	context.AfterFunc(ctx, func() {
 		_ = r.Close()
 	})
 	return nil
 }
 
+// trackInformers tracks the sync status, last event time and watch errors of all informers for metrics and health
+// checks and registers the recorder metrics with the prometheus default registerer.
+func (r *defaultRecorder) trackInformers() error {
+	informersByName := map[string]cache.SharedIndexInformer{
+		"events":             r.eventsInformer.Informer(),
+		"pods":               r.podsInformer.Informer(),
+		"priorityclasses":    r.pcInformer.Informer(),
+		"pdbs":               r.pdbInformer.Informer(),
+		"nodes":              r.nodeInformer.Informer(),
+		"csinodes":           r.csiInformer.Informer(),
+		"castatus":           r.caStatusInformer.Informer(),
+		"controlevents":      r.controlEventsInformer.Informer(),
+		"machinedeployments": r.mcdInformer.Informer(),
+		"machineclasses":     r.mccInformer.Informer(),
+		"workers":            r.workerInformer.Informer(),
+		"deployments":        r.deploymentInformer.Informer(),
+		"configmaps":         r.configmapInformer.Informer(),
+	}
+	for name, informer := range informersByName {
+		err := r.metrics.trackInformer(name, informer)
+		if err != nil {
+			return fmt.Errorf("cannot track metrics of informer %q: %w", name, err)
+		}
+		err = r.health.trackInformer(name, informer)
+		if err != nil {
+			return fmt.Errorf("cannot track health of informer %q: %w", name, err)
+		}
+	}
+	err := r.metrics.register(prometheus.DefaultRegisterer)
+	if err != nil {
+		return fmt.Errorf("cannot register recorder metrics: %w", err)
+	}
+	return nil
+}
+
+// reconcileDeletions marks the pods, nodes and machine deployments which are not deleted in the data db but are
+// absent from the synced informer caches as deleted at the recorder start time. These objects were deleted while the
+// recorder was down and their deletion is flagged as inferred at restart. The queued writes are flushed first so that
+// the rows of the objects replayed by the informers are stored before the not deleted rows are loaded.
+func (r *defaultRecorder) reconcileDeletions() error {
+	err := r.dataAccess.FlushWrites()
+	if err != nil {
+		return fmt.Errorf("cannot flush the queued writes before reconciling deletions: %w", err)
+	}
+	podUIDs := sets.New[string]()
+	for _, obj := range r.podsInformer.Informer().GetStore().List() {
+		podUIDs.Insert(string(obj.(*corev1.Pod).UID))
+	}
+	err = reconcileDeletions("pod_info", podUIDs, r.dataAccess.LoadPodUIDsNotDeleted, func(uid string) (int64, error) {
+		return r.dataAccess.UpdatePodDeletionInferredAtRestart(uid, r.startTime)
+	})
+	if err != nil {
+		return err
+	}
+	nodeNames := sets.New(r.nodeInformer.Informer().GetStore().ListKeys()...)
+	err = reconcileDeletions("node_info", nodeNames, r.dataAccess.LoadNodeNamesNotDeleted, func(name string) (int64, error) {
+		return r.dataAccess.UpdateNodeDeletionInferredAtRestart(name, r.startTime)
+	})
+	if err != nil {
+		return err
+	}
+	mcdNames := sets.New[string]()
+	for _, obj := range r.mcdInformer.Informer().GetStore().List() {
+		mcdNames.Insert(obj.(*unstructured.Unstructured).GetName())
+	}
+	return reconcileDeletions("mcd_info", mcdNames, r.dataAccess.LoadMCDNamesNotDeleted, func(name string) (int64, error) {
+		return r.dataAccess.UpdateMCDDeletionInferredAtRestart(name, r.startTime)
+	})
+}
+
+// reconcileDeletions invokes markDeleted for each key returned by loadNotDeleted that is not in the given present keys.
+func reconcileDeletions(table string, presentKeys sets.Set[string], loadNotDeleted func() ([]string, error), markDeleted func(key string) (int64, error)) error {
+	notDeletedKeys, err := loadNotDeleted()
+	if err != nil {
+		return fmt.Errorf("cannot load the not deleted keys of %s: %w", table, err)
+	}
+	var numVanished int
+	for _, key := range notDeletedKeys {
+		if presentKeys.Has(key) {
+			continue
+		}
+		rowsUpdated, err := markDeleted(key)
+		if err != nil {
+			return fmt.Errorf("cannot mark %q of %s as deleted at restart: %w", key, table, err)
+		}
+		slog.Info("marked object vanished while the recorder was down as deleted", "table", table, "key", key, "rows.updated", rowsUpdated)
+		numVanished++
+	}
+	slog.Info("reconciled deletions at restart", "table", table, "numVanished", numVanished)
+	return nil
+}
+
+// runHeartbeat stores the heartbeat of the recorder session and the watch disconnects which have ended every
+// heartbeatInterval until the context is done.
+func (r *defaultRecorder) runHeartbeat(ctx context.Context) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			err := r.dataAccess.UpdateRecorderSessionHeartbeat(r.sessionID, now)
+			if err != nil {
+				slog.Error("cannot store recorder session heartbeat", "sessionID", r.sessionID, "error", err)
+			}
+			r.storeWatchDisconnects(r.health.takeEndedWatchFailures(now, false))
+		}
+	}
+}
+
+// runCheckpoints stores a checkpoint of the latest rows every interval until the context is done and prunes the
+// checkpoints beyond the CheckpointRetention.
+func (r *defaultRecorder) runCheckpoints(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := r.dataAccess.StoreCheckpoint(time.Now())
+			r.metrics.observeStore("checkpoint_info", err)
+			if err != nil {
+				slog.Error("cannot store checkpoint", "error", err)
+				continue
+			}
+			if r.params.CheckpointRetention > 0 {
+				_, err = r.dataAccess.PruneCheckpoints(r.params.CheckpointRetention)
+				if err != nil {
+					slog.Error("cannot prune checkpoints", "retention", r.params.CheckpointRetention, "error", err)
+				}
+			}
+		}
+	}
+}
+
+func (r *defaultRecorder) storeWatchDisconnects(watchFailures map[string]watchFailure) {
+	for name, failure := range watchFailures {
+		_, err := r.dataAccess.StoreWatchDisconnectInfo(gsh.WatchDisconnectInfo{
+			SessionID: r.sessionID,
+			Informer:  name,
+			BeginTime: failure.since,
+			EndTime:   failure.lastAt,
+			Error:     failure.lastErr.Error(),
+		})
+		r.metrics.observeStore("watch_disconnect_info", err)
+		if err != nil {
+			slog.Error("cannot store watch disconnect", "informer", name, "error", err)
+		}
+	}
+}
+
 func (r *defaultRecorder) runInformers(stopCh <-chan struct{}) {
 	slog.Info("Calling informerFactory.Start()")
 	slog.Info("Calling controllerInformerFactory.Start()")
 	r.informerFactory.Start(stopCh)
+	r.caStatusInformerFactory.Start(stopCh)
 	r.controlInformerFactory.Start(stopCh)
 }
 
@@ -830,69 +1281,118 @@ func getCACommand(deployment *unstructured.Unstructured) ([]string, error) {
 		return []string{}, err
 	}
 	caContainer := (specMap["containers"].([]interface{})[0]).(map[string]interface{})
-	return lo.Map(caContainer["command"].([]interface{}), func(item interface{}, _ int) string {
-		return item.(string)
-	}), nil
+	var caCommand []string
+	for _, key := range []string{"command", "args"} {
+		items, ok := caContainer[key].([]interface{})
+		if !ok {
+			continue
+		}
+		caCommand = append(caCommand, lo.Map(items, func(item interface{}, _ int) string {
+			return item.(string)
+		})...)
+	}
+	return caCommand, nil
 }
 
+// processCACommand returns a map of all the flags present in the given caCommand to their values. Flag values may be
+// given as "--flag=value" or, except for the caBoolFlags, as "--flag value". Boolean flags specified without a value
+// are recorded as "true". A flag without a value that is not followed by a value is recorded as "true" as well since
+// it is an unknown boolean flag.
 func processCACommand(caCommand []string) (result map[string]string) {
-	//TODO get the priority expander for CA and record the priorities
 	result = make(map[string]string)
-	for _, command := range caCommand {
-		command = strings.TrimPrefix(command, "--")
-		commandParts := strings.Split(command, "=")
-		if len(commandParts) < 2 {
+	for i := 0; i < len(caCommand); i++ {
+		command := caCommand[i]
+		if !strings.HasPrefix(command, "-") {
 			continue
 		}
-		key := commandParts[0]
-		value := commandParts[1]
-		if caOptions.Has(key) {
-			result[key] = value
+		command = strings.TrimLeft(command, "-")
+		key, value, found := strings.Cut(command, "=")
+		if key == "" {
+			continue
+		}
+		if !found {
+			value = "true"
+			if !caBoolFlags.Has(key) && i+1 < len(caCommand) && !strings.HasPrefix(caCommand[i+1], "-") {
+				i++
+				value = caCommand[i]
+			}
 		}
+		result[key] = value
 	}
 	return
 }
 
-func parseCACommand(caCommand map[string]string) (caSettings gst.CASettingsInfo, err error) {
+// getCAArg returns the value of the given CA flag from caArgs falling back to the CA default if the flag is not set.
+func getCAArg(caArgs map[string]string, key string) string {
+	val, ok := caArgs[key]
+	if ok {
+		return val
+	}
+	return gsh.CADefaultArgs[key]
+}
 
-	caSettings.Expander = caCommand["expander"]
-	caSettings.MaxNodeProvisionTime, err = time.ParseDuration(caCommand["max-node-provision-time"])
+// ParseCACommand parses the CASettingsInfo from the given cluster-autoscaler flags, using the CA defaults for flags that
+// are not set.
+func ParseCACommand(caCommand map[string]string) (caSettings gst.CASettingsInfo, err error) {
+	caSettings.Expander = getCAArg(caCommand, "expander")
+	caSettings.MaxNodeProvisionTime, err = time.ParseDuration(getCAArg(caCommand, "max-node-provision-time"))
 	if err != nil {
 		err = fmt.Errorf("cannot parse max-node-provision-time  to duration: %w", err)
 		return
 	}
-	caSettings.ScanInterval, err = time.ParseDuration(caCommand["scan-interval"])
+	caSettings.ScanInterval, err = time.ParseDuration(getCAArg(caCommand, "scan-interval"))
 	if err != nil {
 		err = fmt.Errorf("cannot parse scan-interval  to duration: %w", err)
 		return
 	}
-	caSettings.MaxGracefulTerminationSeconds, err = strconv.Atoi(caCommand["max-graceful-termination-sec"])
+	caSettings.MaxGracefulTerminationSeconds, err = strconv.Atoi(getCAArg(caCommand, "max-graceful-termination-sec"))
 	if err != nil {
 		err = fmt.Errorf("cannot parse max-graceful-termination-sec  to int: %w", err)
 		return
 	}
-	caSettings.NewPodScaleUpDelay, err = time.ParseDuration(caCommand["new-pod-scale-up-delay"])
+	caSettings.NewPodScaleUpDelay, err = time.ParseDuration(getCAArg(caCommand, "new-pod-scale-up-delay"))
 	if err != nil {
 		err = fmt.Errorf("cannot parse new-pod-scale-up-delay to duration: %w", err)
 		return
 	}
-	caSettings.MaxEmptyBulkDelete, err = strconv.Atoi(caCommand["max-empty-bulk-delete"])
+	caSettings.MaxEmptyBulkDelete, err = strconv.Atoi(getCAArg(caCommand, "max-empty-bulk-delete"))
 	if err != nil {
 		err = fmt.Errorf("cannot parse max-empty-bulk-delete to int: %w", err)
 		return
 	}
-	caSettings.IgnoreDaemonSetUtilization, err = strconv.ParseBool(caCommand["ignore-daemonsets-utilization"])
+	caSettings.IgnoreDaemonSetUtilization, err = strconv.ParseBool(getCAArg(caCommand, "ignore-daemonsets-utilization"))
 	if err != nil {
 		err = fmt.Errorf("cannot parse ignore-daemonsets-utilization  to bool: %w", err)
 		return
 	}
-	caSettings.MaxNodesTotal, err = strconv.Atoi(caCommand["max-nodes-total"])
+	caSettings.MaxNodesTotal, err = strconv.Atoi(getCAArg(caCommand, "max-nodes-total"))
 	if err != nil {
 		err = fmt.Errorf("cannot convert maxNodesTotal string to int: %w", err)
 		return
 	}
 	return
 }
+
+func (r *defaultRecorder) storeCAArgs(caArgs map[string]string) error {
+	caArgsInfo := gsh.CAArgsInfo{
+		SnapshotTimestamp: time.Now().UTC(),
+		Args:              caArgs,
+	}
+	caArgsInfo.Hash = caArgsInfo.GetHash()
+	storedCAArgsInfo, err := r.dataAccess.GetLatestCAArgsInfo()
+	if err != nil {
+		return fmt.Errorf("cannot get the latest ca args stored in db: %w", err)
+	}
+	if storedCAArgsInfo != nil && storedCAArgsInfo.Hash == caArgsInfo.Hash {
+		slog.Debug("skipping store of CAArgsInfo since it has same hash as latest", "Hash", caArgsInfo.Hash)
+		r.metrics.observeDedupSkip("ca_args_info")
+		return nil
+	}
+	_, err = r.dataAccess.StoreCAArgsInfo(caArgsInfo)
+	r.metrics.observeStore("ca_args_info", err)
+	return err
+}
+
 func (r *defaultRecorder) onAddDeployment(obj interface{}) {
 	deployment := obj.(*unstructured.Unstructured)
 	if deployment.GetName() != "cluster-autoscaler" {
@@ -904,8 +1404,12 @@ func (r *defaultRecorder) onAddDeployment(obj interface{}) {
 		return
 	}
 	processedCACommand := processCACommand(caCommands)
+	err = r.storeCAArgs(processedCACommand)
+	if err != nil {
+		slog.Error("cannot store ca args in ca_args_info", "error", err)
+	}
 
-	caSettings, err := parseCACommand(processedCACommand)
+	caSettings, err := ParseCACommand(processedCACommand)
 	if err != nil {
 		slog.Error("cannot parse the ca command from deployment", "error", err)
 		return
@@ -924,10 +1428,13 @@ func (r *defaultRecorder) onAddDeployment(obj interface{}) {
 	caSettings.Hash = caSettings.GetHash()
 	if storedCASettingsInfo == nil || storedCASettingsInfo.Hash != caSettings.Hash {
 		_, err := r.dataAccess.StoreCASettingsInfo(caSettings)
+		r.metrics.observeStore("ca_settings_info", err)
 		if err != nil {
 			slog.Error("cannot store ca settings in ca_settings_info", "error", err)
 			return
 		}
+	} else {
+		r.metrics.observeDedupSkip("ca_settings_info")
 	}
 	return
 
@@ -973,10 +1480,13 @@ func (r *defaultRecorder) onAddConfigMap(obj interface{}) {
 	}
 	if latestCaDeployment == nil || latestCaDeployment.Hash != caSettings.Hash {
 		_, err = r.dataAccess.StoreCASettingsInfo(caSettings)
+		r.metrics.observeStore("ca_settings_info", err)
 		if err != nil {
 			slog.Error("cannot store ca settings in ca_settings_info", "error", err)
 			return
 		}
+	} else {
+		r.metrics.observeDedupSkip("ca_settings_info")
 	}
 	return
 }
@@ -985,6 +1495,46 @@ func (r *defaultRecorder) onUpdateConfigMap(_, newObj interface{}) {
 	r.onAddConfigMap(newObj)
 }
 
+func (r *defaultRecorder) onAddCAStatus(obj interface{}) {
+	configMap := obj.(*corev1.ConfigMap)
+	if configMap.Name != gsh.CAStatusConfigMapName {
+		return
+	}
+	err := r.processCAStatus(configMap)
+	if err != nil {
+		slog.Error("onAddCAStatus failed.", "error", err)
+	}
+}
+
+func (r *defaultRecorder) onUpdateCAStatus(_, newObj interface{}) {
+	r.onAddCAStatus(newObj)
+}
+
+// processCAStatus parses the cluster-autoscaler status config map and stores a CANodeGroupStatusInfo for every
+// node group whose status has changed since the last recorded one.
+func (r *defaultRecorder) processCAStatus(configMap *corev1.ConfigMap) error {
+	statusInfos, err := gsh.CANodeGroupStatusInfosFromConfigMap(configMap)
+	if err != nil {
+		return err
+	}
+	latestHashes, err := r.dataAccess.LoadLatestCANodeGroupStatusInfoHashes()
+	if err != nil {
+		return err
+	}
+	for _, statusInfo := range statusInfos {
+		if latestHashes[statusInfo.Name] == statusInfo.Hash {
+			r.metrics.observeDedupSkip("ca_nodegroup_status_info")
+			continue
+		}
+		_, err = r.dataAccess.StoreCANodeGroupStatusInfo(statusInfo)
+		r.metrics.observeStore("ca_nodegroup_status_info", err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func getEventTimeFromUnstructured(event *unstructured.Unstructured) (eventTime time.Time, err error) {
 	firstTimestamp, ftok := event.UnstructuredContent()["firstTimestamp"].(*time.Time)
 	lastTimestamp, ltok := event.UnstructuredContent()["lastTimestamp"].(*time.Time)
@@ -1055,9 +1605,9 @@ func (r *defaultRecorder) onAddControlEvent(obj interface{}) {
 		InvolvedObjectNamespace: involvedObjectNamespace.(string),
 		InvolvedObjectUID:       involvedObjectUID.(string),
 	}
-	err = r.dataAccess.StoreEventInfo(event)
+	err = r.dataAccess.QueueEventInfo(event, r.onEventInfoWritten)
 	if err != nil {
-		slog.Error("cannot store the event in event_info", "error", err, "event", eventName)
+		slog.Error("cannot queue the event for event_info", "error", err, "event", eventName)
 		return
 	}
 	if eventKind.(string) != "MachineDeployment" {
@@ -1127,9 +1677,15 @@ func (r *defaultRecorder) processMCD(mcdOld, mcdNew *unstructured.Unstructured)
 		return err
 	}
 	if mcdOldHash != mcdNewInfo.Hash {
-		_, err = r.dataAccess.StoreMachineDeploymentInfo(mcdNewInfo)
+		var rowID int64
+		rowID, err = r.dataAccess.StoreMachineDeploymentInfo(mcdNewInfo)
+		r.metrics.observeStore("mcd_info", err)
+		if err == nil && r.restartResync.Load() {
+			err = r.dataAccess.MarkMCDInfoInferredAtRestart(rowID)
+		}
 	} else {
 		slog.Info("skipping store of MachineDeploymentInfo", "Name", mcdName, "Hash", mcdNewInfo.Hash)
+		r.metrics.observeDedupSkip("mcd_info")
 	}
 	return err
 }
@@ -1159,8 +1715,10 @@ func (r *defaultRecorder) processMCC(mccOld, mccNew *unstructured.Unstructured)
 	}
 	if mccOldHash != mccNewInfo.Hash {
 		_, err = r.dataAccess.StoreMachineClassInfo(mccNewInfo)
+		r.metrics.observeStore("mcc_info", err)
 	} else {
 		slog.Info("skipping store of MachineClassInfo", "Name", mccName, "Hash", mccNewInfo.Hash)
+		r.metrics.observeDedupSkip("mcc_info")
 	}
 	return err
 }
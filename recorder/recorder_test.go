@@ -1,48 +1,96 @@
 package recorder
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
 	gcr "github.com/elankath/gardener-scaling-history"
+	"github.com/elankath/gardener-scaling-history/db"
+	gst "github.com/elankath/gardener-scaling-types"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/samber/lo"
 	assert "github.com/stretchr/testify/require"
+	"io"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/util/json"
-	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
 	"os"
+	"path"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseWorkerPools(t *testing.T) {
-	data, err := os.ReadFile("worker.yaml")
-	if err != nil {
-		t.Fatalf("error reading the worker file %v", err)
-		return
-	}
-	u := &unstructured.Unstructured{}
-	err = yaml.Unmarshal(data, u)
-	if err != nil {
-		t.Fatalf("error unmarshalling %v", err)
-		return
-	}
-	fmt.Println(u)
-	_, err = getWorkerPoolInfos(u)
-	if err != nil {
-		t.Fatalf("error parsing worker pools %v", err)
-		return
-	}
+	timestamp := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	worker := newTestWorker(testShootNamespace, timestamp, 3)
+	poolInfos, err := gcr.WorkerPoolInfosFromUnstructured(worker)
+	assert.Nil(t, err)
+	assert.Len(t, poolInfos, 1)
+	pool := poolInfos["p1"]
+	assert.Equal(t, timestamp, pool.SnapshotTimestamp)
+	assert.Equal(t, 1, pool.Minimum)
+	assert.Equal(t, 3, pool.Maximum)
+	assert.Equal(t, intstr.FromInt32(1), pool.MaxSurge)
+	assert.Equal(t, intstr.FromInt32(0), pool.MaxUnavailable)
+	assert.Equal(t, "m5.large", pool.MachineType)
+	assert.Equal(t, []string{"z1"}, pool.Zones)
 }
 
 // pod triggered scale-up: [{shoot--i034796--aw2-p2-z1 1->3 (max: 3)}]
 func TestParseTriggeredScaleUp(t *testing.T) {
 	msg := "pod triggered scale-up: [{shoot--i034796--aw2-p2-z1 1->3 (max: 3)}]"
-	ng, err := parseTriggeredScaleUpMessage(msg)
+	ngs, err := gcr.ParseTriggeredScaleUpMessage(msg)
 	assert.Nil(t, err)
+	assert.Len(t, ngs, 1)
+	ng := ngs[0]
 	assert.Equal(t, ng.Name, "shoot--i034796--aw2-p2-z1")
 	assert.Equal(t, ng.CurrentSize, 1)
 	assert.Equal(t, ng.TargetSize, 3)
 	assert.Equal(t, ng.MaxSize, 3)
 	fmt.Printf("%v", ng)
+
+	msg = "pod triggered scale-up: [{shoot--i034796--aw2-p2-z1 1->2 (max: 3)} {shoot--i034796--aw2-p2-z2 0->1 (max: 2)}]"
+	ngs, err = gcr.ParseTriggeredScaleUpMessage(msg)
+	assert.Nil(t, err)
+	assert.Equal(t, []gcr.CANodeGroupSize{
+		{Name: "shoot--i034796--aw2-p2-z1", CurrentSize: 1, TargetSize: 2, MaxSize: 3},
+		{Name: "shoot--i034796--aw2-p2-z2", CurrentSize: 0, TargetSize: 1, MaxSize: 2},
+	}, ngs)
+}
+
+func TestParseCAScalingEventMessages(t *testing.T) {
+	reasonCounts, err := gcr.ParseNotTriggerScaleUpMessage("pod didn't trigger scale-up: 1 max node group size reached, 2 node(s) didn't match Pod's node affinity/selector")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"max node group size reached": 1, "node(s) didn't match Pod's node affinity/selector": 2}, reasonCounts)
+
+	reasonCounts, err = gcr.ParseNotTriggerScaleUpMessage("pod didn't trigger scale-up (it wouldn't fit if a new node is added): 3 Insufficient cpu")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"Insufficient cpu": 3}, reasonCounts)
+
+	ng, err := gcr.ParseScaledUpGroupMessage("Scale-up: setting group shoot--i034796--aw2-p2-z1 size to 3 instead of 1 (max: 3)")
+	assert.Nil(t, err)
+	assert.Equal(t, gcr.CANodeGroupSize{Name: "shoot--i034796--aw2-p2-z1", CurrentSize: 1, TargetSize: 3, MaxSize: 3}, ng)
+
+	ng, err = gcr.ParseScaledUpGroupMessage("Scale-up: group shoot--i034796--aw2-p2-z1 size set to 2 instead of 1 (max: 3)")
+	assert.Nil(t, err)
+	assert.Equal(t, gcr.CANodeGroupSize{Name: "shoot--i034796--aw2-p2-z1", CurrentSize: 1, TargetSize: 2, MaxSize: 3}, ng)
+
+	nodeName, err := gcr.ParseScaleDownEmptyMessage(`Scale-down: removing empty node "shoot--i034796--aw2-p2-z1-5d8b7-xkz4q"`)
+	assert.Nil(t, err)
+	assert.Equal(t, "shoot--i034796--aw2-p2-z1-5d8b7-xkz4q", nodeName)
 }
 
 func TestParseMachineSetScaleUp(t *testing.T) {
@@ -53,18 +101,100 @@ func TestParseMachineSetScaleUp(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
-func PrintPodMemory(t *testing.T, fileName string) {
-	data, err := os.ReadFile(fileName)
+func TestParseCACommandWithDefaults(t *testing.T) {
+	caCommand := []string{
+		"./cluster-autoscaler",
+		"--expander=least-waste",
+		"--max-nodes-total=100",
+		"--scale-down-utilization-threshold=0.5",
+		"--balance-similar-node-groups",
+		"--kubeconfig=/var/run/secrets/gardener.cloud/shoot/generic-kubeconfig/kubeconfig",
+	}
+	caArgs := processCACommand(caCommand)
+	assert.Equal(t, "least-waste", caArgs["expander"])
+	assert.Equal(t, "true", caArgs["balance-similar-node-groups"])
+	assert.Equal(t, "0.5", caArgs["scale-down-utilization-threshold"])
+	assert.Equal(t, 5, len(caArgs))
+
+	caSettings, err := ParseCACommand(caArgs)
+	assert.Nil(t, err)
+	assert.Equal(t, "least-waste", caSettings.Expander)
+	assert.Equal(t, 100, caSettings.MaxNodesTotal)
+	assert.Equal(t, 10*time.Second, caSettings.ScanInterval)
+	assert.Equal(t, 15*time.Minute, caSettings.MaxNodeProvisionTime)
+	assert.Equal(t, 600, caSettings.MaxGracefulTerminationSeconds)
+}
+
+func TestParseCACommandWithSpaceSeparatedValues(t *testing.T) {
+	caCommand := []string{
+		"./cluster-autoscaler",
+		"--expander", "priority",
+		"--balance-similar-node-groups",
+		"--scale-down-unneeded-time", "5m",
+		"--max-nodes-total=50",
+		"--skip-nodes-with-local-storage",
+	}
+	caArgs := processCACommand(caCommand)
+	assert.Equal(t, map[string]string{
+		"expander":                      "priority",
+		"balance-similar-node-groups":   "true",
+		"scale-down-unneeded-time":      "5m",
+		"max-nodes-total":               "50",
+		"skip-nodes-with-local-storage": "true",
+	}, caArgs)
+
+	caSettings, err := ParseCACommand(caArgs)
+	assert.Nil(t, err)
+	assert.Equal(t, "priority", caSettings.Expander)
+	assert.Equal(t, 50, caSettings.MaxNodesTotal)
+}
+
+func TestParseCACommandWithPositionalArgs(t *testing.T) {
+	caCommand := []string{
+		"./cluster-autoscaler",
+		"--balance-similar-node-groups", "positional",
+		"--scale-down-enabled=false",
+		"--expander", "least-waste",
+		"--v", "2",
+		"other",
+	}
+	caArgs := processCACommand(caCommand)
+	// a boolean flag does not take the following positional arg as its value
+	assert.Equal(t, map[string]string{
+		"balance-similar-node-groups": "true",
+		"scale-down-enabled":          "false",
+		"expander":                    "least-waste",
+		"v":                           "2",
+	}, caArgs)
+}
+
+func TestCAArgsInfoFromCASettings(t *testing.T) {
+	caSettings, err := ParseCACommand(map[string]string{
+		"expander":                "least-waste",
+		"max-nodes-total":         "10",
+		"max-node-provision-time": "20m",
+		"new-pod-scale-up-delay":  "30s",
+	})
 	assert.Nil(t, err)
-	var pod corev1.Pod
-	err = json.Unmarshal(data, &pod)
+	caSettings.SnapshotTimestamp = time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	caArgs := gcr.CAArgsInfoFromCASettings(caSettings)
+	assert.Equal(t, caSettings.SnapshotTimestamp, caArgs.SnapshotTimestamp)
+	assert.Equal(t, "least-waste", caArgs.Args["expander"])
+	assert.Equal(t, "10", caArgs.Args["max-nodes-total"])
+	assert.Equal(t, caArgs.GetHash(), caArgs.Hash)
+	roundTripped, err := ParseCACommand(caArgs.Args)
 	assert.Nil(t, err)
+	roundTripped.SnapshotTimestamp = caSettings.SnapshotTimestamp
+	assert.Equal(t, caSettings, roundTripped)
+}
+
+func PrintPodMemory(t *testing.T, pod *corev1.Pod) {
 	memquant := pod.Spec.Containers[0].Resources.Requests.Memory()
-	t.Logf("fileName: %s memory: %s", fileName, memquant)
-	t.Logf("fileName: %s memoryscale: %s", fileName, memquant.Format)
-	sumQuantity := gcr.CumulatePodRequests(&pod)
-	t.Logf("fileName: %s memory: %s", fileName, sumQuantity.Memory())
-	t.Logf("fileName: %s memoryscale: %s", fileName, sumQuantity.Memory().Format)
+	t.Logf("pod: %s memory: %s", pod.Name, memquant)
+	t.Logf("pod: %s memoryscale: %s", pod.Name, memquant.Format)
+	sumQuantity := gst.CumulatePodRequests(pod)
+	t.Logf("pod: %s memory: %s", pod.Name, sumQuantity.Memory())
+	t.Logf("pod: %s memoryscale: %s", pod.Name, sumQuantity.Memory().Format)
 
 	if sumQuantity.Memory().Format == resource.BinarySI {
 		return
@@ -77,14 +207,538 @@ func PrintPodMemory(t *testing.T, fileName string) {
 	binaryMem2, err := resource.ParseQuantity(fmt.Sprintf("%dKi", absVal/1024))
 	assert.Nil(t, err)
 
-	t.Logf("fileName: %s memory1: %s", fileName, binaryMem1)
-	t.Logf("fileName: %s memoryscale1: %s", fileName, binaryMem1.Format)
+	t.Logf("pod: %s memory1: %s", pod.Name, binaryMem1)
+	t.Logf("pod: %s memoryscale1: %s", pod.Name, binaryMem1.Format)
 
-	t.Logf("fileName: %s memory2: %s", fileName, &binaryMem2)
-	t.Logf("fileName: %s memoryscale2: %s", fileName, binaryMem2.Format)
+	t.Logf("pod: %s memory2: %s", pod.Name, &binaryMem2)
+	t.Logf("pod: %s memoryscale2: %s", pod.Name, binaryMem2.Format)
 }
+
 func TestPodMemory(t *testing.T) {
-	PrintPodMemory(t, "/tmp/pod1.json")
-	//PrintPodMemory(t, "/tmp/pod2.json")
+	PrintPodMemory(t, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod1"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("1G"),
+			}},
+		}}},
+	})
+}
+
+func TestGetScaleDownActivities(t *testing.T) {
+	now := time.Now().UTC()
+	taintTime := metav1.NewTime(now.Add(-time.Minute).Truncate(time.Second))
+	nodeOld := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}}
+	nodeNew := nodeOld.DeepCopy()
+	nodeNew.Spec.Unschedulable = true
+	nodeNew.Spec.Taints = []corev1.Taint{
+		{Key: gcr.ToBeDeletedTaint, Value: "1719907200", Effect: corev1.TaintEffectNoSchedule, TimeAdded: &taintTime},
+		{Key: "some-other-taint", Effect: corev1.TaintEffectNoSchedule},
+	}
+
+	activities := getScaleDownActivities(nil, nodeNew, now)
+	assert.Empty(t, activities, "no activities expected for newly added node")
+
+	activities = getScaleDownActivities(nodeOld, nodeNew, now)
+	assert.Equal(t, []gcr.NodeScaleDownActivityInfo{
+		{ActivityTime: now, NodeName: "node-a", Activity: gcr.ScaleDownActivityCordoned},
+		{ActivityTime: taintTime.UTC(), NodeName: "node-a", Activity: gcr.ScaleDownActivityToBeDeleted,
+			Detail: "ToBeDeletedByClusterAutoscaler=1719907200:NoSchedule"},
+	}, activities)
+
+	activities = getScaleDownActivities(nodeNew, nodeOld, now)
+	assert.Equal(t, []gcr.NodeScaleDownActivityInfo{
+		{ActivityTime: now, NodeName: "node-a", Activity: gcr.ScaleDownActivityUncordoned},
+	}, activities)
+}
+
+func TestJwtExpiry(t *testing.T) {
+	exp := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"recorder","exp":%d}`, exp.Unix())))
+	expiry, ok := jwtExpiry("eyJhbGciOiJSUzI1NiJ9." + payload + ".c2lnbmF0dXJl")
+	assert.True(t, ok)
+	assert.True(t, exp.Equal(expiry))
+
+	_, ok = jwtExpiry("static-token")
+	assert.False(t, ok)
+	noExpPayload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"recorder"}`))
+	_, ok = jwtExpiry("eyJhbGciOiJSUzI1NiJ9." + noExpPayload + ".c2lnbmF0dXJl")
+	assert.False(t, ok)
+}
+
+func TestRecorderHealthWatchFailures(t *testing.T) {
+	health := newRecorderHealth()
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &corev1.Pod{}, 0, cache.Indexers{})
+	assert.Nil(t, health.trackInformer("pods", informer))
+	noEvents := func(string) (time.Time, bool) { return time.Time{}, false }
+
+	now := time.Now()
+	liveness, readiness := health.informerFailures(now, noEvents)
+	assert.Empty(t, liveness)
+	assert.Equal(t, []string{`informer "pods" not synced`}, readiness)
+
+	unauthorized := apierrors.NewUnauthorized("token expired")
+	health.onWatchError("pods", now, unauthorized)
+	liveness, readiness = health.informerFailures(now, noEvents)
+	assert.Empty(t, liveness)
+	assert.Len(t, readiness, 2)
+	assert.Contains(t, readiness[1], "credentials rejected")
+
+	// errors that keep recurring within the watch error window become a stalled watch
+	for at := now.Add(time.Minute); !at.After(now.Add(watchStallThreshold + time.Minute)); at = at.Add(time.Minute) {
+		health.onWatchError("pods", at, unauthorized)
+	}
+	liveness, _ = health.informerFailures(now.Add(watchStallThreshold+time.Minute), noEvents)
+	assert.Len(t, liveness, 1)
+	assert.Contains(t, liveness[0], `stalled watch of informer "pods"`)
+
+	// a watch without errors within the window has recovered
+	liveness, readiness = health.informerFailures(now.Add(watchStallThreshold+time.Minute+watchErrorWindow+time.Second), noEvents)
+	assert.Empty(t, liveness)
+	assert.Equal(t, []string{`informer "pods" not synced`}, readiness)
+}
+
+func TestRecorderMetricsDBSize(t *testing.T) {
+	dbPath := path.Join(t.TempDir(), "test.db")
+	assert.Nil(t, os.WriteFile(dbPath, make([]byte, 100), 0644))
+	assert.Nil(t, os.WriteFile(dbPath+"-wal", make([]byte, 20), 0644))
+	params := &gcr.RecorderParams{Landscape: "test", ShootNameSpace: testShootNamespace}
+	noWriteStats := func() db.WritePipelineStats { return db.WritePipelineStats{} }
+
+	// the size of a SQLite data db includes its write-ahead log
+	registry := prometheus.NewRegistry()
+	assert.Nil(t, newRecorderMetrics(params, dbPath, time.Now(), noWriteStats).register(registry))
+	families, err := registry.Gather()
+	assert.Nil(t, err)
+	family, ok := lo.Find(families, func(f *dto.MetricFamily) bool { return strings.HasSuffix(f.GetName(), "_db_size_bytes") })
+	assert.True(t, ok)
+	assert.Equal(t, 120.0, family.GetMetric()[0].GetGauge().GetValue())
+
+	// a PostgreSQL data db has no db size
+	registry = prometheus.NewRegistry()
+	assert.Nil(t, newRecorderMetrics(params, "", time.Now(), noWriteStats).register(registry))
+	families, err = registry.Gather()
+	assert.Nil(t, err)
+	assert.False(t, lo.ContainsBy(families, func(f *dto.MetricFamily) bool { return strings.HasSuffix(f.GetName(), "_db_size_bytes") }))
+}
+
+func TestTakeEndedWatchFailures(t *testing.T) {
+	health := newRecorderHealth()
+	now := time.Now()
+	health.onWatchError("pods", now, fmt.Errorf("connection refused"))
+	health.onWatchError("nodes", now.Add(2*time.Minute), fmt.Errorf("connection refused"))
+
+	ended := health.takeEndedWatchFailures(now.Add(watchErrorWindow+time.Second), false)
+	assert.Len(t, ended, 1)
+	assert.Equal(t, now, ended["pods"].since)
+	assert.Empty(t, health.takeEndedWatchFailures(now.Add(watchErrorWindow+time.Second), false))
+
+	ended = health.takeEndedWatchFailures(now.Add(watchErrorWindow+time.Second), true)
+	assert.Len(t, ended, 1)
+	assert.Contains(t, ended, "nodes")
+
+	assert.True(t, isExpectedWatchError(apierrors.NewResourceExpired("too old resource version")))
+	assert.True(t, isExpectedWatchError(io.EOF))
+	assert.False(t, isExpectedWatchError(apierrors.NewUnauthorized("token expired")))
+}
+
+func TestReconcileDeletions(t *testing.T) {
+	var marked []string
+	loadNotDeleted := func() ([]string, error) { return []string{"a", "b", "c"}, nil }
+	markDeleted := func(key string) (int64, error) {
+		marked = append(marked, key)
+		return 1, nil
+	}
+	err := reconcileDeletions("node_info", sets.New("a", "c", "d"), loadNotDeleted, markDeleted)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b"}, marked)
+
+	err = reconcileDeletions("node_info", sets.New[string](), func() ([]string, error) {
+		return nil, fmt.Errorf("db closed")
+	}, markDeleted)
+	assert.NotNil(t, err)
+}
+
+const testShootNamespace = "shoot--test--fake"
+
+// fakeClusters are the fake shoot and seed clusters that a recorder built by newFakeClusterRecorder watches.
+type fakeClusters struct {
+	shoot *kubefake.Clientset
+	seed  *dynamicfake.FakeDynamicClient
+}
+
+// newFakeClusterRecorder starts a recorder that records the fake shoot and seed clusters into a data db in a temp dir
+// and returns it together with the clusters and a read-only DataAccess for the data db. The recorder is stopped at the
+// end of the test.
+func newFakeClusterRecorder(t *testing.T) (r *defaultRecorder, clusters fakeClusters, reader *db.DataAccess) {
+	clusters.shoot = kubefake.NewSimpleClientset()
+	clusters.seed = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		machineDeploymentGVR: "MachineDeploymentList",
+		machineClassGVR:      "MachineClassList",
+		workerGVR:            "WorkerList",
+		deploymentGVR:        "DeploymentList",
+		configmapGVR:         "ConfigMapList",
+		eventGVR:             "EventList",
+		namespaceGVR:         "NamespaceList",
+	})
+	params := gcr.RecorderParams{
+		Landscape:           "test",
+		ShootNameSpace:      testShootNamespace,
+		ShootKubeConfigPath: "kubeconfig-fake.yaml",
+		DBDir:               t.TempDir(),
+	}
+	recorder, err := NewDefaultRecorderWithClients(params, time.Now().UTC(), clusters.shoot, clusters.seed)
+	assert.Nil(t, err)
+	r = recorder.(*defaultRecorder)
+	ctx, cancel := context.WithCancel(context.Background())
+	err = r.Start(ctx)
+	assert.Nil(t, err)
+
+	reader = db.NewReadOnlyDataAccess(path.Join(params.DBDir, "fake.db"))
+	assert.Nil(t, reader.Init())
+	t.Cleanup(func() {
+		cancel()
+		assert.Eventually(t, func() bool {
+			sessions, err := reader.LoadRecorderSessionInfos()
+			return err == nil && len(sessions) == 1 && !sessions[0].EndTime.IsZero()
+		}, 10*time.Second, 50*time.Millisecond, "recorder session not ended")
+		_ = reader.Close()
+	})
+	return
+}
+
+func newTestWorker(namespace string, timestamp time.Time, maximum int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "extensions.gardener.cloud/v1alpha1",
+		"kind":       "Worker",
+		"metadata": map[string]any{
+			"name":      "fake",
+			"namespace": namespace,
+			"annotations": map[string]any{
+				"gardener.cloud/timestamp": timestamp.Format(time.RFC3339Nano),
+			},
+		},
+		"spec": map[string]any{
+			"pools": []any{
+				map[string]any{
+					"name":           "p1",
+					"minimum":        int64(1),
+					"maximum":        maximum,
+					"maxSurge":       int64(1),
+					"maxUnavailable": int64(0),
+					"machineType":    "m5.large",
+					"architecture":   "amd64",
+					"zones":          []any{"z1"},
+				},
+			},
+		},
+	}}
+}
+
+func newTestMCD(namespace string, replicas int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "machine.sapcloud.io/v1alpha1",
+		"kind":       "MachineDeployment",
+		"metadata": map[string]any{
+			"name":      namespace + "-p1-z1",
+			"namespace": namespace,
+		},
+		"spec": map[string]any{
+			"replicas": replicas,
+			"strategy": map[string]any{
+				"rollingUpdate": map[string]any{
+					"maxSurge":       int64(1),
+					"maxUnavailable": int64(0),
+				},
+			},
+			"template": map[string]any{
+				"spec": map[string]any{
+					"class": map[string]any{"name": namespace + "-p1-z1"},
+					"nodeTemplate": map[string]any{
+						"metadata": map[string]any{
+							"labels": map[string]any{
+								gcr.PoolLabel:                   "p1",
+								"topology.ebs.csi.aws.com/zone": "z1",
+							},
+						},
+					},
+				},
+			},
+		},
+	}}
+}
+
+func newTestMCC(namespace string, cpu string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "machine.sapcloud.io/v1alpha1",
+		"kind":       "MachineClass",
+		"metadata": map[string]any{
+			"name":      namespace + "-p1-z1",
+			"namespace": namespace,
+		},
+		"nodeTemplate": map[string]any{
+			"instanceType": "m5.large",
+			"region":       "eu-west-1",
+			"zone":         "z1",
+			"capacity": map[string]any{
+				"cpu":    cpu,
+				"memory": "8Gi",
+			},
+		},
+		"provider": "AWS",
+		"providerSpec": map[string]any{
+			"labels": map[string]any{gcr.PoolLabelAlt: "p1"},
+		},
+	}}
+}
+
+// eventually asserts that the condition is met within the flush interval of the write pipeline and some slack.
+func eventually(t *testing.T, condition func() bool, msg string) {
+	assert.Eventually(t, condition, 5*time.Second, 20*time.Millisecond, msg)
+}
+
+func TestRecorderWithFakeClusters(t *testing.T) {
+	r, clusters, reader := newFakeClusterRecorder(t)
+	ctx := context.Background()
+
+	t.Run("pod", func(t *testing.T) {
+		now := time.Now().UTC().Truncate(time.Second)
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "p1",
+				Namespace:         "default",
+				UID:               "p1-uid",
+				CreationTimestamp: metav1.NewTime(now),
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("1"),
+				}},
+			}}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				Conditions: []corev1.PodCondition{{
+					Type:               corev1.PodScheduled,
+					Status:             corev1.ConditionFalse,
+					Reason:             corev1.PodReasonUnschedulable,
+					LastTransitionTime: metav1.NewTime(now),
+				}},
+			},
+		}
+		pod, err := clusters.shoot.CoreV1().Pods("default").Create(ctx, pod, metav1.CreateOptions{})
+		assert.Nil(t, err)
+		eventually(t, func() bool {
+			podInfo, err := reader.LoadLatestPodInfoWithName("p1")
+			return err == nil && podInfo.PodScheduleStatus == gst.PodUnscheduled
+		}, "unscheduled pod not stored")
+
+		pod.Spec.NodeName = "n1"
+		pod.Status.Phase = corev1.PodRunning
+		pod.Status.Conditions = []corev1.PodCondition{{
+			Type:               corev1.PodScheduled,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(now.Add(time.Second)),
+		}}
+		pod, err = clusters.shoot.CoreV1().Pods("default").Update(ctx, pod, metav1.UpdateOptions{})
+		assert.Nil(t, err)
+		eventually(t, func() bool {
+			podInfo, err := reader.LoadLatestPodInfoWithName("p1")
+			return err == nil && podInfo.NodeName == "n1" && podInfo.PodScheduleStatus == gst.PodScheduleCommited
+		}, "scheduled pod not stored")
+
+		deletionTimestamp := metav1.NewTime(now.Add(2 * time.Second))
+		pod.DeletionTimestamp = &deletionTimestamp
+		_, err = clusters.shoot.CoreV1().Pods("default").Update(ctx, pod, metav1.UpdateOptions{})
+		assert.Nil(t, err)
+		assert.Nil(t, clusters.shoot.CoreV1().Pods("default").Delete(ctx, "p1", metav1.DeleteOptions{}))
+		eventually(t, func() bool {
+			podInfo, err := reader.LoadLatestPodInfoWithName("p1")
+			return err == nil && podInfo.DeletionTimestamp.Equal(deletionTimestamp.Time)
+		}, "pod deletion not stored")
+		podUIDs, err := reader.LoadPodUIDsNotDeleted()
+		assert.Nil(t, err)
+		assert.NotContains(t, podUIDs, "p1-uid")
+	})
+
+	t.Run("node", func(t *testing.T) {
+		_, err := clusters.shoot.StorageV1().CSINodes().Create(ctx, &storagev1.CSINode{
+			ObjectMeta: metav1.ObjectMeta{Name: "n1"},
+			Spec: storagev1.CSINodeSpec{Drivers: []storagev1.CSINodeDriver{{
+				Name:        "ebs.csi.aws.com",
+				NodeID:      "n1",
+				Allocatable: &storagev1.VolumeNodeResources{Count: ptr.To[int32](25)},
+			}}},
+		}, metav1.CreateOptions{})
+		assert.Nil(t, err)
+		// the node is only stored once the allocatable volumes of its CSINode are known
+		eventually(t, func() bool {
+			return r.getAllocatableVolumes("n1") == 25
+		}, "allocatable volumes of CSINode not known")
+
+		now := time.Now().UTC().Truncate(time.Second)
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "n1",
+				CreationTimestamp: metav1.NewTime(now),
+				Labels:            map[string]string{gcr.PoolLabel: "p1"},
+			},
+			Spec: corev1.NodeSpec{ProviderID: "aws:///z1/i-1"},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+				Capacity:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+			},
+		}
+		node, err = clusters.shoot.CoreV1().Nodes().Create(ctx, node, metav1.CreateOptions{})
+		assert.Nil(t, err)
+		eventually(t, func() bool {
+			nodeInfos, err := reader.LoadNodeInfosBefore(time.Now().Add(time.Minute))
+			return err == nil && len(nodeInfos) == 1 && nodeInfos[0].Name == "n1" && nodeInfos[0].AllocatableVolumes == 25
+		}, "node not stored")
+
+		node.Spec.Unschedulable = true
+		_, err = clusters.shoot.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{})
+		assert.Nil(t, err)
+		assert.Nil(t, clusters.shoot.CoreV1().Nodes().Delete(ctx, "n1", metav1.DeleteOptions{}))
+		eventually(t, func() bool {
+			deletionTimestamps, err := reader.LoadNodeDeletionTimestamps()
+			return err == nil && !deletionTimestamps["n1"].IsZero()
+		}, "node deletion not stored")
+		activities, err := reader.LoadNodeScaleDownActivityInfosBetween(now.Add(-time.Minute), time.Now().Add(time.Minute))
+		assert.Nil(t, err)
+		assert.Equal(t, []gcr.ScaleDownActivity{gcr.ScaleDownActivityCordoned, gcr.ScaleDownActivityDeleted},
+			lo.Map(activities, func(a gcr.NodeScaleDownActivityInfo, _ int) gcr.ScaleDownActivity { return a.Activity }))
+	})
+
+	t.Run("machine deployment", func(t *testing.T) {
+		mcds := clusters.seed.Resource(machineDeploymentGVR).Namespace(testShootNamespace)
+		mcd, err := mcds.Create(ctx, newTestMCD(testShootNamespace, 1), metav1.CreateOptions{})
+		assert.Nil(t, err)
+		eventually(t, func() bool {
+			mcdInfo, err := reader.LoadLatestMachineDeploymentInfo(mcd.GetName())
+			return err == nil && mcdInfo.Replicas == 1 && mcdInfo.PoolName == "p1" && mcdInfo.Zone == "z1"
+		}, "machine deployment not stored")
+
+		_, err = mcds.Update(ctx, newTestMCD(testShootNamespace, 2), metav1.UpdateOptions{})
+		assert.Nil(t, err)
+		eventually(t, func() bool {
+			mcdInfo, err := reader.LoadLatestMachineDeploymentInfo(mcd.GetName())
+			return err == nil && mcdInfo.Replicas == 2
+		}, "machine deployment update not stored")
+
+		assert.Nil(t, mcds.Delete(ctx, mcd.GetName(), metav1.DeleteOptions{}))
+		eventually(t, func() bool {
+			mcdInfo, err := reader.LoadLatestMachineDeploymentInfo(mcd.GetName())
+			return err == nil && !mcdInfo.DeletionTimestamp.IsZero()
+		}, "machine deployment deletion not stored")
+		mcdNames, err := reader.LoadMCDNamesNotDeleted()
+		assert.Nil(t, err)
+		assert.Empty(t, mcdNames)
+	})
+
+	t.Run("machine class", func(t *testing.T) {
+		mccs := clusters.seed.Resource(machineClassGVR).Namespace(testShootNamespace)
+		mcc, err := mccs.Create(ctx, newTestMCC(testShootNamespace, "2"), metav1.CreateOptions{})
+		assert.Nil(t, err)
+		eventually(t, func() bool {
+			mccInfo, err := reader.LoadLatestMachineClassInfo(mcc.GetName())
+			return err == nil && mccInfo.InstanceType == "m5.large" && mccInfo.Provider == "AWS" && mccInfo.Capacity.Cpu().Value() == 2
+		}, "machine class not stored")
+
+		_, err = mccs.Update(ctx, newTestMCC(testShootNamespace, "4"), metav1.UpdateOptions{})
+		assert.Nil(t, err)
+		eventually(t, func() bool {
+			mccInfo, err := reader.LoadLatestMachineClassInfo(mcc.GetName())
+			return err == nil && mccInfo.Capacity.Cpu().Value() == 4
+		}, "machine class update not stored")
+
+		assert.Nil(t, mccs.Delete(ctx, mcc.GetName(), metav1.DeleteOptions{}))
+		eventually(t, func() bool {
+			mccInfo, err := reader.LoadLatestMachineClassInfo(mcc.GetName())
+			return err == nil && !mccInfo.DeletionTimestamp.IsZero()
+		}, "machine class deletion not stored")
+	})
+
+	t.Run("worker", func(t *testing.T) {
+		workers := clusters.seed.Resource(workerGVR).Namespace(testShootNamespace)
+		now := time.Now().UTC().Truncate(time.Second)
+		_, err := workers.Create(ctx, newTestWorker(testShootNamespace, now, 3), metav1.CreateOptions{})
+		assert.Nil(t, err)
+		eventually(t, func() bool {
+			poolInfos, err := reader.LoadWorkerPoolInfosBefore(time.Now())
+			return err == nil && len(poolInfos) == 1 && poolInfos[0].Name == "p1" && poolInfos[0].Maximum == 3
+		}, "worker pool not stored")
+
+		_, err = workers.Update(ctx, newTestWorker(testShootNamespace, now.Add(time.Second), 5), metav1.UpdateOptions{})
+		assert.Nil(t, err)
+		eventually(t, func() bool {
+			poolInfos, err := reader.LoadWorkerPoolInfosBefore(time.Now().Add(time.Second))
+			return err == nil && len(poolInfos) == 1 && poolInfos[0].Maximum == 5
+		}, "worker pool update not stored")
+	})
+
+	t.Run("event", func(t *testing.T) {
+		now := metav1.NewTime(time.Now())
+		involvedPod := corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "p2", UID: "p2-uid"}
+		events := []*corev1.Event{
+			{
+				ObjectMeta:     metav1.ObjectMeta{Name: "p2.failed-scheduling", Namespace: "default", UID: "e1"},
+				InvolvedObject: involvedPod,
+				Reason:         gcr.FailedSchedulingReason,
+				Message:        "0/3 nodes are available: 3 Insufficient cpu.",
+				Source:         corev1.EventSource{Component: "default-scheduler"},
+				FirstTimestamp: now,
+			},
+			{
+				ObjectMeta:     metav1.ObjectMeta{Name: "p2.triggered-scale-up", Namespace: "default", UID: "e2"},
+				InvolvedObject: involvedPod,
+				Reason:         gcr.TriggeredScaleUpReason,
+				Message:        "pod triggered scale-up: [{" + testShootNamespace + "-p1-z1 1->2 (max: 3)}]",
+				Source:         corev1.EventSource{Component: "cluster-autoscaler"},
+				FirstTimestamp: now,
+			},
+			{
+				ObjectMeta:     metav1.ObjectMeta{Name: "p2.pulled", Namespace: "default", UID: "e3"},
+				InvolvedObject: involvedPod,
+				Reason:         "Pulled",
+				Message:        "Successfully pulled image",
+				Source:         corev1.EventSource{Component: "kubelet"},
+				FirstTimestamp: now,
+			},
+		}
+		for _, event := range events {
+			_, err := clusters.shoot.CoreV1().Events("default").Create(ctx, event, metav1.CreateOptions{})
+			assert.Nil(t, err)
+		}
+		eventually(t, func() bool {
+			eventInfos, err := reader.LoadAllEvents()
+			return err == nil && len(eventInfos) == 2
+		}, "scheduler and cluster-autoscaler events not stored")
+		eventInfos, err := reader.LoadAllEvents()
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []string{"e1", "e2"}, lo.Map(eventInfos, func(e gst.EventInfo, _ int) string { return e.UID }))
+
+		eventually(t, func() bool {
+			failedSchedulingInfos, err := reader.LoadFailedSchedulingInfosForPod("p2-uid")
+			return err == nil && len(failedSchedulingInfos) == 1 && failedSchedulingInfos[0].TotalNodes == 3
+		}, "FailedScheduling event not stored")
 
+		// the API server merges a repeated event into the existing one
+		repeated := events[0].DeepCopy()
+		repeated.Count = 2
+		repeated.LastTimestamp = metav1.NewTime(now.Add(time.Minute))
+		repeated.Message = "0/4 nodes are available: 4 Insufficient cpu."
+		_, err = clusters.shoot.CoreV1().Events("default").Update(ctx, repeated, metav1.UpdateOptions{})
+		assert.Nil(t, err)
+		eventually(t, func() bool {
+			failedSchedulingInfos, err := reader.LoadFailedSchedulingInfosForPod("p2-uid")
+			return err == nil && len(failedSchedulingInfos) == 2 && failedSchedulingInfos[1].Count == 2 &&
+				failedSchedulingInfos[1].TotalNodes == 4 && failedSchedulingInfos[1].EventTime.Equal(repeated.LastTimestamp.Time.Truncate(time.Millisecond))
+		}, "repeated FailedScheduling event not stored")
+		eventually(t, func() bool {
+			scalingEventInfos, err := reader.LoadCAScalingEventInfosForPod("p2-uid")
+			return err == nil && len(scalingEventInfos) == 1 && scalingEventInfos[0].TargetSize == 2
+		}, "TriggeredScaleUp event not stored")
+	})
 }
@@ -0,0 +1,338 @@
+package replayer
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	gsh "github.com/elankath/gardener-scaling-history"
+	"github.com/elankath/gardener-scaling-history/cost"
+	"github.com/elankath/gardener-scaling-history/db"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BatchReportFileName is the name of the JSON ranking of the variant outcomes written into the report dir of a batch
+// replay. BatchRankingFileName is the name of the same ranking as CSV.
+const (
+	BatchReportFileName  = "batch-report.json"
+	BatchRankingFileName = "batch-ranking.csv"
+)
+
+// RecordedCostReportFileName and RecordedCostEntriesFileName are the names of the JSON and CSV cost report of the
+// recorded cluster written into the report dir of a batch replay. Every variant writes its own cost report with the
+// names cost.ReportFileName and cost.EntriesFileName into its report dir.
+const (
+	RecordedCostReportFileName  = "recorded-cost-report.json"
+	RecordedCostEntriesFileName = "recorded-cost-report.csv"
+)
+
+// LoadBatchReplaySpec loads and validates the BatchReplaySpec at the given path. The overrides paths of the variants
+// are resolved relative to the directory of the spec.
+func LoadBatchReplaySpec(specPath string) (spec gsh.BatchReplaySpec, err error) {
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		err = fmt.Errorf("cannot read batch replay spec %q: %w", specPath, err)
+		return
+	}
+	err = yaml.UnmarshalStrict(data, &spec)
+	if err != nil {
+		err = fmt.Errorf("cannot parse batch replay spec %q: %w", specPath, err)
+		return
+	}
+	if len(spec.Variants) == 0 {
+		err = fmt.Errorf("batch replay spec %q has no variants", specPath)
+		return
+	}
+	names := sets.New[string]()
+	for i, variant := range spec.Variants {
+		if variant.Name == "" || strings.ContainsAny(variant.Name, `/\`) {
+			err = fmt.Errorf("invalid name %q of variant %d", variant.Name, i)
+			return
+		}
+		if names.Has(variant.Name) {
+			err = fmt.Errorf("duplicate variant %q", variant.Name)
+			return
+		}
+		names.Insert(variant.Name)
+		if variant.VirtualClusterKubeConfigPath != "" && variant.VirtualAutoScalerConfigPath == "" {
+			err = fmt.Errorf("variant %q has a virtual cluster without a virtual autoscaler config path", variant.Name)
+			return
+		}
+		if variant.OverridesPath != "" && !filepath.IsAbs(variant.OverridesPath) {
+			spec.Variants[i].OverridesPath = filepath.Join(filepath.Dir(specPath), variant.OverridesPath)
+		}
+	}
+	return
+}
+
+// RunBatchReplay replays the recording of the given params once for every variant, at most Parallelism variants at
+// the same time, and returns the ranked outcomes of the variants. Every variant replays TotalReplayTime in steps of
+// ReplayInterval from StabilizeInterval after the start of the recording, with its own replay report dir below the
+// report dir. The ranking is written as JSON and CSV into the report dir, together with the cost report of the
+// recorded cluster during the same time. A variant that fails has its error in its outcome and is ranked last.
+func RunBatchReplay(ctx context.Context, params gsh.BatchReplayParams) ([]gsh.VariantOutcome, error) {
+	if params.ReplayInterval <= 0 || params.TotalReplayTime < params.ReplayInterval {
+		return nil, fmt.Errorf("total replay time %s must be at least the replay interval %s", params.TotalReplayTime, params.ReplayInterval)
+	}
+	catalog, err := getBatchPriceCatalog(params)
+	if err != nil {
+		return nil, err
+	}
+	dataAccess := newBatchDataAccess(params)
+	err = dataAccess.Init()
+	if err != nil {
+		return nil, fmt.Errorf("cannot initialize the data db: %w", err)
+	}
+	defer func() {
+		_ = dataAccess.Close()
+	}()
+	recorderStartTime, err := dataAccess.GetInitialRecorderStartTime()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the recorder start time: %w", err)
+	}
+	startTime := recorderStartTime.Add(params.StabilizeInterval).UTC()
+	parallelism := params.Parallelism
+	if parallelism <= 0 {
+		parallelism = len(params.Variants)
+	}
+	outcomes := make([]gsh.VariantOutcome, len(params.Variants))
+	semaphore := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, variant := range params.Variants {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			slog.Info("replaying variant", "variant", variant.Name, "overridesPath", variant.OverridesPath)
+			outcome, err := replayVariant(ctx, params, catalog, startTime, variant)
+			if err != nil {
+				slog.Error("cannot replay variant", "variant", variant.Name, "error", err)
+				outcome.Error = err.Error()
+			}
+			outcomes[i] = outcome
+			slog.Info("replayed variant", "variant", variant.Name, "unscheduledPodMinutes", outcome.UnscheduledPodMinutes,
+				"estimatedCost", outcome.EstimatedCost, "scaledUpNodeCount", outcome.ScaledUpNodeCount)
+		}()
+	}
+	wg.Wait()
+	rankOutcomes(outcomes)
+	err = writeBatchReport(params.ReportDir, outcomes)
+	if err != nil {
+		return outcomes, err
+	}
+	err = writeRecordedCostReport(params, dataAccess, catalog, startTime)
+	if err != nil {
+		return outcomes, err
+	}
+	return outcomes, nil
+}
+
+// getBatchPriceCatalog returns the PriceCatalog of the given params, or else a catalog of the MachinePrices of their
+// spec, which apply to all regions.
+func getBatchPriceCatalog(params gsh.BatchReplayParams) (gsh.PriceCatalog, error) {
+	if params.PriceCatalog != nil {
+		return params.PriceCatalog, nil
+	}
+	prices := make([]gsh.InstancePrice, 0, len(params.MachinePrices))
+	for machineType, price := range params.MachinePrices {
+		prices = append(prices, gsh.InstancePrice{InstanceType: machineType, HourlyPrice: price})
+	}
+	catalog, err := cost.NewPriceCatalog("", prices)
+	if err != nil {
+		return nil, fmt.Errorf("invalid machine prices: %w", err)
+	}
+	return catalog, nil
+}
+
+// newBatchDataAccess returns a Store of the data db of the given params. The variants share the data db, so none of them
+// may write to it.
+func newBatchDataAccess(params gsh.BatchReplayParams) db.Store {
+	if params.PostgresDSN != "" {
+		return db.NewPostgresDataAccess(params.PostgresDSN, params.ClusterID)
+	}
+	return db.NewReadOnlyDataAccess(params.DBPath)
+}
+
+// writeRecordedCostReport writes the cost report of the recorded cluster during the TotalReplayTime of the variants
+// from the given start time into the report dir, so that the cost of the variants can be compared with the real cost.
+func writeRecordedCostReport(params gsh.BatchReplayParams, dataAccess db.Store, catalog gsh.PriceCatalog, startTime time.Time) error {
+	report, err := cost.ComputeRecordedCost(dataAccess, catalog, startTime, startTime.Add(params.TotalReplayTime), params.CostWindow)
+	if err != nil {
+		return err
+	}
+	return cost.WriteReport(report, filepath.Join(params.ReportDir, RecordedCostReportFileName), filepath.Join(params.ReportDir, RecordedCostEntriesFileName))
+}
+
+// replayVariant replays the given variant from the given start time and samples its virtual cluster after every replay
+// step. The pods pending after a replay step, which include the pods waiting for the nodes of their scale-up, count as
+// unscheduled until the next replay step.
+func replayVariant(ctx context.Context, params gsh.BatchReplayParams, catalog gsh.PriceCatalog, startTime time.Time, variant gsh.ReplayVariant) (outcome gsh.VariantOutcome, err error) {
+	outcome.Variant = variant.Name
+	outcome.NodeHoursByMachineType = make(map[string]float64)
+	reportDir := filepath.Join(params.ReportDir, variant.Name)
+	err = os.MkdirAll(reportDir, 0755)
+	if err != nil {
+		return outcome, fmt.Errorf("cannot create report dir %q: %w", reportDir, err)
+	}
+	replayerParams := gsh.ReplayerParams{
+		DBPath:                      params.DBPath,
+		PostgresDSN:                 params.PostgresDSN,
+		ClusterID:                   params.ClusterID,
+		ReportDir:                   reportDir,
+		VirtualAutoScalerConfigPath: filepath.Join(reportDir, "autoscaler-config.json"),
+		ReplayInterval:              params.ReplayInterval,
+		TotalReplayTime:             params.TotalReplayTime,
+		StartTime:                   startTime,
+		OverridesPath:               variant.OverridesPath,
+		PriceCatalog:                catalog,
+		CostWindow:                  params.CostWindow,
+	}
+	var virtualCluster gsh.VirtualCluster
+	if variant.VirtualClusterKubeConfigPath == "" {
+		replayerParams.InMemoryVirtualCluster = true
+		replayerParams.InProcessScheduler = true
+		replayerParams.InProcessScaleUp = true
+		replayerParams.NodeProvisionDelay = params.NodeProvisionDelay
+		virtualCluster = NewInMemoryVirtualCluster()
+	} else {
+		replayerParams.VirtualClusterKubeConfigPath = variant.VirtualClusterKubeConfigPath
+		replayerParams.VirtualAutoScalerConfigPath = variant.VirtualAutoScalerConfigPath
+		replayerParams.StabilizeInterval = params.StabilizeInterval
+		virtualCluster, err = NewVirtualClusterFromKubeConfig(variant.VirtualClusterKubeConfigPath)
+		if err != nil {
+			return
+		}
+	}
+	d, err := newDefaultReplayer(replayerParams, newBatchDataAccess(params), virtualCluster)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = d.Close()
+	}()
+	err = d.Start(ctx)
+	if err != nil {
+		return
+	}
+	for range int(params.TotalReplayTime / params.ReplayInterval) {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		err = d.doReplay(ctx)
+		if err != nil {
+			return
+		}
+		var pods []corev1.Pod
+		pods, err = virtualCluster.ListPods(ctx)
+		if err != nil {
+			return outcome, fmt.Errorf("cannot list the virtual pods: %w", err)
+		}
+		for _, pod := range pods {
+			if pod.Spec.NodeName == "" && pod.DeletionTimestamp == nil {
+				outcome.UnscheduledPodMinutes += params.ReplayInterval.Minutes()
+			}
+		}
+	}
+	outcome.ScaledUpNodeCount = d.scaledUpNodeNames.Len()
+	report := d.costAccumulator.Report()
+	for _, entry := range report.Entries {
+		outcome.NodeHoursByMachineType[entry.InstanceType] += entry.NodeHours
+	}
+	outcome.EstimatedCost = report.Cost
+	outcome.Currency = report.Currency
+	outcome.UnpricedMachineTypes = report.UnpricedInstanceTypes
+	return
+}
+
+// rankOutcomes sorts the given outcomes by the fewest unscheduled pod-minutes, then by the lowest estimated cost and
+// then by the fewest scale-ups, and sets their ranks. Failed variants are ranked last.
+func rankOutcomes(outcomes []gsh.VariantOutcome) {
+	slices.SortStableFunc(outcomes, func(a, b gsh.VariantOutcome) int {
+		if aFailed, bFailed := a.Error != "", b.Error != ""; aFailed != bFailed {
+			if aFailed {
+				return 1
+			}
+			return -1
+		}
+		if c := cmp.Compare(a.UnscheduledPodMinutes, b.UnscheduledPodMinutes); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.EstimatedCost, b.EstimatedCost); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.ScaledUpNodeCount, b.ScaledUpNodeCount); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Variant, b.Variant)
+	})
+	for i := range outcomes {
+		outcomes[i].Rank = i + 1
+	}
+}
+
+func writeBatchReport(reportDir string, outcomes []gsh.VariantOutcome) error {
+	bytes, err := json.Marshal(outcomes)
+	if err != nil {
+		return err
+	}
+	reportPath := filepath.Join(reportDir, BatchReportFileName)
+	err = os.WriteFile(reportPath, bytes, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot write batch report to %q: %w", reportPath, err)
+	}
+
+	rankingPath := filepath.Join(reportDir, BatchRankingFileName)
+	f, err := os.Create(rankingPath)
+	if err != nil {
+		return fmt.Errorf("cannot create batch ranking %q: %w", rankingPath, err)
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	_ = w.Write([]string{"Rank", "Variant", "UnscheduledPodMinutes", "NodeHours", "NodeHoursByMachineType", "EstimatedCost", "ScaledUpNodeCount", "Error"})
+	for _, o := range outcomes {
+		machineTypes := make([]string, 0, len(o.NodeHoursByMachineType))
+		for machineType := range o.NodeHoursByMachineType {
+			machineTypes = append(machineTypes, machineType)
+		}
+		slices.Sort(machineTypes)
+		var totalNodeHours float64
+		nodeHours := make([]string, 0, len(machineTypes))
+		for _, machineType := range machineTypes {
+			totalNodeHours += o.NodeHoursByMachineType[machineType]
+			nodeHours = append(nodeHours, machineType+"="+formatFloat(o.NodeHoursByMachineType[machineType]))
+		}
+		_ = w.Write([]string{
+			strconv.Itoa(o.Rank),
+			o.Variant,
+			formatFloat(o.UnscheduledPodMinutes),
+			formatFloat(totalNodeHours),
+			strings.Join(nodeHours, ";"),
+			formatFloat(o.EstimatedCost),
+			strconv.Itoa(o.ScaledUpNodeCount),
+			o.Error,
+		})
+	}
+	w.Flush()
+	err = w.Error()
+	if err != nil {
+		return fmt.Errorf("cannot write batch ranking %q: %w", rankingPath, err)
+	}
+	slog.Info("wrote batch report", "reportPath", reportPath, "rankingPath", rankingPath, "numVariants", len(outcomes))
+	return nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
@@ -0,0 +1,199 @@
+package replayer
+
+import (
+	"fmt"
+	gsh "github.com/elankath/gardener-scaling-history"
+	gst "github.com/elankath/gardener-scaling-types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"log/slog"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// LoadOverrides loads and validates the AutoScalerConfigOverrides YAML file at the given path.
+func LoadOverrides(overridesPath string) (*gsh.AutoScalerConfigOverrides, error) {
+	data, err := os.ReadFile(overridesPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read overrides %q: %w", overridesPath, err)
+	}
+	var overrides gsh.AutoScalerConfigOverrides
+	err = yaml.UnmarshalStrict(data, &overrides)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse overrides %q: %w", overridesPath, err)
+	}
+	for key, override := range overrides.NodeGroups {
+		if override.MinSize != nil && override.MaxSize != nil && *override.MinSize > *override.MaxSize {
+			return nil, fmt.Errorf("min size %d of node group override %q is greater than its max size %d", *override.MinSize, key, *override.MaxSize)
+		}
+	}
+	for priority, exprs := range overrides.Priorities {
+		for _, expr := range exprs {
+			if _, err = regexp.Compile(expr); err != nil {
+				return nil, fmt.Errorf("cannot compile regular expression %q of priority %d: %w", expr, priority, err)
+			}
+		}
+	}
+	if overrides.CASettings.Expander != "" {
+		for _, expander := range strings.Split(overrides.CASettings.Expander, ",") {
+			if !slices.Contains([]string{LeastWasteExpander, MostPodsExpander, PriorityExpander, RandomExpander, "price", "grpc"}, expander) {
+				return nil, fmt.Errorf("unknown expander %q", expander)
+			}
+		}
+	}
+	for name := range overrides.CAArgs {
+		if name == "" || strings.HasPrefix(name, "-") {
+			return nil, fmt.Errorf("CA arg override %q must be a flag name without leading dashes", name)
+		}
+	}
+	return &overrides, nil
+}
+
+// ApplyOverrides applies the given overrides to the autoscaler config and CA args of the given cluster snapshot and
+// recomputes their hashes.
+func ApplyOverrides(overrides *gsh.AutoScalerConfigOverrides, cs *gsh.ClusterSnapshot) {
+	config := &cs.AutoscalerConfig
+	config.NodeGroups = maps.Clone(config.NodeGroups)
+	config.NodeTemplates = maps.Clone(config.NodeTemplates)
+	config.CASettings.NodeGroupsMinMax = maps.Clone(config.CASettings.NodeGroupsMinMax)
+	cs.CAArgs.Args = maps.Clone(cs.CAArgs.Args)
+	if cs.CAArgs.Args == nil {
+		cs.CAArgs.Args = make(map[string]string)
+	}
+
+	for key, override := range overrides.NodeGroups {
+		names := overriddenNodeGroupNames(config.NodeGroups, key)
+		if len(names) == 0 {
+			slog.Warn("node group override matches no node group or worker pool", "key", key, "snapshotTime", cs.SnapshotTime)
+		}
+		for _, name := range names {
+			nodeGroup := config.NodeGroups[name]
+			if override.MinSize != nil {
+				nodeGroup.MinSize = *override.MinSize
+			}
+			if override.MaxSize != nil {
+				nodeGroup.MaxSize = *override.MaxSize
+			}
+			nodeGroup.Hash = nodeGroup.GetHash()
+			config.NodeGroups[name] = nodeGroup
+			if config.CASettings.NodeGroupsMinMax != nil {
+				config.CASettings.NodeGroupsMinMax[name] = gst.MinMax{Min: nodeGroup.MinSize, Max: nodeGroup.MaxSize}
+			}
+		}
+	}
+
+	for key, override := range overrides.NodeTemplates {
+		names := overriddenNodeGroupNames(config.NodeGroups, key)
+		if len(names) == 0 {
+			slog.Warn("node template override matches no node group or worker pool", "key", key, "snapshotTime", cs.SnapshotTime)
+		}
+		for _, name := range names {
+			nodeTemplate, ok := config.NodeTemplates[name]
+			if !ok {
+				continue
+			}
+			if override.InstanceType != "" {
+				nodeTemplate.InstanceType = override.InstanceType
+			}
+			nodeTemplate.Capacity = nodeTemplate.Capacity.DeepCopy()
+			if nodeTemplate.Capacity == nil {
+				nodeTemplate.Capacity = make(corev1.ResourceList)
+			}
+			for resourceName, quantity := range override.Capacity {
+				nodeTemplate.Capacity[resourceName] = quantity.DeepCopy()
+			}
+			nodeTemplate.Labels = maps.Clone(nodeTemplate.Labels)
+			if nodeTemplate.Labels == nil {
+				nodeTemplate.Labels = make(map[string]string)
+			}
+			maps.Copy(nodeTemplate.Labels, override.Labels)
+			if _, ok := nodeTemplate.Labels[corev1.LabelInstanceTypeStable]; ok && override.InstanceType != "" {
+				nodeTemplate.Labels[corev1.LabelInstanceTypeStable] = override.InstanceType
+			}
+			if override.Taints != nil {
+				nodeTemplate.Taints = slices.Clone(override.Taints)
+			}
+			nodeTemplate.Hash = nodeTemplate.GetHash()
+			config.NodeTemplates[name] = nodeTemplate
+		}
+	}
+
+	caSettings := &config.CASettings
+	caOverride := overrides.CASettings
+	if caOverride.Expander != "" {
+		caSettings.Expander = caOverride.Expander
+		cs.CAArgs.Args["expander"] = caOverride.Expander
+	}
+	if caOverride.MaxNodesTotal != nil {
+		caSettings.MaxNodesTotal = *caOverride.MaxNodesTotal
+		cs.CAArgs.Args["max-nodes-total"] = strconv.Itoa(*caOverride.MaxNodesTotal)
+	}
+	if caOverride.MaxNodeProvisionTime != nil {
+		caSettings.MaxNodeProvisionTime = caOverride.MaxNodeProvisionTime.Duration
+		cs.CAArgs.Args["max-node-provision-time"] = caOverride.MaxNodeProvisionTime.Duration.String()
+	}
+	if caOverride.ScanInterval != nil {
+		caSettings.ScanInterval = caOverride.ScanInterval.Duration
+		cs.CAArgs.Args["scan-interval"] = caOverride.ScanInterval.Duration.String()
+	}
+	if caOverride.NewPodScaleUpDelay != nil {
+		caSettings.NewPodScaleUpDelay = caOverride.NewPodScaleUpDelay.Duration
+		cs.CAArgs.Args["new-pod-scale-up-delay"] = caOverride.NewPodScaleUpDelay.Duration.String()
+	}
+	if caOverride.MaxEmptyBulkDelete != nil {
+		caSettings.MaxEmptyBulkDelete = *caOverride.MaxEmptyBulkDelete
+		cs.CAArgs.Args["max-empty-bulk-delete"] = strconv.Itoa(*caOverride.MaxEmptyBulkDelete)
+	}
+	if caOverride.MaxGracefulTerminationSeconds != nil {
+		caSettings.MaxGracefulTerminationSeconds = *caOverride.MaxGracefulTerminationSeconds
+		cs.CAArgs.Args["max-graceful-termination-sec"] = strconv.Itoa(*caOverride.MaxGracefulTerminationSeconds)
+	}
+	if caOverride.IgnoreDaemonSetUtilization != nil {
+		caSettings.IgnoreDaemonSetUtilization = *caOverride.IgnoreDaemonSetUtilization
+		cs.CAArgs.Args["ignore-daemonsets-utilization"] = strconv.FormatBool(*caOverride.IgnoreDaemonSetUtilization)
+	}
+	if overrides.Priorities != nil {
+		caSettings.Priorities = formatPriorities(overrides.Priorities)
+	}
+	maps.Copy(cs.CAArgs.Args, overrides.CAArgs)
+	caSettings.Hash = caSettings.GetHash()
+	cs.CAArgs.Hash = cs.CAArgs.GetHash()
+	config.Hash = config.GetHash()
+}
+
+// overriddenNodeGroupNames returns the sorted names of the node groups matching the given override key, which is
+// either a node group name or a worker pool name.
+func overriddenNodeGroupNames(nodeGroups map[string]gst.NodeGroupInfo, key string) (names []string) {
+	if _, ok := nodeGroups[key]; ok {
+		return []string{key}
+	}
+	for name, nodeGroup := range nodeGroups {
+		if nodeGroup.PoolName == key {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return
+}
+
+// formatPriorities formats the given priorities as the YAML value of the `priorities` key of the
+// `cluster-autoscaler-priority-expander` config map.
+func formatPriorities(priorities map[int][]string) string {
+	keys := make([]int, 0, len(priorities))
+	for priority := range priorities {
+		keys = append(keys, priority)
+	}
+	slices.Sort(keys)
+	var sb strings.Builder
+	for _, priority := range keys {
+		sb.WriteString(strconv.Itoa(priority) + ":\n")
+		for _, expr := range priorities[priority] {
+			sb.WriteString("- " + strconv.Quote(expr) + "\n")
+		}
+	}
+	return sb.String()
+}
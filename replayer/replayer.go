@@ -1,9 +1,13 @@
 package replayer
 
 import (
+	"cmp"
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	gsh "github.com/elankath/gardener-scaling-history"
+	"github.com/elankath/gardener-scaling-history/cost"
 	"github.com/elankath/gardener-scaling-history/db"
 	gst "github.com/elankath/gardener-scaling-types"
 	"github.com/samber/lo"
@@ -12,11 +16,12 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/utils/pointer"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"log/slog"
 	"os"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -25,37 +30,107 @@ const DefaultStabilizeInterval = time.Duration(45 * time.Second)
 const DefaultTotalReplayTime = time.Duration(1 * time.Hour)
 const DefaultReplayInterval = time.Duration(5 * time.Minute)
 
+// DefaultNodeProvisionDelay is the default ReplayerParams.NodeProvisionDelay of the replayer commands, about the time a
+// new machine takes to join a shoot.
+const DefaultNodeProvisionDelay = 3 * time.Minute
+
+// ScaleDownReportFileName is the name of the scale-down report written into the report dir in scale-down mode.
+const ScaleDownReportFileName = "scale-down-report.json"
+
+// ScaleUpReportFileName is the name of the report of the simulated scale-ups written into the report dir when
+// ReplayerParams.InProcessScaleUp is set.
+const ScaleUpReportFileName = "scale-up-report.json"
+
 type defaultReplayer struct {
-	dataAccess          *db.DataAccess
-	clientSet           *kubernetes.Clientset
+	dataAccess          db.Store
+	virtualCluster      gsh.VirtualCluster
 	params              gsh.ReplayerParams
 	initNodes           []gst.NodeInfo
 	lastScenarios       []gsh.Scenario
 	lastClusterSnapshot gsh.ClusterSnapshot
 	lastReplayTime      time.Time
+	// scaleDownStartTime is the replay time at which the virtual nodes were first observed in scale-down mode.
+	scaleDownStartTime time.Time
+	// lastVirtualNodeNames are the names of the nodes in the virtual cluster at the last replay in scale-down mode.
+	lastVirtualNodeNames sets.Set[string]
+	// virtualRemovalTimes is a map of node name to the replay time at which the node was found removed by the
+	// virtual cluster-autoscaler.
+	virtualRemovalTimes map[string]time.Time
+	// recordingGaps are the gaps in the recording loaded at start.
+	recordingGaps []gsh.RecordingGap
+	// scaleUpSimulator scales up the virtual node groups if ReplayerParams.InProcessScaleUp is set.
+	scaleUpSimulator gsh.ScaleUpSimulator
+	// scaleUpStartTime is the replay time of the first replay with the in-process scale-up.
+	scaleUpStartTime time.Time
+	// provisioningNodeReadyTimes is a map of the names of the nodes created by the in-process scale-up that are not
+	// ready yet to the replay time at which they become ready.
+	provisioningNodeReadyTimes map[string]time.Time
+	// overrides are loaded from ReplayerParams.OverridesPath and applied to every recorded cluster snapshot.
+	overrides *gsh.AutoScalerConfigOverrides
+	// costAccumulator sums up the node-hours and cost of the virtual nodes from the first replay on.
+	costAccumulator *cost.Accumulator
+	// recordedNodeAttributes is a map of the names of the initial nodes to their attributes in the recorded autoscaler
+	// config, before any overrides, so that the recorded nodes keep their machine type in a what-if replay.
+	recordedNodeAttributes map[string]cost.NodeAttributes
+	// shootProvider is the cloud provider of the recorded machine classes.
+	shootProvider gsh.CloudProvider
+	// scaledUpNodeNames are the names of the virtual nodes that are not initial nodes.
+	scaledUpNodeNames sets.Set[string]
 }
 
 var _ gsh.Replayer = (*defaultReplayer)(nil)
 
 func NewDefaultReplayer(params gsh.ReplayerParams) (gsh.Replayer, error) {
-	// Load kubeconfig file
-	config, err := clientcmd.BuildConfigFromFlags("", params.VirtualClusterKubeConfigPath)
+	if params.InMemoryVirtualCluster {
+		return NewDefaultReplayerWithVirtualCluster(params, NewInMemoryVirtualCluster())
+	}
+	virtualCluster, err := NewVirtualClusterFromKubeConfig(params.VirtualClusterKubeConfigPath)
 	if err != nil {
-		return nil, fmt.Errorf("cannot create client config: %w", err)
+		return nil, err
 	}
-	// Create clientset
-	clientset, err := kubernetes.NewForConfig(config)
+	return NewDefaultReplayerWithVirtualCluster(params, virtualCluster)
+}
+
+// NewDefaultReplayerWithVirtualCluster returns a replayer that replays into the given virtual cluster instead of the
+// one at params.VirtualClusterKubeConfigPath.
+func NewDefaultReplayerWithVirtualCluster(params gsh.ReplayerParams, virtualCluster gsh.VirtualCluster) (gsh.Replayer, error) {
+	var dataAccess db.Store
+	if params.PostgresDSN != "" {
+		dataAccess = db.NewPostgresDataAccess(params.PostgresDSN, params.ClusterID)
+	} else {
+		// the replayer may replay the data db of a running recorder, so it must not write to it
+		dataAccess = db.NewReadOnlyDataAccess(params.DBPath)
+	}
+	replayer, err := newDefaultReplayer(params, dataAccess, virtualCluster)
 	if err != nil {
-		return nil, fmt.Errorf("cannot create clientset: %w", err)
+		return nil, err
+	}
+	return replayer, nil
+}
+
+func newDefaultReplayer(params gsh.ReplayerParams, dataAccess db.Store, virtualCluster gsh.VirtualCluster) (*defaultReplayer, error) {
+	var overrides *gsh.AutoScalerConfigOverrides
+	if params.OverridesPath != "" {
+		var err error
+		overrides, err = LoadOverrides(params.OverridesPath)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("loaded autoscaler config overrides", "overridesPath", params.OverridesPath, "description", overrides.Description)
 	}
 	return &defaultReplayer{
-		dataAccess: db.NewDataAccess(params.DBPath),
-		clientSet:  clientset,
-		params:     params,
+		dataAccess:                 dataAccess,
+		virtualCluster:             virtualCluster,
+		params:                     params,
+		virtualRemovalTimes:        make(map[string]time.Time),
+		scaleUpSimulator:           NewScaleUpSimulator(0),
+		provisioningNodeReadyTimes: make(map[string]time.Time),
+		overrides:                  overrides,
+		scaledUpNodeNames:          sets.New[string](),
 	}, nil
 }
 
-func WriteAutoScalerConfig(autoscalerConfig gst.AutoScalerConfig, path string) error {
+func WriteAutoScalerConfig(autoscalerConfig gsh.ReplayAutoScalerConfig, path string) error {
 	bytes, err := json.Marshal(autoscalerConfig)
 	if err != nil {
 		return err
@@ -68,50 +143,7 @@ func WriteAutoScalerConfig(autoscalerConfig gst.AutoScalerConfig, path string) e
 }
 
 func (d *defaultReplayer) CleanCluster(ctx context.Context) error {
-	pods, err := d.clientSet.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
-	if err != nil {
-		slog.Error("cannot list the pods", "error", err)
-		return err
-	}
-	for _, pod := range pods.Items {
-		err = d.clientSet.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{
-			GracePeriodSeconds: pointer.Int64(0),
-		})
-		if err != nil {
-			slog.Error("cannot delete the pod", "pod.Name", pod.Name, "error", err)
-			return err
-		}
-	}
-	nodes, err := d.clientSet.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
-	if err != nil {
-		slog.Error("cannot list the nodes", "error", err)
-		return err
-	}
-	for _, node := range nodes.Items {
-		err = d.clientSet.CoreV1().Nodes().Delete(ctx, node.Name, metav1.DeleteOptions{})
-		if err != nil {
-			slog.Error("cannot delete the node", "node.Name", node.Name, "error", err)
-			return err
-		}
-	}
-
-	pcs, err := d.clientSet.SchedulingV1().PriorityClasses().List(ctx, metav1.ListOptions{})
-	if err != nil {
-		slog.Error("cannot list the priority classes", "error", err)
-		return err
-	}
-	for _, pc := range pcs.Items {
-		if strings.HasPrefix(pc.Name, "system-") {
-			continue
-		}
-		err = d.clientSet.SchedulingV1().PriorityClasses().Delete(ctx, pc.Name, metav1.DeleteOptions{})
-		if err != nil {
-			slog.Error("cannot delete the priority class", "pc.Name", pc.Name, "error", err)
-			return err
-		}
-	}
-	slog.Info("cleaned the cluster, deleted all pods in all namespaces")
-	return nil
+	return d.virtualCluster.Clean(ctx)
 }
 
 func (d *defaultReplayer) Start(ctx context.Context) error {
@@ -124,6 +156,12 @@ func (d *defaultReplayer) Start(ctx context.Context) error {
 		return err
 	}
 
+	d.recordingGaps, err = d.dataAccess.GetRecordingGaps()
+	if err != nil {
+		return fmt.Errorf("cannot get the recording gaps: %w", err)
+	}
+	slog.Info("loaded recording gaps", "numRecordingGaps", len(d.recordingGaps))
+
 	replayTime, err := d.getReplayTime()
 	if err != nil {
 		return err
@@ -136,6 +174,21 @@ func (d *defaultReplayer) Start(ctx context.Context) error {
 	if len(d.initNodes) == 0 {
 		return fmt.Errorf("no initial nodeinfos available before replay time %q", replayTime)
 	}
+	err = d.initCost(replayTime)
+	if err != nil {
+		return fmt.Errorf("cannot initialize the cost report: %w", err)
+	}
+	if d.params.InProcessScaleUp {
+		// without a virtual cluster-autoscaler, nothing else creates the initial nodes
+		for _, nodeInfo := range d.initNodes {
+			node := getCoreNodeFromNodeInfo(nodeInfo)
+			err = d.virtualCluster.CreateNode(ctx, &node)
+			if err != nil {
+				return fmt.Errorf("cannot create the initial node %q: %w", node.Name, err)
+			}
+		}
+		slog.Info("created the initial nodes for the in-process scale-up", "numNodes", len(d.initNodes))
+	}
 	//clusterSnapshot, err := d.GetInitialClusterSnapshot()
 	//if err != nil {
 	//	return err
@@ -242,10 +295,37 @@ func getCorePodFromPodInfo(podInfo gst.PodInfo) corev1.Pod {
 	return pod
 }
 
+func getCoreNodeFromNodeInfo(nodeInfo gst.NodeInfo) corev1.Node {
+	return corev1.Node{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Node",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   nodeInfo.Name,
+			Labels: nodeInfo.Labels,
+		},
+		Spec: corev1.NodeSpec{
+			ProviderID: nodeInfo.ProviderID,
+			Taints:     nodeInfo.Taints,
+		},
+		Status: corev1.NodeStatus{
+			Capacity:    nodeInfo.Capacity,
+			Allocatable: nodeInfo.Allocatable,
+			Conditions: []corev1.NodeCondition{{
+				Type:   corev1.NodeReady,
+				Status: corev1.ConditionTrue,
+				Reason: "KubeletReady",
+			}},
+			Phase: corev1.NodeRunning,
+		},
+	}
+}
+
 func (d *defaultReplayer) applyWork(ctx context.Context, work deltaWork) error {
 	for _, pc := range work.pcsToDelete {
 		pc := pc.PriorityClass
-		err := d.clientSet.SchedulingV1().PriorityClasses().Delete(ctx, pc.Name, metav1.DeleteOptions{})
+		err := d.virtualCluster.DeletePriorityClass(ctx, pc.Name)
 		if err != nil {
 			return fmt.Errorf("cannot delete the priorityclass %q: %w", pc.Name, err)
 		}
@@ -258,7 +338,7 @@ func (d *defaultReplayer) applyWork(ctx context.Context, work deltaWork) error {
 
 	for _, pc := range work.pcsToDeploy {
 		pc := pc.PriorityClass
-		_, err := d.clientSet.SchedulingV1().PriorityClasses().Create(ctx, &pc, metav1.CreateOptions{})
+		err := d.virtualCluster.CreatePriorityClass(ctx, &pc)
 		if err != nil {
 			return fmt.Errorf("cannot create the priorityclass %q: %w", pc.Name, err)
 		}
@@ -266,7 +346,7 @@ func (d *defaultReplayer) applyWork(ctx context.Context, work deltaWork) error {
 	}
 
 	for _, pod := range work.podsToDelete {
-		err := d.clientSet.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+		err := d.virtualCluster.DeletePod(ctx, pod.Namespace, pod.Name)
 		if err != nil {
 			return fmt.Errorf("cannot delete the pod %q: %w", pod.Name, err)
 		}
@@ -274,9 +354,9 @@ func (d *defaultReplayer) applyWork(ctx context.Context, work deltaWork) error {
 	}
 	for _, pod := range work.podsToDeploy {
 		corePod := getCorePodFromPodInfo(pod)
-		pd, err := d.clientSet.CoreV1().Pods(pod.Namespace).Create(ctx, &corePod, metav1.CreateOptions{})
+		err := d.virtualCluster.CreatePod(ctx, &corePod)
 		if err != nil {
-			return fmt.Errorf("cannot create the pod %q: %w", pd.Name, err)
+			return fmt.Errorf("cannot create the pod %q: %w", pod.Name, err)
 		}
 		slog.Info("successfully created pod", "name", pod.Name)
 	}
@@ -286,6 +366,9 @@ func (d *defaultReplayer) applyWork(ctx context.Context, work deltaWork) error {
 
 func (d *defaultReplayer) getReplayTime() (replayTime time.Time, err error) {
 	if d.lastReplayTime.IsZero() {
+		if !d.params.StartTime.IsZero() {
+			return d.params.StartTime.UTC(), nil
+		}
 		replayTime, err = d.dataAccess.GetInitialRecorderStartTime()
 		if err != nil {
 			return
@@ -306,6 +389,10 @@ func (d *defaultReplayer) doReplay(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	err = d.checkRecordingGaps(replayTime)
+	if err != nil {
+		return err
+	}
 	defer func() { d.lastReplayTime = replayTime }()
 	slog.Info("getting cluster snapshot at time", "snapshotTime", replayTime)
 	clusterSnapshot, err := d.GetRecordedClusterSnapshot(replayTime)
@@ -313,9 +400,13 @@ func (d *defaultReplayer) doReplay(ctx context.Context) error {
 		return err
 	}
 	// |lCs|---------------|Cs| delta -> Cs - lCs
-	if clusterSnapshot.AutoscalerConfig.Hash != d.lastClusterSnapshot.AutoscalerConfig.Hash {
-		slog.Info("wrote autoscaler config", "prevHash", d.lastClusterSnapshot.AutoscalerConfig.Hash, "currHash", clusterSnapshot.AutoscalerConfig.Hash)
-		err = WriteAutoScalerConfig(clusterSnapshot.AutoscalerConfig, d.params.VirtualAutoScalerConfigPath)
+	if clusterSnapshot.AutoscalerConfig.Hash != d.lastClusterSnapshot.AutoscalerConfig.Hash || clusterSnapshot.CAArgs.Hash != d.lastClusterSnapshot.CAArgs.Hash {
+		slog.Info("wrote autoscaler config", "prevHash", d.lastClusterSnapshot.AutoscalerConfig.Hash, "currHash", clusterSnapshot.AutoscalerConfig.Hash,
+			"prevCAArgsHash", d.lastClusterSnapshot.CAArgs.Hash, "currCAArgsHash", clusterSnapshot.CAArgs.Hash)
+		err = WriteAutoScalerConfig(gsh.ReplayAutoScalerConfig{
+			AutoScalerConfig: clusterSnapshot.AutoscalerConfig,
+			CAArgs:           clusterSnapshot.CAArgs.Args,
+		}, d.params.VirtualAutoScalerConfigPath)
 		if err != nil {
 			return fmt.Errorf("cannot write autoscaler config at time %q to path %q: %w", clusterSnapshot.SnapshotTime, d.params.VirtualAutoScalerConfigPath, err)
 		}
@@ -326,16 +417,294 @@ func (d *defaultReplayer) doReplay(ctx context.Context) error {
 	deltaWk := computeDeltaWork(d.lastClusterSnapshot, clusterSnapshot)
 	if deltaWk.IsEmpty() {
 		slog.Info("no delta work to apply.")
-		return nil
+		err = d.schedulePendingPods(ctx, replayTime)
+		if err != nil {
+			return err
+		}
+		err = d.scaleUp(ctx, clusterSnapshot, replayTime)
+		if err != nil {
+			return err
+		}
+		err = d.recordScaleDowns(ctx, replayTime)
+		if err != nil {
+			return err
+		}
+		return d.recordCost(ctx, clusterSnapshot, replayTime)
 	}
 	err = d.applyWork(ctx, deltaWk)
 	if err != nil {
 		return err
 	}
+	err = d.schedulePendingPods(ctx, replayTime)
+	if err != nil {
+		return err
+	}
+	err = d.scaleUp(ctx, clusterSnapshot, replayTime)
+	if err != nil {
+		return err
+	}
 	slog.Info("applied work, waiting for cluster to stabilize", "stabilizeInterval", d.params.StabilizeInterval)
 	<-time.After(d.params.StabilizeInterval)
 	d.appendScenario(d.lastClusterSnapshot, clusterSnapshot)
 	d.lastClusterSnapshot = clusterSnapshot
+	err = d.recordScaleDowns(ctx, replayTime)
+	if err != nil {
+		return err
+	}
+	return d.recordCost(ctx, clusterSnapshot, replayTime)
+}
+
+// schedulePendingPods binds the pending pods of the virtual cluster to its nodes that are ready at the given replay
+// time with the in-process scheduler. It does nothing unless ReplayerParams.InProcessScheduler is set.
+func (d *defaultReplayer) schedulePendingPods(ctx context.Context, replayTime time.Time) error {
+	if !d.params.InProcessScheduler {
+		return nil
+	}
+	provisioningNodeNames := sets.New[string]()
+	for name, readyTime := range d.provisioningNodeReadyTimes {
+		if readyTime.After(replayTime) {
+			provisioningNodeNames.Insert(name)
+		} else {
+			delete(d.provisioningNodeReadyTimes, name)
+		}
+	}
+	bound, unschedulable, err := newPodScheduler(d.virtualCluster, provisioningNodeNames).schedulePendingPods(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot schedule the pending pods: %w", err)
+	}
+	slog.Info("scheduled pending pods with the in-process scheduler", "bound", bound, "unschedulable", unschedulable,
+		"numProvisioningNodes", provisioningNodeNames.Len())
+	return nil
+}
+
+// scaleUp scales up the node groups of the given cluster snapshot for the pending pods of the virtual cluster with the
+// in-process ScaleUpSimulator, creates the nodes of the scale-up in the virtual cluster and writes the scale-up report.
+// The nodes become ready for the in-process scheduler after the ReplayerParams.NodeProvisionDelay, before which the
+// ScaleUpSimulator counts them as upcoming nodes like the cluster-autoscaler does. It does nothing unless
+// ReplayerParams.InProcessScaleUp is set.
+func (d *defaultReplayer) scaleUp(ctx context.Context, clusterSnapshot gsh.ClusterSnapshot, replayTime time.Time) error {
+	if !d.params.InProcessScaleUp {
+		return nil
+	}
+	if d.scaleUpStartTime.IsZero() {
+		d.scaleUpStartTime = replayTime
+	}
+	nodes, err := d.virtualCluster.ListNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list the virtual nodes: %w", err)
+	}
+	pods, err := d.virtualCluster.ListPods(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list the virtual pods: %w", err)
+	}
+	scenario, err := d.scaleUpSimulator.SimulateScaleUp(clusterSnapshot.AutoscalerConfig, nodes, pods)
+	if err != nil {
+		return fmt.Errorf("cannot simulate the scale-up at time %q: %w", replayTime, err)
+	}
+	if len(scenario.ScaledUpNodes) == 0 {
+		slog.Info("no node group to scale up", "numUnscheduledPods", len(scenario.UnscheduledPods))
+		return nil
+	}
+	for _, node := range scenario.ScaledUpNodes {
+		err = d.virtualCluster.CreateNode(ctx, &node)
+		if err != nil {
+			return fmt.Errorf("cannot create the scaled-up node %q: %w", node.Name, err)
+		}
+		if d.params.NodeProvisionDelay > 0 {
+			d.provisioningNodeReadyTimes[node.Name] = replayTime.Add(d.params.NodeProvisionDelay)
+		}
+	}
+	slog.Info("scaled up node groups with the in-process scale-up", "scaledUpNodeGroups", scenario.ScaledUpNodeGroups,
+		"numNominatedPods", len(scenario.NominatedPods))
+	d.lastScenarios = append(d.lastScenarios, scenario)
+	err = d.writeScaleUpReport()
+	if err != nil {
+		return err
+	}
+	return d.schedulePendingPods(ctx, replayTime)
+}
+
+func (d *defaultReplayer) writeScaleUpReport() error {
+	bytes, err := json.Marshal(gsh.ReplayReport{
+		StartTime: d.scaleUpStartTime,
+		Scenarios: d.lastScenarios,
+		Overrides: d.overrides,
+	})
+	if err != nil {
+		return err
+	}
+	reportPath := path.Join(d.params.ReportDir, ScaleUpReportFileName)
+	err = os.WriteFile(reportPath, bytes, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot write scale-up report to %q: %w", reportPath, err)
+	}
+	slog.Info("wrote scale-up report", "reportPath", reportPath, "numScenarios", len(d.lastScenarios))
+	return nil
+}
+
+// checkRecordingGaps warns about the recording gaps crossed by the replay window from the last replay time to the given
+// replay time. If ReplayerParams.RefuseRecordingGaps is set, it returns an ErrRecordingGap instead.
+func (d *defaultReplayer) checkRecordingGaps(replayTime time.Time) error {
+	windowStart := d.lastReplayTime
+	if windowStart.IsZero() {
+		windowStart = replayTime
+	}
+	for _, gap := range d.recordingGaps {
+		if !gap.Overlaps(windowStart, replayTime) {
+			continue
+		}
+		if d.params.RefuseRecordingGaps {
+			return fmt.Errorf("%w: window from %q to %q crosses %s", gsh.ErrRecordingGap, windowStart, replayTime, gap)
+		}
+		slog.Warn("replay window crosses a recording gap, changes in the gap may be missing", "windowStart", windowStart,
+			"windowEnd", replayTime, "gap", gap)
+	}
+	return nil
+}
+
+// recordScaleDowns records the nodes removed from the virtual cluster by the virtual cluster-autoscaler since the last
+// replay and writes the scale-down report. It does nothing unless ReplayerParams.ScaleDownEnabled is set.
+func (d *defaultReplayer) recordScaleDowns(ctx context.Context, replayTime time.Time) error {
+	if !d.params.ScaleDownEnabled {
+		return nil
+	}
+	nodes, err := d.virtualCluster.ListNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list the virtual nodes: %w", err)
+	}
+	virtualNodeNames := sets.New(lo.Map(nodes, func(item corev1.Node, _ int) string {
+		return item.Name
+	})...)
+	if d.lastVirtualNodeNames == nil {
+		d.scaleDownStartTime = replayTime
+	}
+	for _, nodeName := range sets.List(d.lastVirtualNodeNames.Difference(virtualNodeNames)) {
+		slog.Info("node removed by virtual cluster-autoscaler", "node.Name", nodeName, "replayTime", replayTime)
+		d.virtualRemovalTimes[nodeName] = replayTime
+	}
+	d.lastVirtualNodeNames = virtualNodeNames
+	return d.writeScaleDownReport(replayTime)
+}
+
+// initCost creates the cost accumulator starting at the given replay time and gets the attributes of the initial nodes
+// and the cloud provider of the recorded cluster at that time.
+func (d *defaultReplayer) initCost(replayTime time.Time) error {
+	mccs, err := d.dataAccess.LoadMachineClassInfosBefore(replayTime)
+	if err != nil {
+		return err
+	}
+	mcds, err := d.dataAccess.LoadMachineDeploymentInfosBefore(replayTime)
+	if err != nil {
+		return err
+	}
+	workerPools, err := d.dataAccess.LoadWorkerPoolInfosBefore(replayTime)
+	if err != nil {
+		return err
+	}
+	var config gst.AutoScalerConfig
+	config.NodeTemplates, err = GetNodeTemplates(mccs, mcds)
+	if err != nil {
+		return err
+	}
+	config.NodeGroups, err = GetNodeGroups(mcds, workerPools)
+	if err != nil {
+		return err
+	}
+	d.shootProvider = cost.GetShootCloudProvider(mccs)
+	d.recordedNodeAttributes = make(map[string]cost.NodeAttributes, len(d.initNodes))
+	for _, nodeInfo := range d.initNodes {
+		node := getCoreNodeFromNodeInfo(nodeInfo)
+		d.recordedNodeAttributes[node.Name] = getNodeAttributes(&node, config, d.shootProvider)
+	}
+	d.costAccumulator = cost.NewAccumulator(d.params.PriceCatalog, replayTime, d.params.CostWindow)
+	return nil
+}
+
+// recordCost adds the time from the given replay time to the next replay to the cost of the virtual nodes and writes
+// the cost report into the report dir. The initial nodes keep their recorded attributes, the attributes of the other
+// nodes are taken from the given cluster snapshot.
+func (d *defaultReplayer) recordCost(ctx context.Context, clusterSnapshot gsh.ClusterSnapshot, replayTime time.Time) error {
+	nodes, err := d.virtualCluster.ListNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list the virtual nodes: %w", err)
+	}
+	for i := range nodes {
+		node := &nodes[i]
+		attrs, ok := d.recordedNodeAttributes[node.Name]
+		if !ok {
+			d.scaledUpNodeNames.Insert(node.Name)
+			attrs = getNodeAttributes(node, clusterSnapshot.AutoscalerConfig, d.shootProvider)
+		}
+		d.costAccumulator.AddNode(attrs, replayTime, replayTime.Add(d.params.ReplayInterval))
+	}
+	report := d.costAccumulator.Report()
+	report.Overrides = d.overrides
+	return cost.WriteReport(report, path.Join(d.params.ReportDir, cost.ReportFileName),
+		path.Join(d.params.ReportDir, cost.EntriesFileName))
+}
+
+// getNodeAttributes returns the attributes of the given node from its labels and provider ID, or else from its node
+// group and the node template of its node group in the given config and from the given shoot provider.
+func getNodeAttributes(node *corev1.Node, config gst.AutoScalerConfig, shootProvider gsh.CloudProvider) cost.NodeAttributes {
+	attrs := cost.GetNodeAttributes(node.Labels)
+	attrs.Provider = cmp.Or(cost.GetCloudProvider(node.Spec.ProviderID), shootProvider)
+	if nodeGroup, ok := GetNodeGroupOfNode(config.NodeGroups, node); ok {
+		attrs.Pool = cmp.Or(attrs.Pool, nodeGroup.PoolName)
+		attrs.Zone = cmp.Or(attrs.Zone, nodeGroup.Zone)
+		if nodeTemplate, ok := config.NodeTemplates[nodeGroup.Name]; ok {
+			attrs.Region = cmp.Or(attrs.Region, nodeTemplate.Region)
+			attrs.InstanceType = cmp.Or(attrs.InstanceType, nodeTemplate.InstanceType)
+		}
+	}
+	return attrs
+}
+
+// writeScaleDownReport compares the nodes removed by the virtual cluster-autoscaler with the nodes deleted in the
+// recorded cluster between the scale-down start time and the given replay time and writes the comparison as JSON
+// into the report dir.
+func (d *defaultReplayer) writeScaleDownReport(replayTime time.Time) error {
+	realDeletionTimes, err := d.dataAccess.LoadNodeDeletionTimestamps()
+	if err != nil {
+		return err
+	}
+	comparisons := make(map[string]gsh.NodeScaleDownComparison)
+	for nodeName, virtualRemovalTime := range d.virtualRemovalTimes {
+		comparisons[nodeName] = gsh.NodeScaleDownComparison{
+			NodeName:           nodeName,
+			VirtualRemovalTime: virtualRemovalTime,
+			RealDeletionTime:   realDeletionTimes[nodeName],
+		}
+	}
+	for nodeName, realDeletionTime := range realDeletionTimes {
+		if _, ok := comparisons[nodeName]; ok {
+			continue
+		}
+		if realDeletionTime.Before(d.scaleDownStartTime) || realDeletionTime.After(replayTime) {
+			continue
+		}
+		comparisons[nodeName] = gsh.NodeScaleDownComparison{
+			NodeName:         nodeName,
+			RealDeletionTime: realDeletionTime,
+		}
+	}
+	report := gsh.ReplayReport{
+		StartTime:  d.scaleDownStartTime,
+		ScaleDowns: maps.Values(comparisons),
+		Overrides:  d.overrides,
+	}
+	slices.SortFunc(report.ScaleDowns, func(a, b gsh.NodeScaleDownComparison) int {
+		return strings.Compare(a.NodeName, b.NodeName)
+	})
+	bytes, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	reportPath := path.Join(d.params.ReportDir, ScaleDownReportFileName)
+	err = os.WriteFile(reportPath, bytes, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot write scale-down report to %q: %w", reportPath, err)
+	}
+	slog.Info("wrote scale-down report", "reportPath", reportPath, "numScaleDowns", len(report.ScaleDowns))
 	return nil
 }
 
@@ -380,7 +749,11 @@ func (d *defaultReplayer) GetRecordedClusterSnapshot(startTime time.Time) (cs gs
 	}
 	cs.PriorityClasses, err = d.dataAccess.LoadLatestPriorityClassInfoBeforeSnapshotTime(startTime)
 	if err != nil {
-		return
+		if !errors.Is(err, sql.ErrNoRows) {
+			return
+		}
+		slog.Warn("no priority classes recorded before snapshot time", "startTime", startTime)
+		err = nil
 	}
 	autoscalerConfig.NodeGroups, err = GetNodeGroups(mcds, workerPools)
 	if err != nil {
@@ -388,6 +761,10 @@ func (d *defaultReplayer) GetRecordedClusterSnapshot(startTime time.Time) (cs gs
 	}
 
 	autoscalerConfig.CASettings, err = d.dataAccess.LoadCASettingsBefore(startTime)
+	cs.CAArgs, err = d.getEffectiveCAArgsBefore(startTime, autoscalerConfig.CASettings)
+	if err != nil {
+		return
+	}
 	cs.AutoscalerConfig = autoscalerConfig
 	cs.AutoscalerConfig.Mode = gst.AutoscalerReplayerMode
 	cs.SnapshotTime = startTime
@@ -395,7 +772,11 @@ func (d *defaultReplayer) GetRecordedClusterSnapshot(startTime time.Time) (cs gs
 	cs.AutoscalerConfig.Hash = cs.AutoscalerConfig.GetHash()
 	cs.Pods, err = d.dataAccess.GetLatestPodInfosBeforeSnapshotTime(startTime)
 	if err != nil {
-		return
+		if !errors.Is(err, sql.ErrNoRows) {
+			return
+		}
+		slog.Warn("no pods recorded before snapshot time", "startTime", startTime)
+		err = nil
 	}
 
 	cs.Nodes, err = d.dataAccess.LoadNodeInfosBefore(startTime)
@@ -403,6 +784,41 @@ func (d *defaultReplayer) GetRecordedClusterSnapshot(startTime time.Time) (cs gs
 		return
 	}
 
+	cs.CAStatus, err = d.dataAccess.LoadCANodeGroupStatusInfosBefore(startTime)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return
+		}
+		slog.Warn("no cluster-autoscaler status recorded before snapshot time", "startTime", startTime)
+		err = nil
+	}
+	if d.overrides != nil {
+		ApplyOverrides(d.overrides, &cs)
+	}
+	return
+}
+
+// getEffectiveCAArgsBefore loads the latest recorded CAArgsInfo at or before the given timestamp and replaces its args
+// with the effective args, using the CA defaults for any flags that were not recorded. If no CAArgsInfo was recorded,
+// as in data dbs of older recorders, the args are taken from the given recorded caSettings. Scale-down is only enabled
+// if ReplayerParams.ScaleDownEnabled is set.
+func (d *defaultReplayer) getEffectiveCAArgsBefore(timestamp time.Time, caSettings gst.CASettingsInfo) (caArgs gsh.CAArgsInfo, err error) {
+	caArgs, err = d.dataAccess.LoadCAArgsInfoBefore(timestamp)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return
+		}
+		err = nil
+		if caSettings.SnapshotTimestamp.IsZero() {
+			slog.Warn("no ca args or ca settings recorded before snapshot time, using CA defaults", "snapshotTime", timestamp)
+		} else {
+			slog.Warn("no ca args recorded before snapshot time, using recorded ca settings", "snapshotTime", timestamp)
+			caArgs = gsh.CAArgsInfoFromCASettings(caSettings)
+		}
+	}
+	caArgs.Args = caArgs.GetEffectiveArgs()
+	caArgs.Args["scale-down-enabled"] = strconv.FormatBool(d.params.ScaleDownEnabled)
+	caArgs.Hash = caArgs.GetHash()
 	return
 }
 
@@ -2,18 +2,40 @@ package replayer
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	gsh "github.com/elankath/gardener-scaling-history"
+	"github.com/elankath/gardener-scaling-history/cost"
+	"github.com/elankath/gardener-scaling-history/db"
+	gst "github.com/elankath/gardener-scaling-types"
 	"github.com/samber/lo"
 	assert "github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/ptr"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
 
+// TestNodeCreation creates a ready node in the virtual cluster of VIRTUAL_CLUSTER_KUBECONFIG.
 func TestNodeCreation(t *testing.T) {
-	kubeconfigPath := "/Users/i585976/go/src/github.com/mcm-poc/kvcl/tmp/kubeconfig.yaml"
+	kubeconfigPath := os.Getenv("VIRTUAL_CLUSTER_KUBECONFIG")
+	if kubeconfigPath == "" {
+		t.Skip("VIRTUAL_CLUSTER_KUBECONFIG not set")
+	}
 	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
 	assert.Nil(t, err)
 	clientSet, err := kubernetes.NewForConfig(config)
@@ -64,3 +86,932 @@ func TestNodeCreation(t *testing.T) {
 	nd, err = clientSet.CoreV1().Nodes().UpdateStatus(context.Background(), nd, metav1.UpdateOptions{})
 	assert.Nil(t, err)
 }
+
+func TestCheckRecordingGaps(t *testing.T) {
+	start := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	d := &defaultReplayer{
+		recordingGaps: []gsh.RecordingGap{{
+			BeginTime: start.Add(7 * time.Minute),
+			EndTime:   start.Add(9 * time.Minute),
+			Cause:     gsh.RecorderCrashedGap,
+		}},
+	}
+	assert.Nil(t, d.checkRecordingGaps(start))
+
+	d.lastReplayTime = start
+	assert.Nil(t, d.checkRecordingGaps(start.Add(5*time.Minute)))
+
+	d.lastReplayTime = start.Add(5 * time.Minute)
+	assert.Nil(t, d.checkRecordingGaps(start.Add(10*time.Minute)), "crossing a gap must only warn by default")
+
+	d.params.RefuseRecordingGaps = true
+	err := d.checkRecordingGaps(start.Add(10 * time.Minute))
+	assert.ErrorIs(t, err, gsh.ErrRecordingGap)
+
+	d.lastReplayTime = start.Add(10 * time.Minute)
+	assert.Nil(t, d.checkRecordingGaps(start.Add(15*time.Minute)))
+}
+
+// updateGolden regenerates the golden snapshots in testdata/golden from the timelines in testdata/timelines. Run
+// `go test ./replayer -run TestGoldenSnapshots -update` and review the diff of testdata/golden.
+var updateGolden = flag.Bool("update", false, "regenerate the golden snapshots in testdata/golden")
+
+// timeline is a sequence of recorded rows, stored in order into a data db, and the times at which the recorded
+// cluster snapshots of the data db are compared with the golden snapshots.
+type timeline struct {
+	SnapshotTimes []time.Time    `json:"snapshotTimes"`
+	Steps         []timelineStep `json:"steps"`
+}
+
+// timelineStep stores one row or deletion timestamp. Exactly one field is set. The hashes and pod requests are
+// computed like the recorder does.
+type timelineStep struct {
+	MachineClass              *gsh.MachineClassInfo      `json:"machineClass,omitempty"`
+	MachineDeployment         *gst.MachineDeploymentInfo `json:"machineDeployment,omitempty"`
+	MachineDeploymentDeletion *timelineDeletion          `json:"machineDeploymentDeletion,omitempty"`
+	WorkerPool                *gst.WorkerPoolInfo        `json:"workerPool,omitempty"`
+	PriorityClass             *gst.PriorityClassInfo     `json:"priorityClass,omitempty"`
+	CASettings                *gst.CASettingsInfo        `json:"caSettings,omitempty"`
+	CAArgs                    *gsh.CAArgsInfo            `json:"caArgs,omitempty"`
+	CAStatus                  *gsh.CANodeGroupStatusInfo `json:"caStatus,omitempty"`
+	Node                      *gst.NodeInfo              `json:"node,omitempty"`
+	NodeDeletion              *timelineDeletion          `json:"nodeDeletion,omitempty"`
+	Pod                       *gst.PodInfo               `json:"pod,omitempty"`
+	PodDeletion               *timelineDeletion          `json:"podDeletion,omitempty"`
+	// Checkpoint stores a checkpoint at the given time. It is not part of the data dbs of older recorders.
+	Checkpoint *time.Time `json:"checkpoint,omitempty"`
+}
+
+// timelineDeletion sets the DeletionTimestamp of the rows with the given Name, which is the UID for pods.
+type timelineDeletion struct {
+	Name              string
+	DeletionTimestamp time.Time
+}
+
+func loadTimeline(t *testing.T, timelinePath string) (tl timeline) {
+	data, err := os.ReadFile(timelinePath)
+	assert.Nil(t, err)
+	assert.Nil(t, yaml.Unmarshal(data, &tl))
+	return
+}
+
+func storeTimeline(t *testing.T, store db.Store, tl timeline) {
+	for i, step := range tl.Steps {
+		var err error
+		switch {
+		case step.MachineClass != nil:
+			step.MachineClass.Hash = step.MachineClass.GetHash()
+			_, err = store.StoreMachineClassInfo(*step.MachineClass)
+		case step.MachineDeployment != nil:
+			step.MachineDeployment.Hash = step.MachineDeployment.GetHash()
+			_, err = store.StoreMachineDeploymentInfo(*step.MachineDeployment)
+		case step.MachineDeploymentDeletion != nil:
+			_, err = store.UpdateMCDInfoDeletionTimestamp(step.MachineDeploymentDeletion.Name, step.MachineDeploymentDeletion.DeletionTimestamp)
+		case step.WorkerPool != nil:
+			step.WorkerPool.Hash = step.WorkerPool.GetHash()
+			_, err = store.StoreWorkerPoolInfo(*step.WorkerPool)
+		case step.PriorityClass != nil:
+			step.PriorityClass.Hash = step.PriorityClass.GetHash()
+			_, err = store.StorePriorityClassInfo(*step.PriorityClass)
+		case step.CASettings != nil:
+			step.CASettings.Hash = step.CASettings.GetHash()
+			_, err = store.StoreCASettingsInfo(*step.CASettings)
+		case step.CAArgs != nil:
+			step.CAArgs.Hash = step.CAArgs.GetHash()
+			_, err = store.StoreCAArgsInfo(*step.CAArgs)
+		case step.CAStatus != nil:
+			step.CAStatus.Hash = step.CAStatus.GetHash()
+			_, err = store.StoreCANodeGroupStatusInfo(*step.CAStatus)
+		case step.Node != nil:
+			step.Node.Hash = step.Node.GetHash()
+			_, err = store.StoreNodeInfo(*step.Node)
+		case step.NodeDeletion != nil:
+			_, err = store.UpdateNodeInfoDeletionTimestamp(step.NodeDeletion.Name, step.NodeDeletion.DeletionTimestamp)
+		case step.Pod != nil:
+			step.Pod.Requests = gst.CumulatePodRequests(&corev1.Pod{Spec: step.Pod.Spec})
+			step.Pod.Hash = step.Pod.GetHash()
+			_, err = store.StorePodInfo(*step.Pod)
+		case step.PodDeletion != nil:
+			_, err = store.UpdatePodDeletionTimestamp(types.UID(step.PodDeletion.Name), step.PodDeletion.DeletionTimestamp)
+		case step.Checkpoint != nil:
+			_, err = store.StoreCheckpoint(*step.Checkpoint)
+		default:
+			err = fmt.Errorf("no row set")
+		}
+		assert.Nil(t, err, "cannot store step %d of timeline", i)
+	}
+}
+
+// restoreDataDB restores the SQL dump of a data db, as written by `sqlite3 <db> .dump`, into a new data db.
+func restoreDataDB(t *testing.T, dumpPath, dataDBPath string) {
+	dump, err := os.ReadFile(dumpPath)
+	assert.Nil(t, err)
+	dataDB, err := sql.Open("sqlite", dataDBPath)
+	assert.Nil(t, err)
+	defer dataDB.Close()
+	_, err = dataDB.Exec(string(dump))
+	assert.Nil(t, err)
+}
+
+// recordedSnapshotJSONs returns the indented JSON of the recorded cluster snapshots of the data db at the given
+// times by golden file name. The initial nodes are the nodes before the first snapshot time, like at the start of a
+// replay.
+func recordedSnapshotJSONs(t *testing.T, store db.Store, snapshotTimes []time.Time) map[string][]byte {
+	initNodes, err := store.LoadNodeInfosBefore(snapshotTimes[0])
+	assert.Nil(t, err)
+	d := &defaultReplayer{dataAccess: store, initNodes: initNodes}
+	snapshotJSONs := make(map[string][]byte)
+	for _, snapshotTime := range snapshotTimes {
+		cs, err := d.GetRecordedClusterSnapshot(snapshotTime)
+		assert.Nil(t, err, "cannot get the recorded cluster snapshot at %s", snapshotTime)
+		data, err := json.MarshalIndent(cs, "", "  ")
+		assert.Nil(t, err)
+		snapshotJSONs[snapshotTime.UTC().Format("20060102T150405Z")+".json"] = append(data, '\n')
+	}
+	return snapshotJSONs
+}
+
+// assertGoldenSnapshots compares the snapshots with the golden snapshots in goldenDir, after writing them there if
+// -update is set.
+func assertGoldenSnapshots(t *testing.T, goldenDir string, snapshotJSONs map[string][]byte) {
+	if *updateGolden {
+		assert.Nil(t, os.RemoveAll(goldenDir))
+		assert.Nil(t, os.MkdirAll(goldenDir, 0755))
+		for goldenName, snapshotJSON := range snapshotJSONs {
+			assert.Nil(t, os.WriteFile(filepath.Join(goldenDir, goldenName), snapshotJSON, 0644))
+		}
+	}
+	goldenPaths, err := filepath.Glob(filepath.Join(goldenDir, "*.json"))
+	assert.Nil(t, err)
+	assert.Len(t, goldenPaths, len(snapshotJSONs), "golden snapshots in %s do not match the snapshot times", goldenDir)
+	for name, snapshotJSON := range snapshotJSONs {
+		golden, err := os.ReadFile(filepath.Join(goldenDir, name))
+		assert.Nil(t, err)
+		assert.Equal(t, string(golden), string(snapshotJSON), "recorded cluster snapshot differs from %s", filepath.Join(goldenDir, name))
+	}
+}
+
+// TestGoldenSnapshots stores every timeline of testdata/timelines into a new data db and compares its recorded cluster
+// snapshots with the golden snapshots of the timeline. The dumps of data dbs of older recorders in testdata/dbs,
+// named <timeline>-<version>.sql and written by that recorder from the rows of the timeline it records, are migrated
+// and compared with their own golden snapshots in testdata/golden/<timeline>-<version>.
+func TestGoldenSnapshots(t *testing.T) {
+	timelinePaths, err := filepath.Glob(filepath.Join("testdata", "timelines", "*.yaml"))
+	assert.Nil(t, err)
+	assert.NotEmpty(t, timelinePaths)
+	for _, timelinePath := range timelinePaths {
+		name := strings.TrimSuffix(filepath.Base(timelinePath), ".yaml")
+		goldenDir := filepath.Join("testdata", "golden", name)
+		t.Run(name, func(t *testing.T) {
+			tl := loadTimeline(t, timelinePath)
+			dataAccess := db.NewDataAccess(filepath.Join(t.TempDir(), name+".db"))
+			assert.Nil(t, dataAccess.Init())
+			defer dataAccess.Close()
+			storeTimeline(t, dataAccess, tl)
+			assertGoldenSnapshots(t, goldenDir, recordedSnapshotJSONs(t, dataAccess, tl.SnapshotTimes))
+		})
+
+		dumpPaths, err := filepath.Glob(filepath.Join("testdata", "dbs", name+"-*.sql"))
+		assert.Nil(t, err)
+		for _, dumpPath := range dumpPaths {
+			dumpName := strings.TrimSuffix(filepath.Base(dumpPath), ".sql")
+			t.Run(dumpName, func(t *testing.T) {
+				tl := loadTimeline(t, timelinePath)
+				dataDBPath := filepath.Join(t.TempDir(), name+".db")
+				restoreDataDB(t, dumpPath, dataDBPath)
+				dataAccess := db.NewDataAccess(dataDBPath)
+				assert.Nil(t, dataAccess.Init())
+				defer dataAccess.Close()
+				assertGoldenSnapshots(t, filepath.Join("testdata", "golden", dumpName), recordedSnapshotJSONs(t, dataAccess, tl.SnapshotTimes))
+			})
+		}
+	}
+}
+
+func TestReplayIntoInMemoryVirtualCluster(t *testing.T) {
+	dataDBPath := filepath.Join(t.TempDir(), "scale-up.db")
+	store := db.NewDataAccess(dataDBPath)
+	assert.Nil(t, store.Init())
+	storeTimeline(t, store, loadTimeline(t, "testdata/timelines/scale-up.yaml"))
+	assert.Nil(t, store.InsertRecorderStartTime(time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)))
+	assert.Nil(t, store.Close())
+
+	virtualCluster := NewInMemoryVirtualCluster(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "stale", Namespace: "default"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "stale"}},
+		&schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "stale"}},
+		&schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "system-node-critical"}},
+	)
+	configPath := filepath.Join(t.TempDir(), "autoscaler-config.json")
+	replayer, err := NewDefaultReplayerWithVirtualCluster(gsh.ReplayerParams{
+		DBPath:                      dataDBPath,
+		ReportDir:                   t.TempDir(),
+		VirtualAutoScalerConfigPath: configPath,
+		StabilizeInterval:           time.Millisecond,
+		ReplayInterval:              3 * time.Minute,
+	}, virtualCluster)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = replayer.Close()
+	})
+	ctx := context.Background()
+	assert.Nil(t, replayer.Start(ctx))
+	nodes, err := virtualCluster.ListNodes(ctx)
+	assert.Nil(t, err)
+	assert.Empty(t, nodes)
+
+	d := replayer.(*defaultReplayer)
+	for range 4 {
+		assert.Nil(t, d.doReplay(ctx))
+		snapshot, err := d.GetRecordedClusterSnapshot(d.lastReplayTime)
+		assert.Nil(t, err)
+		pods, err := virtualCluster.ListPods(ctx)
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, lo.Map(snapshot.Pods, func(item gst.PodInfo, _ int) string {
+			return item.Name
+		}), lo.Map(pods, func(item corev1.Pod, _ int) string {
+			return item.Name
+		}), "pods of virtual cluster differ from snapshot at %s", d.lastReplayTime)
+		for _, pod := range pods {
+			assert.Empty(t, pod.Spec.NodeName)
+		}
+	}
+	assert.Equal(t, time.Date(2024, 6, 1, 10, 9, 0, int(time.Millisecond), time.UTC), d.lastReplayTime)
+	pods, err := virtualCluster.ListPods(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, pods, 2)
+
+	pcs, err := virtualCluster.ListPriorityClasses(ctx)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"shoot-system-700", "system-node-critical"}, lo.Map(pcs, func(item schedulingv1.PriorityClass, _ int) string {
+		return item.Name
+	}))
+	var config gsh.ReplayAutoScalerConfig
+	data, err := os.ReadFile(configPath)
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &config))
+	assert.Contains(t, config.NodeGroups, "shoot--test--golden.shoot--test--golden-p1-z1")
+}
+
+func TestInProcessScheduler(t *testing.T) {
+	node := func(name, zone string, taints ...corev1.Taint) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{
+				corev1.LabelHostname:     name,
+				corev1.LabelTopologyZone: zone,
+				gsh.PoolLabel:            "p1",
+			}},
+			Spec: corev1.NodeSpec{Taints: taints},
+			Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("4"),
+				corev1.ResourceMemory: resource.MustParse("16Gi"),
+				corev1.ResourcePods:   resource.MustParse("110"),
+			}},
+		}
+	}
+	pod := func(name, nodeName, cpu string, mutators ...func(*corev1.Pod)) *corev1.Pod {
+		p := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "web"},
+				CreationTimestamp: metav1.NewTime(time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC))},
+			Spec: corev1.PodSpec{
+				NodeName:      nodeName,
+				SchedulerName: BinPackingSchedulerName,
+				Containers: []corev1.Container{{Name: "app", Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+				}}},
+			},
+		}
+		for _, mutate := range mutators {
+			mutate(p)
+		}
+		return p
+	}
+	webSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}
+	noScheduleTaint := corev1.Taint{Key: "dedicated", Value: "batch", Effect: corev1.TaintEffectNoSchedule}
+	tests := map[string]struct {
+		objects []runtime.Object
+		// want maps the names of the pending pods to the names of the nodes they are bound to, or to the empty string
+		// if they are unschedulable.
+		want map[string]string
+	}{
+		"bin-packing-scheduler packs onto the most allocated node": {
+			objects: []runtime.Object{node("n1", "a"), node("n2", "a"), pod("bound", "n1", "2"), pod("new", "", "1")},
+			want:    map[string]string{"new": "n1"},
+		},
+		"default-scheduler spreads onto the least allocated node": {
+			objects: []runtime.Object{node("n1", "a"), node("n2", "a"), pod("bound", "n1", "2"), pod("new", "", "1", func(p *corev1.Pod) {
+				p.Spec.SchedulerName = corev1.DefaultSchedulerName
+			})},
+			want: map[string]string{"new": "n2"},
+		},
+		"insufficient resources": {
+			objects: []runtime.Object{node("n1", "a"), pod("bound", "n1", "2"), pod("new", "", "3")},
+			want:    map[string]string{"new": ""},
+		},
+		"higher priority pods are scheduled first": {
+			objects: []runtime.Object{node("n1", "a"), pod("low", "", "3"), pod("high", "", "3", func(p *corev1.Pod) {
+				p.Spec.Priority = ptr.To[int32](1000)
+				p.CreationTimestamp = metav1.NewTime(p.CreationTimestamp.Add(time.Minute))
+			})},
+			want: map[string]string{"low": "", "high": "n1"},
+		},
+		"node selector": {
+			objects: []runtime.Object{node("n1", "a"), node("n2", "a"), pod("bound", "n1", "2"), pod("new", "", "1", func(p *corev1.Pod) {
+				p.Spec.NodeSelector = map[string]string{corev1.LabelHostname: "n2"}
+			})},
+			want: map[string]string{"new": "n2"},
+		},
+		"required node affinity": {
+			objects: []runtime.Object{node("n1", "a"), node("n2", "b"), pod("bound", "n1", "2"), pod("new", "", "1", func(p *corev1.Pod) {
+				p.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpNotIn, Values: []string{"a"}}},
+					}}},
+				}}
+			})},
+			want: map[string]string{"new": "n2"},
+		},
+		"untolerated taint": {
+			objects: []runtime.Object{node("n1", "a", noScheduleTaint), node("n2", "a"), pod("bound", "n1", "2", func(p *corev1.Pod) {
+				p.Spec.Tolerations = []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}}
+			}), pod("new", "", "1")},
+			want: map[string]string{"new": "n2"},
+		},
+		"tolerated taint": {
+			objects: []runtime.Object{node("n1", "a", noScheduleTaint), node("n2", "a"), pod("bound", "n1", "2", func(p *corev1.Pod) {
+				p.Spec.Tolerations = []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}}
+			}), pod("new", "", "1", func(p *corev1.Pod) {
+				p.Spec.Tolerations = []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "batch", Effect: corev1.TaintEffectNoSchedule}}
+			})},
+			want: map[string]string{"new": "n1"},
+		},
+		"pod anti-affinity": {
+			objects: []runtime.Object{node("n1", "a"), node("n2", "a"), pod("bound", "n1", "2"), pod("new", "", "1", func(p *corev1.Pod) {
+				p.Spec.Affinity = &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{LabelSelector: webSelector, TopologyKey: corev1.LabelHostname}},
+				}}
+			})},
+			want: map[string]string{"new": "n2"},
+		},
+		"pod affinity": {
+			objects: []runtime.Object{node("n1", "a"), node("n2", "b"), node("n3", "b"), pod("bound", "n2", "1"), pod("new", "", "1", func(p *corev1.Pod) {
+				p.Spec.Affinity = &corev1.Affinity{PodAffinity: &corev1.PodAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{LabelSelector: webSelector, TopologyKey: corev1.LabelTopologyZone}},
+				}}
+				p.Spec.NodeSelector = map[string]string{corev1.LabelHostname: "n3"}
+			})},
+			want: map[string]string{"new": "n3"},
+		},
+		"topology spread": {
+			objects: []runtime.Object{node("n1", "a"), node("n2", "b"), pod("bound", "n1", "2"), pod("new", "", "1", func(p *corev1.Pod) {
+				p.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{{
+					MaxSkew: 1, TopologyKey: corev1.LabelTopologyZone, WhenUnsatisfiable: corev1.DoNotSchedule, LabelSelector: webSelector,
+				}}
+			})},
+			want: map[string]string{"new": "n2"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			virtualCluster := NewInMemoryVirtualCluster(tc.objects...)
+			bound, unschedulable, err := newPodScheduler(virtualCluster, nil).schedulePendingPods(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, len(lo.PickBy(tc.want, func(_, nodeName string) bool { return nodeName != "" })), bound)
+			assert.Equal(t, len(tc.want)-bound, unschedulable)
+			pods, err := virtualCluster.ListPods(ctx)
+			assert.Nil(t, err)
+			for _, p := range pods {
+				wantNodeName, ok := tc.want[p.Name]
+				if !ok {
+					continue
+				}
+				assert.Equal(t, wantNodeName, p.Spec.NodeName, "node of pod %q", p.Name)
+				assert.Len(t, p.Status.Conditions, 1)
+				if wantNodeName == "" {
+					assert.Equal(t, corev1.ConditionFalse, p.Status.Conditions[0].Status)
+					assert.Equal(t, corev1.PodReasonUnschedulable, p.Status.Conditions[0].Reason)
+				} else {
+					assert.Equal(t, corev1.ConditionTrue, p.Status.Conditions[0].Status)
+				}
+			}
+		})
+	}
+}
+
+func TestSimulateScaleUp(t *testing.T) {
+	nodeGroup := func(pool string, maxSize int) gst.NodeGroupInfo {
+		return gst.NodeGroupInfo{Name: "shoot--test--sim.shoot--test--sim-" + pool + "-z1", PoolName: pool, Zone: "eu-west-1a", MinSize: 1, MaxSize: maxSize}
+	}
+	nodeTemplate := func(pool, cpu string) gst.NodeTemplate {
+		return gst.NodeTemplate{
+			Name:         "shoot--test--sim.shoot--test--sim-" + pool + "-z1",
+			InstanceType: "m5." + cpu,
+			Region:       "eu-west-1",
+			Zone:         "eu-west-1a",
+			Capacity:     corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourcePods: resource.MustParse("110")},
+			Labels:       map[string]string{gsh.PoolLabel: pool},
+		}
+	}
+	config := func(expander string, p1MaxSize, maxNodesTotal int) gst.AutoScalerConfig {
+		return gst.AutoScalerConfig{
+			NodeGroups: map[string]gst.NodeGroupInfo{
+				"shoot--test--sim.shoot--test--sim-p1-z1": nodeGroup("p1", p1MaxSize),
+				"shoot--test--sim.shoot--test--sim-p2-z1": nodeGroup("p2", 3),
+			},
+			NodeTemplates: map[string]gst.NodeTemplate{
+				"shoot--test--sim.shoot--test--sim-p1-z1": nodeTemplate("p1", "2"),
+				"shoot--test--sim.shoot--test--sim-p2-z1": nodeTemplate("p2", "8"),
+			},
+			CASettings: gst.CASettingsInfo{
+				Expander:      expander,
+				MaxNodesTotal: maxNodesTotal,
+				Priorities:    "10:\n- .*-p1-.*\n20:\n- .*-p2-.*\n",
+			},
+		}
+	}
+	existingNode := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "p1-existing", Labels: map[string]string{
+			gsh.PoolLabel:                   "p1",
+			"topology.ebs.csi.aws.com/zone": "eu-west-1a",
+			corev1.LabelHostname:            "p1-existing",
+		}},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourcePods: resource.MustParse("110")}},
+	}
+	pods := func(count int, cpu string, mutators ...func(*corev1.Pod)) (pods []corev1.Pod) {
+		for i := range count {
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("web-%d", i), Namespace: "default"},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+				}}}},
+			}
+			for _, mutate := range mutators {
+				mutate(&pod)
+			}
+			pods = append(pods, pod)
+		}
+		return
+	}
+	boundPod := pods(1, "1", func(pod *corev1.Pod) {
+		pod.Name = "bound"
+		pod.Spec.NodeName = existingNode.Name
+	})[0]
+	tests := map[string]struct {
+		config            gst.AutoScalerConfig
+		pods              []corev1.Pod
+		wantScaledUp      map[string]int
+		wantNominatedPods int
+		wantScheduledPods int
+	}{
+		"least-waste prefers the node group with the least unrequested resources": {
+			config:            config(LeastWasteExpander, 5, 0),
+			pods:              pods(3, "1500m"),
+			wantScaledUp:      map[string]int{"shoot--test--sim.shoot--test--sim-p1-z1": 3},
+			wantNominatedPods: 3,
+		},
+		"most-pods prefers the node group that schedules the most pods": {
+			config:            config(MostPodsExpander, 2, 0),
+			pods:              pods(3, "1500m"),
+			wantScaledUp:      map[string]int{"shoot--test--sim.shoot--test--sim-p2-z1": 1},
+			wantNominatedPods: 3,
+		},
+		"priority prefers the node group with the highest priority": {
+			config:            config(PriorityExpander, 5, 0),
+			pods:              pods(3, "1500m"),
+			wantScaledUp:      map[string]int{"shoot--test--sim.shoot--test--sim-p2-z1": 1},
+			wantNominatedPods: 3,
+		},
+		"max nodes total limits the scale-up": {
+			config:            config(LeastWasteExpander, 5, 2),
+			pods:              pods(3, "1500m"),
+			wantScaledUp:      map[string]int{"shoot--test--sim.shoot--test--sim-p1-z1": 1},
+			wantNominatedPods: 1,
+		},
+		"max size of the node group limits the scale-up": {
+			config: config(LeastWasteExpander, 5, 0),
+			pods: pods(5, "1500m", func(pod *corev1.Pod) {
+				pod.Spec.NodeSelector = map[string]string{gsh.PoolLabel: "p2"}
+				pod.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU] = resource.MustParse("6")
+			}),
+			wantScaledUp:      map[string]int{"shoot--test--sim.shoot--test--sim-p2-z1": 3},
+			wantNominatedPods: 3,
+		},
+		"pods that fit on existing nodes do not trigger a scale-up": {
+			config:            config(LeastWasteExpander, 5, 0),
+			pods:              pods(1, "500m"),
+			wantScaledUp:      map[string]int{},
+			wantScheduledPods: 1,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			scenario, err := NewScaleUpSimulator(0).SimulateScaleUp(tc.config, []corev1.Node{existingNode}, append([]corev1.Pod{boundPod}, tc.pods...))
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantScaledUp, scenario.ScaledUpNodeGroups)
+			assert.Len(t, scenario.UnscheduledPods, len(tc.pods))
+			assert.Len(t, scenario.NominatedPods, tc.wantNominatedPods)
+			assert.Len(t, scenario.ScheduledPods, tc.wantScheduledPods)
+			assert.Len(t, scenario.ExistingNodes, 1)
+			nodeNames := lo.Map(scenario.ScaledUpNodes, func(item corev1.Node, _ int) string {
+				return item.Name
+			})
+			assert.Len(t, lo.Uniq(nodeNames), len(nodeNames))
+			for _, pod := range scenario.NominatedPods {
+				assert.Contains(t, nodeNames, pod.Status.NominatedNodeName)
+			}
+		})
+	}
+}
+
+func TestReplayWithInProcessScaleUp(t *testing.T) {
+	dataDBPath := filepath.Join(t.TempDir(), "scale-up.db")
+	store := db.NewDataAccess(dataDBPath)
+	assert.Nil(t, store.Init())
+	storeTimeline(t, store, loadTimeline(t, "testdata/timelines/scale-up.yaml"))
+	assert.Nil(t, store.InsertRecorderStartTime(time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)))
+	assert.Nil(t, store.Close())
+
+	catalog, err := cost.NewPriceCatalog("EUR", []gsh.InstancePrice{
+		{Provider: gsh.AWSCloudProvider, Region: "eu-west-1", InstanceType: "m5.large", HourlyPrice: 0.1},
+	})
+	assert.Nil(t, err)
+	virtualCluster := NewInMemoryVirtualCluster()
+	reportDir := t.TempDir()
+	replayer, err := NewDefaultReplayerWithVirtualCluster(gsh.ReplayerParams{
+		DBPath:                      dataDBPath,
+		ReportDir:                   reportDir,
+		VirtualAutoScalerConfigPath: filepath.Join(t.TempDir(), "autoscaler-config.json"),
+		StabilizeInterval:           time.Millisecond,
+		ReplayInterval:              3 * time.Minute,
+		InProcessScheduler:          true,
+		InProcessScaleUp:            true,
+		PriceCatalog:                catalog,
+	}, virtualCluster)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = replayer.Close()
+	})
+	ctx := context.Background()
+	assert.Nil(t, replayer.Start(ctx))
+	d := replayer.(*defaultReplayer)
+	for range 4 {
+		assert.Nil(t, d.doReplay(ctx))
+	}
+
+	nodes, err := virtualCluster.ListNodes(ctx)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"shoot--test--golden-p1-z1-8f2a1-x1", "shoot--test--golden-p1-z1-sim-0"}, lo.Map(nodes, func(item corev1.Node, _ int) string {
+		return item.Name
+	}))
+	pods, err := virtualCluster.ListPods(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, pods, 2)
+	for _, pod := range pods {
+		assert.NotEmpty(t, pod.Spec.NodeName, "pod %q is not bound", pod.Name)
+	}
+
+	var report gsh.ReplayReport
+	data, err := os.ReadFile(filepath.Join(reportDir, ScaleUpReportFileName))
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &report))
+	assert.Len(t, report.Scenarios, 1)
+	assert.Equal(t, map[string]int{"shoot--test--golden.shoot--test--golden-p1-z1": 1}, report.Scenarios[0].ScaledUpNodeGroups)
+	assert.Equal(t, "web-7d4b9c-fghij", report.Scenarios[0].NominatedPods[0].Name)
+
+	// the recorded node exists in all 4 replays and the scaled-up node from the replay at 10:06 on. The scaled-up node
+	// has no provider ID and is priced with the provider of the recorded machine class.
+	var costReport gsh.CostReport
+	data, err = os.ReadFile(filepath.Join(reportDir, cost.ReportFileName))
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &costReport))
+	assert.Equal(t, "EUR", costReport.Currency)
+	assert.Empty(t, costReport.UnpricedInstanceTypes)
+	assert.InDelta(t, 0.3, costReport.NodeHours, 1e-9)
+	assert.InDelta(t, 0.03, costReport.Cost, 1e-9)
+	assert.Len(t, costReport.Entries, 1)
+	assert.Equal(t, gsh.AWSCloudProvider, costReport.Entries[0].Provider)
+	_, err = os.Stat(filepath.Join(reportDir, cost.EntriesFileName))
+	assert.Nil(t, err)
+}
+
+func TestReplayWithOverrides(t *testing.T) {
+	dataDBPath := filepath.Join(t.TempDir(), "scale-up.db")
+	store := db.NewDataAccess(dataDBPath)
+	assert.Nil(t, store.Init())
+	storeTimeline(t, store, loadTimeline(t, "testdata/timelines/scale-up.yaml"))
+	assert.Nil(t, store.InsertRecorderStartTime(time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)))
+	assert.Nil(t, store.Close())
+
+	virtualCluster := NewInMemoryVirtualCluster()
+	reportDir := t.TempDir()
+	replayer, err := NewDefaultReplayerWithVirtualCluster(gsh.ReplayerParams{
+		DBPath:                      dataDBPath,
+		ReportDir:                   reportDir,
+		VirtualAutoScalerConfigPath: filepath.Join(t.TempDir(), "autoscaler-config.json"),
+		StabilizeInterval:           time.Millisecond,
+		ReplayInterval:              3 * time.Minute,
+		InProcessScheduler:          true,
+		InProcessScaleUp:            true,
+		OverridesPath:               "testdata/overrides/what-if.yaml",
+	}, virtualCluster)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = replayer.Close()
+	})
+	ctx := context.Background()
+	assert.Nil(t, replayer.Start(ctx))
+
+	snapshot, err := replayer.GetRecordedClusterSnapshot(time.Date(2024, 6, 1, 10, 5, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	nodeGroupName := "shoot--test--golden.shoot--test--golden-p1-z1"
+	assert.Equal(t, 20, snapshot.AutoscalerConfig.NodeGroups[nodeGroupName].MaxSize)
+	nodeTemplate := snapshot.AutoscalerConfig.NodeTemplates[nodeGroupName]
+	assert.Equal(t, "m5.xlarge", nodeTemplate.InstanceType)
+	assert.Equal(t, "4", nodeTemplate.Capacity.Cpu().String())
+	assert.Equal(t, "110", nodeTemplate.Capacity.Pods().String())
+	assert.Equal(t, nodeTemplate.GetHash(), nodeTemplate.Hash)
+	assert.Equal(t, "priority", snapshot.AutoscalerConfig.CASettings.Expander)
+	assert.Equal(t, "priority", snapshot.CAArgs.Args["expander"])
+	assert.Equal(t, "50", snapshot.CAArgs.Args["max-nodes-total"])
+	assert.Equal(t, "0.5", snapshot.CAArgs.Args["scale-down-utilization-threshold"])
+	assert.Equal(t, "10:\n- \".*-p1-.*\"\n", snapshot.AutoscalerConfig.CASettings.Priorities)
+	priorities, err := parsePriorities(snapshot.AutoscalerConfig.CASettings.Priorities)
+	assert.Nil(t, err)
+	priority, ok := nodeGroupPriority(priorities, nodeGroupName)
+	assert.True(t, ok)
+	assert.Equal(t, 10, priority)
+	assert.Equal(t, snapshot.AutoscalerConfig.GetHash(), snapshot.AutoscalerConfig.Hash)
+
+	d := replayer.(*defaultReplayer)
+	for range 4 {
+		assert.Nil(t, d.doReplay(ctx))
+	}
+	nodes, err := virtualCluster.ListNodes(ctx)
+	assert.Nil(t, err)
+	simNode, found := lo.Find(nodes, func(item corev1.Node) bool {
+		return item.Name == "shoot--test--golden-p1-z1-sim-0"
+	})
+	assert.True(t, found)
+	assert.Equal(t, "m5.xlarge", simNode.Labels[corev1.LabelInstanceTypeStable])
+	assert.Equal(t, "4", simNode.Status.Allocatable.Cpu().String())
+
+	var report gsh.ReplayReport
+	data, err := os.ReadFile(filepath.Join(reportDir, ScaleUpReportFileName))
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &report))
+	assert.NotNil(t, report.Overrides)
+	assert.Equal(t, "p1 on m5.xlarge with max 20 and the priority expander", report.Overrides.Description)
+	assert.Equal(t, 20, *report.Overrides.NodeGroups["p1"].MaxSize)
+
+	var costReport gsh.CostReport
+	data, err = os.ReadFile(filepath.Join(reportDir, cost.ReportFileName))
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &costReport))
+	assert.Equal(t, report.Overrides, costReport.Overrides)
+}
+
+func TestApplyOverridesCAArgs(t *testing.T) {
+	recordedArgs := map[string]string{"scale-down-utilization-threshold": "0.5", "expander": "least-waste"}
+	cs := gsh.ClusterSnapshot{CAArgs: gsh.CAArgsInfo{Args: recordedArgs}}
+	cs.CAArgs.Hash = cs.CAArgs.GetHash()
+	recordedHash := cs.CAArgs.Hash
+
+	ApplyOverrides(&gsh.AutoScalerConfigOverrides{
+		CASettings: gsh.CASettingsOverride{Expander: PriorityExpander},
+		CAArgs:     map[string]string{"scale-down-utilization-threshold": "0.7", "expander": MostPodsExpander},
+	}, &cs)
+	// the CA args override the CA settings and leave the recorded args untouched
+	assert.Equal(t, map[string]string{"scale-down-utilization-threshold": "0.7", "expander": MostPodsExpander}, cs.CAArgs.Args)
+	assert.Equal(t, "0.5", recordedArgs["scale-down-utilization-threshold"])
+	assert.Equal(t, cs.CAArgs.GetHash(), cs.CAArgs.Hash)
+	assert.NotEqual(t, recordedHash, cs.CAArgs.Hash)
+}
+
+func TestLoadOverridesRejectsInvalidOverrides(t *testing.T) {
+	tests := map[string]struct {
+		overrides string
+		errMsg    string
+	}{
+		"unknown field":      {overrides: "nodeGroups:\n  p1:\n    maxSzie: 3\n", errMsg: "unknown field"},
+		"min above max":      {overrides: "nodeGroups:\n  p1:\n    minSize: 4\n    maxSize: 3\n", errMsg: "greater than its max size"},
+		"unknown expander":   {overrides: "caSettings:\n  expander: cheapest\n", errMsg: "unknown expander"},
+		"invalid expression": {overrides: "priorities:\n  10:\n  - \"p1-(\"\n", errMsg: "cannot compile"},
+		"dashed CA arg":      {overrides: "caArgs:\n  --scale-down-enabled: \"false\"\n", errMsg: "without leading dashes"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			overridesPath := filepath.Join(t.TempDir(), "overrides.yaml")
+			assert.Nil(t, os.WriteFile(overridesPath, []byte(tc.overrides), 0644))
+			_, err := LoadOverrides(overridesPath)
+			assert.ErrorContains(t, err, tc.errMsg)
+		})
+	}
+}
+
+func TestRunBatchReplay(t *testing.T) {
+	dataDBPath := filepath.Join(t.TempDir(), "scale-up.db")
+	store := db.NewDataAccess(dataDBPath)
+	assert.Nil(t, store.Init())
+	storeTimeline(t, store, loadTimeline(t, "testdata/timelines/scale-up.yaml"))
+	assert.Nil(t, store.InsertRecorderStartTime(time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)))
+	assert.Nil(t, store.Close())
+
+	specDir := t.TempDir()
+	whatIf, err := os.ReadFile("testdata/overrides/what-if.yaml")
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(filepath.Join(specDir, "what-if.yaml"), whatIf, 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(specDir, "max-1.yaml"), []byte("nodeGroups:\n  p1:\n    maxSize: 1\n"), 0644))
+	specPath := filepath.Join(specDir, "batch.yaml")
+	assert.Nil(t, os.WriteFile(specPath, []byte(`variants:
+- name: baseline
+- name: what-if
+  overridesPath: what-if.yaml
+- name: max-1
+  overridesPath: max-1.yaml
+- name: broken
+  overridesPath: missing.yaml
+machinePrices:
+  m5.large: 0.1
+  m5.xlarge: 0.2
+`), 0644))
+	spec, err := LoadBatchReplaySpec(specPath)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(specDir, "what-if.yaml"), spec.Variants[1].OverridesPath)
+
+	reportDir := t.TempDir()
+	outcomes, err := RunBatchReplay(context.Background(), gsh.BatchReplayParams{
+		DBPath:             dataDBPath,
+		ReportDir:          reportDir,
+		ReplayInterval:     3 * time.Minute,
+		TotalReplayTime:    12 * time.Minute,
+		StabilizeInterval:  time.Minute,
+		NodeProvisionDelay: 2 * time.Minute,
+		Parallelism:        2,
+		BatchReplaySpec:    spec,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"baseline", "what-if", "max-1", "broken"}, lo.Map(outcomes, func(item gsh.VariantOutcome, _ int) string {
+		return item.Variant
+	}))
+	for i, o := range outcomes {
+		assert.Equal(t, i+1, o.Rank)
+	}
+	baseline, whatIfOutcome, max1, broken := outcomes[0], outcomes[1], outcomes[2], outcomes[3]
+
+	// the replays run at 10:01, 10:04, 10:07 and 10:10. The pending pod arrives before the replay at 10:04, which
+	// scales up a new node that is ready at 10:06, so the pod waits until the replay at 10:07.
+	assert.Empty(t, baseline.Error)
+	assert.InDelta(t, 3, baseline.UnscheduledPodMinutes, 1e-9)
+	assert.InDelta(t, 0.35, baseline.NodeHoursByMachineType["m5.large"], 1e-9)
+	assert.InDelta(t, 0.035, baseline.EstimatedCost, 1e-9)
+	assert.Equal(t, 1, baseline.ScaledUpNodeCount)
+
+	// the recorded node stays an m5.large, the new node is an m5.xlarge
+	assert.InDelta(t, 3, whatIfOutcome.UnscheduledPodMinutes, 1e-9)
+	assert.InDelta(t, 0.2, whatIfOutcome.NodeHoursByMachineType["m5.large"], 1e-9)
+	assert.InDelta(t, 0.15, whatIfOutcome.NodeHoursByMachineType["m5.xlarge"], 1e-9)
+	assert.InDelta(t, 0.05, whatIfOutcome.EstimatedCost, 1e-9)
+	assert.Equal(t, 1, whatIfOutcome.ScaledUpNodeCount)
+
+	// without a scale-up, the pod stays pending in the replays at 10:04, 10:07 and 10:10
+	assert.InDelta(t, 9, max1.UnscheduledPodMinutes, 1e-9)
+	assert.InDelta(t, 0.2, max1.NodeHoursByMachineType["m5.large"], 1e-9)
+	assert.Zero(t, max1.ScaledUpNodeCount)
+
+	assert.Contains(t, broken.Error, "missing.yaml")
+
+	ranking, err := os.ReadFile(filepath.Join(reportDir, BatchRankingFileName))
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(ranking)), "\n")
+	assert.Len(t, lines, 5)
+	assert.Equal(t, "Rank,Variant,UnscheduledPodMinutes,NodeHours,NodeHoursByMachineType,EstimatedCost,ScaledUpNodeCount,Error", lines[0])
+	assert.Equal(t, "2,what-if,3.00,0.35,m5.large=0.20;m5.xlarge=0.15,0.05,1,", lines[2])
+	var reportedOutcomes []gsh.VariantOutcome
+	data, err := os.ReadFile(filepath.Join(reportDir, BatchReportFileName))
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &reportedOutcomes))
+	assert.Equal(t, outcomes, reportedOutcomes)
+	_, err = os.Stat(filepath.Join(reportDir, "what-if", ScaleUpReportFileName))
+	assert.Nil(t, err)
+
+	var variantCost gsh.CostReport
+	data, err = os.ReadFile(filepath.Join(reportDir, "what-if", cost.ReportFileName))
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &variantCost))
+	assert.Equal(t, []gsh.CostEntry{
+		{WindowStart: time.Date(2024, 6, 1, 10, 1, 0, 0, time.UTC), Pool: "p1", Zone: "eu-west-1a", Provider: gsh.AWSCloudProvider, Region: "eu-west-1", InstanceType: "m5.large", NodeHours: 0.2, Cost: 0.02},
+		{WindowStart: time.Date(2024, 6, 1, 10, 1, 0, 0, time.UTC), Pool: "p1", Zone: "eu-west-1a", Provider: gsh.AWSCloudProvider, Region: "eu-west-1", InstanceType: "m5.xlarge", NodeHours: 0.15, Cost: 0.03},
+	}, roundCostEntries(variantCost.Entries))
+
+	// the recorded cluster scaled up with another m5.large at 10:06, and its cost covers the replay time of the variants
+	var recordedCost gsh.CostReport
+	data, err = os.ReadFile(filepath.Join(reportDir, RecordedCostReportFileName))
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &recordedCost))
+	assert.Equal(t, time.Date(2024, 6, 1, 10, 1, 0, 0, time.UTC), recordedCost.StartTime)
+	assert.Equal(t, time.Date(2024, 6, 1, 10, 13, 0, 0, time.UTC), recordedCost.EndTime)
+	assert.InDelta(t, 0.2+7.0/60, recordedCost.NodeHours, 1e-9)
+	_, err = os.Stat(filepath.Join(reportDir, RecordedCostEntriesFileName))
+	assert.Nil(t, err)
+}
+
+func roundCostEntries(entries []gsh.CostEntry) []gsh.CostEntry {
+	for i := range entries {
+		entries[i].NodeHours = math.Round(entries[i].NodeHours*1e6) / 1e6
+		entries[i].Cost = math.Round(entries[i].Cost*1e6) / 1e6
+	}
+	return entries
+}
+
+func TestAnalyzeUtilization(t *testing.T) {
+	dataDBPath := filepath.Join(t.TempDir(), "scale-down.db")
+	store := db.NewDataAccess(dataDBPath)
+	assert.Nil(t, store.Init())
+	storeTimeline(t, store, loadTimeline(t, "testdata/timelines/scale-down.yaml"))
+	assert.Nil(t, store.Close())
+
+	x1, x2 := "shoot--test--golden-p1-z1-8f2a1-x1", "shoot--test--golden-p1-z1-8f2a1-x2"
+	startTime := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	reportDir := t.TempDir()
+	params := gsh.UtilizationParams{
+		DBPath:                dataDBPath,
+		ReportDir:             reportDir,
+		StartTime:             startTime,
+		EndTime:               startTime.Add(8 * time.Minute),
+		Step:                  time.Minute,
+		UnderutilizedDuration: 6 * time.Minute,
+	}
+	report, err := AnalyzeUtilization(params)
+	assert.Nil(t, err)
+
+	samplesAt := func(sampleTime time.Time) []gsh.UtilizationSample {
+		return lo.Filter(report.Samples, func(item gsh.UtilizationSample, _ int) bool {
+			return item.SampleTime.Equal(sampleTime)
+		})
+	}
+	// both nodes run a pod requesting 500m and 1Gi
+	samples := samplesAt(startTime.Add(2 * time.Minute))
+	assert.Equal(t, []string{"cluster/", "pool/p1", "node/" + x1, "node/" + x2}, lo.Map(samples, func(item gsh.UtilizationSample, _ int) string {
+		return string(item.Scope) + "/" + item.Name
+	}))
+	assert.Equal(t, 2, samples[0].NumNodes)
+	assert.Equal(t, 2, samples[0].NumPods)
+	assert.Equal(t, gsh.ResourceUsage{Requested: 1, Allocatable: 3.84}, samples[1].CPU)
+	assert.Equal(t, gsh.ResourceUsage{Requested: 2 * 1024 * 1024 * 1024, Allocatable: 14 * 1024 * 1024 * 1024}, samples[1].Memory)
+	assert.Zero(t, samples[1].GPU.Allocatable)
+	assert.Equal(t, "p1", samples[2].Pool)
+	assert.InDelta(t, 0.5/1.92, samples[2].CPU.Utilization(), 1e-9)
+	// the pod on x2 is deleted at 10:03 and x2 at 10:06
+	samples = samplesAt(startTime.Add(4 * time.Minute))
+	assert.Zero(t, samples[3].NumPods)
+	assert.Zero(t, samples[3].CPU.Requested)
+	samples = samplesAt(startTime.Add(6 * time.Minute))
+	assert.Len(t, samples, 3)
+	assert.Equal(t, 1, samples[0].NumNodes)
+
+	// with the recorded threshold of 0.5, x1 is underutilized all the time and x2 only for 5 minutes
+	assert.Equal(t, []gsh.UnderutilizedNode{{
+		Name:     x1,
+		Pool:     "p1",
+		From:     startTime,
+		To:       startTime.Add(8 * time.Minute),
+		Duration: 8 * time.Minute,
+	}}, lo.Map(report.UnderutilizedNodes, func(item gsh.UnderutilizedNode, _ int) gsh.UnderutilizedNode {
+		assert.InDelta(t, 0.5/1.92, item.MaxUtilization, 1e-9)
+		item.MaxUtilization = 0
+		return item
+	}))
+
+	// below a threshold of 0.2, only x2 is underutilized after its pod is deleted
+	params.UnderutilizedThreshold = 0.2
+	params.UnderutilizedDuration = time.Minute
+	report, err = AnalyzeUtilization(params)
+	assert.Nil(t, err)
+	assert.Equal(t, []gsh.UnderutilizedNode{{
+		Name:     x2,
+		Pool:     "p1",
+		From:     startTime.Add(4 * time.Minute),
+		To:       startTime.Add(5 * time.Minute),
+		Duration: time.Minute,
+	}}, report.UnderutilizedNodes)
+
+	samplesCSV, err := os.ReadFile(filepath.Join(reportDir, UtilizationSamplesFileName))
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(samplesCSV)), "\n")
+	assert.Len(t, lines, 1+len(report.Samples))
+	assert.Equal(t, "2024-06-01T10:01:00Z,pool,p1,p1,2,2,1,3.84,0.26,2147483648,15032385536,0.14,0,0,0.00,0,0,0.00", lines[6])
+	nodesCSV, err := os.ReadFile(filepath.Join(reportDir, UnderutilizedNodesFileName))
+	assert.Nil(t, err)
+	assert.Equal(t, "Name,Pool,From,To,Duration,MaxUtilization\n"+x2+",p1,2024-06-01T10:04:00Z,2024-06-01T10:05:00Z,1m0s,0.00\n", string(nodesCSV))
+	var reportJSON gsh.UtilizationReport
+	data, err := os.ReadFile(filepath.Join(reportDir, UtilizationReportFileName))
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &reportJSON))
+	assert.Equal(t, report.UnderutilizedNodes, reportJSON.UnderutilizedNodes)
+	assert.Len(t, reportJSON.Samples, len(report.Samples))
+
+	params.Step = 0
+	_, err = AnalyzeUtilization(params)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,365 @@
+package replayer
+
+import (
+	"fmt"
+	gsh "github.com/elankath/gardener-scaling-history"
+	gst "github.com/elankath/gardener-scaling-types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"log/slog"
+	"math/rand"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// The expanders of the cluster-autoscaler supported by the ScaleUpSimulator.
+const (
+	LeastWasteExpander = "least-waste"
+	MostPodsExpander   = "most-pods"
+	PriorityExpander   = "priority"
+	RandomExpander     = "random"
+)
+
+// defaultScaleUpSimulator simulates the scale-up loop of the cluster-autoscaler: unscheduled pods that fit on the
+// existing nodes are left to the scheduler. For the remaining pods, it estimates for every node group how many nodes of
+// its node template would be needed to schedule them, lets the configured expanders choose among these scale-up
+// options and repeats until all pods are nominated or no node group can be scaled up anymore.
+type defaultScaleUpSimulator struct {
+	rnd *rand.Rand
+}
+
+var _ gsh.ScaleUpSimulator = (*defaultScaleUpSimulator)(nil)
+
+// scaleUpOption is a possible scale-up of a node group by the nodes needed to schedule the given pods.
+type scaleUpOption struct {
+	nodeGroup gst.NodeGroupInfo
+	template  *corev1.Node
+	nodes     []*schedulingNode
+	pods      []*corev1.Pod
+}
+
+// NewScaleUpSimulator returns a ScaleUpSimulator whose random expander and random choice among equally good scale-up
+// options use the given seed, so that simulations are reproducible.
+func NewScaleUpSimulator(seed int64) gsh.ScaleUpSimulator {
+	return &defaultScaleUpSimulator{rnd: rand.New(rand.NewSource(seed))}
+}
+
+func (s *defaultScaleUpSimulator) SimulateScaleUp(config gst.AutoScalerConfig, nodes []corev1.Node, pods []corev1.Pod) (scenario gsh.Scenario, err error) {
+	scenario.ExistingNodes = nodes
+	scenario.ScaledUpNodeGroups = make(map[string]int)
+	schedulingNodes := make([]*schedulingNode, 0, len(nodes))
+	nodesByName := make(map[string]*schedulingNode, len(nodes))
+	nodeGroupSizes := make(map[string]int)
+	for i := range nodes {
+		n := &schedulingNode{node: nodes[i].DeepCopy(), requested: make(corev1.ResourceList)}
+		schedulingNodes = append(schedulingNodes, n)
+		nodesByName[n.node.Name] = n
+		if nodeGroup, ok := GetNodeGroupOfNode(config.NodeGroups, n.node); ok {
+			nodeGroupSizes[nodeGroup.Name]++
+		}
+	}
+	var pending []*corev1.Pod
+	for i := range pods {
+		pod := pods[i].DeepCopy()
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if pod.Spec.NodeName == "" {
+			scenario.UnscheduledPods = append(scenario.UnscheduledPods, *pod)
+			pending = append(pending, pod)
+			continue
+		}
+		if n, ok := nodesByName[pod.Spec.NodeName]; ok {
+			n.addPod(pod)
+		}
+	}
+	sortPodsByPriority(pending)
+
+	// like the cluster-autoscaler, ignore the pods that can be scheduled on the existing nodes
+	var remaining []*corev1.Pod
+	for _, pod := range pending {
+		n, _ := selectNode(pod, schedulingNodes)
+		if n == nil {
+			remaining = append(remaining, pod)
+			continue
+		}
+		pod.Spec.NodeName = n.node.Name
+		n.addPod(pod)
+		scenario.ScheduledPods = append(scenario.ScheduledPods, *pod)
+	}
+
+	expanders := strings.Split(config.CASettings.Expander, ",")
+	totalNodes := len(nodes)
+	for len(remaining) > 0 {
+		var options []scaleUpOption
+		for _, name := range sortedNodeGroupNames(config.NodeGroups) {
+			nodeGroup := config.NodeGroups[name]
+			maxSize := nodeGroup.MaxSize
+			if minMax, ok := config.CASettings.NodeGroupsMinMax[name]; ok {
+				maxSize = minMax.Max
+			}
+			limit := maxSize - nodeGroupSizes[name]
+			if config.CASettings.MaxNodesTotal > 0 {
+				limit = min(limit, config.CASettings.MaxNodesTotal-totalNodes)
+			}
+			if limit <= 0 {
+				continue
+			}
+			template, ok := config.NodeTemplates[name]
+			if !ok {
+				err = fmt.Errorf("cannot find the node template for nodegroup: %s", name)
+				return
+			}
+			option := estimateScaleUp(nodeGroup, newTemplateNode(template), remaining, schedulingNodes, limit)
+			if len(option.pods) > 0 {
+				options = append(options, option)
+			}
+		}
+		if len(options) == 0 {
+			break
+		}
+		var best scaleUpOption
+		best, err = s.chooseOption(expanders, config.CASettings.Priorities, options)
+		if err != nil {
+			return
+		}
+		slog.Debug("simulated scale-up", "nodeGroup", best.nodeGroup.Name, "nodeCount", len(best.nodes), "numPods", len(best.pods))
+		for _, n := range best.nodes {
+			n.node.Name = newNodeName(best.nodeGroup, nodesByName)
+			n.node.Labels[corev1.LabelHostname] = n.node.Name
+			for _, pod := range n.pods {
+				pod.Status.NominatedNodeName = n.node.Name
+				scenario.NominatedPods = append(scenario.NominatedPods, *pod)
+			}
+			schedulingNodes = append(schedulingNodes, n)
+			nodesByName[n.node.Name] = n
+			scenario.ScaledUpNodes = append(scenario.ScaledUpNodes, *n.node)
+		}
+		scenario.ScaledUpNodeGroups[best.nodeGroup.Name] += len(best.nodes)
+		nodeGroupSizes[best.nodeGroup.Name] += len(best.nodes)
+		totalNodes += len(best.nodes)
+		remaining = slices.DeleteFunc(remaining, func(pod *corev1.Pod) bool {
+			return pod.Status.NominatedNodeName != ""
+		})
+	}
+	return
+}
+
+// estimateScaleUp estimates the nodes of the node group needed to schedule the given pods like the binpacking
+// estimator of the cluster-autoscaler: every pod is placed on the first new node it fits on, or on another new node up
+// to the given limit.
+func estimateScaleUp(nodeGroup gst.NodeGroupInfo, template *corev1.Node, pods []*corev1.Pod, nodes []*schedulingNode, limit int) (option scaleUpOption) {
+	option.nodeGroup = nodeGroup
+	option.template = template
+	allNodes := slices.Clone(nodes)
+	for _, pod := range pods {
+		placed := false
+		for _, n := range option.nodes {
+			if filterNode(pod, n, allNodes) == "" {
+				n.addPod(pod)
+				placed = true
+				break
+			}
+		}
+		if !placed && len(option.nodes) < limit {
+			n := &schedulingNode{node: template.DeepCopy(), requested: make(corev1.ResourceList)}
+			n.node.Name = fmt.Sprintf("%s-estimate-%d", template.Name, len(option.nodes))
+			n.node.Labels[corev1.LabelHostname] = n.node.Name
+			if filterNode(pod, n, append(allNodes, n)) == "" {
+				n.addPod(pod)
+				option.nodes = append(option.nodes, n)
+				allNodes = append(allNodes, n)
+				placed = true
+			}
+		}
+		if placed {
+			option.pods = append(option.pods, pod)
+		}
+	}
+	return
+}
+
+// chooseOption filters the given options with the given chain of expanders and chooses a random one among the
+// remaining options.
+func (s *defaultScaleUpSimulator) chooseOption(expanders []string, priorities string, options []scaleUpOption) (scaleUpOption, error) {
+	for _, expander := range expanders {
+		if len(options) == 1 {
+			break
+		}
+		switch strings.TrimSpace(expander) {
+		case LeastWasteExpander:
+			options = bestOptions(options, func(o scaleUpOption) float64 {
+				return -wastedResources(o)
+			})
+		case MostPodsExpander:
+			options = bestOptions(options, func(o scaleUpOption) float64 {
+				return float64(len(o.pods))
+			})
+		case PriorityExpander:
+			nodeGroupPriorities, err := parsePriorities(priorities)
+			if err != nil {
+				return scaleUpOption{}, err
+			}
+			filtered := bestOptions(slices.DeleteFunc(slices.Clone(options), func(o scaleUpOption) bool {
+				_, ok := nodeGroupPriority(nodeGroupPriorities, o.nodeGroup.Name)
+				return !ok
+			}), func(o scaleUpOption) float64 {
+				priority, _ := nodeGroupPriority(nodeGroupPriorities, o.nodeGroup.Name)
+				return float64(priority)
+			})
+			if len(filtered) == 0 {
+				slog.Warn("no node group matches the priorities of the priority expander", "priorities", priorities)
+				continue
+			}
+			options = filtered
+		case RandomExpander, "":
+		default:
+			slog.Warn("unsupported expander, choosing a random node group", "expander", expander)
+		}
+	}
+	return options[s.rnd.Intn(len(options))], nil
+}
+
+// bestOptions returns the options with the highest score.
+func bestOptions(options []scaleUpOption, score func(scaleUpOption) float64) (best []scaleUpOption) {
+	var bestScore float64
+	for _, o := range options {
+		optionScore := score(o)
+		if len(best) == 0 || optionScore > bestScore {
+			best = []scaleUpOption{o}
+			bestScore = optionScore
+		} else if optionScore == bestScore {
+			best = append(best, o)
+		}
+	}
+	return
+}
+
+// wastedResources is the sum of the fractions of cpu and memory of the new nodes of the option that are not requested
+// by its pods, like scored by the least-waste expander.
+func wastedResources(o scaleUpOption) (wasted float64) {
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		allocatable := o.template.Status.Allocatable[name]
+		if allocatable.IsZero() {
+			continue
+		}
+		var requested int64
+		for _, pod := range o.pods {
+			quantity := computePodRequests(pod)[name]
+			requested += quantity.MilliValue()
+		}
+		available := allocatable.MilliValue() * int64(len(o.nodes))
+		wasted += float64(available-requested) / float64(available)
+	}
+	return
+}
+
+// parsePriorities parses the priorities of the `cluster-autoscaler-priority-expander` config map, a map of priority to
+// regular expressions of node group names.
+func parsePriorities(priorities string) (map[int][]*regexp.Regexp, error) {
+	var raw map[int][]string
+	err := yaml.Unmarshal([]byte(priorities), &raw)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse the priorities of the priority expander: %w", err)
+	}
+	parsed := make(map[int][]*regexp.Regexp, len(raw))
+	for priority, exprs := range raw {
+		for _, expr := range exprs {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("cannot compile regular expression %q of priority %d: %w", expr, priority, err)
+			}
+			parsed[priority] = append(parsed[priority], re)
+		}
+	}
+	return parsed, nil
+}
+
+// nodeGroupPriority returns the highest priority whose regular expressions match the given node group name.
+func nodeGroupPriority(priorities map[int][]*regexp.Regexp, nodeGroupName string) (priority int, found bool) {
+	for p, exprs := range priorities {
+		if found && p <= priority {
+			continue
+		}
+		if slices.ContainsFunc(exprs, func(re *regexp.Regexp) bool {
+			return re.MatchString(nodeGroupName)
+		}) {
+			priority, found = p, true
+		}
+	}
+	return
+}
+
+// GetNodeGroupOfNode returns the node group of the given node, matched by the worker pool and zone labels of the node.
+func GetNodeGroupOfNode(nodeGroups map[string]gst.NodeGroupInfo, node *corev1.Node) (gst.NodeGroupInfo, bool) {
+	poolName, ok := node.Labels[gsh.PoolLabel]
+	if !ok {
+		poolName = node.Labels[gsh.PoolLabelAlt]
+	}
+	zone := node.Labels[corev1.LabelTopologyZone]
+	for _, zoneLabel := range gsh.ZoneLabels {
+		if zone != "" {
+			break
+		}
+		zone = node.Labels[zoneLabel]
+	}
+	for _, name := range sortedNodeGroupNames(nodeGroups) {
+		nodeGroup := nodeGroups[name]
+		if nodeGroup.PoolName == poolName && nodeGroup.Zone == zone {
+			return nodeGroup, true
+		}
+	}
+	return gst.NodeGroupInfo{}, false
+}
+
+func sortedNodeGroupNames(nodeGroups map[string]gst.NodeGroupInfo) []string {
+	names := make([]string, 0, len(nodeGroups))
+	for name := range nodeGroups {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// newTemplateNode returns a ready node of the given node template whose allocatable resources are its capacity.
+func newTemplateNode(template gst.NodeTemplate) *corev1.Node {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   template.Name,
+			Labels: make(map[string]string, len(template.Labels)+4),
+		},
+		Spec: corev1.NodeSpec{
+			Taints: slices.Clone(template.Taints),
+		},
+		Status: corev1.NodeStatus{
+			Capacity:    template.Capacity.DeepCopy(),
+			Allocatable: template.Capacity.DeepCopy(),
+			Conditions: []corev1.NodeCondition{{
+				Type:   corev1.NodeReady,
+				Status: corev1.ConditionTrue,
+				Reason: "KubeletReady",
+			}},
+			Phase: corev1.NodeRunning,
+		},
+	}
+	for key, val := range template.Labels {
+		node.Labels[key] = val
+	}
+	node.Labels[corev1.LabelInstanceTypeStable] = template.InstanceType
+	node.Labels[corev1.LabelTopologyRegion] = template.Region
+	node.Labels[corev1.LabelTopologyZone] = template.Zone
+	return node
+}
+
+// newNodeName returns a name for a new node of the given node group that is not yet used by any of the given nodes.
+func newNodeName(nodeGroup gst.NodeGroupInfo, nodesByName map[string]*schedulingNode) string {
+	_, mcdName, _ := strings.Cut(nodeGroup.Name, ".")
+	for i := 0; ; i++ {
+		name := fmt.Sprintf("%s-sim-%d", mcdName, i)
+		if _, ok := nodesByName[name]; !ok {
+			return name
+		}
+	}
+}
@@ -0,0 +1,648 @@
+package replayer
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	gsh "github.com/elankath/gardener-scaling-history"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// BinPackingSchedulerName is the name of the scheduler of gardener shoots with the bin-packing scheduling profile,
+// which scores nodes by their most allocated resources.
+const BinPackingSchedulerName = "bin-packing-scheduler"
+
+type scoringStrategy string
+
+const (
+	leastAllocatedStrategy scoringStrategy = "LeastAllocated"
+	mostAllocatedStrategy  scoringStrategy = "MostAllocated"
+)
+
+// schedulerScoringStrategies maps scheduler names to the scoring strategy of their NodeResourcesFit plugin. Pods of
+// other schedulers are scored like by the default-scheduler.
+var schedulerScoringStrategies = map[string]scoringStrategy{
+	corev1.DefaultSchedulerName: leastAllocatedStrategy,
+	BinPackingSchedulerName:     mostAllocatedStrategy,
+}
+
+// The weights of the score plugins of the default kube-scheduler profile.
+const (
+	resourcesFitWeight    = 1
+	nodeAffinityWeight    = 2
+	taintTolerationWeight = 3
+	topologySpreadWeight  = 2
+	maxNodeScore          = 100
+)
+
+// podScheduler is a simple in-process scheduler for virtual clusters without kube-scheduler. It binds the pending
+// pods of the virtual cluster one by one in the order of their priority and creation to the feasible node with the
+// highest score. Nodes are filtered by the resource requests, node selector, required node affinity, taints, required
+// inter-pod (anti-)affinity and topology spread constraints that cannot be violated. They are scored by their
+// allocation following the scoring strategy of the SchedulerName of the pod, preferred node affinity,
+// PreferNoSchedule taints and topology spread constraints that may be violated. Pods that do not fit on any node get
+// an Unschedulable PodScheduled condition like kube-scheduler sets.
+type podScheduler struct {
+	virtualCluster gsh.VirtualCluster
+	// provisioningNodeNames are the names of the nodes that are not ready yet, to which no pods are bound.
+	provisioningNodeNames sets.Set[string]
+}
+
+// schedulingNode is a node of the virtual cluster with the pods bound to it.
+type schedulingNode struct {
+	node      *corev1.Node
+	pods      []*corev1.Pod
+	requested corev1.ResourceList
+}
+
+func newPodScheduler(virtualCluster gsh.VirtualCluster, provisioningNodeNames sets.Set[string]) *podScheduler {
+	return &podScheduler{virtualCluster: virtualCluster, provisioningNodeNames: provisioningNodeNames}
+}
+
+// schedulePendingPods binds the pending pods of the virtual cluster to its nodes and returns the number of bound and
+// unschedulable pods.
+func (s *podScheduler) schedulePendingPods(ctx context.Context) (bound, unschedulable int, err error) {
+	nodes, err := s.virtualCluster.ListNodes(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot list the virtual nodes: %w", err)
+	}
+	pods, err := s.virtualCluster.ListPods(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot list the virtual pods: %w", err)
+	}
+	schedulingNodes := make([]*schedulingNode, 0, len(nodes))
+	nodesByName := make(map[string]*schedulingNode, len(nodes))
+	for i := range nodes {
+		n := &schedulingNode{node: &nodes[i], requested: make(corev1.ResourceList)}
+		nodesByName[n.node.Name] = n
+		if s.provisioningNodeNames.Has(n.node.Name) {
+			continue
+		}
+		schedulingNodes = append(schedulingNodes, n)
+	}
+	slices.SortFunc(schedulingNodes, func(a, b *schedulingNode) int {
+		return cmp.Compare(a.node.Name, b.node.Name)
+	})
+	var pending []*corev1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if pod.Spec.NodeName == "" {
+			pending = append(pending, pod)
+			continue
+		}
+		if n, ok := nodesByName[pod.Spec.NodeName]; ok {
+			n.addPod(pod)
+		}
+	}
+	sortPodsByPriority(pending)
+	for _, pod := range pending {
+		n, reason := selectNode(pod, schedulingNodes)
+		if n == nil {
+			unschedulable++
+			slog.Debug("pod is unschedulable", "pod.Name", pod.Name, "pod.Namespace", pod.Namespace, "reason", reason)
+			err = s.markUnschedulable(ctx, pod, reason)
+			if err != nil {
+				return
+			}
+			continue
+		}
+		err = s.virtualCluster.BindPod(ctx, pod.Namespace, pod.Name, n.node.Name)
+		if err != nil {
+			err = fmt.Errorf("cannot bind pod %q to node %q: %w", pod.Name, n.node.Name, err)
+			return
+		}
+		pod.Spec.NodeName = n.node.Name
+		n.addPod(pod)
+		bound++
+		slog.Debug("bound pod to node", "pod.Name", pod.Name, "pod.Namespace", pod.Namespace, "node.Name", n.node.Name)
+	}
+	return
+}
+
+func (s *podScheduler) markUnschedulable(ctx context.Context, pod *corev1.Pod, reason string) error {
+	condition := corev1.PodCondition{
+		Type:               corev1.PodScheduled,
+		Status:             corev1.ConditionFalse,
+		Reason:             corev1.PodReasonUnschedulable,
+		Message:            reason,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	idx := slices.IndexFunc(pod.Status.Conditions, func(c corev1.PodCondition) bool {
+		return c.Type == corev1.PodScheduled
+	})
+	if idx >= 0 {
+		existing := pod.Status.Conditions[idx]
+		if existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+			return nil
+		}
+		pod.Status.Conditions[idx] = condition
+	} else {
+		pod.Status.Conditions = append(pod.Status.Conditions, condition)
+	}
+	pod.Status.Phase = corev1.PodPending
+	err := s.virtualCluster.UpdatePodStatus(ctx, pod)
+	if err != nil {
+		return fmt.Errorf("cannot update status of unschedulable pod %q: %w", pod.Name, err)
+	}
+	return nil
+}
+
+func (n *schedulingNode) addPod(pod *corev1.Pod) {
+	n.pods = append(n.pods, pod)
+	for name, quantity := range computePodRequests(pod) {
+		sum := n.requested[name]
+		sum.Add(quantity)
+		n.requested[name] = sum
+	}
+}
+
+// selectNode returns the feasible node with the highest score for the given pod, or the reason why no node is
+// feasible.
+func selectNode(pod *corev1.Pod, nodes []*schedulingNode) (*schedulingNode, string) {
+	if len(nodes) == 0 {
+		return nil, "no nodes available to schedule pods"
+	}
+	var feasible []*schedulingNode
+	reasonCounts := make(map[string]int)
+	for _, n := range nodes {
+		if reason := filterNode(pod, n, nodes); reason != "" {
+			reasonCounts[reason]++
+			continue
+		}
+		feasible = append(feasible, n)
+	}
+	if len(feasible) == 0 {
+		return nil, formatUnschedulableReasons(len(nodes), reasonCounts)
+	}
+	scores := scoreNodes(pod, feasible, nodes)
+	best := 0
+	for i := range feasible {
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+	return feasible[best], ""
+}
+
+// formatUnschedulableReasons formats the reasons like the FailedScheduling event message of kube-scheduler.
+func formatUnschedulableReasons(nodeCount int, reasonCounts map[string]int) string {
+	reasons := make([]string, 0, len(reasonCounts))
+	for reason, count := range reasonCounts {
+		reasons = append(reasons, fmt.Sprintf("%d %s", count, reason))
+	}
+	slices.Sort(reasons)
+	msg := fmt.Sprintf("0/%d nodes are available: ", nodeCount)
+	for i, reason := range reasons {
+		if i > 0 {
+			msg += ", "
+		}
+		msg += reason
+	}
+	return msg + "."
+}
+
+// filterNode returns the reason why the given pod cannot be scheduled on the given node, or an empty string if it
+// can.
+func filterNode(pod *corev1.Pod, n *schedulingNode, nodes []*schedulingNode) string {
+	if n.node.Spec.Unschedulable {
+		return "node(s) were unschedulable"
+	}
+	if !matchesNodeSelectorAndAffinity(pod, n.node) {
+		return "node(s) didn't match Pod's node affinity/selector"
+	}
+	if taint, ok := findUntoleratedTaint(n.node.Spec.Taints, pod.Spec.Tolerations, func(t *corev1.Taint) bool {
+		return t.Effect == corev1.TaintEffectNoSchedule || t.Effect == corev1.TaintEffectNoExecute
+	}); ok {
+		return fmt.Sprintf("node(s) had untolerated taint {%s: %s}", taint.Key, taint.Value)
+	}
+	if reason := fitsResources(pod, n); reason != "" {
+		return reason
+	}
+	if !satisfiesPodAffinity(pod, n, nodes) {
+		return "node(s) didn't match pod affinity rules"
+	}
+	if !satisfiesPodAntiAffinity(pod, n, nodes) {
+		return "node(s) didn't match pod anti-affinity rules"
+	}
+	if !satisfiesTopologySpread(pod, n, nodes) {
+		return "node(s) didn't match pod topology spread constraints"
+	}
+	return ""
+}
+
+func fitsResources(pod *corev1.Pod, n *schedulingNode) string {
+	if allocatablePods, ok := n.node.Status.Allocatable[corev1.ResourcePods]; ok && int64(len(n.pods))+1 > allocatablePods.Value() {
+		return "Too many pods"
+	}
+	for name, request := range computePodRequests(pod) {
+		if request.IsZero() {
+			continue
+		}
+		allocatable := n.node.Status.Allocatable[name]
+		free := allocatable.DeepCopy()
+		free.Sub(n.requested[name])
+		if free.Cmp(request) < 0 {
+			return "Insufficient " + string(name)
+		}
+	}
+	return ""
+}
+
+// computePodRequests returns the effective resource requests of the given pod: the maximum of the sum of the requests
+// of its containers and the requests of each init container, plus the pod overhead.
+func computePodRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := make(corev1.ResourceList)
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			sum := requests[name]
+			sum.Add(quantity)
+			requests[name] = sum
+		}
+	}
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if current, ok := requests[name]; !ok || quantity.Cmp(current) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	for name, quantity := range pod.Spec.Overhead {
+		sum := requests[name]
+		sum.Add(quantity)
+		requests[name] = sum
+	}
+	return requests
+}
+
+// sortPodsByPriority sorts the given pods like the scheduling queue of kube-scheduler, by descending priority and
+// ascending creation time.
+func sortPodsByPriority(pods []*corev1.Pod) {
+	slices.SortStableFunc(pods, func(a, b *corev1.Pod) int {
+		if c := cmp.Compare(podPriority(b), podPriority(a)); c != 0 {
+			return c
+		}
+		if c := a.CreationTimestamp.Compare(b.CreationTimestamp.Time); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+}
+
+func podPriority(pod *corev1.Pod) int32 {
+	if pod.Spec.Priority != nil {
+		return *pod.Spec.Priority
+	}
+	return 0
+}
+
+func matchesNodeSelectorAndAffinity(pod *corev1.Pod, node *corev1.Node) bool {
+	for key, val := range pod.Spec.NodeSelector {
+		if node.Labels[key] != val {
+			return false
+		}
+	}
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.NodeAffinity == nil || pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true
+	}
+	return slices.ContainsFunc(pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms, func(term corev1.NodeSelectorTerm) bool {
+		return matchesNodeSelectorTerm(term, node)
+	})
+}
+
+// matchesNodeSelectorTerm checks if the given node matches all requirements of the given term. A term without
+// requirements matches no node.
+func matchesNodeSelectorTerm(term corev1.NodeSelectorTerm, node *corev1.Node) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+	for _, req := range term.MatchExpressions {
+		if !matchesNodeSelectorRequirement(req, node.Labels) {
+			return false
+		}
+	}
+	for _, req := range term.MatchFields {
+		if req.Key != "metadata.name" || !matchesNodeSelectorRequirement(req, map[string]string{req.Key: node.Name}) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesNodeSelectorRequirement(req corev1.NodeSelectorRequirement, nodeLabels map[string]string) bool {
+	val, ok := nodeLabels[req.Key]
+	switch req.Operator {
+	case corev1.NodeSelectorOpIn:
+		return ok && slices.Contains(req.Values, val)
+	case corev1.NodeSelectorOpNotIn:
+		return !ok || !slices.Contains(req.Values, val)
+	case corev1.NodeSelectorOpExists:
+		return ok
+	case corev1.NodeSelectorOpDoesNotExist:
+		return !ok
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		if !ok || len(req.Values) != 1 {
+			return false
+		}
+		labelVal, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return false
+		}
+		reqVal, err := strconv.ParseInt(req.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		if req.Operator == corev1.NodeSelectorOpGt {
+			return labelVal > reqVal
+		}
+		return labelVal < reqVal
+	}
+	return false
+}
+
+func findUntoleratedTaint(taints []corev1.Taint, tolerations []corev1.Toleration, inclusionFilter func(*corev1.Taint) bool) (corev1.Taint, bool) {
+	for i := range taints {
+		taint := &taints[i]
+		if !inclusionFilter(taint) {
+			continue
+		}
+		if !slices.ContainsFunc(tolerations, func(toleration corev1.Toleration) bool {
+			return toleration.ToleratesTaint(taint)
+		}) {
+			return *taint, true
+		}
+	}
+	return corev1.Taint{}, false
+}
+
+// matchesPodAffinityTerm checks if the given pod matches the namespaces and label selector of the given term of the
+// given owner pod.
+func matchesPodAffinityTerm(term corev1.PodAffinityTerm, owner, pod *corev1.Pod) bool {
+	if term.NamespaceSelector == nil {
+		namespaces := term.Namespaces
+		if len(namespaces) == 0 {
+			namespaces = []string{owner.Namespace}
+		}
+		if !slices.Contains(namespaces, pod.Namespace) {
+			return false
+		}
+	} else if len(term.NamespaceSelector.MatchLabels) != 0 || len(term.NamespaceSelector.MatchExpressions) != 0 {
+		// the labels of namespaces are not known, so only the explicitly listed namespaces match
+		if !slices.Contains(term.Namespaces, pod.Namespace) {
+			return false
+		}
+	}
+	selector, err := metav1.LabelSelectorAsSelector(term.LabelSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(pod.Labels))
+}
+
+func sameTopologyDomain(topologyKey string, a, b *corev1.Node) bool {
+	val, ok := a.Labels[topologyKey]
+	if !ok {
+		return false
+	}
+	other, ok := b.Labels[topologyKey]
+	return ok && val == other
+}
+
+// satisfiesPodAffinity checks the required pod affinity terms of the given pod: every term must match a pod in the
+// topology domain of the node. A term that matches no pod at all is satisfied if it matches the pod itself, so that
+// the first pod of a group with affinity to itself can be scheduled.
+func satisfiesPodAffinity(pod *corev1.Pod, n *schedulingNode, nodes []*schedulingNode) bool {
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.PodAffinity == nil {
+		return true
+	}
+	for _, term := range pod.Spec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+		if _, ok := n.node.Labels[term.TopologyKey]; !ok {
+			return false
+		}
+		matchedInDomain, matchedAnywhere := false, false
+		for _, other := range nodes {
+			for _, p := range other.pods {
+				if !matchesPodAffinityTerm(term, pod, p) {
+					continue
+				}
+				matchedAnywhere = true
+				if sameTopologyDomain(term.TopologyKey, n.node, other.node) {
+					matchedInDomain = true
+				}
+			}
+		}
+		if !matchedInDomain && (matchedAnywhere || !matchesPodAffinityTerm(term, pod, pod)) {
+			return false
+		}
+	}
+	return true
+}
+
+// satisfiesPodAntiAffinity checks the required pod anti-affinity terms of the given pod and of the pods already bound
+// in the topology domains of the node.
+func satisfiesPodAntiAffinity(pod *corev1.Pod, n *schedulingNode, nodes []*schedulingNode) bool {
+	for _, other := range nodes {
+		for _, p := range other.pods {
+			if pod.Spec.Affinity != nil && pod.Spec.Affinity.PodAntiAffinity != nil {
+				for _, term := range pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+					if sameTopologyDomain(term.TopologyKey, n.node, other.node) && matchesPodAffinityTerm(term, pod, p) {
+						return false
+					}
+				}
+			}
+			if p.Spec.Affinity != nil && p.Spec.Affinity.PodAntiAffinity != nil {
+				for _, term := range p.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+					if sameTopologyDomain(term.TopologyKey, n.node, other.node) && matchesPodAffinityTerm(term, p, pod) {
+						return false
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+// countTopologyDomainPods counts the pods matching the given topology spread constraint of the given pod in every
+// topology domain of the nodes that match the node selector and affinity of the pod.
+func countTopologyDomainPods(pod *corev1.Pod, constraint corev1.TopologySpreadConstraint, nodes []*schedulingNode) map[string]int {
+	selector, err := metav1.LabelSelectorAsSelector(constraint.LabelSelector)
+	if err != nil {
+		return nil
+	}
+	if len(constraint.MatchLabelKeys) > 0 {
+		for _, key := range constraint.MatchLabelKeys {
+			if val, ok := pod.Labels[key]; ok {
+				req, err := labels.NewRequirement(key, selection.Equals, []string{val})
+				if err == nil {
+					selector = selector.Add(*req)
+				}
+			}
+		}
+	}
+	counts := make(map[string]int)
+	for _, n := range nodes {
+		domain, ok := n.node.Labels[constraint.TopologyKey]
+		if !ok || !matchesNodeSelectorAndAffinity(pod, n.node) {
+			continue
+		}
+		counts[domain] += 0
+		for _, p := range n.pods {
+			if p.Namespace == pod.Namespace && selector.Matches(labels.Set(p.Labels)) {
+				counts[domain]++
+			}
+		}
+	}
+	return counts
+}
+
+// satisfiesTopologySpread checks the DoNotSchedule topology spread constraints of the given pod: the number of
+// matching pods in the topology domain of the node including the pod must not exceed the minimum number of matching
+// pods in a domain by more than MaxSkew.
+func satisfiesTopologySpread(pod *corev1.Pod, n *schedulingNode, nodes []*schedulingNode) bool {
+	for _, constraint := range pod.Spec.TopologySpreadConstraints {
+		if constraint.WhenUnsatisfiable != corev1.DoNotSchedule {
+			continue
+		}
+		domain, ok := n.node.Labels[constraint.TopologyKey]
+		if !ok {
+			return false
+		}
+		counts := countTopologyDomainPods(pod, constraint, nodes)
+		minCount := -1
+		for _, count := range counts {
+			if minCount < 0 || count < minCount {
+				minCount = count
+			}
+		}
+		if counts[domain]+1-max(minCount, 0) > int(constraint.MaxSkew) {
+			return false
+		}
+	}
+	return true
+}
+
+// scoreNodes returns the weighted sum of the normalized scores of every feasible node.
+func scoreNodes(pod *corev1.Pod, feasible []*schedulingNode, nodes []*schedulingNode) []int64 {
+	strategy, ok := schedulerScoringStrategies[pod.Spec.SchedulerName]
+	if !ok {
+		strategy = leastAllocatedStrategy
+	}
+	requests := computePodRequests(pod)
+	resourceScores := make([]int64, len(feasible))
+	affinityScores := make([]int64, len(feasible))
+	taintCounts := make([]int64, len(feasible))
+	spreadCounts := make([]int64, len(feasible))
+	for i, n := range feasible {
+		resourceScores[i] = scoreResources(strategy, requests, n)
+		affinityScores[i] = scorePreferredNodeAffinity(pod, n.node)
+		taintCounts[i] = countUntoleratedPreferNoScheduleTaints(pod, n.node)
+		spreadCounts[i] = countScheduleAnywaySpread(pod, n, nodes)
+	}
+	normalizeScores(affinityScores, false)
+	normalizeScores(taintCounts, true)
+	normalizeScores(spreadCounts, true)
+	scores := make([]int64, len(feasible))
+	for i := range feasible {
+		scores[i] = resourcesFitWeight*resourceScores[i] + nodeAffinityWeight*affinityScores[i] +
+			taintTolerationWeight*taintCounts[i] + topologySpreadWeight*spreadCounts[i]
+	}
+	return scores
+}
+
+// scoreResources scores the cpu and memory allocation of the node after binding the pod with the given requests.
+func scoreResources(strategy scoringStrategy, requests corev1.ResourceList, n *schedulingNode) int64 {
+	var sum, count int64
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		allocatable := n.node.Status.Allocatable[name]
+		if allocatable.IsZero() {
+			continue
+		}
+		requested := n.requested[name].DeepCopy()
+		requested.Add(requests[name])
+		used := min(requested.MilliValue(), allocatable.MilliValue())
+		if strategy == mostAllocatedStrategy {
+			sum += used * maxNodeScore / allocatable.MilliValue()
+		} else {
+			sum += (allocatable.MilliValue() - used) * maxNodeScore / allocatable.MilliValue()
+		}
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / count
+}
+
+func scorePreferredNodeAffinity(pod *corev1.Pod, node *corev1.Node) (score int64) {
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.NodeAffinity == nil {
+		return
+	}
+	for _, term := range pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		if matchesNodeSelectorTerm(term.Preference, node) {
+			score += int64(term.Weight)
+		}
+	}
+	return
+}
+
+func countUntoleratedPreferNoScheduleTaints(pod *corev1.Pod, node *corev1.Node) (count int64) {
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect != corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !slices.ContainsFunc(pod.Spec.Tolerations, func(toleration corev1.Toleration) bool {
+			return toleration.ToleratesTaint(taint)
+		}) {
+			count++
+		}
+	}
+	return
+}
+
+// countScheduleAnywaySpread counts the pods matching the ScheduleAnyway topology spread constraints of the pod in the
+// topology domains of the node.
+func countScheduleAnywaySpread(pod *corev1.Pod, n *schedulingNode, nodes []*schedulingNode) (count int64) {
+	for _, constraint := range pod.Spec.TopologySpreadConstraints {
+		if constraint.WhenUnsatisfiable != corev1.ScheduleAnyway {
+			continue
+		}
+		domain, ok := n.node.Labels[constraint.TopologyKey]
+		if !ok {
+			continue
+		}
+		count += int64(countTopologyDomainPods(pod, constraint, nodes)[domain])
+	}
+	return
+}
+
+// normalizeScores scales the given scores to [0, maxNodeScore] relative to the highest score. If reverse is set, the
+// lowest score is the best.
+func normalizeScores(scores []int64, reverse bool) {
+	var maxScore int64
+	for _, score := range scores {
+		maxScore = max(maxScore, score)
+	}
+	for i, score := range scores {
+		if maxScore == 0 {
+			if reverse {
+				scores[i] = maxNodeScore
+			}
+			continue
+		}
+		scores[i] = score * maxNodeScore / maxScore
+		if reverse {
+			scores[i] = maxNodeScore - scores[i]
+		}
+	}
+}
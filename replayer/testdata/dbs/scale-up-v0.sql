@@ -0,0 +1,143 @@
+PRAGMA foreign_keys=OFF;
+BEGIN TRANSACTION;
+CREATE TABLE recorder_state_info(
+    BeginTimestamp INT NOT NULL );
+CREATE TABLE worker_pool_info(
+	RowID INTEGER PRIMARY KEY AUTOINCREMENT,
+	CreationTimestamp INT NOT NULL,
+	SnapshotTimestamp INT NOT NULL,
+	Name TEXT,
+	Namespace TEXT,
+	MachineType      TEXT, 
+	Architecture      TEXT,
+	Minimum           INT,
+	Maximum           INT,
+	MaxSurge          TEXT,
+	MaxUnavailable    TEXT,
+	Zones             TEXT,
+	DeletionTimestamp INT,
+	Hash TEXT);
+INSERT INTO worker_pool_info VALUES(1,1717232400000,1717236000000,'p1','shoot--test--golden','m5.large','amd64',1,3,'1','0','eu-west-1a',NULL,'f22e5f6c52ab2a563f17c8310d9cf351');
+CREATE TABLE mcd_info(
+	RowID INTEGER PRIMARY KEY AUTOINCREMENT,
+	CreationTimestamp INT NOT NULL,
+	SnapshotTimestamp INT NOT NULL,
+	Name TEXT,
+	Namespace TEXT,
+	Replicas INTEGER,
+	PoolName TEXT,
+	Zone TEXT,
+	MaxSurge TEXT,
+	MaxUnavailable TEXT, 
+	MachineClassName TEXT,
+	Labels TEXT,
+	Taints TEXT,
+	DeletionTimestamp INT,
+	Hash TEXT);
+INSERT INTO mcd_info VALUES(1,1717232400000,1717236000000,'shoot--test--golden-p1-z1','shoot--test--golden',1,'p1','eu-west-1a','1','0','shoot--test--golden-p1-z1-8f2a1','{"topology.ebs.csi.aws.com/zone":"eu-west-1a","worker.gardener.cloud/pool":"p1"}','',NULL,'3097fdce0332ac1f839ceed802c6b1c5');
+INSERT INTO mcd_info VALUES(2,1717232400000,1717236210000,'shoot--test--golden-p1-z1','shoot--test--golden',2,'p1','eu-west-1a','1','0','shoot--test--golden-p1-z1-8f2a1','{"topology.ebs.csi.aws.com/zone":"eu-west-1a","worker.gardener.cloud/pool":"p1"}','',NULL,'13104a5b3e8823d1c38d78ea135a93a5');
+CREATE TABLE mcc_info(
+	RowID INTEGER PRIMARY KEY AUTOINCREMENT,
+	CreationTimestamp INT NOT NULL,
+	SnapshotTimestamp INT NOT NULL,
+	Name TEXT,
+	Namespace TEXT,
+	InstanceType TEXT,
+	PoolName TEXT,
+	Region TEXT,
+	Zone TEXT,
+	Labels TEXT,
+	Capacity TEXT,
+	DeletionTimestamp INT,
+	Hash TEXT);
+INSERT INTO mcc_info VALUES(1,1717232400000,1717236000000,'shoot--test--golden-p1-z1-8f2a1','shoot--test--golden','m5.large','p1','eu-west-1','eu-west-1a','{"worker_gardener_cloud_pool":"p1"}','{"cpu":"2","memory":"8Gi","pods":"110"}',NULL,'e3455d3d8f6e767446a8548bcb62f8de');
+CREATE TABLE event_info(
+	UID varchar(128) PRIMARY KEY,
+	EventTime DATETIME NOT NULL,
+	ReportingController VARCHAR(256),
+	Reason VARCHAR(128),
+	Message TEXT,
+	InvolvedObjectKind varchar(128),
+	InvolvedObjectName varchar(128),
+	InvolvedObjectNamespace varchar(128),
+	InvolvedObjectUID varchar(128));
+CREATE TABLE node_info (
+	RowID INTEGER PRIMARY KEY AUTOINCREMENT,
+	CreationTimestamp INT NOT NULL,
+	SnapshotTimestamp INT NOT NULL,
+	Name TEXT, 
+	Namespace TEXT, 
+	ProviderID TEXT, 
+	AllocatableVolumes INTEGER,
+	Labels TEXT, 
+	Taints TEXT, 
+	Allocatable TEXT, 
+	Capacity TEXT, 
+	DeletionTimestamp DATETIME,
+	Hash TEXT);
+INSERT INTO node_info VALUES(1,1717234200000,1717234260000,'shoot--test--golden-p1-z1-8f2a1-x1','','aws:///eu-west-1a/i-1',25,'{"topology.ebs.csi.aws.com/zone":"eu-west-1a","worker.gardener.cloud/pool":"p1"}','','{"cpu":"1920m","memory":"7Gi","pods":"110"}','{"cpu":"2","memory":"8Gi","pods":"110"}',NULL,'f0049a8c011b18384a16774600616297');
+INSERT INTO node_info VALUES(2,1717236360000,1717236390000,'shoot--test--golden-p1-z1-8f2a1-x2','','aws:///eu-west-1a/i-2',25,'{"topology.ebs.csi.aws.com/zone":"eu-west-1a","worker.gardener.cloud/pool":"p1"}','','{"cpu":"1920m","memory":"7Gi","pods":"110"}','{"cpu":"2","memory":"8Gi","pods":"110"}',NULL,'8779be6162dbe2e5dd60f0c8635f8267');
+CREATE TABLE pod_info (
+	RowID INTEGER PRIMARY KEY AUTOINCREMENT,
+	CreationTimestamp INT NOT NULL,
+	SnapshotTimestamp INT NOT NULL,
+	Name TEXT,
+	Namespace TEXT,
+	UID TEXT NOT NULL,
+	NodeName TEXT,
+	NominatedNodeName TEXT,
+	Labels TEXT,
+	Requests TEXT,
+	Spec TEXT,
+	ScheduleStatus INTEGER,
+	DeletionTimestamp INT,
+	Hash TEXT);
+INSERT INTO pod_info VALUES(1,1717236030000,1717236040000,'web-7d4b9c-abcde','default','web-1','shoot--test--golden-p1-z1-8f2a1-x1','','{"app":"web"}','{"cpu":"1","memory":"2Gi"}','{"volumes":[{"name":"kube-api-access-abcde","projected":{"sources":[{"serviceAccountToken":{"expirationSeconds":3607,"path":"token"}}]}}],"containers":[{"name":"web","image":"nginx:1.27","resources":{"requests":{"cpu":"1","memory":"2Gi"}},"volumeMounts":[{"name":"kube-api-access-abcde","readOnly":true,"mountPath":"/var/run/secrets/kubernetes.io/serviceaccount"}]}],"nodeName":"shoot--test--golden-p1-z1-8f2a1-x1","schedulerName":"default-scheduler"}',1,NULL,'d92d4a92f448a3f4e25d7273564c9613');
+INSERT INTO pod_info VALUES(2,1717236180000,1717236181000,'web-7d4b9c-fghij','default','web-2','','','{"app":"web"}','{"cpu":"1","memory":"2Gi"}','{"volumes":[{"name":"kube-api-access-fghij","projected":{"sources":[{"serviceAccountToken":{"expirationSeconds":3607,"path":"token"}}]}}],"containers":[{"name":"web","image":"nginx:1.27","resources":{"requests":{"cpu":"1","memory":"2Gi"}},"volumeMounts":[{"name":"kube-api-access-fghij","readOnly":true,"mountPath":"/var/run/secrets/kubernetes.io/serviceaccount"}]}],"schedulerName":"default-scheduler"}',0,NULL,'146633f3cc5e204eaaf4abc5dd5a5581');
+INSERT INTO pod_info VALUES(3,1717236180000,1717236420000,'web-7d4b9c-fghij','default','web-2','shoot--test--golden-p1-z1-8f2a1-x2','','{"app":"web"}','{"cpu":"1","memory":"2Gi"}','{"volumes":[{"name":"kube-api-access-fghij","projected":{"sources":[{"serviceAccountToken":{"expirationSeconds":3607,"path":"token"}}]}}],"containers":[{"name":"web","image":"nginx:1.27","resources":{"requests":{"cpu":"1","memory":"2Gi"}},"volumeMounts":[{"name":"kube-api-access-fghij","readOnly":true,"mountPath":"/var/run/secrets/kubernetes.io/serviceaccount"}]}],"nodeName":"shoot--test--golden-p1-z1-8f2a1-x2","schedulerName":"default-scheduler"}',1,NULL,'77039dcb023a9f85930fce673a521c07');
+CREATE TABLE pc_info (
+	RowID INTEGER PRIMARY KEY AUTOINCREMENT,
+	CreationTimestamp INT NOT NULL,
+	SnapshotTimestamp INT NOT NULL,
+	Name TEXT,
+	UID TEXT,
+	Value INT NOT NULL,
+	GlobalDefault BOOLEAN,
+	PreemptionPolicy TEXT,
+	Description TEXT,
+	Labels TEXT,
+	DeletionTimestamp INT,
+	Hash TEXT);
+INSERT INTO pc_info VALUES(1,1717232400000,1717236000000,'shoot-system-700','pc-1',700,0,'PreemptLowerPriority','PriorityClass for shoot system components','',NULL,'cba42083050b3ac4f2458951999c6242');
+CREATE TABLE pdb_info(
+    							id INTEGER PRIMARY KEY AUTOINCREMENT,
+    							uid TEXT,
+    							name TEXT,
+    							generation INT,
+    							creationTimestamp DATETIME,
+    							deletionTimestamp DATETIME,
+    							minAvailable TEXT,
+    							maxUnAvailable TEXT,
+    							spec TEXT);
+CREATE TABLE ca_settings_info(
+    RowID INTEGER PRIMARY KEY AUTOINCREMENT,
+    SnapshotTimestamp INT NOT NULL,
+    Expander TEXT,
+    ScanInterval INT,
+    MaxNodeProvisionTime          INT,
+    MaxGracefulTerminationSeconds INT,
+    NewPodScaleUpDelay            INT,
+    MaxEmptyBulkDelete            INT,
+    IgnoreDaemonSetUtilization    BOOLEAN,
+    MaxNodesTotal                 INT,
+	Priorities TEXT,
+	Hash TEXT);
+INSERT INTO ca_settings_info VALUES(1,1717236000000,'least-waste',1200000,10000,600,0,10,0,10,'','e1db351e49d57f05b98fc2f40f4dd7c7');
+INSERT INTO sqlite_sequence VALUES('mcc_info',1);
+INSERT INTO sqlite_sequence VALUES('mcd_info',2);
+INSERT INTO sqlite_sequence VALUES('worker_pool_info',1);
+INSERT INTO sqlite_sequence VALUES('pc_info',1);
+INSERT INTO sqlite_sequence VALUES('ca_settings_info',1);
+INSERT INTO sqlite_sequence VALUES('node_info',2);
+INSERT INTO sqlite_sequence VALUES('pod_info',3);
+COMMIT;
@@ -0,0 +1,369 @@
+package replayer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	gsh "github.com/elankath/gardener-scaling-history"
+	"github.com/elankath/gardener-scaling-history/db"
+	gst "github.com/elankath/gardener-scaling-types"
+	corev1 "k8s.io/api/core/v1"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UtilizationReportFileName is the name of the JSON utilization report written into the report dir of a utilization
+// analysis. UtilizationSamplesFileName and UnderutilizedNodesFileName are the names of its samples and underutilized
+// nodes as CSV.
+const (
+	UtilizationReportFileName  = "utilization-report.json"
+	UtilizationSamplesFileName = "utilization-samples.csv"
+	UnderutilizedNodesFileName = "underutilized-nodes.csv"
+)
+
+// gpuResourceNames are the extended resources of GPU devices.
+var gpuResourceNames = []corev1.ResourceName{"nvidia.com/gpu", "amd.com/gpu"}
+
+// utilizationNode is a recorded node at the time of a sample, with the requests of the pods scheduled on it.
+type utilizationNode struct {
+	sample gsh.UtilizationSample
+	// utilization is the utilization of the node as computed by the cluster-autoscaler.
+	utilization float64
+	// threshold is the utilization below which the node is underutilized.
+	threshold float64
+}
+
+// AnalyzeUtilization samples the recorded cluster of the given params every step with the point-in-time logic of
+// GetRecordedClusterSnapshot, compares the requests of the scheduled pods with the allocatable resources of every node,
+// worker pool and the whole cluster and finds the nodes that stayed underutilized. The report is written as JSON and
+// CSV into the report dir.
+func AnalyzeUtilization(params gsh.UtilizationParams) (report gsh.UtilizationReport, err error) {
+	if params.Step <= 0 {
+		err = fmt.Errorf("invalid utilization step %s", params.Step)
+		return
+	}
+	var dataAccess db.Store
+	if params.PostgresDSN != "" {
+		dataAccess = db.NewPostgresDataAccess(params.PostgresDSN, params.ClusterID)
+	} else {
+		dataAccess = db.NewReadOnlyDataAccess(params.DBPath)
+	}
+	d, err := newDefaultReplayer(gsh.ReplayerParams{
+		DBPath:                 params.DBPath,
+		PostgresDSN:            params.PostgresDSN,
+		ClusterID:              params.ClusterID,
+		InMemoryVirtualCluster: true,
+	}, dataAccess, NewInMemoryVirtualCluster())
+	if err != nil {
+		return
+	}
+	err = dataAccess.Init()
+	if err != nil {
+		err = fmt.Errorf("cannot initialize the data db: %w", err)
+		return
+	}
+	defer func() {
+		_ = d.Close()
+	}()
+
+	report.StartTime = params.StartTime.UTC()
+	if params.StartTime.IsZero() {
+		report.StartTime, err = dataAccess.GetInitialRecorderStartTime()
+		if err != nil {
+			err = fmt.Errorf("cannot get the recorder start time: %w", err)
+			return
+		}
+		report.StartTime = report.StartTime.UTC()
+	}
+	report.EndTime = params.EndTime.UTC()
+	if params.EndTime.IsZero() {
+		report.EndTime, err = db.GetRecordingEndTime(dataAccess)
+		if err != nil {
+			return
+		}
+	}
+	if report.EndTime.Before(report.StartTime) {
+		err = fmt.Errorf("end time %s is before start time %s", report.EndTime, report.StartTime)
+		return
+	}
+	report.Step = params.Step
+	report.UnderutilizedDuration = params.UnderutilizedDuration
+
+	// openPeriods are the periods of the nodes that are underutilized at the last sample
+	openPeriods := make(map[string]*gsh.UnderutilizedNode)
+	closePeriod := func(name string) {
+		period := openPeriods[name]
+		delete(openPeriods, name)
+		period.Duration = period.To.Sub(period.From)
+		if period.Duration >= params.UnderutilizedDuration {
+			report.UnderutilizedNodes = append(report.UnderutilizedNodes, *period)
+		}
+	}
+	for sampleTime := report.StartTime; !sampleTime.After(report.EndTime); sampleTime = sampleTime.Add(params.Step) {
+		var cs gsh.ClusterSnapshot
+		cs, err = d.GetRecordedClusterSnapshot(sampleTime)
+		if err != nil {
+			err = fmt.Errorf("cannot get the recorded cluster snapshot at %s: %w", sampleTime, err)
+			return
+		}
+		var nodes []utilizationNode
+		nodes, err = getUtilizationNodes(cs, params.UnderutilizedThreshold)
+		if err != nil {
+			return
+		}
+		report.Samples = append(report.Samples, aggregateUtilizationSamples(cs.SnapshotTime, nodes)...)
+
+		sampledNames := make(map[string]bool, len(nodes))
+		for _, n := range nodes {
+			sampledNames[n.sample.Name] = true
+			period, open := openPeriods[n.sample.Name]
+			switch {
+			case n.utilization >= n.threshold && open:
+				closePeriod(n.sample.Name)
+			case n.utilization < n.threshold && open:
+				period.To = sampleTime
+				period.MaxUtilization = max(period.MaxUtilization, n.utilization)
+			case n.utilization < n.threshold:
+				openPeriods[n.sample.Name] = &gsh.UnderutilizedNode{
+					Name:           n.sample.Name,
+					Pool:           n.sample.Pool,
+					From:           sampleTime,
+					To:             sampleTime,
+					MaxUtilization: n.utilization,
+				}
+			}
+		}
+		for name := range openPeriods {
+			if !sampledNames[name] {
+				closePeriod(name)
+			}
+		}
+	}
+	for name := range openPeriods {
+		closePeriod(name)
+	}
+	slices.SortFunc(report.UnderutilizedNodes, func(a, b gsh.UnderutilizedNode) int {
+		if c := a.From.Compare(b.From); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	slog.Info("analyzed utilization", "startTime", report.StartTime, "endTime", report.EndTime, "step", report.Step,
+		"numSamples", len(report.Samples), "numUnderutilizedNodes", len(report.UnderutilizedNodes))
+	if params.ReportDir != "" {
+		err = writeUtilizationReport(params.ReportDir, report)
+	}
+	return
+}
+
+// getUtilizationNodes returns the nodes of the given cluster snapshot that exist at its snapshot time, sorted by name,
+// with the requests of the pods scheduled on them. The threshold of the nodes is the given threshold, or else the
+// recorded scale-down utilization threshold of the cluster-autoscaler.
+func getUtilizationNodes(cs gsh.ClusterSnapshot, threshold float64) ([]utilizationNode, error) {
+	cpuThreshold, gpuThreshold := threshold, threshold
+	if threshold <= 0 {
+		var err error
+		cpuThreshold, err = strconv.ParseFloat(cs.CAArgs.Args["scale-down-utilization-threshold"], 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse the scale-down utilization threshold: %w", err)
+		}
+		gpuThreshold, err = strconv.ParseFloat(cs.CAArgs.Args["scale-down-gpu-utilization-threshold"], 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse the scale-down GPU utilization threshold: %w", err)
+		}
+	}
+	nodeInfos := getNodeInfosAt(cs.Nodes, cs.SnapshotTime)
+	nodes := make([]utilizationNode, 0, len(nodeInfos))
+	nodeIndexes := make(map[string]int, len(nodeInfos))
+	for i, nodeInfo := range nodeInfos {
+		node := getCoreNodeFromNodeInfo(nodeInfo)
+		nodeIndexes[node.Name] = i
+		nodes = append(nodes, utilizationNode{
+			sample: gsh.UtilizationSample{
+				SampleTime:       cs.SnapshotTime,
+				Scope:            gsh.NodeUtilizationScope,
+				Name:             node.Name,
+				Pool:             getNodeAttributes(&node, cs.AutoscalerConfig, "").Pool,
+				NumNodes:         1,
+				CPU:              gsh.ResourceUsage{Allocatable: quantityValue(nodeInfo.Allocatable, corev1.ResourceCPU)},
+				Memory:           gsh.ResourceUsage{Allocatable: quantityValue(nodeInfo.Allocatable, corev1.ResourceMemory)},
+				GPU:              gsh.ResourceUsage{Allocatable: quantityValue(nodeInfo.Allocatable, gpuResourceNames...)},
+				EphemeralStorage: gsh.ResourceUsage{Allocatable: quantityValue(nodeInfo.Allocatable, corev1.ResourceEphemeralStorage)},
+			},
+		})
+	}
+	for _, pod := range cs.Pods {
+		i, ok := nodeIndexes[pod.NodeName]
+		if pod.NodeName == "" || !ok {
+			continue
+		}
+		sample := &nodes[i].sample
+		sample.NumPods++
+		sample.CPU.Requested += quantityValue(pod.Requests, corev1.ResourceCPU)
+		sample.Memory.Requested += quantityValue(pod.Requests, corev1.ResourceMemory)
+		sample.GPU.Requested += quantityValue(pod.Requests, gpuResourceNames...)
+		sample.EphemeralStorage.Requested += quantityValue(pod.Requests, corev1.ResourceEphemeralStorage)
+	}
+	for i := range nodes {
+		n := &nodes[i]
+		// like the cluster-autoscaler, GPU nodes are only utilized by their GPUs
+		if n.sample.GPU.Allocatable > 0 {
+			n.utilization, n.threshold = n.sample.GPU.Utilization(), gpuThreshold
+		} else {
+			n.utilization, n.threshold = max(n.sample.CPU.Utilization(), n.sample.Memory.Utilization()), cpuThreshold
+		}
+	}
+	return nodes, nil
+}
+
+// getNodeInfosAt returns the nodes among the given recorded node infos that exist at the given time, sorted by name. A
+// node is represented by its latest node info at the time, or by its first node info if it was created but not yet
+// recorded at the time.
+func getNodeInfosAt(nodeInfos []gst.NodeInfo, t time.Time) []gst.NodeInfo {
+	latest := make(map[string]gst.NodeInfo)
+	for _, n := range nodeInfos {
+		if n.CreationTimestamp.After(t) || (!n.DeletionTimestamp.IsZero() && !n.DeletionTimestamp.After(t)) {
+			continue
+		}
+		l, ok := latest[n.Name]
+		switch {
+		case !ok:
+			latest[n.Name] = n
+		case n.SnapshotTimestamp.After(t):
+			if l.SnapshotTimestamp.After(t) && n.SnapshotTimestamp.Before(l.SnapshotTimestamp) {
+				latest[n.Name] = n
+			}
+		case l.SnapshotTimestamp.After(t) || n.SnapshotTimestamp.After(l.SnapshotTimestamp):
+			latest[n.Name] = n
+		}
+	}
+	result := make([]gst.NodeInfo, 0, len(latest))
+	for _, n := range latest {
+		result = append(result, n)
+	}
+	slices.SortFunc(result, func(a, b gst.NodeInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return result
+}
+
+// aggregateUtilizationSamples returns the sample of the whole cluster, followed by the samples of the worker pools and
+// the samples of the given nodes.
+func aggregateUtilizationSamples(sampleTime time.Time, nodes []utilizationNode) []gsh.UtilizationSample {
+	cluster := gsh.UtilizationSample{SampleTime: sampleTime, Scope: gsh.ClusterUtilizationScope}
+	pools := make(map[string]*gsh.UtilizationSample)
+	var poolNames []string
+	for _, n := range nodes {
+		pool, ok := pools[n.sample.Pool]
+		if !ok {
+			pool = &gsh.UtilizationSample{SampleTime: sampleTime, Scope: gsh.PoolUtilizationScope, Name: n.sample.Pool, Pool: n.sample.Pool}
+			pools[n.sample.Pool] = pool
+			poolNames = append(poolNames, n.sample.Pool)
+		}
+		addUtilizationSample(&cluster, n.sample)
+		addUtilizationSample(pool, n.sample)
+	}
+	slices.Sort(poolNames)
+	samples := make([]gsh.UtilizationSample, 0, 1+len(poolNames)+len(nodes))
+	samples = append(samples, cluster)
+	for _, poolName := range poolNames {
+		samples = append(samples, *pools[poolName])
+	}
+	for _, n := range nodes {
+		samples = append(samples, n.sample)
+	}
+	return samples
+}
+
+func addUtilizationSample(sum *gsh.UtilizationSample, s gsh.UtilizationSample) {
+	sum.NumNodes += s.NumNodes
+	sum.NumPods += s.NumPods
+	for _, usage := range []struct{ sum, s *gsh.ResourceUsage }{
+		{&sum.CPU, &s.CPU},
+		{&sum.Memory, &s.Memory},
+		{&sum.GPU, &s.GPU},
+		{&sum.EphemeralStorage, &s.EphemeralStorage},
+	} {
+		usage.sum.Requested += usage.s.Requested
+		usage.sum.Allocatable += usage.s.Allocatable
+	}
+}
+
+// quantityValue returns the sum of the values of the given resources in the given resource list, in cores for CPU.
+func quantityValue(resources corev1.ResourceList, names ...corev1.ResourceName) (value float64) {
+	for _, name := range names {
+		if quantity, ok := resources[name]; ok {
+			value += quantity.AsApproximateFloat64()
+		}
+	}
+	return
+}
+
+func writeUtilizationReport(reportDir string, report gsh.UtilizationReport) error {
+	bytes, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	reportPath := filepath.Join(reportDir, UtilizationReportFileName)
+	err = os.WriteFile(reportPath, bytes, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot write utilization report to %q: %w", reportPath, err)
+	}
+
+	header := []string{"SampleTime", "Scope", "Name", "Pool", "NumNodes", "NumPods"}
+	resourceNames := []string{"CPU", "Memory", "GPU", "EphemeralStorage"}
+	for _, resourceName := range resourceNames {
+		header = append(header, resourceName+"Requested", resourceName+"Allocatable", resourceName+"Utilization")
+	}
+	samplesPath := filepath.Join(reportDir, UtilizationSamplesFileName)
+	err = writeCSV(samplesPath, header, len(report.Samples), func(i int) []string {
+		s := report.Samples[i]
+		record := []string{s.SampleTime.Format(time.RFC3339), string(s.Scope), s.Name, s.Pool, strconv.Itoa(s.NumNodes), strconv.Itoa(s.NumPods)}
+		for _, usage := range []gsh.ResourceUsage{s.CPU, s.Memory, s.GPU, s.EphemeralStorage} {
+			record = append(record,
+				strconv.FormatFloat(usage.Requested, 'f', -1, 64),
+				strconv.FormatFloat(usage.Allocatable, 'f', -1, 64),
+				formatFloat(usage.Utilization()))
+		}
+		return record
+	})
+	if err != nil {
+		return err
+	}
+
+	nodesPath := filepath.Join(reportDir, UnderutilizedNodesFileName)
+	err = writeCSV(nodesPath, []string{"Name", "Pool", "From", "To", "Duration", "MaxUtilization"}, len(report.UnderutilizedNodes), func(i int) []string {
+		n := report.UnderutilizedNodes[i]
+		return []string{n.Name, n.Pool, n.From.Format(time.RFC3339), n.To.Format(time.RFC3339), n.Duration.String(), formatFloat(n.MaxUtilization)}
+	})
+	if err != nil {
+		return err
+	}
+	slog.Info("wrote utilization report", "reportPath", reportPath, "samplesPath", samplesPath, "underutilizedNodesPath", nodesPath)
+	return nil
+}
+
+// writeCSV writes the given header and the given number of records to a CSV file at the given path.
+func writeCSV(path string, header []string, numRecords int, record func(i int) []string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("cannot create %q: %w", path, err)
+	}
+	w := csv.NewWriter(f)
+	_ = w.Write(header)
+	for i := range numRecords {
+		_ = w.Write(record(i))
+	}
+	w.Flush()
+	err = errors.Join(w.Error(), f.Close())
+	if err != nil {
+		return fmt.Errorf("cannot write %q: %w", path, err)
+	}
+	return nil
+}
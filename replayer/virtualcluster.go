@@ -0,0 +1,191 @@
+package replayer
+
+import (
+	"context"
+	"fmt"
+	gsh "github.com/elankath/gardener-scaling-history"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/pointer"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// clientVirtualCluster is a VirtualCluster accessed through a k8s client, such as the client of a kvcl virtual cluster
+// or of a client-go fake clientset.
+type clientVirtualCluster struct {
+	client kubernetes.Interface
+}
+
+var _ gsh.VirtualCluster = (*clientVirtualCluster)(nil)
+
+// NewVirtualCluster returns a VirtualCluster that is accessed through the given client.
+func NewVirtualCluster(client kubernetes.Interface) gsh.VirtualCluster {
+	return &clientVirtualCluster{client: client}
+}
+
+// NewVirtualClusterFromKubeConfig returns a VirtualCluster for the cluster of the given kubeconfig, such as a kvcl
+// virtual cluster.
+func NewVirtualClusterFromKubeConfig(kubeConfigPath string) (gsh.VirtualCluster, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create client config: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create clientset: %w", err)
+	}
+	return NewVirtualCluster(clientset), nil
+}
+
+// NewInMemoryVirtualCluster returns a VirtualCluster that holds the given objects in the in-memory object tracker of
+// a client-go fake clientset. It runs in process without an API server, so nothing schedules its pods or scales its
+// nodes.
+func NewInMemoryVirtualCluster(objects ...runtime.Object) gsh.VirtualCluster {
+	clientset := kubefake.NewSimpleClientset(objects...)
+	// the object tracker of the fake clientset does not apply pod bindings on its own
+	clientset.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "binding" {
+			return false, nil, nil
+		}
+		binding := action.(k8stesting.CreateAction).GetObject().(*corev1.Binding)
+		obj, err := clientset.Tracker().Get(corev1.SchemeGroupVersion.WithResource("pods"), action.GetNamespace(), binding.Name)
+		if err != nil {
+			return true, nil, err
+		}
+		pod := obj.(*corev1.Pod).DeepCopy()
+		if pod.Spec.NodeName != "" {
+			return true, nil, apierrors.NewConflict(corev1.Resource("pods/binding"), binding.Name, fmt.Errorf("pod is already assigned to node %q", pod.Spec.NodeName))
+		}
+		pod.Spec.NodeName = binding.Target.Name
+		pod.Status.Conditions = slices.DeleteFunc(pod.Status.Conditions, func(c corev1.PodCondition) bool {
+			return c.Type == corev1.PodScheduled
+		})
+		pod.Status.Conditions = append(pod.Status.Conditions, corev1.PodCondition{
+			Type:               corev1.PodScheduled,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(time.Now()),
+		})
+		return true, nil, clientset.Tracker().Update(corev1.SchemeGroupVersion.WithResource("pods"), pod, action.GetNamespace())
+	})
+	return NewVirtualCluster(clientset)
+}
+
+func (v *clientVirtualCluster) Clean(ctx context.Context) error {
+	pods, err := v.ListPods(ctx)
+	if err != nil {
+		slog.Error("cannot list the pods", "error", err)
+		return err
+	}
+	for _, pod := range pods {
+		err = v.client.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{
+			GracePeriodSeconds: pointer.Int64(0),
+		})
+		if err != nil {
+			slog.Error("cannot delete the pod", "pod.Name", pod.Name, "error", err)
+			return err
+		}
+	}
+	nodes, err := v.ListNodes(ctx)
+	if err != nil {
+		slog.Error("cannot list the nodes", "error", err)
+		return err
+	}
+	for _, node := range nodes {
+		err = v.DeleteNode(ctx, node.Name)
+		if err != nil {
+			slog.Error("cannot delete the node", "node.Name", node.Name, "error", err)
+			return err
+		}
+	}
+
+	pcs, err := v.ListPriorityClasses(ctx)
+	if err != nil {
+		slog.Error("cannot list the priority classes", "error", err)
+		return err
+	}
+	for _, pc := range pcs {
+		if strings.HasPrefix(pc.Name, "system-") {
+			continue
+		}
+		err = v.DeletePriorityClass(ctx, pc.Name)
+		if err != nil {
+			slog.Error("cannot delete the priority class", "pc.Name", pc.Name, "error", err)
+			return err
+		}
+	}
+	slog.Info("cleaned the cluster, deleted all pods in all namespaces")
+	return nil
+}
+
+func (v *clientVirtualCluster) ListNodes(ctx context.Context) ([]corev1.Node, error) {
+	nodes, err := v.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return nodes.Items, nil
+}
+
+func (v *clientVirtualCluster) CreateNode(ctx context.Context, node *corev1.Node) error {
+	_, err := v.client.CoreV1().Nodes().Create(ctx, node, metav1.CreateOptions{})
+	return err
+}
+
+func (v *clientVirtualCluster) DeleteNode(ctx context.Context, name string) error {
+	return v.client.CoreV1().Nodes().Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+func (v *clientVirtualCluster) ListPods(ctx context.Context) ([]corev1.Pod, error) {
+	pods, err := v.client.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+func (v *clientVirtualCluster) CreatePod(ctx context.Context, pod *corev1.Pod) error {
+	_, err := v.client.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	return err
+}
+
+func (v *clientVirtualCluster) DeletePod(ctx context.Context, namespace, name string) error {
+	return v.client.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+func (v *clientVirtualCluster) BindPod(ctx context.Context, namespace, name, nodeName string) error {
+	return v.client.CoreV1().Pods(namespace).Bind(ctx, &corev1.Binding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Target:     corev1.ObjectReference{Kind: "Node", Name: nodeName},
+	}, metav1.CreateOptions{})
+}
+
+func (v *clientVirtualCluster) UpdatePodStatus(ctx context.Context, pod *corev1.Pod) error {
+	_, err := v.client.CoreV1().Pods(pod.Namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+	return err
+}
+
+func (v *clientVirtualCluster) ListPriorityClasses(ctx context.Context) ([]schedulingv1.PriorityClass, error) {
+	pcs, err := v.client.SchedulingV1().PriorityClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return pcs.Items, nil
+}
+
+func (v *clientVirtualCluster) CreatePriorityClass(ctx context.Context, pc *schedulingv1.PriorityClass) error {
+	_, err := v.client.SchedulingV1().PriorityClasses().Create(ctx, pc, metav1.CreateOptions{})
+	return err
+}
+
+func (v *clientVirtualCluster) DeletePriorityClass(ctx context.Context, name string) error {
+	return v.client.SchedulingV1().PriorityClasses().Delete(ctx, name, metav1.DeleteOptions{})
+}